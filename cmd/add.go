@@ -53,7 +53,7 @@ Examples:
 			targetServiceName = templateName
 		}
 
-		cfg, err := config.LoadConfig()
+		cfg, err := config.LoadRawConfig()
 		if err != nil {
 			return fmt.Errorf("failed to load configuration: %w", err)
 		}
@@ -0,0 +1,196 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/abdultolba/nizam/internal/config"
+	"github.com/abdultolba/nizam/internal/docker"
+	"github.com/abdultolba/nizam/internal/operations"
+	"github.com/abdultolba/nizam/internal/resolve"
+	"github.com/abdultolba/nizam/internal/snapshot"
+	"github.com/spf13/cobra"
+)
+
+var cloneCmd = &cobra.Command{
+	Use:   "clone <service> <new-name>",
+	Short: "Clone a database service with its data",
+	Long: `Add a copy of a database service to .nizam.yaml, start it and load the
+original's data into it, so destructive changes such as migrations can be
+tried on the copy.
+
+The clone is published on the next free host ports above the original's and
+gets its own named volumes. Services that bind-mount a host directory
+read-write cannot be cloned, as the copy would share it.
+
+By default a fresh snapshot of the running service is taken and restored.
+With --from-snapshot, the original's snapshot with that tag is restored
+instead, and the original does not need to run.`,
+	Example: `  nizam clone postgres postgres-copy
+  nizam clone postgres migration-test --from-snapshot before-migration
+  nizam migrate postgres --dir migrations/   # then compare with the copy`,
+	Args: cobra.ExactArgs(2),
+	RunE: runClone,
+}
+
+func init() {
+	cloneCmd.Flags().String("from-snapshot", "", "restore the original's snapshot with this tag instead of taking a new one")
+	cloneCmd.Flags().String("identity", "", "age identity file for encrypted snapshots")
+	cloneCmd.Flags().Duration("timeout", 10*time.Minute, "maximum time for the whole clone")
+
+	rootCmd.AddCommand(cloneCmd)
+}
+
+func runClone(cmd *cobra.Command, args []string) error {
+	source, name := args[0], args[1]
+
+	// Parse flags
+	fromSnapshot, _ := cmd.Flags().GetString("from-snapshot")
+	identity, _ := cmd.Flags().GetString("identity")
+	timeout, _ := cmd.Flags().GetDuration("timeout")
+
+	if !config.ConfigExists() {
+		return fmt.Errorf("no .nizam.yaml configuration found. Run 'nizam init' first")
+	}
+
+	// The clone is written to the raw config, keeping variables and
+	// profiles as they are; the loaded config is what runs
+	rawCfg, err := config.LoadRawConfig()
+	if err != nil {
+		return fmt.Errorf("failed to load configuration: %w", err)
+	}
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return fmt.Errorf("failed to load configuration: %w", err)
+	}
+
+	sourceService, exists := cfg.GetService(source)
+	if !exists {
+		return fmt.Errorf("service '%s' not found in configuration", source)
+	}
+
+	if identity == "" {
+		identity = cfg.EncryptionIdentity()
+	}
+	if identity == "" {
+		identity = os.Getenv("NIZAM_AGE_IDENTITY_FILE")
+	}
+
+	// Create Docker client
+	dockerClient, err := docker.NewClient()
+	if err != nil {
+		return fmt.Errorf("failed to create Docker client: %w", err)
+	}
+	defer dockerClient.Close()
+	dockerClient.UseConfig(cfg)
+
+	snapshotSvc := snapshot.NewService(dockerClient)
+
+	engine, ok := resolve.DetectEngine(sourceService.Image, source)
+	if !ok || !snapshotSvc.SupportsEngine(engine) {
+		return fmt.Errorf("service '%s' is not a database nizam can snapshot", source)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	portAvailable, err := dockerClient.PortProbe(ctx)
+	if err != nil {
+		return err
+	}
+
+	// Validated now, saved once the data is ready to load
+	clone, err := rawCfg.CloneService(cfg, source, name, portAvailable)
+	if err != nil {
+		return err
+	}
+
+	// Settle where the data comes from before touching the config
+	restoreOpts := snapshot.RestoreOptions{Into: name, IdentityFile: identity}
+	if fromSnapshot != "" {
+		if err := findSnapshotTag(snapshotSvc, source, fromSnapshot); err != nil {
+			return err
+		}
+		restoreOpts.Tag = fromSnapshot
+	} else {
+		fmt.Printf("📸 Taking a snapshot of '%s'...\n", source)
+		_, err := snapshotSvc.Create(ctx, cfg, source, snapshot.CreateOptions{
+			Note:    fmt.Sprintf("cloned into %s", name),
+			Storage: cfg.SnapshotStorage(),
+		})
+		if err != nil {
+			return fmt.Errorf("failed to snapshot service '%s': %w", source, err)
+		}
+		restoreOpts.Latest = true
+	}
+
+	if err := saveConfig(rawCfg); err != nil {
+		return fmt.Errorf("failed to save configuration: %w", err)
+	}
+	fmt.Printf("✅ Added service '%s' to %s\n", name, config.GetConfigPath())
+
+	cfg, err = config.LoadConfig()
+	if err != nil {
+		return cloneFailed(name, fmt.Errorf("failed to load configuration: %w", err))
+	}
+	if err := startClone(ctx, dockerClient, snapshotSvc, cfg, name); err != nil {
+		return cloneFailed(name, err)
+	}
+
+	fmt.Printf("📥 Loading data into '%s'...\n", name)
+	if err := snapshotSvc.Restore(ctx, cfg, source, restoreOpts); err != nil {
+		return cloneFailed(name, fmt.Errorf("failed to restore snapshot: %w", err))
+	}
+
+	fmt.Printf("\n🎉 Cloned '%s' into '%s'\n", source, name)
+	if len(clone.Ports) > 0 {
+		fmt.Printf("   Ports: %s\n", strings.Join(clone.Ports, ", "))
+	}
+	fmt.Printf("💡 Remove the copy with 'nizam down %s' and 'nizam remove %s'\n", name, name)
+	return nil
+}
+
+// findSnapshotTag checks that a service has a snapshot with the tag
+func findSnapshotTag(snapshotSvc *snapshot.Service, serviceName, tag string) error {
+	snapshots, err := snapshotSvc.List(serviceName)
+	if err != nil {
+		return fmt.Errorf("failed to list snapshots: %w", err)
+	}
+	for _, info := range snapshots {
+		if info.Tag == tag {
+			return nil
+		}
+	}
+	return fmt.Errorf("snapshot with tag '%s' not found for service '%s'", tag, serviceName)
+}
+
+// startClone starts the cloned service and waits until its database
+// accepts connections
+func startClone(ctx context.Context, dockerClient *docker.Client, snapshotSvc *snapshot.Service, cfg *config.Config, name string) error {
+	fmt.Printf("🚀 Starting '%s'...\n", name)
+	runner := operations.NewRunner(dockerClient, cfg)
+	runner.Progress = printProgress
+	err := runner.Start(ctx, []string{name}, operations.StartOptions{Recreate: docker.RecreateIfChanged})
+	if err != nil {
+		var failures operations.Errors
+		if errors.As(err, &failures) {
+			printFailures(failures)
+		}
+		return fmt.Errorf("failed to start service '%s': %w", name, err)
+	}
+
+	if err := snapshotSvc.WaitReady(ctx, cfg, name, time.Second); err != nil {
+		return fmt.Errorf("service '%s' did not become ready: %w", name, err)
+	}
+	return nil
+}
+
+// cloneFailed explains that the clone stays in the config after err
+func cloneFailed(name string, err error) error {
+	fmt.Printf("⚠️  Service '%s' stays in the configuration; remove it with 'nizam remove %s'\n", name, name)
+	return err
+}
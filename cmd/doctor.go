@@ -3,7 +3,6 @@ package cmd
 import (
 	"context"
 	"fmt"
-	"strconv"
 	"strings"
 	"time"
 
@@ -38,7 +37,7 @@ The output shows:
 - ✗ for failures (must be fixed before running Nizam)
 
 Available check IDs for --skip:
-- docker.daemon     : Docker daemon connectivity
+- docker.daemon     : Container engine connectivity, backend and API version
 - docker.compose    : Docker Compose plugin
 - disk.free         : Available disk space
 - memory.usage      : System memory usage
@@ -128,16 +127,16 @@ func getPortChecks(cfg *config.Config) []doctor.Check {
 
 	for serviceName, service := range cfg.Services {
 		for _, portMapping := range service.Ports {
-			parts := strings.Split(portMapping, ":")
-			if len(parts) == 2 {
-				if hostPort, err := strconv.Atoi(parts[0]); err == nil {
-					portChecks = append(portChecks, checks.PortInUse{
-						Host: "localhost",
-						Port: hostPort,
-						Svc:  serviceName,
-					})
-				}
+			// "auto" ports are picked when the container is created
+			mapping, err := config.ParsePort(portMapping)
+			if err != nil || mapping.Auto {
+				continue
 			}
+			portChecks = append(portChecks, checks.PortInUse{
+				Host: "localhost",
+				Port: mapping.HostPort,
+				Svc:  serviceName,
+			})
 		}
 	}
 
@@ -167,7 +166,7 @@ func printAvailableChecks(checks []doctor.Check) {
 		var description string
 		switch id {
 		case "docker.daemon":
-			description = "Docker daemon connectivity"
+			description = "Container engine connectivity"
 		case "docker.compose":
 			description = "Docker Compose plugin"
 		case "disk.free":
@@ -4,15 +4,21 @@ import (
 	"context"
 	"fmt"
 
+	"github.com/abdultolba/nizam/internal/config"
 	"github.com/abdultolba/nizam/internal/docker"
 	"github.com/spf13/cobra"
 )
 
+var downVolumes bool
+
 var downCmd = &cobra.Command{
 	Use:   "down",
 	Short: "Stop all running nizam services",
 	Long: `Stop and remove all running nizam-managed containers.
-This will gracefully stop all services that were started with 'nizam up'.`,
+This will gracefully stop all services that were started with 'nizam up'.
+Services are stopped in reverse dependency order, so dependents stop before
+the services they depend on. With --volumes, the nizam_<project>_<service>_<name>
+volumes of the stopped services are removed as well, deleting their data.`,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		// Create Docker client
 		dockerClient, err := docker.NewClient()
@@ -20,6 +26,7 @@ This will gracefully stop all services that were started with 'nizam up'.`,
 			return fmt.Errorf("failed to create Docker client: %w", err)
 		}
 		defer dockerClient.Close()
+		dockerClient.SetProject(config.CurrentProjectName())
 
 		ctx := context.Background()
 
@@ -37,16 +44,28 @@ This will gracefully stop all services that were started with 'nizam up'.`,
 		fmt.Printf("🛑 Stopping %d service(s)...\n", len(containers))
 
 		var errors []string
-		for _, container := range containers {
-			fmt.Printf("   Stopping %s...", container.Service)
+		for _, serviceName := range stopOrder(containers) {
+			fmt.Printf("   Stopping %s...", serviceName)
 
-			if err := dockerClient.StopService(ctx, container.Service); err != nil {
+			if err := dockerClient.StopService(ctx, serviceName); err != nil {
 				fmt.Printf(" ❌\n")
-				errors = append(errors, fmt.Sprintf("%s: %v", container.Service, err))
+				errors = append(errors, fmt.Sprintf("%s: %v", serviceName, err))
 				continue
 			}
 
 			fmt.Printf(" ✅\n")
+
+			if downVolumes {
+				errors = append(errors, removeServiceVolumes(ctx, dockerClient, serviceName)...)
+			}
+		}
+
+		removed, err := dockerClient.RemoveUnusedNetworks(ctx)
+		for _, networkName := range removed {
+			fmt.Printf("   Removed network %s\n", networkName)
+		}
+		if err != nil {
+			errors = append(errors, fmt.Sprintf("networks: %v", err))
 		}
 
 		if len(errors) > 0 {
@@ -63,6 +82,65 @@ This will gracefully stop all services that were started with 'nizam up'.`,
 	},
 }
 
+// stopOrder returns the services of the given containers so that dependents
+// are stopped before the services they depend on. Services that are not in
+// the configuration (or when it can't be loaded) are stopped first.
+func stopOrder(containers []docker.ContainerInfo) []string {
+	running := make(map[string]bool, len(containers))
+	for _, container := range containers {
+		running[container.Service] = true
+	}
+
+	var ordered []string
+	if config.ConfigExists() {
+		if cfg, err := config.LoadConfig(); err == nil {
+			if order, err := cfg.StartOrder(nil); err == nil {
+				ordered = order
+			}
+		}
+	}
+
+	var result []string
+	known := make(map[string]bool, len(ordered))
+	for _, name := range ordered {
+		known[name] = true
+	}
+	for _, container := range containers {
+		if !known[container.Service] {
+			result = append(result, container.Service)
+		}
+	}
+	for i := len(ordered) - 1; i >= 0; i-- {
+		if running[ordered[i]] {
+			result = append(result, ordered[i])
+		}
+	}
+	return result
+}
+
+// removeServiceVolumes removes the nizam volumes of a stopped service and
+// returns one message per volume that could not be removed
+func removeServiceVolumes(ctx context.Context, dockerClient *docker.Client, serviceName string) []string {
+	volumes, err := dockerClient.ServiceVolumes(ctx, serviceName)
+	if err != nil {
+		return []string{fmt.Sprintf("%s: %v", serviceName, err)}
+	}
+
+	var errors []string
+	for _, v := range volumes {
+		fmt.Printf("   Removing volume %s...", v.Name)
+		if err := dockerClient.RemoveVolume(ctx, v.Name, false); err != nil {
+			fmt.Printf(" ❌\n")
+			errors = append(errors, fmt.Sprintf("%s: %v", serviceName, err))
+			continue
+		}
+		fmt.Printf(" ✅\n")
+	}
+	return errors
+}
+
 func init() {
+	downCmd.Flags().BoolVar(&downVolumes, "volumes", false, "also remove the volumes of stopped services")
+
 	rootCmd.AddCommand(downCmd)
 }
@@ -0,0 +1,91 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/abdultolba/nizam/internal/config"
+	"github.com/abdultolba/nizam/internal/docker"
+	"github.com/abdultolba/nizam/internal/resolve"
+	"github.com/abdultolba/nizam/internal/runtime"
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+)
+
+var (
+	envOutputFormat string
+	envPrefixes     map[string]string
+)
+
+var envCmd = &cobra.Command{
+	Use:   "env [service...]",
+	Short: "Print connection settings for application code",
+	Long: `Print the connection settings of services as environment variables, so
+application code can reach them without hard-coding hosts, ports or passwords.
+
+Database services export <PREFIX>_URL, _HOST, _PORT, _USER, _PASSWORD and
+_NAME. The prefix is DATABASE for PostgreSQL and MySQL, REDIS for Redis and
+MONGO for MongoDB; when several selected services share an engine, each uses
+its service name instead (analytics-db becomes ANALYTICS_DB). Other services
+with ports export <PREFIX>_HOST and _PORT. Set env_prefix on a service, or
+pass --prefix, to choose the prefix yourself.
+
+Ports are read from the running containers, so the values stay correct with
+"auto" ports or --port-strategy=next-free. Without arguments every service
+is included.`,
+	Example: `  nizam env > .env
+  eval "$(nizam env -o export)"
+  nizam env postgres redis -o json
+  nizam env --prefix analytics=ANALYTICS_DB
+  echo 'eval "$(nizam env -o direnv)"' > .envrc`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := config.LoadConfig()
+		if err != nil {
+			return fmt.Errorf("failed to load config: %w", err)
+		}
+
+		serviceNames := args
+		if len(serviceNames) == 0 {
+			serviceNames = cfg.GetServiceNames()
+		}
+		for name := range envPrefixes {
+			if _, exists := cfg.GetService(name); !exists {
+				return fmt.Errorf("--prefix: service '%s' not found in config", name)
+			}
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		var rt runtime.Runtime
+		dockerClient, err := docker.NewClient()
+		if err != nil {
+			log.Debug().Err(err).Msg("Container engine unavailable, using configured ports")
+		} else {
+			defer dockerClient.Close()
+			rt = dockerClient
+		}
+
+		vars, err := resolve.ServiceEnv(ctx, rt, cfg, serviceNames, envPrefixes)
+		if err != nil {
+			return err
+		}
+
+		output, err := resolve.FormatEnv(vars, envOutputFormat, cfg.FilePath)
+		if err != nil {
+			return err
+		}
+		fmt.Print(output)
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(envCmd)
+
+	envCmd.Flags().StringVarP(&envOutputFormat, "output", "o", resolve.EnvFormatDotenv,
+		fmt.Sprintf("Output format (%s)", strings.Join(resolve.EnvFormats, ", ")))
+	envCmd.Flags().StringToStringVar(&envPrefixes, "prefix", nil, "Variable prefix for a service, as service=PREFIX (repeatable)")
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 
+	"github.com/abdultolba/nizam/internal/config"
 	"github.com/abdultolba/nizam/internal/docker"
 	"github.com/spf13/cobra"
 )
@@ -35,6 +36,7 @@ Note: Use -- to separate nizam flags from container command flags:
 			return fmt.Errorf("failed to create Docker client: %w", err)
 		}
 		defer dockerClient.Close()
+		dockerClient.SetProject(config.CurrentProjectName())
 
 		ctx := context.Background()
 
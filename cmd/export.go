@@ -34,11 +34,15 @@ Examples:
 			return fmt.Errorf("no .nizam.yaml configuration found. Run 'nizam init' first")
 		}
 
-		// Load configuration
-		cfg, err := config.LoadConfig()
+		// Load configuration without expanding variables so that ${VAR}
+		// placeholders are exported instead of their (possibly secret) values
+		cfg, err := config.LoadRawConfig()
 		if err != nil {
 			return fmt.Errorf("failed to load configuration: %w", err)
 		}
+		if err := cfg.ApplyProfile(config.ActiveProfile(cfg)); err != nil {
+			return fmt.Errorf("failed to load configuration: %w", err)
+		}
 
 		// Get the service configuration
 		service, exists := cfg.GetService(serviceName)
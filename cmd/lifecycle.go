@@ -0,0 +1,165 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/abdultolba/nizam/internal/config"
+	"github.com/abdultolba/nizam/internal/docker"
+	"github.com/abdultolba/nizam/internal/operations"
+	"github.com/spf13/cobra"
+)
+
+// restartCmd restarts service containers
+var restartCmd = &cobra.Command{
+	Use:   "restart [services...]",
+	Short: "Restart one or more services",
+	Long: `Restart the containers of one or more services without recreating them.
+If no services are specified, all services are restarted in dependency order.`,
+	Example: `  nizam restart
+  nizam restart postgres redis`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runLifecycle(args, "🔄 Restarting", "restart", func(ctx context.Context, runner *operations.Runner) error {
+			return runner.Restart(ctx, args)
+		})
+	},
+}
+
+// pullCmd pulls service images
+var pullCmd = &cobra.Command{
+	Use:   "pull [services...]",
+	Short: "Pull the latest images of services",
+	Long: `Pull the images of one or more services from their registries, even if
+they are already present locally. Run 'nizam up' afterwards to recreate
+containers from the new images.`,
+	Example: `  nizam pull
+  nizam pull postgres`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runLifecycle(args, "📥 Pulling", "pull", func(ctx context.Context, runner *operations.Runner) error {
+			return runner.Pull(ctx, args)
+		})
+	},
+}
+
+var (
+	buildForce   bool
+	buildNoCache bool
+)
+
+// buildCmd builds service images
+var buildCmd = &cobra.Command{
+	Use:   "build [services...]",
+	Short: "Build the images of services",
+	Long: `Build the images of services that have a build configuration.
+Services without one are skipped.
+
+Images are tagged with a hash of their build context, Dockerfile, args and
+target, so an image is only rebuilt when one of them changed. Use --force
+to rebuild anyway.`,
+	Example: `  nizam build
+  nizam build postgres
+  nizam build --no-cache`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runLifecycle(args, "🔨 Building", "build", func(ctx context.Context, runner *operations.Runner) error {
+			return runner.Build(ctx, args, operations.BuildOptions{Force: buildForce, NoCache: buildNoCache})
+		})
+	},
+}
+
+func init() {
+	buildCmd.Flags().BoolVar(&buildForce, "force", false, "rebuild images even if they are up to date")
+	buildCmd.Flags().BoolVar(&buildNoCache, "no-cache", false, "do not use the Docker build cache")
+
+	rootCmd.AddCommand(restartCmd)
+	rootCmd.AddCommand(pullCmd)
+	rootCmd.AddCommand(buildCmd)
+}
+
+// runLifecycle loads the config, creates a runner scoped to its project and
+// runs op with per-service progress output
+func runLifecycle(args []string, title, verb string, op func(context.Context, *operations.Runner) error) error {
+	runner, closeFn, err := newRunner()
+	if err != nil {
+		return err
+	}
+	defer closeFn()
+
+	if len(args) == 0 {
+		fmt.Printf("%s all services...\n", title)
+	} else {
+		fmt.Printf("%s services...\n", title)
+	}
+
+	if err := op(context.Background(), runner); err != nil {
+		var failures operations.Errors
+		if !errors.As(err, &failures) {
+			return err
+		}
+		fmt.Printf("\n⚠️  Some services failed to %s:\n", verb)
+		printFailures(failures)
+		return fmt.Errorf("failed to %s %d service(s)", verb, len(failures))
+	}
+
+	fmt.Println("\n🎉 Done!")
+	return nil
+}
+
+// newRunner creates an operations runner for the current config
+func newRunner() (*operations.Runner, func(), error) {
+	if !config.ConfigExists() {
+		return nil, nil, fmt.Errorf("no .nizam.yaml configuration found. Run 'nizam init' first")
+	}
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to load configuration: %w", err)
+	}
+
+	if err := applyLock(cfg); err != nil {
+		return nil, nil, err
+	}
+
+	dockerClient, err := docker.NewClient()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create Docker client: %w", err)
+	}
+	dockerClient.UseConfig(cfg)
+
+	runner := operations.NewRunner(dockerClient, cfg)
+	runner.Progress = printProgress
+	return runner, func() { dockerClient.Close() }, nil
+}
+
+var progressMu sync.Mutex
+
+// printProgress prints an operations event as a single line; it is safe to
+// call from several goroutines
+func printProgress(event operations.Event) {
+	progressMu.Lock()
+	defer progressMu.Unlock()
+
+	switch event.Status {
+	case operations.StatusProgress:
+		fmt.Printf("      %s | %s\n", event.Service, event.Message)
+	case operations.StatusWaiting:
+		fmt.Printf("   ⏳ %s %s\n", event.Service, event.Message)
+	case operations.StatusDone:
+		if event.Message != "" {
+			fmt.Printf("   ✅ %s (%s)\n", event.Service, event.Message)
+		} else {
+			fmt.Printf("   ✅ %s\n", event.Service)
+		}
+	case operations.StatusSkipped:
+		fmt.Printf("   ⏭️  %s (%s)\n", event.Service, event.Message)
+	case operations.StatusFailed:
+		fmt.Printf("   ❌ %s\n", event.Service)
+	}
+}
+
+func printFailures(failures operations.Errors) {
+	for _, failure := range failures {
+		fmt.Printf("   • %s\n", failure.Error())
+	}
+}
@@ -23,6 +23,7 @@ This command applies various rules to check for:
 - Image tags (avoid :latest)
 - Port mapping format
 - Resource limit recommendations
+- A .nizam.lock that no longer matches the configured images
 
 Rules can be selectively applied and the output can be formatted as JSON.`,
 		Example: `  # Lint default config file
@@ -46,6 +47,7 @@ Rules can be selectively applied and the output can be formatted as JSON.`,
 				lint.NoLatest(cfg),
 				lint.PortsShape(cfg),
 				lint.LimitsRecommended(cfg),
+				lint.LockStale(cfg),
 			)
 
 			if jsonOut {
@@ -0,0 +1,122 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/abdultolba/nizam/internal/config"
+	"github.com/abdultolba/nizam/internal/docker"
+	"github.com/spf13/cobra"
+)
+
+var lockCheck bool
+
+var lockCmd = &cobra.Command{
+	Use:   "lock",
+	Short: "Pin service images to registry digests",
+	Long: `Resolve the image of every service to the digest it currently points to
+and write the result to .nizam.lock next to your configuration.
+
+Commit .nizam.lock so that everyone runs exactly the same images: while the
+lock matches the config, 'nizam up' and 'nizam pull' use the pinned digests
+instead of the tags. Services built from a Dockerfile are not locked.
+
+Run 'nizam lock' again to move to the latest images, or after changing an
+image in .nizam.yaml. 'nizam lint' warns when the lock is out of date.`,
+	Example: `  nizam lock
+  nizam lock --check   # Fail if .nizam.lock is out of date`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if !config.ConfigExists() {
+			return fmt.Errorf("no .nizam.yaml configuration found. Run 'nizam init' first")
+		}
+
+		cfg, err := config.LoadConfig()
+		if err != nil {
+			return fmt.Errorf("failed to load configuration: %w", err)
+		}
+
+		if lockCheck {
+			return checkLock(cfg)
+		}
+
+		dockerClient, err := docker.NewClient()
+		if err != nil {
+			return fmt.Errorf("failed to create Docker client: %w", err)
+		}
+		defer dockerClient.Close()
+
+		ctx := context.Background()
+		lock := &config.Lock{Version: 1, Images: map[string]config.LockedImage{}}
+
+		fmt.Println("🔒 Resolving image digests...")
+		for _, name := range cfg.LockableServices() {
+			image := cfg.Services[name].Image
+			digest, err := dockerClient.ResolveDigest(ctx, image)
+			if err != nil {
+				return fmt.Errorf("service '%s': %w", name, err)
+			}
+			locked := config.LockedImage{Image: image, Digest: digest}
+			lock.Images[name] = locked
+			fmt.Printf("   ✅ %s → %s\n", name, locked.Reference())
+		}
+
+		if err := cfg.SaveLock(lock); err != nil {
+			return err
+		}
+
+		fmt.Printf("\n📝 Wrote %s\n", cfg.LockPath())
+		return nil
+	},
+}
+
+func init() {
+	lockCmd.Flags().BoolVar(&lockCheck, "check", false, "check that .nizam.lock matches the config without resolving digests")
+
+	rootCmd.AddCommand(lockCmd)
+}
+
+// checkLock reports whether the lockfile matches the config
+func checkLock(cfg *config.Config) error {
+	lock, err := cfg.LoadLock()
+	if err != nil {
+		return err
+	}
+	if lock == nil {
+		return fmt.Errorf("no %s found. Run 'nizam lock' first", config.LockFileName)
+	}
+
+	stale := lock.StaleEntries(cfg)
+	if len(stale) == 0 {
+		fmt.Printf("✅ %s is up to date\n", config.LockFileName)
+		return nil
+	}
+
+	fmt.Printf("⚠️  %s is out of date:\n", config.LockFileName)
+	for _, entry := range stale {
+		fmt.Printf("   • %s\n", entry)
+	}
+	return fmt.Errorf("lockfile is out of date; run 'nizam lock'")
+}
+
+// applyLock pins service images to the digests in the lockfile, warning
+// about entries that no longer match the config
+func applyLock(cfg *config.Config) error {
+	lock, err := cfg.LoadLock()
+	if err != nil {
+		return err
+	}
+	if lock == nil {
+		return nil
+	}
+
+	if stale := lock.StaleEntries(cfg); len(stale) > 0 {
+		fmt.Printf("⚠️  %s is out of date:\n", config.LockFileName)
+		for _, entry := range stale {
+			fmt.Printf("   • %s\n", entry)
+		}
+		fmt.Println("💡 Services without a current lock entry use their configured tag; run 'nizam lock' to update it")
+	}
+
+	cfg.ApplyLock(lock)
+	return nil
+}
@@ -8,6 +8,7 @@ import (
 	"os/signal"
 	"syscall"
 
+	"github.com/abdultolba/nizam/internal/config"
 	"github.com/abdultolba/nizam/internal/docker"
 	"github.com/spf13/cobra"
 )
@@ -33,6 +34,7 @@ Use --follow to continuously stream new logs.`,
 			return fmt.Errorf("failed to create Docker client: %w", err)
 		}
 		defer dockerClient.Close()
+		dockerClient.SetProject(config.CurrentProjectName())
 
 		ctx := context.Background()
 
@@ -0,0 +1,89 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/abdultolba/nizam/internal/config"
+	"github.com/abdultolba/nizam/internal/docker"
+	"github.com/olekukonko/tablewriter"
+	"github.com/spf13/cobra"
+)
+
+// lsCmd lists nizam projects on the host
+var lsCmd = &cobra.Command{
+	Use:   "ls",
+	Short: "List nizam projects on this host",
+	Long: `List every nizam project that has containers on this host, with its
+services and how many of them are running.
+
+Each project's containers, volumes and networks are named
+nizam_<project>_<service>. The project name comes from --project, the
+'project' field in .nizam.yaml, or the name of the directory containing it.`,
+	Example: `  nizam ls
+  nizam ls --json`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		jsonOutput, _ := cmd.Flags().GetBool("json")
+
+		dockerClient, err := docker.NewClient()
+		if err != nil {
+			return fmt.Errorf("failed to create Docker client: %w", err)
+		}
+		defer dockerClient.Close()
+
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+
+		projects, err := dockerClient.ListProjects(ctx)
+		if err != nil {
+			return err
+		}
+
+		if jsonOutput {
+			encoder := json.NewEncoder(os.Stdout)
+			encoder.SetIndent("", "  ")
+			return encoder.Encode(projects)
+		}
+
+		if len(projects) == 0 {
+			fmt.Println("📭 No nizam projects found")
+			return nil
+		}
+
+		current := config.CurrentProjectName()
+		table := tablewriter.NewTable(os.Stdout,
+			tablewriter.WithHeader([]string{"Project", "Running", "Services", "Config"}),
+		)
+		for _, project := range projects {
+			name := project.Name
+			if name == current {
+				name += " *"
+			}
+			configPath := project.ConfigPath
+			if configPath == "" {
+				configPath = "-"
+			}
+			table.Append([]string{
+				name,
+				fmt.Sprintf("%d/%d", project.Running, project.Total),
+				strings.Join(project.Services, ", "),
+				configPath,
+			})
+		}
+		table.Render()
+		fmt.Println("\n* current project")
+
+		return nil
+	},
+}
+
+func init() {
+	lsCmd.Flags().Bool("json", false, "output in JSON format")
+
+	rootCmd.AddCommand(lsCmd)
+}
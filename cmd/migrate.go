@@ -0,0 +1,285 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/abdultolba/nizam/internal/config"
+	"github.com/abdultolba/nizam/internal/docker"
+	"github.com/abdultolba/nizam/internal/migrate"
+	"github.com/abdultolba/nizam/internal/resolve"
+	"github.com/abdultolba/nizam/internal/runtime"
+	"github.com/olekukonko/tablewriter"
+	"github.com/spf13/cobra"
+)
+
+var (
+	migrateDir     string
+	migrateTimeout time.Duration
+)
+
+// migrateCmd applies pending migrations, like 'migrate up'
+var migrateCmd = &cobra.Command{
+	Use:   "migrate <service>",
+	Short: "Apply database migrations",
+	Long: `Apply a directory of migration files to a database service through the
+engine's client inside its container.
+
+Migration files are named <version>_<name>.sql (PostgreSQL, MySQL) or
+<version>_<name>.js (MongoDB), with optional .up/.down variants for
+reversible migrations:
+
+  migrations/
+    0001_create_users.up.sql
+    0001_create_users.down.sql
+    0002_add_email_index.sql
+
+Migrations run in version order and applied versions are recorded in a
+nizam_migrations table (collection on MongoDB). On PostgreSQL each migration
+and its bookkeeping run in a single transaction.
+
+The directory comes from --dir or the service's 'migrations' setting.
+Without a subcommand, pending migrations are applied.`,
+	Example: `  nizam migrate postgres --dir migrations/
+  nizam migrate status postgres
+  nizam migrate down postgres 2
+  nizam migrate redo postgres`,
+	Args: cobra.ExactArgs(1),
+	RunE: runMigrateUp,
+}
+
+var migrateUpCmd = &cobra.Command{
+	Use:   "up <service>",
+	Short: "Apply pending migrations",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runMigrateUp,
+}
+
+var migrateDownCmd = &cobra.Command{
+	Use:   "down <service> [N]",
+	Short: "Revert the last N applied migrations (default 1)",
+	Args:  cobra.RangeArgs(1, 2),
+	RunE:  runMigrateDown,
+}
+
+var migrateStatusCmd = &cobra.Command{
+	Use:   "status <service>",
+	Short: "Show applied and pending migrations",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runMigrateStatus,
+}
+
+var migrateRedoCmd = &cobra.Command{
+	Use:   "redo <service>",
+	Short: "Revert and re-apply the last applied migration",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runMigrateRedo,
+}
+
+func init() {
+	rootCmd.AddCommand(migrateCmd)
+
+	migrateCmd.AddCommand(migrateUpCmd)
+	migrateCmd.AddCommand(migrateDownCmd)
+	migrateCmd.AddCommand(migrateStatusCmd)
+	migrateCmd.AddCommand(migrateRedoCmd)
+
+	migrateCmd.PersistentFlags().StringVar(&migrateDir, "dir", "", "migrations directory (default: the service's 'migrations' setting)")
+	migrateCmd.PersistentFlags().DurationVar(&migrateTimeout, "timeout", 10*time.Minute, "maximum time for the whole run")
+}
+
+func runMigrateUp(cmd *cobra.Command, args []string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), migrateTimeout)
+	defer cancel()
+
+	migrator, dockerClient, err := newMigrator(ctx, args[0], migrateDir)
+	if err != nil {
+		return err
+	}
+	defer dockerClient.Close()
+
+	return applyMigrations(ctx, args[0], migrator)
+}
+
+func runMigrateDown(cmd *cobra.Command, args []string) error {
+	n := 1
+	if len(args) > 1 {
+		var err error
+		n, err = strconv.Atoi(args[1])
+		if err != nil || n < 1 {
+			return fmt.Errorf("invalid number of migrations '%s'", args[1])
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), migrateTimeout)
+	defer cancel()
+
+	migrator, dockerClient, err := newMigrator(ctx, args[0], migrateDir)
+	if err != nil {
+		return err
+	}
+	defer dockerClient.Close()
+
+	reverted, err := migrator.Down(ctx, n, func(m migrate.Migration) {
+		fmt.Printf("   ⏪ %s\n", m)
+	})
+	if err != nil {
+		return err
+	}
+	if len(reverted) == 0 {
+		fmt.Printf("✅ No applied migrations for '%s'\n", args[0])
+		return nil
+	}
+	fmt.Printf("✅ Reverted %d migration(s) on '%s'\n", len(reverted), args[0])
+	return nil
+}
+
+func runMigrateStatus(cmd *cobra.Command, args []string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), migrateTimeout)
+	defer cancel()
+
+	migrator, dockerClient, err := newMigrator(ctx, args[0], migrateDir)
+	if err != nil {
+		return err
+	}
+	defer dockerClient.Close()
+
+	statuses, err := migrator.Status(ctx)
+	if err != nil {
+		return err
+	}
+	if len(statuses) == 0 {
+		fmt.Printf("📭 No migrations found for '%s'\n", args[0])
+		return nil
+	}
+
+	pending := 0
+	table := tablewriter.NewTable(os.Stdout,
+		tablewriter.WithHeader([]string{"Version", "Name", "Status", "Applied At"}),
+	)
+	for _, status := range statuses {
+		state := "pending"
+		switch {
+		case status.Applied && status.UpFile == "":
+			state = "applied (file missing)"
+		case status.Applied:
+			state = "applied"
+		default:
+			pending++
+		}
+		appliedAt := status.AppliedAt
+		if appliedAt == "" {
+			appliedAt = "-"
+		}
+		table.Append([]string{strconv.FormatUint(status.Version, 10), status.Name, state, appliedAt})
+	}
+	table.Render()
+
+	fmt.Printf("\n%d migration(s), %d pending\n", len(statuses), pending)
+	return nil
+}
+
+func runMigrateRedo(cmd *cobra.Command, args []string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), migrateTimeout)
+	defer cancel()
+
+	migrator, dockerClient, err := newMigrator(ctx, args[0], migrateDir)
+	if err != nil {
+		return err
+	}
+	defer dockerClient.Close()
+
+	migration, err := migrator.Redo(ctx, func(m migrate.Migration, up bool) {
+		if up {
+			fmt.Printf("   ⏩ %s\n", m)
+		} else {
+			fmt.Printf("   ⏪ %s\n", m)
+		}
+	})
+	if err != nil {
+		return err
+	}
+	fmt.Printf("✅ Re-applied %s on '%s'\n", migration, args[0])
+	return nil
+}
+
+// applyMigrations applies a migrator's pending migrations and reports them
+func applyMigrations(ctx context.Context, serviceName string, migrator *migrate.Migrator) error {
+	fmt.Printf("🗃️  Migrating '%s'...\n", serviceName)
+	applied, err := migrator.Up(ctx, func(m migrate.Migration) {
+		fmt.Printf("   ⏩ %s\n", m)
+	})
+	if err != nil {
+		return fmt.Errorf("service '%s': %w", serviceName, err)
+	}
+	if len(applied) == 0 {
+		fmt.Printf("✅ '%s' is up to date\n", serviceName)
+		return nil
+	}
+	fmt.Printf("✅ Applied %d migration(s) to '%s'\n", len(applied), serviceName)
+	return nil
+}
+
+// newMigrator resolves a service and loads its migrations from dir, or from
+// the service's 'migrations' setting if dir is empty. The caller closes the
+// returned client.
+func newMigrator(ctx context.Context, serviceName, dir string) (*migrate.Migrator, *docker.Client, error) {
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to load config: %w", err)
+	}
+
+	service, exists := cfg.GetService(serviceName)
+	if !exists {
+		return nil, nil, fmt.Errorf("service '%s' not found in config", serviceName)
+	}
+	if dir == "" {
+		dir = service.Migrations
+	}
+	if dir == "" {
+		return nil, nil, fmt.Errorf("no migrations directory for '%s'; pass --dir or set 'migrations' in the service config", serviceName)
+	}
+
+	dockerClient, err := docker.NewClient()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create docker client: %w", err)
+	}
+
+	migrator, err := loadMigrator(ctx, dockerClient, cfg, serviceName, dir)
+	if err != nil {
+		dockerClient.Close()
+		return nil, nil, err
+	}
+	return migrator, dockerClient, nil
+}
+
+// loadMigrator checks that a service is a running database and loads the
+// migrations in dir for it
+func loadMigrator(ctx context.Context, rt runtime.Runtime, cfg *config.Config, serviceName, dir string) (*migrate.Migrator, error) {
+	service := cfg.Services[serviceName]
+	engine, ok := resolve.DetectEngine(service.Image, serviceName)
+	if !ok {
+		return nil, fmt.Errorf("service '%s' is not a supported database (postgres, mysql, mongo)", serviceName)
+	}
+	if _, err := migrate.Extension(engine); err != nil {
+		return nil, fmt.Errorf("service '%s': %w", serviceName, err)
+	}
+
+	serviceInfo, err := resolve.GetServiceInfo(ctx, rt, cfg, serviceName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve service info: %w", err)
+	}
+
+	running, err := runtime.IsRunning(ctx, rt, serviceInfo.Container)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check container status: %w", err)
+	}
+	if !running {
+		return nil, fmt.Errorf("container %s is not running", serviceInfo.Container)
+	}
+
+	return migrate.New(rt, serviceInfo, dir)
+}
@@ -11,7 +11,7 @@ import (
 
 	"github.com/abdultolba/nizam/internal/binary"
 	"github.com/abdultolba/nizam/internal/config"
-	"github.com/abdultolba/nizam/internal/dockerx"
+	"github.com/abdultolba/nizam/internal/docker"
 	"github.com/abdultolba/nizam/internal/resolve"
 	"github.com/rs/zerolog/log"
 	"github.com/spf13/cobra"
@@ -114,7 +114,7 @@ func runMongoshCmd(cmd *cobra.Command, args []string) error {
 	var serviceInfo resolve.ServiceInfo
 	if serviceName != "" {
 		// Specific service requested
-		serviceInfo, err = resolve.GetServiceInfo(cfg, serviceName)
+		serviceInfo, err = resolveServiceInfo(cfg, serviceName)
 		if err != nil {
 			return fmt.Errorf("failed to resolve service info: %w", err)
 		}
@@ -162,7 +162,7 @@ func findFirstMongoDBService(cfg *config.Config) (resolve.ServiceInfo, error) {
 	for serviceName, service := range cfg.GetAllServices() {
 		engine := resolve.DetermineEngine(service.Image, serviceName)
 		if engine == "mongo" {
-			return resolve.GetServiceInfo(cfg, serviceName)
+			return resolveServiceInfo(cfg, serviceName)
 		}
 	}
 
@@ -215,7 +215,7 @@ func connectWithHostMongosh(service resolve.ServiceInfo, extraArgs []string) err
 
 // connectWithContainerMongosh connects using mongosh inside the container
 func connectWithContainerMongosh(service resolve.ServiceInfo, extraArgs []string) error {
-	docker, err := dockerx.NewClient()
+	dockerClient, err := docker.NewClient()
 	if err != nil {
 		return fmt.Errorf("failed to create Docker client: %w", err)
 	}
@@ -251,7 +251,7 @@ func connectWithContainerMongosh(service resolve.ServiceInfo, extraArgs []string
 	// Execute with TTY for interactive session
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
-	return docker.ExecTTY(ctx, service.Container, cmd)
+	return dockerClient.ExecTTY(ctx, service.Container, cmd)
 }
 
 // redactMongoCredentials redacts sensitive information from command arguments for logging
@@ -11,7 +11,7 @@ import (
 
 	"github.com/abdultolba/nizam/internal/binary"
 	"github.com/abdultolba/nizam/internal/config"
-	"github.com/abdultolba/nizam/internal/dockerx"
+	"github.com/abdultolba/nizam/internal/docker"
 	"github.com/abdultolba/nizam/internal/resolve"
 	"github.com/rs/zerolog/log"
 	"github.com/spf13/cobra"
@@ -114,7 +114,7 @@ func runMysqlCmd(cmd *cobra.Command, args []string) error {
 	var serviceInfo resolve.ServiceInfo
 	if serviceName != "" {
 		// Specific service requested
-		serviceInfo, err = resolve.GetServiceInfo(cfg, serviceName)
+		serviceInfo, err = resolveServiceInfo(cfg, serviceName)
 		if err != nil {
 			return fmt.Errorf("failed to resolve service info: %w", err)
 		}
@@ -162,7 +162,7 @@ func findFirstMySQLService(cfg *config.Config) (resolve.ServiceInfo, error) {
 	for serviceName, service := range cfg.GetAllServices() {
 		engine := resolve.DetermineEngine(service.Image, serviceName)
 		if engine == "mysql" {
-			return resolve.GetServiceInfo(cfg, serviceName)
+			return resolveServiceInfo(cfg, serviceName)
 		}
 	}
 
@@ -216,7 +216,7 @@ func connectWithHostMySQL(service resolve.ServiceInfo, extraArgs []string) error
 
 // connectWithContainerMySQL connects using mysql inside the container
 func connectWithContainerMySQL(service resolve.ServiceInfo, extraArgs []string) error {
-	docker, err := dockerx.NewClient()
+	dockerClient, err := docker.NewClient()
 	if err != nil {
 		return fmt.Errorf("failed to create Docker client: %w", err)
 	}
@@ -250,7 +250,7 @@ func connectWithContainerMySQL(service resolve.ServiceInfo, extraArgs []string)
 	// Execute with TTY for interactive session
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
-	return docker.ExecTTY(ctx, service.Container, cmd)
+	return dockerClient.ExecTTY(ctx, service.Container, cmd)
 }
 
 // extractConfigFile extracts the config file path from command arguments
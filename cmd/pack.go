@@ -9,7 +9,7 @@ import (
 	"time"
 
 	"github.com/abdultolba/nizam/internal/config"
-	"github.com/abdultolba/nizam/internal/dockerx"
+	"github.com/abdultolba/nizam/internal/docker"
 	"github.com/abdultolba/nizam/internal/seedpack"
 	"github.com/olekukonko/tablewriter"
 	"github.com/spf13/cobra"
@@ -77,9 +77,15 @@ var packInstallCmd = &cobra.Command{
 	Long: `Install a seed pack to a running service.
 
 The pack name can include a version (pack@version) or use the latest version.
-This will restore the pack's data to the specified service.`,
+The pack's data files are verified against its checksums and restored to the
+specified service.
+
+With --mode=replace (the default) the service's data is replaced by the
+pack's. With --mode=merge the pack's data is added to the existing database:
+existing tables, collections and rows are kept. Redis packs can only replace.`,
 	Example: `  nizam pack install postgres ecommerce-data
   nizam pack install postgres ecommerce-data@1.0.0
+  nizam pack install postgres ecommerce-data --mode merge
   nizam pack install redis session-cache --force`,
 	Args: cobra.ExactArgs(2),
 	RunE: runPackInstall,
@@ -147,6 +153,8 @@ func init() {
 	// Install command flags
 	packInstallCmd.Flags().Bool("force", false, "force installation even if errors occur")
 	packInstallCmd.Flags().Bool("dry-run", false, "show what would be installed without installing")
+	packInstallCmd.Flags().String("mode", seedpack.InstallReplace, "install mode: replace, merge")
+	packInstallCmd.Flags().String("identity", "", "age identity file for packs of encrypted snapshots")
 
 	// Remove command flags
 	packRemoveCmd.Flags().String("version", "", "specific version to remove")
@@ -179,14 +187,14 @@ func runPackCreate(cmd *cobra.Command, args []string) error {
 	}
 
 	// Create Docker client
-	docker, err := dockerx.NewClient()
+	dockerClient, err := docker.NewClient()
 	if err != nil {
 		return fmt.Errorf("failed to create Docker client: %w", err)
 	}
-	defer docker.Close()
+	defer dockerClient.Close()
 
 	// Create seed pack service
-	packSvc := seedpack.NewService(docker)
+	packSvc := seedpack.NewService(dockerClient)
 
 	// Create seed pack
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
@@ -234,14 +242,14 @@ func runPackList(cmd *cobra.Command, args []string) error {
 	}
 
 	// Create Docker client
-	docker, err := dockerx.NewClient()
+	dockerClient, err := docker.NewClient()
 	if err != nil {
 		return fmt.Errorf("failed to create Docker client: %w", err)
 	}
-	defer docker.Close()
+	defer dockerClient.Close()
 
 	// Create seed pack service
-	packSvc := seedpack.NewService(docker)
+	packSvc := seedpack.NewService(dockerClient)
 
 	// List packs
 	packs, err := packSvc.List(engine)
@@ -315,14 +323,14 @@ func runPackSearch(cmd *cobra.Command, args []string) error {
 	}
 
 	// Create Docker client
-	docker, err := dockerx.NewClient()
+	dockerClient, err := docker.NewClient()
 	if err != nil {
 		return fmt.Errorf("failed to create Docker client: %w", err)
 	}
-	defer docker.Close()
+	defer dockerClient.Close()
 
 	// Create seed pack service
-	packSvc := seedpack.NewService(docker)
+	packSvc := seedpack.NewService(dockerClient)
 
 	// Search packs
 	opts := seedpack.SearchOptions{
@@ -389,30 +397,40 @@ func runPackInstall(cmd *cobra.Command, args []string) error {
 	// Parse flags
 	force, _ := cmd.Flags().GetBool("force")
 	dryRun, _ := cmd.Flags().GetBool("dry-run")
+	mode, _ := cmd.Flags().GetString("mode")
+	identity, _ := cmd.Flags().GetString("identity")
 
 	// Load config
 	cfg, err := config.LoadConfig()
 	if err != nil {
 		return fmt.Errorf("failed to load config: %w", err)
 	}
+	if identity == "" {
+		identity = cfg.EncryptionIdentity()
+	}
+	if identity == "" {
+		identity = os.Getenv("NIZAM_AGE_IDENTITY_FILE")
+	}
 
 	// Create Docker client
-	docker, err := dockerx.NewClient()
+	dockerClient, err := docker.NewClient()
 	if err != nil {
 		return fmt.Errorf("failed to create Docker client: %w", err)
 	}
-	defer docker.Close()
+	defer dockerClient.Close()
 
 	// Create seed pack service
-	packSvc := seedpack.NewService(docker)
+	packSvc := seedpack.NewService(dockerClient)
 
 	// Install pack
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
 	defer cancel()
 
 	opts := seedpack.InstallOptions{
-		Force:  force,
-		DryRun: dryRun,
+		Force:        force,
+		DryRun:       dryRun,
+		Mode:         strings.ToLower(mode),
+		IdentityFile: identity,
 	}
 
 	if err := packSvc.Install(ctx, cfg, serviceName, packName, opts); err != nil {
@@ -431,14 +449,14 @@ func runPackInfo(cmd *cobra.Command, args []string) error {
 	packName := args[1]
 
 	// Create Docker client
-	docker, err := dockerx.NewClient()
+	dockerClient, err := docker.NewClient()
 	if err != nil {
 		return fmt.Errorf("failed to create Docker client: %w", err)
 	}
-	defer docker.Close()
+	defer dockerClient.Close()
 
 	// Create seed pack service
-	packSvc := seedpack.NewService(docker)
+	packSvc := seedpack.NewService(dockerClient)
 
 	// Get pack info
 	manifest, err := packSvc.Info(engine, packName)
@@ -517,14 +535,14 @@ func runPackRemove(cmd *cobra.Command, args []string) error {
 	version, _ := cmd.Flags().GetString("version")
 
 	// Create Docker client
-	docker, err := dockerx.NewClient()
+	dockerClient, err := docker.NewClient()
 	if err != nil {
 		return fmt.Errorf("failed to create Docker client: %w", err)
 	}
-	defer docker.Close()
+	defer dockerClient.Close()
 
 	// Create seed pack service
-	packSvc := seedpack.NewService(docker)
+	packSvc := seedpack.NewService(dockerClient)
 
 	// Remove pack
 	if err := packSvc.Remove(engine, packName, version); err != nil {
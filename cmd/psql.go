@@ -10,8 +10,9 @@ import (
 
 	"github.com/abdultolba/nizam/internal/binary"
 	"github.com/abdultolba/nizam/internal/config"
-	"github.com/abdultolba/nizam/internal/dockerx"
+	"github.com/abdultolba/nizam/internal/docker"
 	"github.com/abdultolba/nizam/internal/resolve"
+	"github.com/abdultolba/nizam/internal/runtime"
 	"github.com/rs/zerolog/log"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
@@ -65,7 +66,7 @@ func runPSQL(cmd *cobra.Command, args []string) error {
 	}
 
 	// Resolve service info
-	serviceInfo, err := resolve.GetServiceInfo(cfg, serviceName)
+	serviceInfo, err := resolveServiceInfo(cfg, serviceName)
 	if err != nil {
 		return fmt.Errorf("failed to resolve service info: %w", err)
 	}
@@ -102,14 +103,14 @@ func runPSQL(cmd *cobra.Command, args []string) error {
 }
 
 func connectViaDocker(ctx context.Context, serviceInfo resolve.ServiceInfo, extraArgs []string) error {
-	docker, err := dockerx.NewClient()
+	dockerClient, err := docker.NewClient()
 	if err != nil {
 		return fmt.Errorf("failed to create docker client: %w", err)
 	}
-	defer docker.Close()
+	defer dockerClient.Close()
 
 	// Check if container is running
-	running, err := docker.ContainerIsRunning(ctx, serviceInfo.Container)
+	running, err := runtime.IsRunning(ctx, dockerClient, serviceInfo.Container)
 	if err != nil {
 		return fmt.Errorf("failed to check container status: %w", err)
 	}
@@ -123,7 +124,7 @@ func connectViaDocker(ctx context.Context, serviceInfo resolve.ServiceInfo, extr
 	// Add connection string
 	connStr := serviceInfo.GetConnectionString()
 	if viper.GetBool("verbose") {
-		fmt.Fprintf(os.Stderr, "Connecting to: %s\n", dockerx.RedactConnectionString(connStr, false))
+		fmt.Fprintf(os.Stderr, "Connecting to: %s\n", resolve.RedactConnectionString(connStr, false))
 	}
 	cmd = append(cmd, connStr)
 
@@ -131,7 +132,24 @@ func connectViaDocker(ctx context.Context, serviceInfo resolve.ServiceInfo, extr
 	cmd = append(cmd, extraArgs...)
 
 	// Execute with TTY
-	return docker.ExecTTY(ctx, serviceInfo.Container, cmd)
+	return dockerClient.ExecTTY(ctx, serviceInfo.Container, cmd)
+}
+
+// resolveServiceInfo resolves a service's connection details for the
+// database client commands, reading its published host port from the
+// container when the engine is reachable
+func resolveServiceInfo(cfg *config.Config, serviceName string) (resolve.ServiceInfo, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	dockerClient, err := docker.NewClient()
+	if err != nil {
+		log.Debug().Err(err).Msg("Container engine unavailable, using configured ports")
+		return resolve.GetServiceInfo(ctx, nil, cfg, serviceName)
+	}
+	defer dockerClient.Close()
+
+	return resolve.GetServiceInfo(ctx, dockerClient, cfg, serviceName)
 }
 
 // connectWithHostPsql connects using the host's psql binary
@@ -141,12 +159,12 @@ func connectWithHostPsql(service resolve.ServiceInfo, extraArgs []string) error
 	args = append(args, extraArgs...)
 
 	if viper.GetBool("verbose") {
-		fmt.Fprintf(os.Stderr, "Connecting to: %s\n", dockerx.RedactConnectionString(connStr, false))
+		fmt.Fprintf(os.Stderr, "Connecting to: %s\n", resolve.RedactConnectionString(connStr, false))
 	}
 
 	log.Debug().
 		Str("command", "psql").
-		Strs("args", []string{dockerx.RedactConnectionString(connStr, true)}).
+		Strs("args", []string{resolve.RedactConnectionString(connStr, true)}).
 		Msg("Executing host psql client")
 
 	// Execute psql with connection string
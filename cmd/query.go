@@ -0,0 +1,139 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/abdultolba/nizam/internal/config"
+	"github.com/abdultolba/nizam/internal/docker"
+	"github.com/abdultolba/nizam/internal/query"
+	"github.com/abdultolba/nizam/internal/resolve"
+	"github.com/abdultolba/nizam/internal/runtime"
+	"github.com/spf13/cobra"
+)
+
+var (
+	queryFile         string
+	queryOutputFormat string
+	queryDatabase     string
+	queryTimeout      time.Duration
+)
+
+var queryCmd = &cobra.Command{
+	Use:   "query <service> [statement]",
+	Short: "Run a statement against a database service",
+	Long: `Run a single statement through the service's own client inside its
+container and print the results as a table, JSON or CSV, so scripts and tests
+can check data without knowing which engine backs a service.
+
+The statement depends on the engine:
+- PostgreSQL and MySQL: SQL, e.g. "SELECT * FROM users"
+- MongoDB: a mongosh expression, e.g. "db.users.find({active: true})"
+- Redis: a command, e.g. "HGETALL user:1"
+
+Read the statement from a file with --file, or from stdin with --file -.
+SQL with several statements is rejected, as their results cannot share a table.
+The command fails if the client reports an error.`,
+	Example: `  nizam query postgres "SELECT id, email FROM users"
+  nizam query mysql "SELECT COUNT(*) AS n FROM orders" -o json
+  nizam query mongo "db.users.find({}, {email: 1})" -o csv
+  nizam query redis "LRANGE queue 0 -1"
+  nizam query postgres -f report.sql -o csv > report.csv`,
+	Args: cobra.RangeArgs(1, 2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		serviceName := args[0]
+		if !slices.Contains(query.Formats, queryOutputFormat) {
+			return fmt.Errorf("unknown format '%s' (expected one of %s)", queryOutputFormat, strings.Join(query.Formats, ", "))
+		}
+
+		statement, err := readStatement(args[1:])
+		if err != nil {
+			return err
+		}
+
+		cfg, err := config.LoadConfig()
+		if err != nil {
+			return fmt.Errorf("failed to load config: %w", err)
+		}
+
+		service, exists := cfg.GetService(serviceName)
+		if !exists {
+			return fmt.Errorf("service '%s' not found in config", serviceName)
+		}
+		if _, ok := resolve.DetectEngine(service.Image, serviceName); !ok {
+			return fmt.Errorf("service '%s' is not a supported database (postgres, mysql, mongo, redis)", serviceName)
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), queryTimeout)
+		defer cancel()
+
+		dockerClient, err := docker.NewClient()
+		if err != nil {
+			return fmt.Errorf("failed to create docker client: %w", err)
+		}
+		defer dockerClient.Close()
+
+		serviceInfo, err := resolve.GetServiceInfo(ctx, dockerClient, cfg, serviceName)
+		if err != nil {
+			return fmt.Errorf("failed to resolve service info: %w", err)
+		}
+		if queryDatabase != "" {
+			serviceInfo.Database = queryDatabase
+		}
+
+		running, err := runtime.IsRunning(ctx, dockerClient, serviceInfo.Container)
+		if err != nil {
+			return fmt.Errorf("failed to check container status: %w", err)
+		}
+		if !running {
+			return fmt.Errorf("container %s is not running", serviceInfo.Container)
+		}
+
+		result, err := query.Run(ctx, dockerClient, serviceInfo, statement)
+		if err != nil {
+			return err
+		}
+		return result.Write(os.Stdout, queryOutputFormat)
+	},
+}
+
+// readStatement returns the statement given as an argument or, with --file,
+// read from a file or stdin
+func readStatement(args []string) (string, error) {
+	if queryFile == "" {
+		if len(args) == 0 {
+			return "", fmt.Errorf("no statement given; pass it as an argument or with --file")
+		}
+		return args[0], nil
+	}
+	if len(args) > 0 {
+		return "", fmt.Errorf("give the statement either as an argument or with --file, not both")
+	}
+
+	var data []byte
+	var err error
+	if queryFile == "-" {
+		data, err = io.ReadAll(os.Stdin)
+	} else {
+		data, err = os.ReadFile(queryFile)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to read statement: %w", err)
+	}
+	return string(data), nil
+}
+
+func init() {
+	rootCmd.AddCommand(queryCmd)
+
+	queryCmd.Flags().StringVarP(&queryFile, "file", "f", "", "Read the statement from a file (- for stdin)")
+	queryCmd.Flags().StringVarP(&queryOutputFormat, "output", "o", query.FormatTable,
+		fmt.Sprintf("Output format (%s)", strings.Join(query.Formats, ", ")))
+	queryCmd.Flags().StringVar(&queryDatabase, "db", "", "database name (override config)")
+	queryCmd.Flags().DurationVar(&queryTimeout, "timeout", 60*time.Second, "Maximum time to wait for the statement")
+}
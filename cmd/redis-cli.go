@@ -11,8 +11,9 @@ import (
 
 	"github.com/abdultolba/nizam/internal/binary"
 	"github.com/abdultolba/nizam/internal/config"
-	"github.com/abdultolba/nizam/internal/dockerx"
+	"github.com/abdultolba/nizam/internal/docker"
 	"github.com/abdultolba/nizam/internal/resolve"
+	"github.com/abdultolba/nizam/internal/runtime"
 	"github.com/rs/zerolog/log"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
@@ -63,7 +64,7 @@ func runRedisCliCmd(cmd *cobra.Command, args []string) error {
 	}
 
 	// Resolve service info
-	serviceInfo, err := resolve.GetServiceInfo(cfg, serviceName)
+	serviceInfo, err := resolveServiceInfo(cfg, serviceName)
 	if err != nil {
 		return fmt.Errorf("failed to resolve service info: %w", err)
 	}
@@ -92,14 +93,14 @@ func runRedisCliCmd(cmd *cobra.Command, args []string) error {
 }
 
 func connectRedisViaDocker(ctx context.Context, serviceInfo resolve.ServiceInfo, extraArgs []string) error {
-	docker, err := dockerx.NewClient()
+	dockerClient, err := docker.NewClient()
 	if err != nil {
 		return fmt.Errorf("failed to create docker client: %w", err)
 	}
-	defer docker.Close()
+	defer dockerClient.Close()
 
 	// Check if container is running
-	running, err := docker.ContainerIsRunning(ctx, serviceInfo.Container)
+	running, err := runtime.IsRunning(ctx, dockerClient, serviceInfo.Container)
 	if err != nil {
 		return fmt.Errorf("failed to check container status: %w", err)
 	}
@@ -123,14 +124,14 @@ func connectRedisViaDocker(ctx context.Context, serviceInfo resolve.ServiceInfo,
 
 	if viper.GetBool("verbose") {
 		connStr := fmt.Sprintf("redis://%s:%d", serviceInfo.Host, serviceInfo.Port)
-		fmt.Fprintf(os.Stderr, "Connecting to: %s\n", dockerx.RedactConnectionString(connStr, false))
+		fmt.Fprintf(os.Stderr, "Connecting to: %s\n", resolve.RedactConnectionString(connStr, false))
 	}
 
 	// Add extra arguments
 	cmd = append(cmd, extraArgs...)
 
 	// Execute with TTY
-	return docker.ExecTTY(ctx, serviceInfo.Container, cmd)
+	return dockerClient.ExecTTY(ctx, serviceInfo.Container, cmd)
 }
 
 // connectWithHostRedis connects using the host's redis-cli binary
@@ -153,7 +154,7 @@ func connectWithHostRedis(service resolve.ServiceInfo, extraArgs []string) error
 
 	if viper.GetBool("verbose") {
 		connStr := fmt.Sprintf("redis://%s:%d", service.Host, service.Port)
-		fmt.Fprintf(os.Stderr, "Connecting to: %s\n", dockerx.RedactConnectionString(connStr, false))
+		fmt.Fprintf(os.Stderr, "Connecting to: %s\n", resolve.RedactConnectionString(connStr, false))
 	}
 
 	log.Debug().
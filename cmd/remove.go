@@ -33,7 +33,7 @@ Examples:
 			return fmt.Errorf("no .nizam.yaml configuration found. Run 'nizam init' first")
 		}
 
-		cfg, err := config.LoadConfig()
+		cfg, err := config.LoadRawConfig()
 		if err != nil {
 			return fmt.Errorf("failed to load configuration: %w", err)
 		}
@@ -83,6 +83,8 @@ Examples:
 		if err != nil {
 			fmt.Printf("⚠️  Warning: Could not connect to Docker: %v\n", err)
 			fmt.Println("Services will be removed from configuration but may still be running")
+		} else {
+			dockerClient.UseConfig(cfg)
 		}
 
 		removedServices := []string{}
@@ -1,6 +1,7 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
 	"time"
 
@@ -66,17 +67,24 @@ network issues, or resource conflicts. It uses exponential backoff between attem
 }
 
 func runOperation(operation string, args []string) error {
+	runner, closeFn, err := newRunner()
+	if err != nil {
+		return err
+	}
+	defer closeFn()
+
+	ctx := context.Background()
 	switch operation {
 	case "start":
-		return operations.Start(args)
+		return runner.Start(ctx, args, operations.StartOptions{WaitForDeps: true, WaitTimeout: 2 * time.Minute})
 	case "stop":
-		return operations.Stop(args)
+		return runner.Stop(ctx, args)
 	case "restart":
-		return operations.Restart(args)
+		return runner.Restart(ctx, args)
 	case "pull":
-		return operations.Pull(args)
+		return runner.Pull(ctx, args)
 	case "build":
-		return operations.Build(args)
+		return runner.Build(ctx, args, operations.BuildOptions{})
 	default:
 		return fmt.Errorf("unsupported operation: %s", operation)
 	}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"os"
 
+	"github.com/abdultolba/nizam/internal/config"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 )
@@ -33,11 +34,15 @@ func init() {
 
 	// Global flags
 	rootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "config file (default is .nizam.yaml)")
-	rootCmd.PersistentFlags().StringP("profile", "p", "dev", "configuration profile to use")
+	rootCmd.PersistentFlags().String("project", "", "project name scoping containers, volumes and networks (default: config 'project' or directory name)")
+	rootCmd.PersistentFlags().String("backend", "", "container backend: docker, podman, a Docker context name or an engine URL such as ssh://user@host")
+	rootCmd.PersistentFlags().StringP("profile", "p", "dev", "configuration profile to apply (overrides the profile field in .nizam.yaml)")
 	rootCmd.PersistentFlags().BoolP("verbose", "v", false, "enable verbose logging")
 
 	// Bind flags to viper
 	viper.BindPFlag("profile", rootCmd.PersistentFlags().Lookup("profile"))
+	viper.BindPFlag("project", rootCmd.PersistentFlags().Lookup("project"))
+	viper.BindPFlag("backend", rootCmd.PersistentFlags().Lookup("backend"))
 	viper.BindPFlag("verbose", rootCmd.PersistentFlags().Lookup("verbose"))
 }
 
@@ -57,6 +62,20 @@ func initConfig() {
 	viper.SetEnvPrefix("NIZAM")
 	viper.AutomaticEnv()
 
+	// Only an explicit --profile or NIZAM_PROFILE overrides the profile
+	// selected in the config file
+	if rootCmd.PersistentFlags().Changed("profile") || os.Getenv("NIZAM_PROFILE") != "" {
+		config.SetProfile(viper.GetString("profile"))
+	}
+
+	if project := viper.GetString("project"); rootCmd.PersistentFlags().Changed("project") || os.Getenv("NIZAM_PROJECT") != "" {
+		config.SetProject(project)
+	}
+
+	if backend := viper.GetString("backend"); rootCmd.PersistentFlags().Changed("backend") || os.Getenv("NIZAM_BACKEND") != "" {
+		config.SetBackend(backend)
+	}
+
 	// If a config file is found, read it in.
 	if err := viper.ReadInConfig(); err == nil {
 		if viper.GetBool("verbose") {
@@ -4,14 +4,18 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"os"
+	"path/filepath"
 	"strings"
 	"time"
 
 	"github.com/abdultolba/nizam/internal/compress"
 	"github.com/abdultolba/nizam/internal/config"
-	"github.com/abdultolba/nizam/internal/dockerx"
+	"github.com/abdultolba/nizam/internal/docker"
+	"github.com/abdultolba/nizam/internal/remote"
 	"github.com/abdultolba/nizam/internal/snapshot"
+	"github.com/docker/go-units"
 	"github.com/olekukonko/tablewriter"
 	"github.com/spf13/cobra"
 )
@@ -33,10 +37,22 @@ var snapshotCreateCmd = &cobra.Command{
 	Long: `Create a snapshot of a service database.
 
 The snapshot will be stored in .nizam/snapshots/<service>/<timestamp>-<tag>/
-with a manifest.json file and compressed database dump.`,
+with a manifest.json file and compressed database dump.
+
+With --encrypt or --recipient the dump is encrypted with age after
+compression. Recipients come from --recipient or, if none are given, from
+snapshots.encryption.recipients in the config. Configured recipients alone
+do not encrypt snapshots; pass --encrypt to use them.
+
+With --storage chunked (or snapshots.storage: chunked in the config) the
+dump is split into content-addressed chunks in .nizam/chunks/, shared by all
+snapshots, so repeated snapshots of similar data take little extra space.
+Chunked snapshots cannot be encrypted.`,
 	Example: `  nizam snapshot create postgres
   nizam snapshot create postgres --tag "before-migration"
-  nizam snapshot create redis --compress gzip --note "pre-deploy state"`,
+  nizam snapshot create postgres --storage chunked
+  nizam snapshot create redis --compress gzip --note "pre-deploy state"
+  nizam snapshot create postgres --recipient age1ql3z7hjy54pw3hyww5ayyfg7zqgvc7w3j2elw8zmrj2kg5sfn9aqmcac8p`,
 	Args: cobra.ExactArgs(1),
 	RunE: runSnapshotCreate,
 }
@@ -47,9 +63,13 @@ var snapshotListCmd = &cobra.Command{
 	Short: "List snapshots",
 	Long: `List snapshots for a specific service or all services.
 
-Without a service argument, lists all snapshots across all services.`,
+Without a service argument, lists all snapshots across all services.
+
+With --remote, lists the snapshots on the remote in snapshots.remote
+instead. Only their manifests are downloaded.`,
 	Example: `  nizam snapshot list
   nizam snapshot list postgres
+  nizam snapshot list --remote
   nizam snapshot list --json`,
 	Args: cobra.MaximumNArgs(1),
 	RunE: runSnapshotList,
@@ -57,34 +77,185 @@ Without a service argument, lists all snapshots across all services.`,
 
 // snapshotRestoreCmd restores a snapshot
 var snapshotRestoreCmd = &cobra.Command{
-	Use:   "restore <service>",
+	Use:   "restore <snapshot-ref>",
 	Short: "Restore a snapshot",
 	Long: `Restore a snapshot for a service.
 
-By default, restores the latest snapshot. Use --tag, --latest, or --before
-to specify which snapshot to restore.`,
+The snapshot reference is a service name, optionally followed by @tag. By
+default, restores the latest snapshot. Use --tag, --latest, or --before
+to specify which snapshot to restore.
+
+With --into, the snapshot is restored into another service instead of the
+one it was taken from. The target must run the same database engine, and
+is refused if its image is an older major version than the snapshot's
+unless --allow-older is given.
+
+Encrypted snapshots are decrypted with the age identity file from
+--identity, snapshots.encryption.identity in the config, or the
+NIZAM_AGE_IDENTITY_FILE environment variable.`,
 	Example: `  nizam snapshot restore postgres
   nizam snapshot restore postgres --tag "before-migration"
+  nizam snapshot restore postgres@before-migration --into postgres-copy
   nizam snapshot restore postgres --latest
-  nizam snapshot restore postgres --before "2025-08-01 12:00"`,
+  nizam snapshot restore postgres --before "2025-08-01 12:00"
+  nizam snapshot restore postgres --identity ~/.config/nizam/age.key`,
 	Args: cobra.ExactArgs(1),
 	RunE: runSnapshotRestore,
 }
 
 // snapshotPruneCmd prunes old snapshots
 var snapshotPruneCmd = &cobra.Command{
-	Use:   "prune <service>",
+	Use:   "prune [service]",
 	Short: "Remove old snapshots",
-	Long: `Remove old snapshots, keeping only the N most recent ones.
+	Long: `Remove old snapshots according to the retention rules in the config:
 
-This helps manage disk space by removing outdated snapshots while
-preserving the most recent ones.`,
-	Example: `  nizam snapshot prune postgres --keep 5
-  nizam snapshot prune postgres --keep 3 --dry-run`,
-	Args: cobra.ExactArgs(1),
+  snapshots:
+    retention:
+      keep_last: 5      # the 5 most recent snapshots
+      keep_daily: 7     # the newest snapshot of each of the last 7 days
+      keep_weekly: 4    # the newest snapshot of each of the last 4 weeks
+      max_size: 2g      # cap on the total size per service
+      services:
+        postgres:
+          keep_last: 10 # per-service overrides
+
+A snapshot is kept if any rule keeps it. Tagged and pinned snapshots are
+never removed. --keep replaces the configured rules with keeping that many
+of the most recent snapshots, and works without a config.
+
+Use --all to prune every service with snapshots, and --dry-run to see what
+each rule keeps and why.`,
+	Example: `  nizam snapshot prune --all
+  nizam snapshot prune --all --dry-run
+  nizam snapshot prune postgres --keep 5`,
+	Args: cobra.MaximumNArgs(1),
 	RunE: runSnapshotPrune,
 }
 
+// snapshotPinCmd pins a snapshot
+var snapshotPinCmd = &cobra.Command{
+	Use:   "pin <service>",
+	Short: "Protect a snapshot from pruning",
+	Long: `Pin a snapshot so 'nizam snapshot prune' never removes it.
+
+By default the latest snapshot is pinned.`,
+	Example: `  nizam snapshot pin postgres
+  nizam snapshot pin postgres --tag "before-migration"`,
+	Args: cobra.ExactArgs(1),
+	RunE: runSnapshotPin,
+}
+
+// snapshotUnpinCmd unpins a snapshot
+var snapshotUnpinCmd = &cobra.Command{
+	Use:   "unpin <service>",
+	Short: "Allow a pinned snapshot to be pruned",
+	Long: `Unpin a snapshot so retention rules apply to it again.
+
+By default the latest snapshot is unpinned.`,
+	Example: `  nizam snapshot unpin postgres --tag "before-migration"`,
+	Args:    cobra.ExactArgs(1),
+	RunE:    runSnapshotUnpin,
+}
+
+// snapshotExportCmd exports a snapshot as a portable archive
+var snapshotExportCmd = &cobra.Command{
+	Use:   "export <service>",
+	Short: "Export a snapshot as a single archive",
+	Long: `Export a snapshot as a single tar archive holding its manifest and data.
+
+By default the latest snapshot is exported to <service>-<snapshot>.nzsnap in
+the current directory. Use -o - to write the archive to stdout.`,
+	Example: `  nizam snapshot export postgres --tag "before-migration" -o before-migration.nzsnap
+  nizam snapshot export postgres -o - | ssh build-box nizam snapshot import -`,
+	Args: cobra.ExactArgs(1),
+	RunE: runSnapshotExport,
+}
+
+// snapshotImportCmd imports a snapshot archive
+var snapshotImportCmd = &cobra.Command{
+	Use:   "import <file>",
+	Short: "Import a snapshot archive",
+	Long: `Import a snapshot archive created by 'nizam snapshot export'.
+
+The checksums in the manifest are verified before the snapshot is added to
+.nizam/snapshots/. Use - to read the archive from stdin and --service to
+register it under a different service.`,
+	Example: `  nizam snapshot import before-migration.nzsnap
+  nizam snapshot import before-migration.nzsnap --service api-db
+  cat before-migration.nzsnap | nizam snapshot import -`,
+	Args: cobra.ExactArgs(1),
+	RunE: runSnapshotImport,
+}
+
+// snapshotVerifyCmd checks snapshot integrity
+var snapshotVerifyCmd = &cobra.Command{
+	Use:   "verify [service]",
+	Short: "Verify snapshot integrity",
+	Long: `Verify snapshots for a specific service or all services.
+
+Every manifest is validated and every file's SHA-256 checksum recomputed.
+Partial .tmp files and staging directories left by interrupted snapshots or
+imports are reported as orphans.
+
+With --trial, each intact snapshot is also restored into a throwaway
+container of the image recorded in its manifest, to prove the dump loads.
+The image is pulled if missing; when it cannot be pulled the trial is
+skipped. Encrypted snapshots are only trial restored with an identity file.
+
+Exits with a non-zero status if any problem is found.`,
+	Example: `  nizam snapshot verify
+  nizam snapshot verify postgres --trial
+  nizam snapshot verify --json`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runSnapshotVerify,
+}
+
+// snapshotPushCmd uploads a snapshot to the remote
+var snapshotPushCmd = &cobra.Command{
+	Use:   "push <service>",
+	Short: "Upload a snapshot to the remote",
+	Long: `Upload a snapshot to the remote storage configured in the project:
+
+  snapshots:
+    remote:
+      type: dir                  # a shared directory, such as an NFS mount
+      path: /mnt/team/snapshots
+
+  snapshots:
+    remote:
+      type: s3                   # an S3-compatible bucket, such as MinIO
+      endpoint: localhost:9000
+      bucket: nizam-snapshots
+      prefix: my-project         # optional, to share a bucket
+      access_key: admin          # default: AWS_ACCESS_KEY_ID
+      secret_key: password123    # default: AWS_SECRET_ACCESS_KEY
+      insecure: true             # plain HTTP
+
+By default the latest snapshot is pushed. Chunked snapshots are uploaded
+as plain compressed files; encrypted snapshots stay encrypted. The bucket
+is created if it does not exist.`,
+	Example: `  nizam snapshot push postgres
+  nizam snapshot push postgres --tag "before-migration"`,
+	Args: cobra.ExactArgs(1),
+	RunE: runSnapshotPush,
+}
+
+// snapshotPullCmd downloads a snapshot from the remote
+var snapshotPullCmd = &cobra.Command{
+	Use:   "pull <service>",
+	Short: "Download a snapshot from the remote",
+	Long: `Download a snapshot from the remote in snapshots.remote.
+
+By default the latest remote snapshot is pulled. The checksums in the
+manifest are verified before the snapshot is added to .nizam/snapshots/,
+where it can be restored like any other.`,
+	Example: `  nizam snapshot pull postgres
+  nizam snapshot pull postgres --tag "before-migration"
+  nizam snapshot restore postgres --tag "before-migration"`,
+	Args: cobra.ExactArgs(1),
+	RunE: runSnapshotPull,
+}
+
 func init() {
 	rootCmd.AddCommand(snapshotCmd)
 
@@ -93,25 +264,60 @@ func init() {
 	snapshotCmd.AddCommand(snapshotListCmd)
 	snapshotCmd.AddCommand(snapshotRestoreCmd)
 	snapshotCmd.AddCommand(snapshotPruneCmd)
+	snapshotCmd.AddCommand(snapshotPinCmd)
+	snapshotCmd.AddCommand(snapshotUnpinCmd)
+	snapshotCmd.AddCommand(snapshotExportCmd)
+	snapshotCmd.AddCommand(snapshotImportCmd)
+	snapshotCmd.AddCommand(snapshotVerifyCmd)
+	snapshotCmd.AddCommand(snapshotPushCmd)
+	snapshotCmd.AddCommand(snapshotPullCmd)
 
 	// Create command flags
 	snapshotCreateCmd.Flags().String("tag", "", "tag for the snapshot")
 	snapshotCreateCmd.Flags().String("note", "", "note/description for the snapshot")
 	snapshotCreateCmd.Flags().String("compress", "zstd", "compression type: zstd, gzip, none")
+	snapshotCreateCmd.Flags().Bool("encrypt", false, "encrypt the snapshot with age to the configured recipients")
+	snapshotCreateCmd.Flags().StringArray("recipient", nil, "age public key to encrypt to (repeatable, implies --encrypt)")
+	snapshotCreateCmd.Flags().String("storage", "", "storage for the snapshot data: files, chunked (default: snapshots.storage, then files)")
 
 	// List command flags
 	snapshotListCmd.Flags().Bool("json", false, "output in JSON format")
+	snapshotListCmd.Flags().Bool("remote", false, "list snapshots on the remote")
 
 	// Restore command flags
 	snapshotRestoreCmd.Flags().String("tag", "", "restore specific tag")
 	snapshotRestoreCmd.Flags().Bool("latest", false, "restore latest snapshot")
 	snapshotRestoreCmd.Flags().String("before", "", "restore latest snapshot before timestamp (YYYY-MM-DD HH:MM)")
 	snapshotRestoreCmd.Flags().Bool("force", false, "force restore even if errors occur")
+	snapshotRestoreCmd.Flags().String("identity", "", "age identity file for encrypted snapshots")
+	snapshotRestoreCmd.Flags().String("into", "", "restore into this service instead of the snapshot's own")
+	snapshotRestoreCmd.Flags().Bool("allow-older", false, "allow --into a service running an older major version")
 
 	// Prune command flags
-	snapshotPruneCmd.Flags().Int("keep", 3, "number of snapshots to keep")
+	snapshotPruneCmd.Flags().Bool("all", false, "prune every service with snapshots")
+	snapshotPruneCmd.Flags().Int("keep", 0, "number of recent snapshots to keep, instead of the configured rules")
 	snapshotPruneCmd.Flags().Bool("dry-run", false, "show what would be removed without removing")
-	snapshotPruneCmd.MarkFlagRequired("keep")
+
+	// Pin command flags
+	snapshotPinCmd.Flags().String("tag", "", "pin specific tag (default: latest)")
+	snapshotUnpinCmd.Flags().String("tag", "", "unpin specific tag (default: latest)")
+
+	// Export command flags
+	snapshotExportCmd.Flags().String("tag", "", "export specific tag (default: latest)")
+	snapshotExportCmd.Flags().StringP("output", "o", "", "output file, or - for stdout")
+
+	// Import command flags
+	snapshotImportCmd.Flags().String("service", "", "register the snapshot under this service")
+
+	// Verify command flags
+	snapshotVerifyCmd.Flags().Bool("trial", false, "trial restore each snapshot into a throwaway container")
+	snapshotVerifyCmd.Flags().String("identity", "", "age identity file for trial restoring encrypted snapshots")
+	snapshotVerifyCmd.Flags().Duration("timeout", 10*time.Minute, "maximum time for the whole run")
+	snapshotVerifyCmd.Flags().Bool("json", false, "output in JSON format")
+
+	// Push and pull command flags
+	snapshotPushCmd.Flags().String("tag", "", "push specific tag (default: latest)")
+	snapshotPullCmd.Flags().String("tag", "", "pull specific tag (default: latest)")
 }
 
 func runSnapshotCreate(cmd *cobra.Command, args []string) error {
@@ -121,6 +327,9 @@ func runSnapshotCreate(cmd *cobra.Command, args []string) error {
 	tag, _ := cmd.Flags().GetString("tag")
 	note, _ := cmd.Flags().GetString("note")
 	compressFlag, _ := cmd.Flags().GetString("compress")
+	encrypt, _ := cmd.Flags().GetBool("encrypt")
+	recipients, _ := cmd.Flags().GetStringArray("recipient")
+	storage, _ := cmd.Flags().GetString("storage")
 
 	// Validate compression
 	var compression compress.Compression
@@ -141,15 +350,27 @@ func runSnapshotCreate(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("failed to load config: %w", err)
 	}
 
+	// Resolve encryption recipients
+	if encrypt && len(recipients) == 0 {
+		recipients = cfg.EncryptionRecipients()
+		if len(recipients) == 0 {
+			return fmt.Errorf("--encrypt needs recipients; pass --recipient or set snapshots.encryption.recipients in the config")
+		}
+	}
+
+	if storage == "" {
+		storage = cfg.SnapshotStorage()
+	}
+
 	// Create Docker client
-	docker, err := dockerx.NewClient()
+	dockerClient, err := docker.NewClient()
 	if err != nil {
 		return fmt.Errorf("failed to create Docker client: %w", err)
 	}
-	defer docker.Close()
+	defer dockerClient.Close()
 
 	// Create snapshot service
-	snapshotSvc := snapshot.NewService(docker)
+	snapshotSvc := snapshot.NewService(dockerClient)
 
 	// Create snapshot
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
@@ -159,6 +380,8 @@ func runSnapshotCreate(cmd *cobra.Command, args []string) error {
 		Tag:         tag,
 		Note:        note,
 		Compression: compression,
+		Recipients:  recipients,
+		Storage:     storage,
 	}
 
 	manifest, err := snapshotSvc.Create(ctx, cfg, serviceName, opts)
@@ -171,6 +394,16 @@ func runSnapshotCreate(cmd *cobra.Command, args []string) error {
 	fmt.Printf("  Tag: %s\n", manifest.Tag)
 	fmt.Printf("  Created: %s\n", manifest.CreatedAt.Format("2006-01-02 15:04:05"))
 	fmt.Printf("  Compression: %s\n", manifest.Compression)
+	if manifest.IsChunked() {
+		chunks := 0
+		for _, file := range manifest.Files {
+			chunks += len(file.Chunks)
+		}
+		fmt.Printf("  Storage: %s (%d chunks)\n", manifest.Storage, chunks)
+	}
+	if manifest.IsEncrypted() {
+		fmt.Printf("  Encryption: %s (%d recipient(s))\n", manifest.Encryption, len(manifest.Recipients))
+	}
 	if manifest.Note != "" {
 		fmt.Printf("  Note: %s\n", manifest.Note)
 	}
@@ -181,6 +414,7 @@ func runSnapshotCreate(cmd *cobra.Command, args []string) error {
 func runSnapshotList(cmd *cobra.Command, args []string) error {
 	// Parse flags
 	jsonOutput, _ := cmd.Flags().GetBool("json")
+	remoteList, _ := cmd.Flags().GetBool("remote")
 
 	var serviceName string
 	if len(args) > 0 {
@@ -188,14 +422,18 @@ func runSnapshotList(cmd *cobra.Command, args []string) error {
 	}
 
 	// Create Docker client (needed for snapshot service)
-	docker, err := dockerx.NewClient()
+	dockerClient, err := docker.NewClient()
 	if err != nil {
 		return fmt.Errorf("failed to create Docker client: %w", err)
 	}
-	defer docker.Close()
+	defer dockerClient.Close()
 
 	// Create snapshot service
-	snapshotSvc := snapshot.NewService(docker)
+	snapshotSvc := snapshot.NewService(dockerClient)
+
+	if remoteList {
+		return listRemoteSnapshots(snapshotSvc, serviceName, jsonOutput)
+	}
 
 	// List snapshots
 	snapshots, err := snapshotSvc.List(serviceName)
@@ -219,7 +457,7 @@ func runSnapshotList(cmd *cobra.Command, args []string) error {
 	}
 
 	// Table output - prepare data
-	headers := []string{"Service", "Tag", "Created", "Age", "Size", "Engine", "Note"}
+	headers := []string{"Service", "Tag", "Created", "Age", "Size", "Dedup", "Engine", "Encrypted", "Pinned", "Note"}
 	rows := [][]string{}
 
 	for _, snapshot := range snapshots {
@@ -236,13 +474,26 @@ func runSnapshotList(cmd *cobra.Command, args []string) error {
 			note = "-"
 		}
 
+		encrypted := "no"
+		if snapshot.Encrypted {
+			encrypted = "yes"
+		}
+
+		pinned := "no"
+		if snapshot.Pinned {
+			pinned = "yes"
+		}
+
 		rows = append(rows, []string{
 			snapshot.Service,
 			tag,
 			snapshot.CreatedAt.Format("2006-01-02 15:04"),
 			snapshot.GetAge(),
-			snapshot.FormatSize(),
+			snapshot.FormatLogicalSize(),
+			snapshot.FormatDedupSize(),
 			snapshot.Engine,
+			encrypted,
+			pinned,
 			note,
 		})
 	}
@@ -258,17 +509,120 @@ func runSnapshotList(cmd *cobra.Command, args []string) error {
 	table.Render()
 	fmt.Printf("\nTotal: %d snapshots\n", len(snapshots))
 
+	// Chunked snapshots share the chunk store, which Dedup sizes exclude
+	for _, info := range snapshots {
+		if info.Chunked {
+			if chunks, size, err := snapshotSvc.ChunkStoreUsage(); err == nil {
+				fmt.Printf("Chunk store: %s in %d chunks\n", snapshot.FormatBytes(size), chunks)
+			}
+			break
+		}
+	}
+
+	return nil
+}
+
+// listRemoteSnapshots prints the snapshots on the configured remote
+func listRemoteSnapshots(snapshotSvc *snapshot.Service, serviceName string, jsonOutput bool) error {
+	backend, err := openSnapshotRemote()
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+
+	snapshots, err := snapshotSvc.ListRemote(ctx, backend, serviceName)
+	if err != nil {
+		return fmt.Errorf("failed to list remote snapshots: %w", err)
+	}
+
+	if len(snapshots) == 0 {
+		if serviceName != "" {
+			fmt.Printf("No snapshots found for service '%s' on %s\n", serviceName, backend)
+		} else {
+			fmt.Printf("No snapshots found on %s\n", backend)
+		}
+		return nil
+	}
+
+	if jsonOutput {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(snapshots)
+	}
+
+	headers := []string{"Service", "Tag", "Created", "Age", "Size", "Engine", "Encrypted", "Note"}
+	table := tablewriter.NewTable(os.Stdout,
+		tablewriter.WithHeader(headers),
+	)
+
+	for _, info := range snapshots {
+		tag := info.Tag
+		if tag == "" {
+			tag = "-"
+		}
+
+		note := info.Note
+		if len(note) > 30 {
+			note = note[:27] + "..."
+		}
+		if note == "" {
+			note = "-"
+		}
+
+		encrypted := "no"
+		if info.Encrypted {
+			encrypted = "yes"
+		}
+
+		table.Append([]string{
+			info.Service,
+			tag,
+			info.CreatedAt.Format("2006-01-02 15:04"),
+			info.GetAge(),
+			info.FormatSize(),
+			info.Engine,
+			encrypted,
+			note,
+		})
+	}
+	table.Render()
+	fmt.Printf("\nTotal: %d snapshots on %s\n", len(snapshots), backend)
 	return nil
 }
 
+// openSnapshotRemote opens the remote in snapshots.remote
+func openSnapshotRemote() (remote.Backend, error) {
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load config: %w", err)
+	}
+	backend, err := remote.Open(cfg.SnapshotRemote())
+	if err != nil {
+		return nil, fmt.Errorf("failed to open remote: %w", err)
+	}
+	return backend, nil
+}
+
 func runSnapshotRestore(cmd *cobra.Command, args []string) error {
-	serviceName := args[0]
+	serviceName, refTag := snapshot.ParseRef(args[0])
 
 	// Parse flags
 	tag, _ := cmd.Flags().GetString("tag")
 	latest, _ := cmd.Flags().GetBool("latest")
 	beforeStr, _ := cmd.Flags().GetString("before")
 	force, _ := cmd.Flags().GetBool("force")
+	identity, _ := cmd.Flags().GetString("identity")
+	into, _ := cmd.Flags().GetString("into")
+	allowOlder, _ := cmd.Flags().GetBool("allow-older")
+
+	if refTag != "" {
+		if tag != "" && tag != refTag {
+			return fmt.Errorf("snapshot reference %s conflicts with --tag %s", args[0], tag)
+		}
+		tag = refTag
+	}
 
 	// Parse before timestamp
 	var beforeTime *time.Time
@@ -302,60 +656,504 @@ func runSnapshotRestore(cmd *cobra.Command, args []string) error {
 	}
 
 	// Create Docker client
-	docker, err := dockerx.NewClient()
+	dockerClient, err := docker.NewClient()
 	if err != nil {
 		return fmt.Errorf("failed to create Docker client: %w", err)
 	}
-	defer docker.Close()
+	defer dockerClient.Close()
 
 	// Create snapshot service
-	snapshotSvc := snapshot.NewService(docker)
+	snapshotSvc := snapshot.NewService(dockerClient)
 
 	// Restore snapshot
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
 	defer cancel()
 
 	opts := snapshot.RestoreOptions{
-		Tag:    tag,
-		Latest: latest,
-		Before: beforeTime,
-		Force:  force,
+		Tag:        tag,
+		Latest:     latest,
+		Before:     beforeTime,
+		Force:      force,
+		Into:       into,
+		AllowOlder: allowOlder,
+	}
+	switch {
+	case identity != "":
+		opts.IdentityFile = identity
+	case cfg.EncryptionIdentity() != "":
+		opts.IdentityFile = cfg.EncryptionIdentity()
+	default:
+		opts.IdentityFile = os.Getenv("NIZAM_AGE_IDENTITY_FILE")
 	}
 
 	if err := snapshotSvc.Restore(ctx, cfg, serviceName, opts); err != nil {
 		return fmt.Errorf("failed to restore snapshot: %w", err)
 	}
 
+	if into != "" && into != serviceName {
+		fmt.Printf("Snapshot of service '%s' restored successfully into '%s'\n", serviceName, into)
+		return nil
+	}
 	fmt.Printf("Snapshot restored successfully for service '%s'\n", serviceName)
 	return nil
 }
 
 func runSnapshotPrune(cmd *cobra.Command, args []string) error {
-	serviceName := args[0]
-
 	// Parse flags
+	all, _ := cmd.Flags().GetBool("all")
 	keep, _ := cmd.Flags().GetInt("keep")
 	dryRun, _ := cmd.Flags().GetBool("dry-run")
 
+	if all == (len(args) > 0) {
+		return fmt.Errorf("specify a service or --all")
+	}
+	if keep < 0 {
+		return fmt.Errorf("--keep must not be negative")
+	}
+
+	// Retention rules come from the config; --keep works without one
+	cfg, cfgErr := config.LoadConfig()
+	if cfgErr != nil && keep == 0 {
+		return fmt.Errorf("failed to load config: %w", cfgErr)
+	}
+
+	// Create Docker client (needed for snapshot service)
+	dockerClient, err := docker.NewClient()
+	if err != nil {
+		return fmt.Errorf("failed to create Docker client: %w", err)
+	}
+	defer dockerClient.Close()
+
+	// Create snapshot service
+	snapshotSvc := snapshot.NewService(dockerClient)
+
+	services := args
+	if all {
+		services, err = snapshotSvc.ListServices()
+		if err != nil {
+			return fmt.Errorf("failed to list services: %w", err)
+		}
+		if len(services) == 0 {
+			fmt.Println("No snapshots found")
+			return nil
+		}
+	}
+
+	var decisions []snapshot.RetentionDecision
+	for _, serviceName := range services {
+		policy := snapshot.RetentionPolicy{KeepLast: keep}
+		if keep == 0 {
+			policy, err = snapshot.PolicyFromConfig(cfg.RetentionFor(serviceName))
+			if err != nil {
+				return fmt.Errorf("invalid retention for service %s: %w", serviceName, err)
+			}
+		}
+		if policy.IsZero() {
+			return fmt.Errorf("no retention rules for service %s; set snapshots.retention in the config or pass --keep", serviceName)
+		}
+
+		serviceDecisions, err := snapshotSvc.Prune(serviceName, snapshot.PruneOptions{
+			Policy: policy,
+			DryRun: dryRun,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to prune snapshots for service %s: %w", serviceName, err)
+		}
+		decisions = append(decisions, serviceDecisions...)
+	}
+
+	if dryRun {
+		printRetentionDecisions(decisions)
+		return nil
+	}
+
+	var removed int
+	var removedSize int64
+	for _, decision := range decisions {
+		if !decision.Keep {
+			removed++
+			removedSize += decision.Snapshot.Size
+		}
+	}
+	fmt.Printf("Removed %d snapshots (%s), kept %d\n", removed, units.BytesSize(float64(removedSize)), len(decisions)-removed)
+	return nil
+}
+
+// printRetentionDecisions renders what a prune keeps and removes as a table
+func printRetentionDecisions(decisions []snapshot.RetentionDecision) {
+	if len(decisions) == 0 {
+		fmt.Println("No snapshots found")
+		return
+	}
+
+	headers := []string{"Service", "Tag", "Created", "Size", "Action", "Reason"}
+	table := tablewriter.NewTable(os.Stdout,
+		tablewriter.WithHeader(headers),
+	)
+
+	removed := 0
+	for _, decision := range decisions {
+		tag := decision.Snapshot.Tag
+		if tag == "" {
+			tag = "-"
+		}
+		action := "keep"
+		if !decision.Keep {
+			action = "remove"
+			removed++
+		}
+
+		table.Append([]string{
+			decision.Snapshot.Service,
+			tag,
+			decision.Snapshot.CreatedAt.Format("2006-01-02 15:04"),
+			decision.Snapshot.FormatSize(),
+			action,
+			strings.Join(decision.Reasons, ", "),
+		})
+	}
+	table.Render()
+	fmt.Printf("\nWould remove %d of %d snapshots (dry run)\n", removed, len(decisions))
+}
+
+func runSnapshotPin(cmd *cobra.Command, args []string) error {
+	return setSnapshotPinned(cmd, args[0], true)
+}
+
+func runSnapshotUnpin(cmd *cobra.Command, args []string) error {
+	return setSnapshotPinned(cmd, args[0], false)
+}
+
+// setSnapshotPinned pins or unpins the snapshot selected by --tag
+func setSnapshotPinned(cmd *cobra.Command, serviceName string, pinned bool) error {
+	tag, _ := cmd.Flags().GetString("tag")
+
+	// Create Docker client (needed for snapshot service)
+	dockerClient, err := docker.NewClient()
+	if err != nil {
+		return fmt.Errorf("failed to create Docker client: %w", err)
+	}
+	defer dockerClient.Close()
+
+	// Create snapshot service
+	snapshotSvc := snapshot.NewService(dockerClient)
+
+	info, err := snapshotSvc.SetPinned(serviceName, tag, pinned)
+	if err != nil {
+		return fmt.Errorf("failed to update snapshot: %w", err)
+	}
+
+	state := "Pinned"
+	if !pinned {
+		state = "Unpinned"
+	}
+	fmt.Printf("%s snapshot %s of service '%s'\n", state, info.GetDisplayName(), serviceName)
+	return nil
+}
+
+func runSnapshotExport(cmd *cobra.Command, args []string) error {
+	serviceName := args[0]
+
+	// Parse flags
+	tag, _ := cmd.Flags().GetString("tag")
+	output, _ := cmd.Flags().GetString("output")
+
+	// Create Docker client (needed for snapshot service)
+	dockerClient, err := docker.NewClient()
+	if err != nil {
+		return fmt.Errorf("failed to create Docker client: %w", err)
+	}
+	defer dockerClient.Close()
+
+	// Create snapshot service
+	snapshotSvc := snapshot.NewService(dockerClient)
+	opts := snapshot.ExportOptions{Tag: tag}
+
+	if output == "-" {
+		if _, err := snapshotSvc.Export(serviceName, opts, os.Stdout); err != nil {
+			return fmt.Errorf("failed to export snapshot: %w", err)
+		}
+		return nil
+	}
+
+	// Write to a temporary file first so a failed export leaves nothing behind
+	var file *os.File
+	if output == "" {
+		file, err = os.CreateTemp(".", "."+serviceName+"-*"+snapshot.ArchiveExtension)
+	} else {
+		file, err = os.CreateTemp(filepath.Dir(output), "."+filepath.Base(output)+"-*")
+	}
+	if err != nil {
+		return fmt.Errorf("failed to create output file: %w", err)
+	}
+	defer os.Remove(file.Name())
+
+	manifest, err := snapshotSvc.Export(serviceName, opts, file)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("failed to export snapshot: %w", err)
+	}
+
+	if output == "" {
+		name := manifest.CreatedAt.Format("20060102-150405")
+		if tag != "" {
+			name = tag
+		}
+		output = fmt.Sprintf("%s-%s%s", serviceName, name, snapshot.ArchiveExtension)
+	}
+	if err := os.Rename(file.Name(), output); err != nil {
+		return fmt.Errorf("failed to write %s: %w", output, err)
+	}
+
+	fmt.Printf("Snapshot exported successfully:\n")
+	fmt.Printf("  Service: %s\n", manifest.Service)
+	fmt.Printf("  Created: %s\n", manifest.CreatedAt.Format("2006-01-02 15:04:05"))
+	fmt.Printf("  File: %s\n", output)
+	return nil
+}
+
+func runSnapshotImport(cmd *cobra.Command, args []string) error {
+	// Parse flags
+	serviceName, _ := cmd.Flags().GetString("service")
+
+	var input io.Reader = os.Stdin
+	if args[0] != "-" {
+		file, err := os.Open(args[0])
+		if err != nil {
+			return fmt.Errorf("failed to open archive: %w", err)
+		}
+		defer file.Close()
+		input = file
+	}
+
+	// Create Docker client (needed for snapshot service)
+	dockerClient, err := docker.NewClient()
+	if err != nil {
+		return fmt.Errorf("failed to create Docker client: %w", err)
+	}
+	defer dockerClient.Close()
+
+	// Create snapshot service
+	snapshotSvc := snapshot.NewService(dockerClient)
+
+	info, err := snapshotSvc.Import(input, snapshot.ImportOptions{Service: serviceName})
+	if err != nil {
+		return fmt.Errorf("failed to import snapshot: %w", err)
+	}
+
+	fmt.Printf("Snapshot imported successfully:\n")
+	fmt.Printf("  Service: %s\n", info.Service)
+	if info.Tag != "" {
+		fmt.Printf("  Tag: %s\n", info.Tag)
+	}
+	fmt.Printf("  Created: %s\n", info.CreatedAt.Format("2006-01-02 15:04:05"))
+	fmt.Printf("  Size: %s\n", info.FormatSize())
+	return nil
+}
+
+func runSnapshotPush(cmd *cobra.Command, args []string) error {
+	serviceName := args[0]
+
+	// Parse flags
+	tag, _ := cmd.Flags().GetString("tag")
+
+	backend, err := openSnapshotRemote()
+	if err != nil {
+		return err
+	}
+
+	// Create Docker client (needed for snapshot service)
+	dockerClient, err := docker.NewClient()
+	if err != nil {
+		return fmt.Errorf("failed to create Docker client: %w", err)
+	}
+	defer dockerClient.Close()
+
+	// Create snapshot service
+	snapshotSvc := snapshot.NewService(dockerClient)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Minute)
+	defer cancel()
+
+	manifest, err := snapshotSvc.Push(ctx, backend, serviceName, snapshot.PushOptions{Tag: tag})
+	if err != nil {
+		return fmt.Errorf("failed to push snapshot: %w", err)
+	}
+
+	var size int64
+	for _, file := range manifest.Files {
+		size += file.Size
+	}
+
+	fmt.Printf("Snapshot pushed successfully:\n")
+	fmt.Printf("  Service: %s\n", manifest.Service)
+	if manifest.Tag != "" {
+		fmt.Printf("  Tag: %s\n", manifest.Tag)
+	}
+	fmt.Printf("  Created: %s\n", manifest.CreatedAt.Format("2006-01-02 15:04:05"))
+	fmt.Printf("  Size: %s\n", snapshot.FormatBytes(size))
+	fmt.Printf("  Remote: %s\n", backend)
+	return nil
+}
+
+func runSnapshotPull(cmd *cobra.Command, args []string) error {
+	serviceName := args[0]
+
+	// Parse flags
+	tag, _ := cmd.Flags().GetString("tag")
+
+	backend, err := openSnapshotRemote()
+	if err != nil {
+		return err
+	}
+
 	// Create Docker client (needed for snapshot service)
-	docker, err := dockerx.NewClient()
+	dockerClient, err := docker.NewClient()
+	if err != nil {
+		return fmt.Errorf("failed to create Docker client: %w", err)
+	}
+	defer dockerClient.Close()
+
+	// Create snapshot service
+	snapshotSvc := snapshot.NewService(dockerClient)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Minute)
+	defer cancel()
+
+	info, err := snapshotSvc.Pull(ctx, backend, serviceName, snapshot.PullOptions{Tag: tag})
+	if err != nil {
+		return fmt.Errorf("failed to pull snapshot: %w", err)
+	}
+
+	fmt.Printf("Snapshot pulled successfully:\n")
+	fmt.Printf("  Service: %s\n", info.Service)
+	if info.Tag != "" {
+		fmt.Printf("  Tag: %s\n", info.Tag)
+	}
+	fmt.Printf("  Created: %s\n", info.CreatedAt.Format("2006-01-02 15:04:05"))
+	fmt.Printf("  Size: %s\n", info.FormatSize())
+	fmt.Printf("  Remote: %s\n", backend)
+	return nil
+}
+
+func runSnapshotVerify(cmd *cobra.Command, args []string) error {
+	// Parse flags
+	trial, _ := cmd.Flags().GetBool("trial")
+	identity, _ := cmd.Flags().GetString("identity")
+	timeout, _ := cmd.Flags().GetDuration("timeout")
+	jsonOutput, _ := cmd.Flags().GetBool("json")
+
+	var serviceName string
+	if len(args) > 0 {
+		serviceName = args[0]
+	}
+
+	if identity == "" {
+		if cfg, err := config.LoadConfig(); err == nil {
+			identity = cfg.EncryptionIdentity()
+		}
+	}
+	if identity == "" {
+		identity = os.Getenv("NIZAM_AGE_IDENTITY_FILE")
+	}
+
+	// Create Docker client (needed for trial restores)
+	dockerClient, err := docker.NewClient()
 	if err != nil {
 		return fmt.Errorf("failed to create Docker client: %w", err)
 	}
-	defer docker.Close()
+	defer dockerClient.Close()
 
 	// Create snapshot service
-	snapshotSvc := snapshot.NewService(docker)
+	snapshotSvc := snapshot.NewService(dockerClient)
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
 
-	// Prune snapshots
-	opts := snapshot.PruneOptions{
-		Keep:   keep,
-		DryRun: dryRun,
+	report, err := snapshotSvc.Verify(ctx, serviceName, snapshot.VerifyOptions{
+		Trial:        trial,
+		IdentityFile: identity,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to verify snapshots: %w", err)
 	}
 
-	if err := snapshotSvc.Prune(serviceName, opts); err != nil {
-		return fmt.Errorf("failed to prune snapshots: %w", err)
+	if jsonOutput {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(report); err != nil {
+			return err
+		}
+	} else {
+		printVerifyReport(report, trial)
 	}
 
+	if !report.OK {
+		cmd.SilenceUsage = true
+		return fmt.Errorf("snapshot verification failed")
+	}
 	return nil
 }
+
+// printVerifyReport renders a verify report as a table
+func printVerifyReport(report *snapshot.VerifyReport, trial bool) {
+	if len(report.Snapshots) == 0 && len(report.Orphans) == 0 {
+		fmt.Println("No snapshots found")
+		return
+	}
+
+	headers := []string{"Service", "Tag", "Created", "Status"}
+	if trial {
+		headers = append(headers, "Trial")
+	}
+	headers = append(headers, "Details")
+
+	table := tablewriter.NewTable(os.Stdout,
+		tablewriter.WithHeader(headers),
+	)
+	failed := 0
+	for _, result := range report.Snapshots {
+		tag := result.Tag
+		if tag == "" {
+			tag = "-"
+		}
+		created := "-"
+		if !result.CreatedAt.IsZero() {
+			created = result.CreatedAt.Format("2006-01-02 15:04")
+		}
+		status := "✅ ok"
+		if !result.OK {
+			status = "❌ failed"
+			failed++
+		}
+		details := strings.Join(result.Errors, "; ")
+		if details == "" {
+			details = result.TrialNote
+		}
+		if details == "" {
+			details = "-"
+		}
+
+		row := []string{result.Service, tag, created, status}
+		if trial {
+			trialStatus := result.Trial
+			if trialStatus == "" {
+				trialStatus = "-"
+			}
+			row = append(row, trialStatus)
+		}
+		table.Append(append(row, details))
+	}
+	table.Render()
+
+	if len(report.Orphans) > 0 {
+		fmt.Printf("\n⚠️  Orphaned files from interrupted snapshots:\n")
+		for _, orphan := range report.Orphans {
+			fmt.Printf("  %s\n", orphan)
+		}
+	}
+
+	fmt.Printf("\nVerified %d snapshots: %d failed, %d orphaned files\n", len(report.Snapshots), failed, len(report.Orphans))
+}
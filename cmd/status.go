@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"strings"
 
+	"github.com/abdultolba/nizam/internal/config"
 	"github.com/abdultolba/nizam/internal/docker"
 	"github.com/spf13/cobra"
 )
@@ -16,13 +17,16 @@ var statusCmd = &cobra.Command{
 - Service name and container ID
 - Current status (running, stopped, etc.)
 - Port mappings
-- Docker image information`,
+- Docker image information
+- Networks each service is attached to
+- Services whose container is out of date with .nizam.yaml (marked with *)`,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		dockerClient, err := docker.NewClient()
 		if err != nil {
 			return fmt.Errorf("failed to create Docker client: %w", err)
 		}
 		defer dockerClient.Close()
+		dockerClient.SetProject(config.CurrentProjectName())
 
 		ctx := context.Background()
 
@@ -37,18 +41,30 @@ var statusCmd = &cobra.Command{
 			return nil
 		}
 
+		// Load the config, if any, to detect containers that are out of date
+		var cfg *config.Config
+		if config.ConfigExists() {
+			if cfg, _ = config.LoadConfig(); cfg != nil {
+				if lock, err := cfg.LoadLock(); err == nil {
+					cfg.ApplyLock(lock)
+				}
+			}
+		}
+
 		fmt.Printf("📊 Nizam Services Status (%d service(s))\n\n", len(containers))
 
 		// Print table header
-		fmt.Printf("%-15s %-12s %-20s %-20s %s\n", "SERVICE", "STATUS", "CONTAINER ID", "PORTS", "IMAGE")
-		fmt.Printf("%-15s %-12s %-20s %-20s %s\n",
+		fmt.Printf("%-15s %-12s %-20s %-20s %-20s %s\n", "SERVICE", "STATUS", "CONTAINER ID", "PORTS", "IMAGE", "NETWORKS")
+		fmt.Printf("%-15s %-12s %-20s %-20s %-20s %s\n",
 			strings.Repeat("-", 15),
 			strings.Repeat("-", 12),
 			strings.Repeat("-", 20),
 			strings.Repeat("-", 20),
+			strings.Repeat("-", 20),
 			strings.Repeat("-", 20))
 
 		// Print service information
+		outdated := 0
 		for _, container := range containers {
 			status := getStatusEmoji(container.Status) + " " + container.Status
 			ports := strings.Join(container.Ports, ", ")
@@ -62,12 +78,28 @@ var statusCmd = &cobra.Command{
 				image = image[:17] + "..."
 			}
 
-			fmt.Printf("%-15s %-12s %-20s %-20s %s\n",
-				container.Service,
+			networks := strings.Join(container.Networks, ", ")
+			if networks == "" {
+				networks = "none"
+			}
+
+			service := container.Service
+			if isOutdated(cfg, container) {
+				service += " *"
+				outdated++
+			}
+
+			fmt.Printf("%-15s %-12s %-20s %-20s %-20s %s\n",
+				service,
 				status,
 				container.ID,
 				ports,
-				image)
+				image,
+				networks)
+		}
+
+		if outdated > 0 {
+			fmt.Printf("\n* %d service(s) differ from .nizam.yaml; run 'nizam up' to recreate them\n", outdated)
 		}
 
 		fmt.Println("\n💡 Use 'nizam logs <service>' to view logs")
@@ -77,6 +109,27 @@ var statusCmd = &cobra.Command{
 	},
 }
 
+// isOutdated reports whether a container was created from a different
+// configuration than the one currently defined for its service
+func isOutdated(cfg *config.Config, container docker.ContainerInfo) bool {
+	if cfg == nil {
+		return false
+	}
+	service, exists := cfg.GetService(container.Service)
+	if !exists {
+		return false
+	}
+	// Built services run the image tagged with their context hash
+	if service.Build != nil {
+		contextHash, err := docker.BuildContextHash(service.Build)
+		if err != nil {
+			return false
+		}
+		service.Image = docker.BuildImageName(cfg.ProjectName(), container.Service, contextHash)
+	}
+	return container.ConfigHash != service.Hash()
+}
+
 func getStatusEmoji(status string) string {
 	statusLower := strings.ToLower(status)
 
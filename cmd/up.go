@@ -2,24 +2,59 @@ package cmd
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/abdultolba/nizam/internal/config"
 	"github.com/abdultolba/nizam/internal/docker"
+	"github.com/abdultolba/nizam/internal/migrate"
+	"github.com/abdultolba/nizam/internal/operations"
 	"github.com/spf13/cobra"
 )
 
+var (
+	upNoDeps        bool
+	upWaitTimeout   time.Duration
+	upNoRecreate    bool
+	upForceRecreate bool
+	upPortStrategy  string
+	upMigrate       bool
+	upAdoptLegacy   bool
+)
+
 var upCmd = &cobra.Command{
 	Use:   "up [services...]",
 	Short: "Start one or more services",
 	Long: `Start one or more services defined in your .nizam.yaml configuration.
 If no services are specified, all services will be started.
 
+Services listed in a service's depends_on are started first, together with
+everything they depend on. Independent services start in parallel, and a
+dependent service waits until each dependency meets its condition
+(started, healthy or port-open).
+
+Existing containers are recreated when the service's configuration changed
+since they were created. Use --no-recreate to keep existing containers, or
+--force-recreate to replace them regardless.
+
+Ports written as "auto:5432" are published on the first free host port from
+5432 upwards. With --port-strategy=next-free, fixed ports that are taken
+move to the next free port too. The ports actually used are shown by
+'nizam status' and picked up by psql, snapshot and wait-for.
+
+With --migrate, the migrations of started services that set 'migrations'
+in their config are applied once the database accepts connections.
+
 Examples:
   nizam up                    # Start all services
   nizam up postgres           # Start only postgres
-  nizam up postgres redis     # Start postgres and redis`,
+  nizam up postgres redis     # Start postgres and redis
+  nizam up api --no-deps      # Start api without its dependencies
+  nizam up --force-recreate   # Recreate all containers
+  nizam up --port-strategy=next-free  # Move off host ports that are taken
+  nizam up --migrate          # Start and apply pending migrations`,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		// Check if config exists
 		if !config.ConfigExists() {
@@ -32,54 +67,71 @@ Examples:
 			return fmt.Errorf("failed to load configuration: %w", err)
 		}
 
+		if err := cfg.ValidateDependencies(); err != nil {
+			return fmt.Errorf("invalid configuration: %w", err)
+		}
+
+		if err := applyLock(cfg); err != nil {
+			return err
+		}
+
+		if err := config.ValidatePortStrategy(upPortStrategy); err != nil {
+			return err
+		}
+
+		if upNoRecreate && upForceRecreate {
+			return fmt.Errorf("--no-recreate and --force-recreate cannot be used together")
+		}
+		opts := operations.StartOptions{
+			WaitForDeps:  !upNoDeps,
+			WaitTimeout:  upWaitTimeout,
+			Recreate:     docker.RecreateIfChanged,
+			PortStrategy: upPortStrategy,
+			AdoptLegacy:  upAdoptLegacy,
+		}
+		if upNoRecreate {
+			opts.Recreate = docker.RecreateNever
+		} else if upForceRecreate {
+			opts.Recreate = docker.RecreateAlways
+		}
+
 		// Create Docker client
 		dockerClient, err := docker.NewClient()
 		if err != nil {
 			return fmt.Errorf("failed to create Docker client: %w", err)
 		}
 		defer dockerClient.Close()
+		dockerClient.UseConfig(cfg)
+
+		runner := operations.NewRunner(dockerClient, cfg)
+		runner.Progress = printProgress
 
 		// Determine which services to start
-		servicesToStart := make(map[string]config.Service)
+		servicesToStart, err := runner.StartOrder(args, opts)
+		if err != nil {
+			return err
+		}
 
 		if len(args) == 0 {
-			// Start all services
-			servicesToStart = cfg.GetAllServices()
-			fmt.Printf("🚀 Starting all services (%s)...\n", strings.Join(cfg.GetServiceNames(), ", "))
+			fmt.Printf("🚀 Starting all services (%s)...\n", strings.Join(servicesToStart, ", "))
 		} else {
-			// Start specific services
-			for _, serviceName := range args {
-				service, exists := cfg.GetService(serviceName)
-				if !exists {
-					return fmt.Errorf("service '%s' not found in configuration", serviceName)
-				}
-				servicesToStart[serviceName] = service
-			}
-			fmt.Printf("🚀 Starting services: %s...\n", strings.Join(args, ", "))
+			fmt.Printf("🚀 Starting services: %s...\n", strings.Join(servicesToStart, ", "))
 		}
 
-		// Start services
-		ctx := context.Background()
-		var errors []string
-
-		for serviceName, serviceConfig := range servicesToStart {
-			fmt.Printf("   Starting %s...", serviceName)
-
-			if err := dockerClient.StartService(ctx, serviceName, serviceConfig); err != nil {
-				fmt.Printf(" ❌\n")
-				errors = append(errors, fmt.Sprintf("%s: %v", serviceName, err))
-				continue
+		if err := runner.Start(context.Background(), args, opts); err != nil {
+			var failures operations.Errors
+			if !errors.As(err, &failures) {
+				return err
 			}
-
-			fmt.Printf(" ✅\n")
+			fmt.Println("\n⚠️  Some services failed to start:")
+			printFailures(failures)
+			return fmt.Errorf("failed to start %d service(s)", len(failures))
 		}
 
-		if len(errors) > 0 {
-			fmt.Println("\n⚠️  Some services failed to start:")
-			for _, err := range errors {
-				fmt.Printf("   • %s\n", err)
+		if upMigrate {
+			if err := migrateStarted(dockerClient, cfg, servicesToStart); err != nil {
+				return err
 			}
-			return fmt.Errorf("failed to start %d service(s)", len(errors))
 		}
 
 		fmt.Println("\n🎉 All services started successfully!")
@@ -91,5 +143,50 @@ Examples:
 }
 
 func init() {
+	upCmd.Flags().BoolVar(&upNoDeps, "no-deps", false, "don't start or wait for dependencies of the given services")
+	upCmd.Flags().BoolVar(&upNoRecreate, "no-recreate", false, "keep existing containers even if their configuration changed")
+	upCmd.Flags().BoolVar(&upForceRecreate, "force-recreate", false, "recreate containers even if their configuration is unchanged")
+	upCmd.Flags().StringVar(&upPortStrategy, "port-strategy", config.PortStrategyFixed, "what to do when a host port is taken: fixed (fail) or next-free")
+	upCmd.Flags().DurationVar(&upWaitTimeout, "wait-timeout", 2*time.Minute, "maximum time to wait for each dependency condition")
+	upCmd.Flags().BoolVar(&upMigrate, "migrate", false, "apply the configured migrations of started services")
+	upCmd.Flags().BoolVar(&upAdoptLegacy, "adopt-legacy", false, "replace containers and reuse volumes created before project scoping")
+
 	rootCmd.AddCommand(upCmd)
 }
+
+// migrateStarted applies the migrations of the started services that
+// configure a migrations directory, waiting for each database to accept
+// connections first
+func migrateStarted(dockerClient *docker.Client, cfg *config.Config, serviceNames []string) error {
+	for _, name := range serviceNames {
+		dir := cfg.Services[name].Migrations
+		if dir == "" {
+			continue
+		}
+
+		migrator, err := waitForMigrator(dockerClient, cfg, name, dir)
+		if err != nil {
+			return err
+		}
+		if err := applyMigrations(context.Background(), name, migrator); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// waitForMigrator loads a service's migrations once its database accepts
+// connections, waiting at most --wait-timeout
+func waitForMigrator(dockerClient *docker.Client, cfg *config.Config, serviceName, dir string) (*migrate.Migrator, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), upWaitTimeout)
+	defer cancel()
+
+	migrator, err := loadMigrator(ctx, dockerClient, cfg, serviceName, dir)
+	if err != nil {
+		return nil, err
+	}
+	if err := migrator.WaitReady(ctx, time.Second); err != nil {
+		return nil, fmt.Errorf("service '%s': %w", serviceName, err)
+	}
+	return migrator, nil
+}
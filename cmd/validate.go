@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"strings"
 
 	"github.com/abdultolba/nizam/internal/config"
 	"github.com/spf13/cobra"
@@ -63,15 +64,54 @@ properly formatted and contains required fields.`,
 				return nil
 			}
 
+			if err := cfg.ValidateDependencies(); err != nil {
+				if jsonOut {
+					_ = json.NewEncoder(os.Stdout).Encode(map[string]any{"ok": false, "error": err.Error()})
+				} else {
+					fmt.Printf("Configuration validation failed: %v\n", err)
+				}
+				if strict {
+					return err
+				}
+				return nil
+			}
+
+			if len(cfg.UnresolvedVars) > 0 {
+				err := fmt.Errorf("unresolved variables: %s", strings.Join(cfg.UnresolvedVars, ", "))
+				if jsonOut {
+					_ = json.NewEncoder(os.Stdout).Encode(map[string]any{
+						"ok":         false,
+						"error":      err.Error(),
+						"unresolved": cfg.UnresolvedVars,
+					})
+				} else {
+					fmt.Printf("Configuration validation failed: %v\n", err)
+					fmt.Printf("  Set them in the environment, .env or .nizam.env, or use ${VAR:-default}\n")
+				}
+				if strict {
+					return err
+				}
+				return nil
+			}
+
 			if jsonOut {
 				_ = json.NewEncoder(os.Stdout).Encode(map[string]any{
-					"ok":       true,
-					"services": len(cfg.Services),
-					"profile":  cfg.Profile,
+					"ok":              true,
+					"services":        len(cfg.Services),
+					"profile":         cfg.Profile,
+					"profile_applied": cfg.AppliedProfile != "",
+					"profiles":        cfg.GetProfileNames(),
 				})
 			} else {
 				fmt.Printf("✔ Configuration is valid\n")
-				fmt.Printf("  Profile: %s\n", cfg.Profile)
+				if cfg.AppliedProfile != "" {
+					fmt.Printf("  Profile: %s (overrides applied)\n", cfg.Profile)
+				} else {
+					fmt.Printf("  Profile: %s (no overrides defined)\n", cfg.Profile)
+				}
+				if len(cfg.Profiles) > 0 {
+					fmt.Printf("  Available profiles: %s\n", strings.Join(cfg.GetProfileNames(), ", "))
+				}
 				fmt.Printf("  Services: %d\n", len(cfg.Services))
 			}
 			return nil
@@ -0,0 +1,181 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/abdultolba/nizam/internal/config"
+	"github.com/abdultolba/nizam/internal/docker"
+	"github.com/olekukonko/tablewriter"
+	"github.com/spf13/cobra"
+)
+
+// volumesCmd represents the volumes command
+var volumesCmd = &cobra.Command{
+	Use:     "volumes",
+	Aliases: []string{"volume"},
+	Short:   "Manage volumes created by nizam",
+	Long: `List and remove the Docker volumes nizam creates for services.
+
+Named volumes are created as nizam_<project>_<service>_<name>, either from a service's
+volume field or from named entries in its volumes list.`,
+}
+
+// volumesListCmd lists nizam volumes
+var volumesListCmd = &cobra.Command{
+	Use:     "ls [service]",
+	Aliases: []string{"list"},
+	Short:   "List nizam volumes",
+	Example: `  nizam volumes ls
+  nizam volumes ls postgres
+  nizam volumes ls --json`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runVolumesList,
+}
+
+// volumesRemoveCmd removes nizam volumes
+var volumesRemoveCmd = &cobra.Command{
+	Use:     "rm <volume|service>...",
+	Aliases: []string{"remove"},
+	Short:   "Remove nizam volumes",
+	Long: `Remove nizam volumes by volume name, or all volumes of a service by
+service name. Only volumes listed by 'nizam volumes ls' for the current
+project can be removed. Volumes still in use by a container cannot be removed
+unless --force is given; stop the service with 'nizam down' first.`,
+	Example: `  nizam volumes rm nizam_myapp_postgres_pgdata
+  nizam volumes rm postgres redis
+  nizam volumes rm postgres --force`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: runVolumesRemove,
+}
+
+func init() {
+	rootCmd.AddCommand(volumesCmd)
+
+	volumesCmd.AddCommand(volumesListCmd)
+	volumesCmd.AddCommand(volumesRemoveCmd)
+
+	volumesListCmd.Flags().Bool("json", false, "output in JSON format")
+	volumesRemoveCmd.Flags().Bool("force", false, "force removal of volumes")
+}
+
+func runVolumesList(cmd *cobra.Command, args []string) error {
+	jsonOutput, _ := cmd.Flags().GetBool("json")
+
+	dockerClient, err := docker.NewClient()
+	if err != nil {
+		return fmt.Errorf("failed to create Docker client: %w", err)
+	}
+	defer dockerClient.Close()
+	dockerClient.SetProject(config.CurrentProjectName())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	var volumes []docker.VolumeInfo
+	if len(args) > 0 {
+		volumes, err = dockerClient.ServiceVolumes(ctx, args[0])
+	} else {
+		volumes, err = dockerClient.ListVolumes(ctx)
+	}
+	if err != nil {
+		return err
+	}
+
+	if jsonOutput {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(volumes)
+	}
+
+	if len(volumes) == 0 {
+		fmt.Println("📭 No nizam volumes found")
+		return nil
+	}
+
+	table := tablewriter.NewTable(os.Stdout,
+		tablewriter.WithHeader([]string{"Volume", "Service", "Driver", "Created"}),
+	)
+	for _, v := range volumes {
+		service := v.Service
+		if service == "" {
+			service = "-"
+		}
+		table.Append([]string{v.Name, service, v.Driver, v.CreatedAt})
+	}
+	table.Render()
+	fmt.Printf("\nTotal: %d volumes\n", len(volumes))
+
+	return nil
+}
+
+func runVolumesRemove(cmd *cobra.Command, args []string) error {
+	force, _ := cmd.Flags().GetBool("force")
+
+	dockerClient, err := docker.NewClient()
+	if err != nil {
+		return fmt.Errorf("failed to create Docker client: %w", err)
+	}
+	defer dockerClient.Close()
+	dockerClient.SetProject(config.CurrentProjectName())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	// Volume names are only accepted if they belong to the current project
+	projectVolumes, err := dockerClient.ListVolumes(ctx)
+	if err != nil {
+		return err
+	}
+	owned := make(map[string]bool, len(projectVolumes))
+	for _, v := range projectVolumes {
+		owned[v.Name] = true
+	}
+
+	var names []string
+	for _, arg := range args {
+		if strings.HasPrefix(arg, "nizam_") {
+			if !owned[arg] {
+				return fmt.Errorf("volume '%s' is not a volume of project '%s'", arg, dockerClient.Project())
+			}
+			names = append(names, arg)
+			continue
+		}
+
+		volumes, err := dockerClient.ServiceVolumes(ctx, arg)
+		if err != nil {
+			return err
+		}
+		if len(volumes) == 0 {
+			fmt.Printf("ℹ️  No volumes found for service '%s'\n", arg)
+		}
+		for _, v := range volumes {
+			names = append(names, v.Name)
+		}
+	}
+
+	var errors []string
+	for _, name := range names {
+		fmt.Printf("   Removing %s...", name)
+		if err := dockerClient.RemoveVolume(ctx, name, force); err != nil {
+			fmt.Printf(" ❌\n")
+			errors = append(errors, err.Error())
+			continue
+		}
+		fmt.Printf(" ✅\n")
+	}
+
+	if len(errors) > 0 {
+		fmt.Println("\n⚠️  Some volumes could not be removed:")
+		for _, err := range errors {
+			fmt.Printf("   • %s\n", err)
+		}
+		return fmt.Errorf("failed to remove %d volume(s)", len(errors))
+	}
+
+	return nil
+}
@@ -1,12 +1,15 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
-	"net"
-	"net/http"
 	"time"
 
 	"github.com/abdultolba/nizam/internal/config"
+	"github.com/abdultolba/nizam/internal/docker"
+	"github.com/abdultolba/nizam/internal/readiness"
+	"github.com/abdultolba/nizam/internal/resolve"
+	"github.com/abdultolba/nizam/internal/runtime"
 	"github.com/spf13/cobra"
 )
 
@@ -57,6 +60,14 @@ before starting dependent services.`,
 				servicesToWait = args
 			}
 
+			// Ports are read from the containers when the engine is
+			// reachable, since they may differ from the configured ones
+			var rt runtime.Runtime
+			if dockerClient, err := docker.NewClient(); err == nil {
+				defer dockerClient.Close()
+				rt = dockerClient
+			}
+
 			fmt.Printf("Waiting for %d service(s) to become ready (timeout: %v)...\n",
 				len(servicesToWait), timeoutDuration)
 
@@ -69,6 +80,10 @@ before starting dependent services.`,
 						return fmt.Errorf("service %s not found in configuration", serviceName)
 					}
 
+					if rt != nil {
+						service = resolve.WithPublishedPorts(context.Background(), rt, cfg.ContainerName(serviceName), service)
+					}
+
 					ready := checkServiceReadiness(service, serviceName)
 					if !ready {
 						allReady = false
@@ -96,90 +111,13 @@ before starting dependent services.`,
 }
 
 func checkServiceReadiness(service config.Service, serviceName string) bool {
-	// Check if service has health check configuration
-	if service.HealthCheck != nil && len(service.HealthCheck.Test) > 0 {
-		// For simplicity, assume first test command is an HTTP endpoint if it starts with "http"
-		test := service.HealthCheck.Test[0]
-		if len(test) > 4 && test[:4] == "http" {
-			if checkHTTPEndpoint(test) {
-				fmt.Printf("✔ %s: health check passed\n", serviceName)
-				return true
-			}
-			fmt.Printf("⏳ %s: waiting for health check...\n", serviceName)
-			return false
-		}
-		// For other health check types, assume ready (could be enhanced)
-		fmt.Printf("✔ %s: health check configured\n", serviceName)
-		return true
-	}
-
-	// Check if service has exposed ports
-	if len(service.Ports) > 0 {
-		for _, portMapping := range service.Ports {
-			// Parse port mapping (e.g., "8080:80")
-			hostPort, _, err := parsePortMapping(portMapping)
-			if err != nil {
-				fmt.Printf("! %s: invalid port mapping %s\n", serviceName, portMapping)
-				continue
-			}
-
-			if checkTCPPort(fmt.Sprintf("localhost:%s", hostPort)) {
-				fmt.Printf("✔ %s: port %s is ready\n", serviceName, hostPort)
-				return true
-			}
-		}
-		fmt.Printf("⏳ %s: waiting for ports...\n", serviceName)
-		return false
-	}
-
-	// If no health check or ports, assume ready
-	fmt.Printf("✔ %s: no readiness checks configured\n", serviceName)
-	return true
-}
-
-func checkHTTPEndpoint(url string) bool {
-	client := &http.Client{Timeout: 2 * time.Second}
-	resp, err := client.Get(url)
-	if err != nil {
-		return false
+	result := readiness.CheckService(service)
+	if result.Ready {
+		fmt.Printf("✔ %s: %s\n", serviceName, result.Message)
+	} else {
+		fmt.Printf("⏳ %s: %s\n", serviceName, result.Message)
 	}
-	defer resp.Body.Close()
-	return resp.StatusCode >= 200 && resp.StatusCode < 400
-}
-
-func checkTCPPort(address string) bool {
-	conn, err := net.DialTimeout("tcp", address, 2*time.Second)
-	if err != nil {
-		return false
-	}
-	conn.Close()
-	return true
-}
-
-func parsePortMapping(portMapping string) (hostPort, containerPort string, err error) {
-	// Simple parsing for "host:container" format
-	if len(portMapping) == 0 {
-		return "", "", fmt.Errorf("empty port mapping")
-	}
-
-	// For simplicity, assume format is "host:container" or just "port"
-	parts := []rune(portMapping)
-	colonIdx := -1
-	for i, r := range parts {
-		if r == ':' {
-			colonIdx = i
-			break
-		}
-	}
-
-	if colonIdx == -1 {
-		// Just a port number
-		return portMapping, portMapping, nil
-	}
-
-	hostPort = string(parts[:colonIdx])
-	containerPort = string(parts[colonIdx+1:])
-	return hostPort, containerPort, nil
+	return result.Ready
 }
 
 func init() {
@@ -0,0 +1,233 @@
+// Package backend resolves which container engine nizam talks to: a local
+// or remote Docker daemon, selected through DOCKER_HOST or Docker contexts
+// like the docker CLI does, or a Podman socket.
+package backend
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/abdultolba/nizam/internal/config"
+	"github.com/docker/docker/client"
+)
+
+// Sources describing how an endpoint was chosen
+const (
+	SourceConfig     = "config"
+	SourceDockerHost = "DOCKER_HOST"
+	SourceContainer  = "CONTAINER_HOST"
+	SourceContext    = "docker context"
+	SourceSocket     = "podman socket"
+	SourceDefault    = "default"
+)
+
+// sshDialHost is the placeholder host used when connections are dialed
+// over SSH; the HTTP client needs a host name but never resolves it
+const sshDialHost = "http://docker.example.com"
+
+// Endpoint is a resolved container engine address
+type Endpoint struct {
+	// Type is config.BackendDocker or config.BackendPodman
+	Type string `json:"type"`
+	// Host is the engine address; empty means the Docker default socket
+	Host string `json:"host,omitempty"`
+	// Context is the Docker context the host was read from, if any
+	Context string `json:"context,omitempty"`
+	// Source tells how the endpoint was chosen
+	Source string `json:"source"`
+
+	// TLS holds client certificates from a Docker context
+	TLS *TLSFiles `json:"-"`
+}
+
+// TLSFiles are the certificate files used to reach a TLS-protected engine
+type TLSFiles struct {
+	CA   string
+	Cert string
+	Key  string
+}
+
+// Resolve determines the engine endpoint for a backend selection. Docker
+// backends use, in order: an explicit host, an explicit context,
+// DOCKER_HOST, the DOCKER_CONTEXT or current docker context, and the
+// default socket. Podman backends use an explicit host, CONTAINER_HOST, or
+// the rootless or rootful Podman socket.
+func Resolve(b config.Backend) (Endpoint, error) {
+	if err := b.Validate(); err != nil {
+		return Endpoint{}, err
+	}
+
+	if b.Type == config.BackendPodman {
+		return resolvePodman(b)
+	}
+
+	switch {
+	case b.Host != "":
+		return Endpoint{Type: config.BackendDocker, Host: b.Host, Source: SourceConfig}, nil
+	case b.Context != "":
+		return loadContext(b.Context)
+	}
+
+	if host := os.Getenv("DOCKER_HOST"); host != "" {
+		return Endpoint{Type: config.BackendDocker, Host: host, Source: SourceDockerHost}, nil
+	}
+
+	name, err := currentContext()
+	if err != nil {
+		return Endpoint{}, err
+	}
+	if name != "" && name != defaultContext {
+		return loadContext(name)
+	}
+
+	return Endpoint{Type: config.BackendDocker, Source: SourceDefault}, nil
+}
+
+func resolvePodman(b config.Backend) (Endpoint, error) {
+	endpoint := Endpoint{Type: config.BackendPodman}
+
+	if b.Host != "" {
+		endpoint.Host, endpoint.Source = b.Host, SourceConfig
+		return endpoint, nil
+	}
+	if host := os.Getenv("CONTAINER_HOST"); host != "" {
+		endpoint.Host, endpoint.Source = host, SourceContainer
+		return endpoint, nil
+	}
+
+	var candidates []string
+	if runtimeDir := os.Getenv("XDG_RUNTIME_DIR"); runtimeDir != "" {
+		candidates = append(candidates, filepath.Join(runtimeDir, "podman", "podman.sock"))
+	}
+	candidates = append(candidates, "/run/podman/podman.sock")
+
+	for _, socket := range candidates {
+		if _, err := os.Stat(socket); err == nil {
+			endpoint.Host, endpoint.Source = "unix://"+socket, SourceSocket
+			return endpoint, nil
+		}
+	}
+
+	return Endpoint{}, fmt.Errorf("no Podman socket found (tried %s); run 'systemctl --user enable --now podman.socket' or set CONTAINER_HOST",
+		strings.Join(candidates, ", "))
+}
+
+// String describes the endpoint for messages
+func (e Endpoint) String() string {
+	host := e.Host
+	if host == "" {
+		host = client.DefaultDockerHost
+	}
+	if e.Context != "" {
+		return fmt.Sprintf("%s (%s, context %s)", e.Type, host, e.Context)
+	}
+	return fmt.Sprintf("%s (%s)", e.Type, host)
+}
+
+// IsLocal reports whether the engine runs on this machine, so that ports it
+// publishes are bound here: a local socket, or a TCP address on loopback
+func (e Endpoint) IsLocal() bool {
+	switch {
+	case e.Host == "", strings.HasPrefix(e.Host, "unix://"), strings.HasPrefix(e.Host, "npipe://"):
+		return true
+	case strings.HasPrefix(e.Host, "tcp://"):
+		host := strings.TrimPrefix(e.Host, "tcp://")
+		if h, _, err := net.SplitHostPort(host); err == nil {
+			host = h
+		}
+		if host == "localhost" {
+			return true
+		}
+		ip := net.ParseIP(host)
+		return ip != nil && ip.IsLoopback()
+	}
+	return false
+}
+
+// ClientOpts returns the Docker client options that connect to the endpoint
+func (e Endpoint) ClientOpts() ([]client.Opt, error) {
+	opts := []client.Opt{client.FromEnv, client.WithAPIVersionNegotiation()}
+
+	if strings.HasPrefix(e.Host, "ssh://") {
+		dialer, err := sshDialer(e.Host, e.Type)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, client.WithHost(sshDialHost), client.WithDialContext(dialer))
+	} else if e.Host != "" {
+		opts = append(opts, client.WithHost(e.Host))
+	}
+
+	if e.TLS != nil {
+		opts = append(opts, client.WithTLSClientConfig(e.TLS.CA, e.TLS.Cert, e.TLS.Key))
+	}
+	return opts, nil
+}
+
+// NewClient creates a Docker API client connected to the backend
+func NewClient(b config.Backend) (*client.Client, Endpoint, error) {
+	endpoint, err := Resolve(b)
+	if err != nil {
+		return nil, Endpoint{}, err
+	}
+
+	opts, err := endpoint.ClientOpts()
+	if err != nil {
+		return nil, Endpoint{}, err
+	}
+
+	cli, err := client.NewClientWithOpts(opts...)
+	if err != nil {
+		return nil, Endpoint{}, fmt.Errorf("failed to create Docker client for %s: %w", endpoint, err)
+	}
+	return cli, endpoint, nil
+}
+
+// NewCurrentClient creates a Docker API client for the backend selected by
+// --backend or the config file
+func NewCurrentClient() (*client.Client, Endpoint, error) {
+	return NewClient(config.CurrentBackend())
+}
+
+// Info describes the engine behind an endpoint
+type Info struct {
+	Endpoint   Endpoint `json:"endpoint"`
+	Engine     string   `json:"engine"`
+	Version    string   `json:"version"`
+	APIVersion string   `json:"api_version"`
+	Gaps       []Gap    `json:"gaps,omitempty"`
+}
+
+// Inspect queries the engine's version, detects whether it is Podman
+// (which can also be reached through DOCKER_HOST) and lists the nizam
+// features it lacks
+func Inspect(ctx context.Context, cli client.APIClient, endpoint Endpoint) (Info, error) {
+	sv, err := cli.ServerVersion(ctx)
+	if err != nil {
+		return Info{}, fmt.Errorf("failed to read engine version: %w", err)
+	}
+
+	engine := endpoint.Type
+	for _, component := range sv.Components {
+		if strings.Contains(strings.ToLower(component.Name), "podman") {
+			engine = config.BackendPodman
+		}
+	}
+
+	apiVersion := cli.ClientVersion()
+	if apiVersion == "" {
+		apiVersion = sv.APIVersion
+	}
+
+	return Info{
+		Endpoint:   endpoint,
+		Engine:     engine,
+		Version:    sv.Version,
+		APIVersion: apiVersion,
+		Gaps:       Gaps(engine, apiVersion),
+	}, nil
+}
@@ -0,0 +1,158 @@
+package backend
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/abdultolba/nizam/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// isolate clears the environment Resolve reads and points it at an empty
+// docker config directory, which it returns
+func isolate(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	t.Setenv("DOCKER_CONFIG", dir)
+	t.Setenv("DOCKER_HOST", "")
+	t.Setenv("DOCKER_CONTEXT", "")
+	t.Setenv("CONTAINER_HOST", "")
+	t.Setenv("XDG_RUNTIME_DIR", t.TempDir())
+	return dir
+}
+
+func writeDockerContext(t *testing.T, configDir, name, host string, withTLS bool) {
+	t.Helper()
+	sum := sha256.Sum256([]byte(name))
+	id := hex.EncodeToString(sum[:])
+
+	metaDir := filepath.Join(configDir, "contexts", "meta", id)
+	require.NoError(t, os.MkdirAll(metaDir, 0755))
+	meta := `{"Name":"` + name + `","Endpoints":{"docker":{"Host":"` + host + `","SkipTLSVerify":false}}}`
+	require.NoError(t, os.WriteFile(filepath.Join(metaDir, "meta.json"), []byte(meta), 0644))
+
+	if withTLS {
+		tlsDir := filepath.Join(configDir, "contexts", "tls", id, "docker")
+		require.NoError(t, os.MkdirAll(tlsDir, 0755))
+		for _, file := range []string{"ca.pem", "cert.pem", "key.pem"} {
+			require.NoError(t, os.WriteFile(filepath.Join(tlsDir, file), []byte("pem"), 0600))
+		}
+	}
+}
+
+func TestResolve_Default(t *testing.T) {
+	isolate(t)
+
+	endpoint, err := Resolve(config.Backend{})
+	require.NoError(t, err)
+	assert.Equal(t, Endpoint{Type: config.BackendDocker, Source: SourceDefault}, endpoint)
+}
+
+func TestResolve_DockerHost(t *testing.T) {
+	isolate(t)
+	t.Setenv("DOCKER_HOST", "tcp://10.0.0.5:2375")
+
+	endpoint, err := Resolve(config.Backend{})
+	require.NoError(t, err)
+	assert.Equal(t, "tcp://10.0.0.5:2375", endpoint.Host)
+	assert.Equal(t, SourceDockerHost, endpoint.Source)
+
+	// An explicit host wins over DOCKER_HOST
+	endpoint, err = Resolve(config.Backend{Host: "ssh://me@box"})
+	require.NoError(t, err)
+	assert.Equal(t, "ssh://me@box", endpoint.Host)
+	assert.Equal(t, SourceConfig, endpoint.Source)
+}
+
+func TestResolve_CurrentContext(t *testing.T) {
+	dir := isolate(t)
+	writeDockerContext(t, dir, "remote", "tcp://build-box:2376", true)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "config.json"), []byte(`{"currentContext":"remote"}`), 0644))
+
+	endpoint, err := Resolve(config.Backend{})
+	require.NoError(t, err)
+	assert.Equal(t, "tcp://build-box:2376", endpoint.Host)
+	assert.Equal(t, "remote", endpoint.Context)
+	assert.Equal(t, SourceContext, endpoint.Source)
+	require.NotNil(t, endpoint.TLS)
+	assert.Equal(t, "cert.pem", filepath.Base(endpoint.TLS.Cert))
+
+	// DOCKER_HOST takes precedence over the current context
+	t.Setenv("DOCKER_HOST", "unix:///tmp/docker.sock")
+	endpoint, err = Resolve(config.Backend{})
+	require.NoError(t, err)
+	assert.Equal(t, SourceDockerHost, endpoint.Source)
+}
+
+func TestResolve_ExplicitContext(t *testing.T) {
+	dir := isolate(t)
+	writeDockerContext(t, dir, "colima", "unix:///home/me/.colima/docker.sock", false)
+
+	endpoint, err := Resolve(config.Backend{Context: "colima"})
+	require.NoError(t, err)
+	assert.Equal(t, "unix:///home/me/.colima/docker.sock", endpoint.Host)
+	assert.Nil(t, endpoint.TLS)
+
+	_, err = Resolve(config.Backend{Context: "missing"})
+	assert.ErrorContains(t, err, `docker context "missing" not found`)
+}
+
+func TestResolve_Podman(t *testing.T) {
+	isolate(t)
+
+	_, err := Resolve(config.Backend{Type: config.BackendPodman})
+	assert.ErrorContains(t, err, "no Podman socket found")
+
+	runtimeDir := os.Getenv("XDG_RUNTIME_DIR")
+	socket := filepath.Join(runtimeDir, "podman", "podman.sock")
+	require.NoError(t, os.MkdirAll(filepath.Dir(socket), 0755))
+	require.NoError(t, os.WriteFile(socket, nil, 0600))
+
+	endpoint, err := Resolve(config.Backend{Type: config.BackendPodman})
+	require.NoError(t, err)
+	assert.Equal(t, "unix://"+socket, endpoint.Host)
+	assert.Equal(t, SourceSocket, endpoint.Source)
+
+	t.Setenv("CONTAINER_HOST", "ssh://core@podman-vm/run/podman/podman.sock")
+	endpoint, err = Resolve(config.Backend{Type: config.BackendPodman})
+	require.NoError(t, err)
+	assert.Equal(t, SourceContainer, endpoint.Source)
+}
+
+func TestEndpoint_ClientOpts(t *testing.T) {
+	_, err := Endpoint{Type: config.BackendDocker, Host: "ssh://"}.ClientOpts()
+	assert.ErrorContains(t, err, "missing host name")
+
+	opts, err := Endpoint{Type: config.BackendDocker, Host: "ssh://me@box:2222"}.ClientOpts()
+	require.NoError(t, err)
+	assert.NotEmpty(t, opts)
+}
+
+func TestEndpoint_IsLocal(t *testing.T) {
+	for _, host := range []string{"", "unix:///var/run/docker.sock", "npipe:////./pipe/docker_engine", "tcp://localhost:2375", "tcp://127.0.0.1:2375"} {
+		assert.True(t, Endpoint{Host: host}.IsLocal(), host)
+	}
+	for _, host := range []string{"ssh://me@box", "tcp://10.0.0.5:2376", "tcp://docker.internal:2376"} {
+		assert.False(t, Endpoint{Host: host}.IsLocal(), host)
+	}
+}
+
+func TestGaps(t *testing.T) {
+	assert.Empty(t, Gaps(config.BackendDocker, "1.43"))
+
+	gaps := Gaps(config.BackendPodman, "1.41")
+	var features []Feature
+	for _, gap := range gaps {
+		features = append(features, gap.Feature)
+	}
+	assert.Equal(t, []Feature{FeatureHealthcheck, FeatureDigestResolution}, features)
+
+	err := Supports(config.BackendDocker, "1.28", FeatureBuildTarget)
+	var unsupported *UnsupportedError
+	require.ErrorAs(t, err, &unsupported)
+	assert.Contains(t, err.Error(), "requires Docker API 1.29")
+}
@@ -0,0 +1,112 @@
+package backend
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/abdultolba/nizam/internal/config"
+)
+
+// defaultContext is the implicit context that uses DOCKER_HOST or the
+// default socket
+const defaultContext = "default"
+
+// dockerConfigDir returns the docker CLI configuration directory
+func dockerConfigDir() string {
+	if dir := os.Getenv("DOCKER_CONFIG"); dir != "" {
+		return dir
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ".docker"
+	}
+	return filepath.Join(home, ".docker")
+}
+
+// currentContext returns the context selected with DOCKER_CONTEXT or
+// 'docker context use'
+func currentContext() (string, error) {
+	if name := os.Getenv("DOCKER_CONTEXT"); name != "" {
+		return name, nil
+	}
+
+	data, err := os.ReadFile(filepath.Join(dockerConfigDir(), "config.json"))
+	if os.IsNotExist(err) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to read docker config: %w", err)
+	}
+
+	var cliConfig struct {
+		CurrentContext string `json:"currentContext"`
+	}
+	if err := json.Unmarshal(data, &cliConfig); err != nil {
+		return "", fmt.Errorf("failed to parse docker config: %w", err)
+	}
+	return cliConfig.CurrentContext, nil
+}
+
+// contextMeta is the part of a docker context's meta.json nizam needs
+type contextMeta struct {
+	Name      string `json:"Name"`
+	Endpoints map[string]struct {
+		Host          string `json:"Host"`
+		SkipTLSVerify bool   `json:"SkipTLSVerify"`
+	} `json:"Endpoints"`
+}
+
+// loadContext reads a context from the docker CLI's context store, where
+// each context lives in a directory named after the SHA-256 of its name
+func loadContext(name string) (Endpoint, error) {
+	if name == defaultContext {
+		return Endpoint{Type: config.BackendDocker, Context: name, Source: SourceContext}, nil
+	}
+
+	sum := sha256.Sum256([]byte(name))
+	id := hex.EncodeToString(sum[:])
+	store := filepath.Join(dockerConfigDir(), "contexts")
+
+	data, err := os.ReadFile(filepath.Join(store, "meta", id, "meta.json"))
+	if os.IsNotExist(err) {
+		return Endpoint{}, fmt.Errorf("docker context %q not found; see 'docker context ls'", name)
+	}
+	if err != nil {
+		return Endpoint{}, fmt.Errorf("failed to read docker context %q: %w", name, err)
+	}
+
+	var meta contextMeta
+	if err := json.Unmarshal(data, &meta); err != nil {
+		return Endpoint{}, fmt.Errorf("failed to parse docker context %q: %w", name, err)
+	}
+	dockerEndpoint, ok := meta.Endpoints["docker"]
+	if !ok || dockerEndpoint.Host == "" {
+		return Endpoint{}, fmt.Errorf("docker context %q has no docker endpoint", name)
+	}
+
+	endpoint := Endpoint{
+		Type:    config.BackendDocker,
+		Host:    dockerEndpoint.Host,
+		Context: name,
+		Source:  SourceContext,
+	}
+
+	tlsDir := filepath.Join(store, "tls", id, "docker")
+	files := TLSFiles{
+		CA:   filepath.Join(tlsDir, "ca.pem"),
+		Cert: filepath.Join(tlsDir, "cert.pem"),
+		Key:  filepath.Join(tlsDir, "key.pem"),
+	}
+	if _, err := os.Stat(files.Cert); err == nil {
+		if dockerEndpoint.SkipTLSVerify {
+			files.CA = ""
+		}
+		endpoint.TLS = &files
+	}
+
+	return endpoint, nil
+}
@@ -0,0 +1,85 @@
+package backend
+
+import (
+	"fmt"
+
+	"github.com/abdultolba/nizam/internal/config"
+	"github.com/docker/docker/api/types/versions"
+)
+
+// Feature is an engine capability nizam relies on
+type Feature string
+
+const (
+	// FeatureHealthcheck runs the health_check configured for a service
+	FeatureHealthcheck Feature = "healthchecks"
+	// FeatureDigestResolution resolves image tags to registry digests
+	// without pulling, used by 'nizam lock'
+	FeatureDigestResolution Feature = "digest resolution"
+	// FeatureBuildTarget builds a named stage of a multi-stage Dockerfile
+	FeatureBuildTarget Feature = "build targets"
+)
+
+// Gap is a feature an engine does not support, and why
+type Gap struct {
+	Feature Feature `json:"feature"`
+	Reason  string  `json:"reason"`
+}
+
+// minAPIVersion is the Docker API version that introduced each feature
+var minAPIVersion = map[Feature]string{
+	FeatureHealthcheck:      "1.24",
+	FeatureDigestResolution: "1.30",
+	FeatureBuildTarget:      "1.29",
+}
+
+// podmanGaps are features Podman's Docker-compatible API lacks
+var podmanGaps = map[Feature]string{
+	FeatureHealthcheck:      "Podman runs healthchecks from systemd timers, which containers created through its Docker-compatible API do not get",
+	FeatureDigestResolution: "Podman's Docker-compatible API does not implement distribution inspect",
+}
+
+// Gaps lists the features an engine of the given type and API version
+// does not support
+func Gaps(engine, apiVersion string) []Gap {
+	var gaps []Gap
+	for _, feature := range []Feature{FeatureHealthcheck, FeatureDigestResolution, FeatureBuildTarget} {
+		if reason := unsupportedReason(engine, apiVersion, feature); reason != "" {
+			gaps = append(gaps, Gap{Feature: feature, Reason: reason})
+		}
+	}
+	return gaps
+}
+
+// Supports returns an *UnsupportedError if the engine lacks the feature
+func Supports(engine, apiVersion string, feature Feature) error {
+	if reason := unsupportedReason(engine, apiVersion, feature); reason != "" {
+		return &UnsupportedError{Engine: engine, Feature: feature, Reason: reason}
+	}
+	return nil
+}
+
+// unsupportedReason explains why the engine lacks the feature, or returns
+// an empty string if it supports it
+func unsupportedReason(engine, apiVersion string, feature Feature) string {
+	if engine == config.BackendPodman {
+		if reason, ok := podmanGaps[feature]; ok {
+			return reason
+		}
+	}
+	if min, ok := minAPIVersion[feature]; ok && apiVersion != "" && versions.LessThan(apiVersion, min) {
+		return fmt.Sprintf("requires Docker API %s or newer, the engine speaks %s", min, apiVersion)
+	}
+	return ""
+}
+
+// UnsupportedError reports a feature the engine in use does not support
+type UnsupportedError struct {
+	Engine  string
+	Feature Feature
+	Reason  string
+}
+
+func (e *UnsupportedError) Error() string {
+	return fmt.Sprintf("the %s backend does not support %s: %s", e.Engine, e.Feature, e.Reason)
+}
@@ -0,0 +1,169 @@
+package backend
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+)
+
+// sshDialer returns a dialer that reaches a remote engine by running
+// '<engine> system dial-stdio' over ssh, the same way the docker CLI does.
+// ssh runs in batch mode, so a host that needs a password or an unknown
+// host key fails instead of prompting.
+func sshDialer(host, engine string) (func(ctx context.Context, network, addr string) (net.Conn, error), error) {
+	u, err := url.Parse(host)
+	if err != nil {
+		return nil, fmt.Errorf("invalid ssh host %q: %w", host, err)
+	}
+	if u.Hostname() == "" {
+		return nil, fmt.Errorf("invalid ssh host %q: missing host name", host)
+	}
+
+	args := []string{"-o", "BatchMode=yes", "-o", "ConnectTimeout=30"}
+	if u.User != nil {
+		args = append(args, "-l", u.User.Username())
+	}
+	if port := u.Port(); port != "" {
+		args = append(args, "-p", port)
+	}
+	args = append(args, "--", u.Hostname(), engine, "system", "dial-stdio")
+
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		return newCommandConn(ctx, "ssh", args...)
+	}, nil
+}
+
+// commandConn is a net.Conn over the stdin and stdout of a command. The
+// command is killed when ctx ends or the connection is closed. Reads and
+// writes that fail because the command exited return its stderr.
+type commandConn struct {
+	name   string
+	cmd    *exec.Cmd
+	stdin  *os.File
+	stdout *os.File
+	stderr lockedBuffer
+
+	waitOnce  sync.Once
+	waitErr   error
+	closeOnce sync.Once
+}
+
+func newCommandConn(ctx context.Context, name string, args ...string) (net.Conn, error) {
+	// os.Pipe ends support deadlines, unlike the pipes of exec.Cmd
+	stdinR, stdinW, err := os.Pipe()
+	if err != nil {
+		return nil, err
+	}
+	stdoutR, stdoutW, err := os.Pipe()
+	if err != nil {
+		stdinR.Close()
+		stdinW.Close()
+		return nil, err
+	}
+
+	c := &commandConn{name: name, stdin: stdinW, stdout: stdoutR}
+	c.cmd = exec.CommandContext(ctx, name, args...)
+	c.cmd.Stdin = stdinR
+	c.cmd.Stdout = stdoutW
+	c.cmd.Stderr = &c.stderr
+
+	err = c.cmd.Start()
+	// The command holds its own copies of these ends
+	stdinR.Close()
+	stdoutW.Close()
+	if err != nil {
+		stdinW.Close()
+		stdoutR.Close()
+		return nil, fmt.Errorf("failed to run %s: %w", name, err)
+	}
+	return c, nil
+}
+
+func (c *commandConn) Read(p []byte) (int, error) {
+	n, err := c.stdout.Read(p)
+	if err == io.EOF {
+		err = c.exitError(io.EOF)
+	}
+	return n, err
+}
+
+func (c *commandConn) Write(p []byte) (int, error) {
+	n, err := c.stdin.Write(p)
+	if err != nil && !errors.Is(err, os.ErrDeadlineExceeded) {
+		err = c.exitError(err)
+	}
+	return n, err
+}
+
+// exitError waits for the command once its output ended and describes why
+// it exited, or returns err if it exited cleanly without a message
+func (c *commandConn) exitError(err error) error {
+	c.wait()
+	stderr := strings.TrimSpace(c.stderr.String())
+	switch {
+	case stderr != "":
+		return fmt.Errorf("%s: %s", c.name, stderr)
+	case c.waitErr != nil:
+		return fmt.Errorf("%s: %w", c.name, c.waitErr)
+	}
+	return err
+}
+
+func (c *commandConn) wait() {
+	c.waitOnce.Do(func() { c.waitErr = c.cmd.Wait() })
+}
+
+func (c *commandConn) Close() error {
+	c.closeOnce.Do(func() {
+		c.stdin.Close()
+		c.cmd.Process.Kill()
+		c.wait()
+		c.stdout.Close()
+	})
+	return nil
+}
+
+func (c *commandConn) LocalAddr() net.Addr  { return commandAddr{} }
+func (c *commandConn) RemoteAddr() net.Addr { return commandAddr{} }
+
+func (c *commandConn) SetDeadline(t time.Time) error {
+	if err := c.SetReadDeadline(t); err != nil {
+		return err
+	}
+	return c.SetWriteDeadline(t)
+}
+
+func (c *commandConn) SetReadDeadline(t time.Time) error  { return c.stdout.SetReadDeadline(t) }
+func (c *commandConn) SetWriteDeadline(t time.Time) error { return c.stdin.SetWriteDeadline(t) }
+
+type commandAddr struct{}
+
+func (commandAddr) Network() string { return "command" }
+func (commandAddr) String() string  { return "command" }
+
+// lockedBuffer collects a command's stderr while the connection reads it
+type lockedBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *lockedBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *lockedBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
@@ -0,0 +1,63 @@
+package backend
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSSHDialer_ReportsStderr(t *testing.T) {
+	// A stand-in ssh that fails like an authentication error, echoing its
+	// arguments
+	dir := t.TempDir()
+	script := "#!/bin/sh\necho \"denied: $*\" >&2\nexit 255\n"
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "ssh"), []byte(script), 0o755))
+	t.Setenv("PATH", dir)
+
+	dial, err := sshDialer("ssh://me@box:2222", "docker")
+	require.NoError(t, err)
+	conn, err := dial(context.Background(), "tcp", "")
+	require.NoError(t, err)
+	defer conn.Close()
+
+	_, err = conn.Read(make([]byte, 1))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "ssh: denied: -o BatchMode=yes")
+	assert.Contains(t, err.Error(), "-l me -p 2222 -- box docker system dial-stdio")
+}
+
+func TestCommandConn_Deadline(t *testing.T) {
+	conn, err := newCommandConn(context.Background(), "sleep", "5")
+	require.NoError(t, err)
+	defer conn.Close()
+
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(50*time.Millisecond)))
+	_, err = conn.Read(make([]byte, 1))
+	assert.True(t, errors.Is(err, os.ErrDeadlineExceeded), "Read() error = %v", err)
+}
+
+func TestCommandConn_ContextKillsCommand(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	conn, err := newCommandConn(ctx, "sleep", "5")
+	require.NoError(t, err)
+	defer conn.Close()
+
+	cancel()
+	done := make(chan error, 1)
+	go func() {
+		_, err := conn.Read(make([]byte, 1))
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		assert.ErrorContains(t, err, "killed")
+	case <-time.After(3 * time.Second):
+		t.Fatal("Read() did not return after the context ended")
+	}
+}
@@ -0,0 +1,87 @@
+package chunkstore
+
+import (
+	"bufio"
+	"io"
+	"math/bits"
+)
+
+// Default chunk sizes. Boundaries depend on content, so an insert into a
+// dump only changes the chunks around it.
+const (
+	MinChunkSize = 256 << 10
+	AvgChunkSize = 1 << 20
+	MaxChunkSize = 4 << 20
+)
+
+// gear holds a random value per byte for the rolling hash
+var gear [256]uint64
+
+func init() {
+	// splitmix64 with a fixed seed, so chunk boundaries are stable across
+	// releases and machines
+	seed := uint64(0x6e697a616d)
+	for i := range gear {
+		seed += 0x9e3779b97f4a7c15
+		z := seed
+		z = (z ^ (z >> 30)) * 0xbf58476d1ce4e5b9
+		z = (z ^ (z >> 27)) * 0x94d049bb133111eb
+		gear[i] = z ^ (z >> 31)
+	}
+}
+
+// Chunker splits a stream into content-defined chunks with a gear rolling
+// hash: a chunk ends where the hash's top bits are zero, but never before
+// the minimum or after the maximum size.
+type Chunker struct {
+	r    *bufio.Reader
+	min  int
+	max  int
+	mask uint64
+	buf  []byte
+}
+
+// NewChunker returns a chunker over r with the default chunk sizes
+func NewChunker(r io.Reader) *Chunker {
+	return newChunker(r, MinChunkSize, AvgChunkSize, MaxChunkSize)
+}
+
+// newChunker returns a chunker with custom sizes. avg must be a power of two.
+func newChunker(r io.Reader, min, avg, max int) *Chunker {
+	// The low bits of a gear hash only depend on the last few bytes, so
+	// the cut condition tests the top bits
+	maskBits := bits.TrailingZeros(uint(avg))
+	return &Chunker{
+		r:    bufio.NewReaderSize(r, max),
+		min:  min,
+		max:  max,
+		mask: ((uint64(1) << maskBits) - 1) << (64 - maskBits),
+		buf:  make([]byte, 0, max),
+	}
+}
+
+// Next returns the next chunk, or io.EOF after the last one. The chunk is
+// only valid until the next call.
+func (c *Chunker) Next() ([]byte, error) {
+	c.buf = c.buf[:0]
+	var hash uint64
+	for len(c.buf) < c.max {
+		b, err := c.r.ReadByte()
+		if err == io.EOF {
+			if len(c.buf) == 0 {
+				return nil, io.EOF
+			}
+			return c.buf, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		c.buf = append(c.buf, b)
+		hash = (hash << 1) + gear[b]
+		if len(c.buf) >= c.min && hash&c.mask == 0 {
+			break
+		}
+	}
+	return c.buf, nil
+}
@@ -0,0 +1,245 @@
+// Package chunkstore stores data as content-addressed, zstd-compressed
+// chunks. Streams are split with a rolling hash, so data shared between
+// snapshots, even of different services, is stored once.
+package chunkstore
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+var (
+	encoder, _ = zstd.NewWriter(nil)
+	decoder, _ = zstd.NewReader(nil)
+)
+
+// Store is a directory of chunks named by the SHA-256 of their content
+type Store struct {
+	dir string
+}
+
+// GCResult summarises a garbage collection run
+type GCResult struct {
+	Removed int   `json:"removed"`
+	Freed   int64 `json:"freed"`
+}
+
+// Open opens the chunk store in dir, creating the directory if needed
+func Open(dir string) (*Store, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create chunk store: %w", err)
+	}
+	return &Store{dir: dir}, nil
+}
+
+// Dir returns the store's directory
+func (s *Store) Dir() string {
+	return s.dir
+}
+
+// path returns where a chunk is stored, fanned out by its first two hex
+// digits to keep directories small
+func (s *Store) path(id string) string {
+	return filepath.Join(s.dir, id[:2], id)
+}
+
+// validID reports whether id looks like a chunk ID
+func validID(id string) bool {
+	if len(id) != sha256.Size*2 {
+		return false
+	}
+	_, err := hex.DecodeString(id)
+	return err == nil
+}
+
+// Put stores a chunk and returns its ID. A chunk that is already stored is
+// not written again; its modification time is refreshed so a concurrent
+// garbage collection keeps it.
+func (s *Store) Put(data []byte) (string, error) {
+	sum := sha256.Sum256(data)
+	id := hex.EncodeToString(sum[:])
+	path := s.path(id)
+
+	now := time.Now()
+	if err := os.Chtimes(path, now, now); err == nil {
+		return id, nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return "", fmt.Errorf("failed to create chunk directory: %w", err)
+	}
+	temp, err := os.CreateTemp(filepath.Dir(path), id+".*.tmp")
+	if err != nil {
+		return "", fmt.Errorf("failed to create chunk: %w", err)
+	}
+	if _, err := temp.Write(encoder.EncodeAll(data, nil)); err != nil {
+		temp.Close()
+		os.Remove(temp.Name())
+		return "", fmt.Errorf("failed to write chunk: %w", err)
+	}
+	if err := temp.Close(); err != nil {
+		os.Remove(temp.Name())
+		return "", fmt.Errorf("failed to write chunk: %w", err)
+	}
+	if err := os.Rename(temp.Name(), path); err != nil {
+		os.Remove(temp.Name())
+		return "", fmt.Errorf("failed to store chunk: %w", err)
+	}
+	return id, nil
+}
+
+// Get returns a chunk's content, checking it against its ID
+func (s *Store) Get(id string) ([]byte, error) {
+	if !validID(id) {
+		return nil, fmt.Errorf("invalid chunk id %q", id)
+	}
+	compressed, err := os.ReadFile(s.path(id))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read chunk %s: %w", id, err)
+	}
+	data, err := decoder.DecodeAll(compressed, nil)
+	if err != nil {
+		return nil, fmt.Errorf("chunk %s is corrupt: %w", id, err)
+	}
+	if sum := sha256.Sum256(data); hex.EncodeToString(sum[:]) != id {
+		return nil, fmt.Errorf("chunk %s is corrupt: checksum mismatch", id)
+	}
+	return data, nil
+}
+
+// Size returns the number of bytes a chunk takes on disk
+func (s *Store) Size(id string) (int64, error) {
+	if !validID(id) {
+		return 0, fmt.Errorf("invalid chunk id %q", id)
+	}
+	info, err := os.Stat(s.path(id))
+	if err != nil {
+		return 0, fmt.Errorf("failed to stat chunk %s: %w", id, err)
+	}
+	return info.Size(), nil
+}
+
+// WriteFrom splits r into chunks, stores them and returns their IDs in
+// order
+func (s *Store) WriteFrom(r io.Reader) ([]string, error) {
+	chunker := NewChunker(r)
+	var ids []string
+	for {
+		chunk, err := chunker.Next()
+		if err == io.EOF {
+			return ids, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read data: %w", err)
+		}
+		id, err := s.Put(chunk)
+		if err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+}
+
+// NewReader returns a reader over the concatenated content of the chunks
+func (s *Store) NewReader(ids []string) io.Reader {
+	return &reader{store: s, ids: ids}
+}
+
+// reader reads chunks one at a time
+type reader struct {
+	store *Store
+	ids   []string
+	buf   []byte
+}
+
+// Read implements io.Reader
+func (r *reader) Read(p []byte) (int, error) {
+	for len(r.buf) == 0 {
+		if len(r.ids) == 0 {
+			return 0, io.EOF
+		}
+		data, err := r.store.Get(r.ids[0])
+		if err != nil {
+			return 0, err
+		}
+		r.buf = data
+		r.ids = r.ids[1:]
+	}
+	n := copy(p, r.buf)
+	r.buf = r.buf[n:]
+	return n, nil
+}
+
+// List returns the stored size of every chunk, by ID
+func (s *Store) List() (map[string]int64, error) {
+	chunks := make(map[string]int64)
+	err := s.walk(func(path string, info os.FileInfo) error {
+		if validID(info.Name()) {
+			chunks[info.Name()] = info.Size()
+		}
+		return nil
+	})
+	return chunks, err
+}
+
+// GC removes chunks that are not in live. Chunks and partial writes
+// modified after before are kept, since a snapshot being created may not
+// have recorded them yet.
+func (s *Store) GC(live map[string]bool, before time.Time) (GCResult, error) {
+	var result GCResult
+	err := s.walk(func(path string, info os.FileInfo) error {
+		name := info.Name()
+		isTemp := strings.HasSuffix(name, ".tmp")
+		if !isTemp && (!validID(name) || live[name]) {
+			return nil
+		}
+		if !info.ModTime().Before(before) {
+			return nil
+		}
+		if err := os.Remove(path); err != nil {
+			return fmt.Errorf("failed to remove chunk %s: %w", name, err)
+		}
+		if !isTemp {
+			result.Removed++
+		}
+		result.Freed += info.Size()
+		return nil
+	})
+	return result, err
+}
+
+// walk calls fn for every file in the store
+func (s *Store) walk(fn func(path string, info os.FileInfo) error) error {
+	dirs, err := os.ReadDir(s.dir)
+	if err != nil {
+		return fmt.Errorf("failed to read chunk store: %w", err)
+	}
+	for _, dir := range dirs {
+		if !dir.IsDir() {
+			continue
+		}
+		entries, err := os.ReadDir(filepath.Join(s.dir, dir.Name()))
+		if err != nil {
+			return fmt.Errorf("failed to read chunk store: %w", err)
+		}
+		for _, entry := range entries {
+			info, err := entry.Info()
+			if err != nil {
+				// Removed by a concurrent run
+				continue
+			}
+			if err := fn(filepath.Join(s.dir, dir.Name(), entry.Name()), info); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,142 @@
+package chunkstore
+
+import (
+	"bytes"
+	"io"
+	"math/rand"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// randomData returns deterministic incompressible data
+func randomData(seed int64, size int) []byte {
+	data := make([]byte, size)
+	rand.New(rand.NewSource(seed)).Read(data)
+	return data
+}
+
+// chunks splits data with small chunk sizes
+func chunks(t *testing.T, data []byte) [][]byte {
+	t.Helper()
+	chunker := newChunker(bytes.NewReader(data), 1<<10, 4<<10, 16<<10)
+	var result [][]byte
+	for {
+		chunk, err := chunker.Next()
+		if err == io.EOF {
+			return result
+		}
+		require.NoError(t, err)
+		result = append(result, append([]byte(nil), chunk...))
+	}
+}
+
+func TestChunker(t *testing.T) {
+	data := randomData(1, 256<<10)
+	parts := chunks(t, data)
+
+	assert.Equal(t, data, bytes.Join(parts, nil))
+	for i, part := range parts {
+		assert.LessOrEqual(t, len(part), 16<<10)
+		if i < len(parts)-1 {
+			assert.GreaterOrEqual(t, len(part), 1<<10)
+		}
+	}
+	assert.Greater(t, len(parts), 8, "expected content-defined cuts well below the maximum size")
+}
+
+func TestChunker_InsertOnlyChangesNearbyChunks(t *testing.T) {
+	data := randomData(2, 256<<10)
+	edited := append(append(append([]byte(nil), data[:100<<10]...), []byte("inserted row")...), data[100<<10:]...)
+
+	original := make(map[string]bool)
+	for _, part := range chunks(t, data) {
+		original[string(part)] = true
+	}
+
+	editedParts := chunks(t, edited)
+	shared := 0
+	for _, part := range editedParts {
+		if original[string(part)] {
+			shared++
+		}
+	}
+	assert.GreaterOrEqual(t, shared, len(editedParts)-2)
+}
+
+func TestStore_PutGet(t *testing.T) {
+	store, err := Open(t.TempDir())
+	require.NoError(t, err)
+
+	data := bytes.Repeat([]byte("nizam "), 1000)
+	id, err := store.Put(data)
+	require.NoError(t, err)
+
+	again, err := store.Put(data)
+	require.NoError(t, err)
+	assert.Equal(t, id, again)
+
+	got, err := store.Get(id)
+	require.NoError(t, err)
+	assert.Equal(t, data, got)
+
+	size, err := store.Size(id)
+	require.NoError(t, err)
+	assert.Less(t, size, int64(len(data)), "chunks should be compressed")
+
+	// Tampered chunks are detected
+	require.NoError(t, os.WriteFile(store.path(id), encoder.EncodeAll([]byte("tampered"), nil), 0o644))
+	_, err = store.Get(id)
+	assert.ErrorContains(t, err, "corrupt")
+
+	_, err = store.Get("../../etc/passwd")
+	assert.Error(t, err)
+}
+
+func TestStore_WriteFromDeduplicates(t *testing.T) {
+	store, err := Open(t.TempDir())
+	require.NoError(t, err)
+
+	data := randomData(3, 6<<20)
+	first, err := store.WriteFrom(bytes.NewReader(data))
+	require.NoError(t, err)
+	second, err := store.WriteFrom(bytes.NewReader(data))
+	require.NoError(t, err)
+	assert.Equal(t, first, second)
+
+	stored, err := store.List()
+	require.NoError(t, err)
+	assert.Len(t, stored, len(first))
+
+	got, err := io.ReadAll(store.NewReader(first))
+	require.NoError(t, err)
+	assert.Equal(t, data, got)
+}
+
+func TestStore_GC(t *testing.T) {
+	store, err := Open(t.TempDir())
+	require.NoError(t, err)
+
+	live, err := store.Put([]byte("live"))
+	require.NoError(t, err)
+	dead, err := store.Put([]byte("dead"))
+	require.NoError(t, err)
+
+	// Recent chunks survive, as they may belong to a snapshot in progress
+	result, err := store.GC(map[string]bool{live: true}, time.Now().Add(-time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, GCResult{}, result)
+
+	result, err = store.GC(map[string]bool{live: true}, time.Now().Add(time.Second))
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.Removed)
+	assert.Positive(t, result.Freed)
+
+	_, err = store.Get(live)
+	assert.NoError(t, err)
+	_, err = store.Get(dead)
+	assert.Error(t, err)
+}
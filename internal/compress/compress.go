@@ -9,6 +9,7 @@ import (
 	"os"
 	"strings"
 
+	"filippo.io/age"
 	"github.com/klauspost/compress/zstd"
 )
 
@@ -48,23 +49,43 @@ type ReaderCloser interface {
 	io.Closer
 }
 
-// CompressedWriter wraps a writer with compression and checksum calculation
+// CompressedWriter wraps a writer with compression, optional encryption and
+// checksum calculation. The checksum covers the bytes written to the file.
 type CompressedWriter struct {
 	file       *os.File
 	compressor WriterCloser
+	encryptor  io.WriteCloser
 	hasher     hash.Hash
 	writer     io.Writer
 }
 
 // NewCompressedWriter creates a new compressed writer
 func NewCompressedWriter(path string, comp Compression) (*CompressedWriter, error) {
+	return NewEncryptedWriter(path, comp)
+}
+
+// NewEncryptedWriter creates a compressed writer that encrypts the
+// compressed stream to the given age recipients. Without recipients the
+// data is only compressed.
+func NewEncryptedWriter(path string, comp Compression, recipients ...age.Recipient) (*CompressedWriter, error) {
 	file, err := os.OpenFile(path, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o644)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create file: %w", err)
 	}
 
 	hasher := sha256.New()
-	multiWriter := io.MultiWriter(file, hasher)
+	var multiWriter io.Writer = io.MultiWriter(file, hasher)
+
+	// Encrypt after compressing, since ciphertext does not compress
+	var encryptor io.WriteCloser
+	if len(recipients) > 0 {
+		encryptor, err = age.Encrypt(multiWriter, recipients...)
+		if err != nil {
+			file.Close()
+			return nil, fmt.Errorf("failed to create age writer: %w", err)
+		}
+		multiWriter = encryptor
+	}
 
 	var compressor WriterCloser
 	var writer io.Writer = multiWriter
@@ -91,6 +112,7 @@ func NewCompressedWriter(path string, comp Compression) (*CompressedWriter, erro
 	return &CompressedWriter{
 		file:       file,
 		compressor: compressor,
+		encryptor:  encryptor,
 		hasher:     hasher,
 		writer:     writer,
 	}, nil
@@ -110,6 +132,13 @@ func (cw *CompressedWriter) Close() (string, error) {
 		err = cw.compressor.Close()
 	}
 
+	// Then flush the final encrypted chunk
+	if cw.encryptor != nil {
+		if encErr := cw.encryptor.Close(); encErr != nil && err == nil {
+			err = encErr
+		}
+	}
+
 	// Sync and close file
 	if syncErr := cw.file.Sync(); syncErr != nil && err == nil {
 		err = syncErr
@@ -134,17 +163,33 @@ type CompressedReader struct {
 
 // NewCompressedReader creates a new compressed reader
 func NewCompressedReader(path string, comp Compression) (*CompressedReader, error) {
+	return NewEncryptedReader(path, comp)
+}
+
+// NewEncryptedReader creates a compressed reader for a file written by
+// NewEncryptedWriter, decrypting it with one of the given age identities.
+// Without identities the file is read as plain compressed data.
+func NewEncryptedReader(path string, comp Compression, identities ...age.Identity) (*CompressedReader, error) {
 	file, err := os.Open(path)
 	if err != nil {
 		return nil, fmt.Errorf("failed to open file: %w", err)
 	}
 
+	var source io.Reader = file
+	if len(identities) > 0 {
+		source, err = age.Decrypt(file, identities...)
+		if err != nil {
+			file.Close()
+			return nil, fmt.Errorf("failed to decrypt: %w", err)
+		}
+	}
+
 	var decompressor ReaderCloser
-	var reader io.Reader = file
+	var reader io.Reader = source
 
 	switch comp {
 	case CompZstd:
-		zr, err := zstd.NewReader(file)
+		zr, err := zstd.NewReader(source)
 		if err != nil {
 			file.Close()
 			return nil, fmt.Errorf("failed to create zstd reader: %w", err)
@@ -153,7 +198,7 @@ func NewCompressedReader(path string, comp Compression) (*CompressedReader, erro
 		reader = zr
 
 	case CompGzip:
-		gr, err := gzip.NewReader(file)
+		gr, err := gzip.NewReader(source)
 		if err != nil {
 			file.Close()
 			return nil, fmt.Errorf("failed to create gzip reader: %w", err)
@@ -162,7 +207,7 @@ func NewCompressedReader(path string, comp Compression) (*CompressedReader, erro
 		reader = gr
 
 	case CompNone:
-		decompressor = &nopCloser{Reader: file}
+		decompressor = &nopCloser{Reader: source}
 	}
 
 	return &CompressedReader{
@@ -3,7 +3,10 @@ package compress
 import (
 	"io"
 	"os"
+	"path/filepath"
 	"testing"
+
+	"filippo.io/age"
 )
 
 func TestCompressionTypes(t *testing.T) {
@@ -83,3 +86,64 @@ func TestCompressedWriterRoundtrip(t *testing.T) {
 		})
 	}
 }
+
+func TestEncryptedWriterRoundtrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "data.zst")
+	identity, err := age.GenerateX25519Identity()
+	if err != nil {
+		t.Fatalf("Failed to generate identity: %v", err)
+	}
+
+	testData := []byte("Hello, World! This is a test of encrypted data.")
+
+	writer, err := NewEncryptedWriter(path, CompZstd, identity.Recipient())
+	if err != nil {
+		t.Fatalf("Failed to create encrypted writer: %v", err)
+	}
+	if _, err := writer.Write(testData); err != nil {
+		t.Fatalf("Failed to write data: %v", err)
+	}
+	checksum, err := writer.Close()
+	if err != nil {
+		t.Fatalf("Failed to close writer: %v", err)
+	}
+
+	// The checksum covers the encrypted bytes on disk
+	fileChecksum, err := CalculateSHA256(path)
+	if err != nil {
+		t.Fatalf("Failed to hash file: %v", err)
+	}
+	if checksum != fileChecksum {
+		t.Errorf("Checksum mismatch: writer %s, file %s", checksum, fileChecksum)
+	}
+
+	// Without the identity the data is not a zstd stream
+	if reader, err := NewCompressedReader(path, CompZstd); err == nil {
+		if _, err := io.ReadAll(reader); err == nil {
+			t.Error("Expected reading without the identity to fail")
+		}
+		reader.Close()
+	}
+
+	other, err := age.GenerateX25519Identity()
+	if err != nil {
+		t.Fatalf("Failed to generate identity: %v", err)
+	}
+	if _, err := NewEncryptedReader(path, CompZstd, other); err == nil {
+		t.Error("Expected decrypting with the wrong identity to fail")
+	}
+
+	reader, err := NewEncryptedReader(path, CompZstd, identity)
+	if err != nil {
+		t.Fatalf("Failed to create encrypted reader: %v", err)
+	}
+	defer reader.Close()
+
+	readData, err := io.ReadAll(reader)
+	if err != nil {
+		t.Fatalf("Failed to read data: %v", err)
+	}
+	if string(readData) != string(testData) {
+		t.Errorf("Data mismatch: expected %s, got %s", string(testData), string(readData))
+	}
+}
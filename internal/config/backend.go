@@ -0,0 +1,124 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Container backend types
+const (
+	BackendDocker = "docker"
+	BackendPodman = "podman"
+)
+
+// Backend selects the container engine nizam talks to. The zero value uses
+// DOCKER_HOST or the current Docker context, like the docker CLI. A plain
+// string is parsed with ParseBackend.
+//
+//	backend:
+//	  type: podman              # docker (default) or podman
+//	  host: ssh://me@build-box  # explicit engine address
+//	  context: remote           # Docker context to use instead of the current one
+type Backend struct {
+	Type    string `yaml:"type,omitempty" mapstructure:"type"`
+	Host    string `yaml:"host,omitempty" mapstructure:"host"`
+	Context string `yaml:"context,omitempty" mapstructure:"context"`
+}
+
+// ParseBackend parses the shorthand accepted by --backend and a scalar
+// backend field: "docker" or "podman" select the engine type, a value
+// containing "://" is an engine address such as ssh://user@host or
+// tcp://host:2376, and anything else names a Docker context.
+func ParseBackend(value string) Backend {
+	value = strings.TrimSpace(value)
+	switch {
+	case value == "":
+		return Backend{}
+	case value == BackendDocker || value == BackendPodman:
+		return Backend{Type: value}
+	case strings.Contains(value, "://"):
+		return Backend{Host: value}
+	default:
+		return Backend{Context: value}
+	}
+}
+
+// UnmarshalYAML accepts either a shorthand string or a backend mapping
+func (b *Backend) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		var value string
+		if err := node.Decode(&value); err != nil {
+			return err
+		}
+		*b = ParseBackend(value)
+		return nil
+	}
+
+	type plain Backend
+	var backend plain
+	if err := node.Decode(&backend); err != nil {
+		return err
+	}
+	*b = Backend(backend)
+	return b.Validate()
+}
+
+// Validate checks that the backend settings are consistent
+func (b Backend) Validate() error {
+	switch b.Type {
+	case "", BackendDocker, BackendPodman:
+	default:
+		return fmt.Errorf("unknown backend type %q (expected %s or %s)", b.Type, BackendDocker, BackendPodman)
+	}
+	if b.Context != "" && b.Type == BackendPodman {
+		return fmt.Errorf("docker contexts cannot be used with the %s backend", BackendPodman)
+	}
+	if b.Context != "" && b.Host != "" {
+		return fmt.Errorf("backend host and context are mutually exclusive")
+	}
+	return nil
+}
+
+// String describes the backend for messages
+func (b Backend) String() string {
+	kind := b.Type
+	if kind == "" {
+		kind = BackendDocker
+	}
+	switch {
+	case b.Host != "":
+		return kind + " at " + b.Host
+	case b.Context != "":
+		return kind + " context " + b.Context
+	default:
+		return kind
+	}
+}
+
+// backendOverride is set from the --backend flag or NIZAM_BACKEND and
+// replaces the backend configured in the config file
+var backendOverride *Backend
+
+// SetBackend selects the backend from a --backend value, overriding the
+// config file
+func SetBackend(value string) {
+	backend := ParseBackend(value)
+	backendOverride = &backend
+}
+
+// CurrentBackend returns the backend to connect to: the --backend override,
+// the backend field of the config file in the current directory, or the
+// zero Backend
+func CurrentBackend() Backend {
+	if backendOverride != nil {
+		return *backendOverride
+	}
+	if ConfigExists() {
+		if cfg, err := LoadRawConfig(); err == nil && cfg.Backend != nil {
+			return *cfg.Backend
+		}
+	}
+	return Backend{}
+}
@@ -0,0 +1,48 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseBackend(t *testing.T) {
+	assert.Equal(t, Backend{}, ParseBackend(""))
+	assert.Equal(t, Backend{Type: BackendPodman}, ParseBackend("podman"))
+	assert.Equal(t, Backend{Host: "ssh://me@box"}, ParseBackend("ssh://me@box"))
+	assert.Equal(t, Backend{Context: "colima"}, ParseBackend("colima"))
+}
+
+func TestLoadConfig_Backend(t *testing.T) {
+	cfg, err := LoadConfigFromFile(writeConfig(t, `
+backend: podman
+services:
+  redis:
+    image: redis:7
+`))
+	require.NoError(t, err)
+	require.NotNil(t, cfg.Backend)
+	assert.Equal(t, BackendPodman, cfg.Backend.Type)
+
+	cfg, err = LoadConfigFromFile(writeConfig(t, `
+backend:
+  host: tcp://10.0.0.5:2376
+services: {}
+`))
+	require.NoError(t, err)
+	assert.Equal(t, "tcp://10.0.0.5:2376", cfg.Backend.Host)
+
+	_, err = LoadConfigFromFile(writeConfig(t, `
+backend:
+  type: containerd
+services: {}
+`))
+	assert.ErrorContains(t, err, "unknown backend type")
+}
+
+func TestBackend_Validate(t *testing.T) {
+	assert.Error(t, Backend{Type: BackendPodman, Context: "remote"}.Validate())
+	assert.Error(t, Backend{Host: "tcp://x:2375", Context: "remote"}.Validate())
+	assert.NoError(t, Backend{Type: BackendPodman, Host: "unix:///run/podman/podman.sock"}.Validate())
+}
@@ -0,0 +1,87 @@
+package config
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultDockerfile is the Dockerfile used when a build block names none
+const DefaultDockerfile = "Dockerfile"
+
+// Build describes how to build a service image from a local Dockerfile.
+// A plain string is shorthand for the context directory.
+//
+//	build:
+//	  context: ./docker/postgres
+//	  dockerfile: Dockerfile.dev
+//	  args:
+//	    PG_MAJOR: "16"
+//	  target: runtime
+type Build struct {
+	Context    string            `yaml:"context" mapstructure:"context"`
+	Dockerfile string            `yaml:"dockerfile,omitempty" mapstructure:"dockerfile"`
+	Args       map[string]string `yaml:"args,omitempty" mapstructure:"args"`
+	Target     string            `yaml:"target,omitempty" mapstructure:"target"`
+}
+
+// UnmarshalYAML accepts either a context path or a build mapping
+func (b *Build) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		return node.Decode(&b.Context)
+	}
+
+	type plain Build
+	var build plain
+	if err := node.Decode(&build); err != nil {
+		return err
+	}
+	*b = Build(build)
+	return nil
+}
+
+// DockerfilePath returns the Dockerfile path relative to the context
+func (b *Build) DockerfilePath() string {
+	if b.Dockerfile == "" {
+		return DefaultDockerfile
+	}
+	return b.Dockerfile
+}
+
+// Validate checks that the build block is well-formed
+func (b *Build) Validate() error {
+	if b.Context == "" {
+		return fmt.Errorf("build context is required")
+	}
+	if filepath.IsAbs(b.Dockerfile) {
+		return fmt.Errorf("build dockerfile %q must be relative to the build context", b.Dockerfile)
+	}
+	return nil
+}
+
+// resolveBuildContexts makes build contexts absolute, relative to the
+// directory of the config file
+func (c *Config) resolveBuildContexts() error {
+	baseDir := "."
+	if c.FilePath != "" {
+		baseDir = filepath.Dir(c.FilePath)
+	}
+
+	for name, service := range c.Services {
+		if service.Build == nil {
+			continue
+		}
+		if err := service.Build.Validate(); err != nil {
+			return fmt.Errorf("service '%s': %w", name, err)
+		}
+
+		build := *service.Build
+		if !filepath.IsAbs(build.Context) {
+			build.Context = filepath.Join(baseDir, build.Context)
+		}
+		service.Build = &build
+		c.Services[name] = service
+	}
+	return nil
+}
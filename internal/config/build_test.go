@@ -0,0 +1,52 @@
+package config
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadConfig_Build(t *testing.T) {
+	path := writeConfig(t, `
+services:
+  postgres:
+    build: ./docker/postgres
+  api:
+    image: myorg/api:dev
+    build:
+      context: /srv/api
+      dockerfile: Dockerfile.dev
+      args:
+        GO_VERSION: "1.22"
+      target: runtime
+`)
+
+	cfg, err := LoadConfigFromFile(path)
+	require.NoError(t, err)
+
+	postgres := cfg.Services["postgres"]
+	require.NotNil(t, postgres.Build)
+	assert.Equal(t, filepath.Join(filepath.Dir(path), "docker/postgres"), postgres.Build.Context)
+	assert.Equal(t, DefaultDockerfile, postgres.Build.DockerfilePath())
+
+	api := cfg.Services["api"]
+	require.NotNil(t, api.Build)
+	assert.Equal(t, "/srv/api", api.Build.Context)
+	assert.Equal(t, "Dockerfile.dev", api.Build.DockerfilePath())
+	assert.Equal(t, map[string]string{"GO_VERSION": "1.22"}, api.Build.Args)
+	assert.Equal(t, "runtime", api.Build.Target)
+}
+
+func TestLoadConfig_BuildRequiresContext(t *testing.T) {
+	path := writeConfig(t, `
+services:
+  postgres:
+    build:
+      dockerfile: Dockerfile
+`)
+
+	_, err := LoadConfigFromFile(path)
+	assert.ErrorContains(t, err, "build context is required")
+}
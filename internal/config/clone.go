@@ -0,0 +1,135 @@
+package config
+
+import (
+	"fmt"
+	"regexp"
+
+	"gopkg.in/yaml.v3"
+)
+
+// serviceNamePattern is what container and volume names accept
+var serviceNamePattern = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9_.-]*$`)
+
+// cloneDroppedKeys are profile override keys a clone does not inherit: its
+// ports are set in its base entry and its env prefix would clash
+var cloneDroppedKeys = map[string]bool{"ports": true, "env_prefix": true}
+
+// CloneService adds a copy of a service under a new name to the raw config
+// c and returns it. loaded is the same config as LoadConfig returns it,
+// with the active profile applied and variables expanded; ports are
+// computed from it.
+//
+// The clone keeps the original's entry as written, including variables,
+// and gets a copy of the original's overrides in every profile. Fixed host
+// ports move to the next port above them that no service of loaded uses
+// and for which available reports true, and are written as literal ports;
+// "auto" ports stay as they are. Named volumes are scoped by service, so
+// the clone gets its own. Writable bind mounts would share data with the
+// original and are rejected.
+func (c *Config) CloneService(loaded *Config, source, name string, available func(port int) bool) (Service, error) {
+	effective, exists := loaded.GetService(source)
+	if !exists {
+		return Service{}, fmt.Errorf("service '%s' not found in config", source)
+	}
+	original, exists := c.GetService(source)
+	if !exists {
+		return Service{}, fmt.Errorf("service '%s' is only defined in profile '%s'; move it to services to clone it", source, loaded.AppliedProfile)
+	}
+	if c.hasService(name) || loaded.hasService(name) {
+		return Service{}, fmt.Errorf("service '%s' already exists in config", name)
+	}
+	if !serviceNamePattern.MatchString(name) {
+		return Service{}, fmt.Errorf("invalid service name '%s': use letters, digits, '_', '.' and '-'", name)
+	}
+
+	for _, mount := range effective.Volumes {
+		if mount.Type == VolumeTypeBind && !mount.ReadOnly {
+			return Service{}, fmt.Errorf("service '%s' bind-mounts %s read-write, which a clone would share; mount it read-only or use a named volume", source, mount.Source)
+		}
+	}
+
+	clone := original
+	// The original's prefix would export clashing variables
+	clone.EnvPrefix = ""
+
+	if original.Environment != nil {
+		clone.Environment = make(map[string]string, len(original.Environment))
+		for key, value := range original.Environment {
+			clone.Environment[key] = value
+		}
+	}
+	clone.Volumes = append([]VolumeMount(nil), original.Volumes...)
+
+	used := make(map[int]bool)
+	for _, service := range loaded.Services {
+		for _, spec := range service.Ports {
+			if mapping, err := ParsePort(spec); err == nil && !mapping.Auto {
+				used[mapping.HostPort] = true
+			}
+		}
+	}
+
+	clone.Ports = make([]string, 0, len(effective.Ports))
+	for _, spec := range effective.Ports {
+		mapping, err := ParsePort(spec)
+		if err != nil {
+			return Service{}, fmt.Errorf("service '%s': %w", source, err)
+		}
+		if !mapping.Auto {
+			port := mapping.HostPort + 1
+			for port <= 65535 && (used[port] || !available(port)) {
+				port++
+			}
+			if port > 65535 {
+				return Service{}, fmt.Errorf("no free host port above %d", mapping.HostPort)
+			}
+			mapping.HostPort = port
+			used[port] = true
+		}
+		clone.Ports = append(clone.Ports, mapping.String())
+	}
+
+	if c.Services == nil {
+		c.Services = make(map[string]Service)
+	}
+	c.Services[name] = clone
+
+	for _, profile := range c.Profiles {
+		if override, exists := profile.Services[source]; exists {
+			profile.Services[name] = cloneOverride(override)
+		}
+	}
+	return clone, nil
+}
+
+// hasService reports whether the config or any of its profiles defines a
+// service
+func (c *Config) hasService(name string) bool {
+	if _, exists := c.Services[name]; exists {
+		return true
+	}
+	for _, profile := range c.Profiles {
+		if _, exists := profile.Services[name]; exists {
+			return true
+		}
+	}
+	return false
+}
+
+// cloneOverride copies a profile override for a clone, leaving out
+// cloneDroppedKeys
+func cloneOverride(override yaml.Node) yaml.Node {
+	if override.Kind != yaml.MappingNode {
+		return override
+	}
+
+	clone := override
+	clone.Content = nil
+	for i := 0; i+1 < len(override.Content); i += 2 {
+		if cloneDroppedKeys[override.Content[i].Value] {
+			continue
+		}
+		clone.Content = append(clone.Content, override.Content[i], override.Content[i+1])
+	}
+	return clone
+}
@@ -0,0 +1,124 @@
+package config
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+func TestCloneService(t *testing.T) {
+	cfg := &Config{Services: map[string]Service{
+		"postgres": {
+			Image:       "postgres:16",
+			Ports:       []string{"5432:5432", "auto:9187"},
+			Environment: map[string]string{"POSTGRES_USER": "user"},
+			Volume:      "pgdata",
+			Volumes:     []VolumeMount{{Type: VolumeTypeBind, Source: "/init", Target: "/docker-entrypoint-initdb.d", ReadOnly: true}},
+			EnvPrefix:   "DB",
+		},
+		"replica": {Image: "postgres:16", Ports: []string{"5433:5432"}},
+	}}
+
+	// 5434 is taken by another program
+	available := func(port int) bool { return port != 5434 }
+
+	clone, err := cfg.CloneService(cfg, "postgres", "postgres-copy", available)
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"5435:5432", "auto:9187"}, clone.Ports)
+	assert.Equal(t, "pgdata", clone.Volume)
+	assert.Empty(t, clone.EnvPrefix)
+	assert.Equal(t, clone, cfg.Services["postgres-copy"])
+
+	// The original is untouched
+	clone.Environment["POSTGRES_USER"] = "other"
+	assert.Equal(t, "user", cfg.Services["postgres"].Environment["POSTGRES_USER"])
+	assert.Equal(t, []string{"5432:5432", "auto:9187"}, cfg.Services["postgres"].Ports)
+
+	// Ports of earlier clones are skipped
+	second, err := cfg.CloneService(cfg, "postgres", "postgres-copy2", available)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"5436:5432", "auto:9187"}, second.Ports)
+}
+
+func TestCloneService_Errors(t *testing.T) {
+	cfg := &Config{Services: map[string]Service{
+		"postgres": {Image: "postgres:16"},
+		"mysql": {
+			Image:   "mysql:8",
+			Volumes: []VolumeMount{{Type: VolumeTypeBind, Source: "./data", Target: "/var/lib/mysql"}},
+		},
+	}}
+	available := func(int) bool { return true }
+
+	_, err := cfg.CloneService(cfg, "missing", "copy", available)
+	assert.ErrorContains(t, err, "not found")
+
+	_, err = cfg.CloneService(cfg, "postgres", "mysql", available)
+	assert.ErrorContains(t, err, "already exists")
+
+	_, err = cfg.CloneService(cfg, "postgres", "bad/name", available)
+	assert.ErrorContains(t, err, "invalid service name")
+
+	_, err = cfg.CloneService(cfg, "mysql", "mysql-copy", available)
+	assert.ErrorContains(t, err, "read-write")
+	assert.NotContains(t, cfg.Services, "mysql-copy")
+
+	// Services a profile adds are not in the base config
+	raw := &Config{Services: map[string]Service{}}
+	_, err = raw.CloneService(cfg, "postgres", "postgres-copy", available)
+	assert.ErrorContains(t, err, "only defined in profile")
+}
+
+func TestCloneService_InterpolatedPortsAndProfiles(t *testing.T) {
+	path := writeConfig(t, `
+services:
+  postgres:
+    image: postgres:16
+    ports: ["${PG_PORT:-5432}:5432"]
+    env:
+      POSTGRES_PASSWORD: ${PG_PASSWORD:-secret}
+    env_prefix: DB
+  replica:
+    image: postgres:16
+    ports: ["${REPLICA_PORT:-5433}:5432"]
+profiles:
+  test:
+    services:
+      postgres:
+        ports: ["55432:5432"]
+        env:
+          POSTGRES_DB: test
+`)
+	raw, err := LoadRawConfigFromFile(path)
+	require.NoError(t, err)
+	loaded, err := LoadConfigFromFile(path)
+	require.NoError(t, err)
+
+	clone, err := raw.CloneService(loaded, "postgres", "postgres-copy", func(int) bool { return true })
+	require.NoError(t, err)
+
+	// Ports come from the expanded config, variables elsewhere stay
+	assert.Equal(t, []string{"5434:5432"}, clone.Ports)
+	assert.Equal(t, "${PG_PASSWORD:-secret}", clone.Environment["POSTGRES_PASSWORD"])
+
+	data, err := yaml.Marshal(raw)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(path, data, 0o644))
+
+	// The clone follows the original's profile overrides, except its ports
+	SetProfile("test")
+	defer SetProfile("")
+	cfg, err := LoadConfigFromFile(path)
+	require.NoError(t, err)
+
+	copied, exists := cfg.GetService("postgres-copy")
+	require.True(t, exists)
+	assert.Equal(t, "test", copied.Environment["POSTGRES_DB"])
+	assert.Equal(t, "secret", copied.Environment["POSTGRES_PASSWORD"])
+	assert.Equal(t, []string{"5434:5432"}, copied.Ports)
+	assert.Empty(t, copied.EnvPrefix)
+}
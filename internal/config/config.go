@@ -11,19 +11,49 @@ import (
 
 // Config represents the main configuration structure
 type Config struct {
+	Project  string             `yaml:"project,omitempty" mapstructure:"project"`
+	Backend  *Backend           `yaml:"backend,omitempty" mapstructure:"backend"`
 	Profile  string             `yaml:"profile" mapstructure:"profile"`
 	Services map[string]Service `yaml:"services" mapstructure:"services"`
+	Profiles map[string]Profile `yaml:"profiles,omitempty" mapstructure:"profiles"`
+
+	Snapshots *Snapshots `yaml:"snapshots,omitempty" mapstructure:"snapshots"`
+
+	// AppliedProfile is the name of the profile whose overrides were merged
+	// into Services, or empty if no profile overrides were applied
+	AppliedProfile string `yaml:"-" mapstructure:"-"`
+
+	// FilePath is the absolute path of the file the config was loaded from
+	FilePath string `yaml:"-" mapstructure:"-"`
+
+	// UnresolvedVars lists ${VAR} references that had no value and no default
+	// during interpolation; they were replaced with empty strings
+	UnresolvedVars []string `yaml:"-" mapstructure:"-"`
 }
 
 // Service represents a single service configuration
 type Service struct {
 	Image       string            `yaml:"image" mapstructure:"image"`
+	Build       *Build            `yaml:"build,omitempty" mapstructure:"build"`
 	Ports       []string          `yaml:"ports" mapstructure:"ports"`
 	Environment map[string]string `yaml:"env" mapstructure:"env"`
 	Volume      string            `yaml:"volume" mapstructure:"volume"`
 	Networks    []string          `yaml:"networks" mapstructure:"networks"`
 	Command     []string          `yaml:"command" mapstructure:"command"`
 	HealthCheck *HealthCheck      `yaml:"health_check" mapstructure:"health_check"`
+	Enabled     *bool             `yaml:"enabled,omitempty" mapstructure:"enabled"`
+	DependsOn   Dependencies      `yaml:"depends_on,omitempty" mapstructure:"depends_on"`
+	DataPath    string            `yaml:"data_path,omitempty" mapstructure:"data_path"`
+	Volumes     []VolumeMount     `yaml:"volumes,omitempty" mapstructure:"volumes"`
+	Resources   *Resources        `yaml:"resources,omitempty" mapstructure:"resources"`
+
+	// EnvPrefix names the variables 'nizam env' exports for the service,
+	// e.g. ANALYTICS for ANALYTICS_URL
+	EnvPrefix string `yaml:"env_prefix,omitempty" mapstructure:"env_prefix"`
+
+	// Migrations is the directory of migration files 'nizam migrate' and
+	// 'nizam up --migrate' apply, relative to the config file
+	Migrations string `yaml:"migrations,omitempty" mapstructure:"migrations"`
 }
 
 // HealthCheck represents health check configuration
@@ -40,8 +70,50 @@ func LoadConfig() (*Config, error) {
 }
 
 // LoadConfigFromFile loads configuration from the specified file path,
-// or uses default detection if path is empty
+// or uses default detection if path is empty. The active profile's
+// overrides are merged into the returned services and ${VAR} references
+// are expanded from the environment and any .env files next to the config.
 func LoadConfigFromFile(configPath string) (*Config, error) {
+	config, err := LoadRawConfigFromFile(configPath)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := config.ApplyProfile(ActiveProfile(config)); err != nil {
+		return nil, err
+	}
+
+	if err := config.Interpolate(); err != nil {
+		return nil, err
+	}
+
+	if err := config.resolveBindMounts(); err != nil {
+		return nil, err
+	}
+
+	if err := config.resolveBuildContexts(); err != nil {
+		return nil, err
+	}
+
+	config.resolveMigrationDirs()
+
+	if err := config.resolveSnapshotPaths(); err != nil {
+		return nil, err
+	}
+
+	return config, nil
+}
+
+// LoadRawConfig loads the configuration file exactly as written, without
+// applying profile overrides or expanding variables. Use it when the config
+// is going to be saved back.
+func LoadRawConfig() (*Config, error) {
+	return LoadRawConfigFromFile("")
+}
+
+// LoadRawConfigFromFile loads the configuration from the specified file path
+// without applying profile overrides or expanding variables
+func LoadRawConfigFromFile(configPath string) (*Config, error) {
 	if configPath == "" {
 		// Use viper to get the config file path (respects --config flag)
 		configPath = viper.ConfigFileUsed()
@@ -67,6 +139,12 @@ func LoadConfigFromFile(configPath string) (*Config, error) {
 		config.Profile = "dev"
 	}
 
+	if abs, err := filepath.Abs(configPath); err == nil {
+		config.FilePath = abs
+	} else {
+		config.FilePath = configPath
+	}
+
 	return &config, nil
 }
 
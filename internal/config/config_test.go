@@ -0,0 +1,240 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const profileConfig = `profile: dev
+services:
+  postgres:
+    image: postgres:16
+    ports: ["5432:5432"]
+    env:
+      POSTGRES_USER: user
+      POSTGRES_PASSWORD: password
+    volume: pgdata
+  redis:
+    image: redis:7
+    ports: ["6379:6379"]
+  mailhog:
+    image: mailhog/mailhog
+    enabled: false
+profiles:
+  test:
+    services:
+      postgres:
+        ports: ["55432:5432"]
+        env:
+          POSTGRES_PASSWORD: test
+      redis:
+        enabled: false
+  ci:
+    services:
+      postgres:
+        image: postgres:15
+      mailhog:
+        enabled: true
+      minio:
+        image: minio/minio
+`
+
+func writeConfig(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), ".nizam.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	return path
+}
+
+func TestLoadConfigFromFile_DefaultProfile(t *testing.T) {
+	path := writeConfig(t, profileConfig)
+
+	cfg, err := LoadConfigFromFile(path)
+	require.NoError(t, err)
+
+	assert.Equal(t, "dev", cfg.Profile)
+	assert.Empty(t, cfg.AppliedProfile)
+	assert.ElementsMatch(t, []string{"postgres", "redis"}, cfg.GetServiceNames())
+	assert.Equal(t, []string{"ci", "test"}, cfg.GetProfileNames())
+}
+
+func TestLoadConfigFromFile_ProfileOverrides(t *testing.T) {
+	path := writeConfig(t, profileConfig)
+
+	SetProfile("test")
+	defer SetProfile("")
+
+	cfg, err := LoadConfigFromFile(path)
+	require.NoError(t, err)
+
+	assert.Equal(t, "test", cfg.Profile)
+	assert.Equal(t, "test", cfg.AppliedProfile)
+	assert.ElementsMatch(t, []string{"postgres"}, cfg.GetServiceNames())
+
+	pg, _ := cfg.GetService("postgres")
+	assert.Equal(t, "postgres:16", pg.Image)
+	assert.Equal(t, []string{"55432:5432"}, pg.Ports)
+	assert.Equal(t, "user", pg.Environment["POSTGRES_USER"])
+	assert.Equal(t, "test", pg.Environment["POSTGRES_PASSWORD"])
+	assert.Equal(t, "pgdata", pg.Volume)
+}
+
+func TestLoadConfigFromFile_ProfileEnablesAndAddsServices(t *testing.T) {
+	path := writeConfig(t, profileConfig)
+
+	SetProfile("ci")
+	defer SetProfile("")
+
+	cfg, err := LoadConfigFromFile(path)
+	require.NoError(t, err)
+
+	assert.ElementsMatch(t, []string{"postgres", "redis", "mailhog", "minio"}, cfg.GetServiceNames())
+
+	pg, _ := cfg.GetService("postgres")
+	assert.Equal(t, "postgres:15", pg.Image)
+	assert.Equal(t, "password", pg.Environment["POSTGRES_PASSWORD"])
+}
+
+func TestLoadConfigFromFile_UnknownProfile(t *testing.T) {
+	path := writeConfig(t, profileConfig)
+
+	SetProfile("staging")
+	defer SetProfile("")
+
+	_, err := LoadConfigFromFile(path)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "staging")
+}
+
+func TestLoadRawConfigFromFile_KeepsBaseServices(t *testing.T) {
+	path := writeConfig(t, profileConfig)
+
+	SetProfile("test")
+	defer SetProfile("")
+
+	cfg, err := LoadRawConfigFromFile(path)
+	require.NoError(t, err)
+
+	assert.ElementsMatch(t, []string{"postgres", "redis", "mailhog"}, cfg.GetServiceNames())
+	pg, _ := cfg.GetService("postgres")
+	assert.Equal(t, []string{"5432:5432"}, pg.Ports)
+}
+
+func TestInterpolateString(t *testing.T) {
+	env := map[string]string{"USER": "alice", "EMPTY": ""}
+	lookup := func(name string) (string, bool) {
+		value, ok := env[name]
+		return value, ok
+	}
+
+	tests := []struct {
+		input    string
+		expected string
+		wantErr  bool
+	}{
+		{"plain", "plain", false},
+		{"${USER}", "alice", false},
+		{"user=${USER}!", "user=alice!", false},
+		{"${MISSING:-fallback}", "fallback", false},
+		{"${EMPTY:-fallback}", "fallback", false},
+		{"${EMPTY-fallback}", "", false},
+		{"${MISSING:-${USER}}", "alice", false},
+		{"$$USER", "$USER", false},
+		{"cost: $5", "cost: $5", false},
+		{"${MISSING:?must be set}", "", true},
+		{"${EMPTY:?must be set}", "", true},
+		{"${EMPTY?must be set}", "", false},
+		{"${USER", "", true},
+	}
+
+	for _, test := range tests {
+		unresolved := map[string]bool{}
+		result, err := interpolateString(test.input, lookup, unresolved)
+		if test.wantErr {
+			assert.Error(t, err, test.input)
+			continue
+		}
+		require.NoError(t, err, test.input)
+		assert.Equal(t, test.expected, result, test.input)
+	}
+
+	unresolved := map[string]bool{}
+	result, err := interpolateString("${MISSING}", lookup, unresolved)
+	require.NoError(t, err)
+	assert.Empty(t, result)
+	assert.True(t, unresolved["MISSING"])
+}
+
+func TestLoadConfigFromFile_Interpolation(t *testing.T) {
+	path := writeConfig(t, `services:
+  postgres:
+    image: postgres:${PG_VERSION:-16}
+    ports: ["${PG_PORT}:5432"]
+    env:
+      POSTGRES_PASSWORD: ${PG_PASSWORD}
+      POSTGRES_USER: ${PG_USER}
+    health_check:
+      test: ["CMD", "pg_isready", "-U", "${PG_USER}"]
+`)
+	dir := filepath.Dir(path)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, ".env"), []byte("# shared defaults\nPG_PORT=5432\nPG_PASSWORD=fromdotenv\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, ".nizam.env"), []byte("export PG_PASSWORD=\"from nizam env\"\n"), 0644))
+	t.Setenv("PG_PORT", "15432")
+
+	cfg, err := LoadConfigFromFile(path)
+	require.NoError(t, err)
+
+	pg, _ := cfg.GetService("postgres")
+	assert.Equal(t, "postgres:16", pg.Image)
+	assert.Equal(t, []string{"15432:5432"}, pg.Ports)
+	assert.Equal(t, "from nizam env", pg.Environment["POSTGRES_PASSWORD"])
+	assert.Equal(t, "", pg.Environment["POSTGRES_USER"])
+	assert.Equal(t, []string{"CMD", "pg_isready", "-U", ""}, pg.HealthCheck.Test)
+	assert.Equal(t, []string{"PG_USER"}, cfg.UnresolvedVars)
+
+	raw, err := LoadRawConfigFromFile(path)
+	require.NoError(t, err)
+	rawPg, _ := raw.GetService("postgres")
+	assert.Equal(t, "${PG_PASSWORD}", rawPg.Environment["POSTGRES_PASSWORD"])
+}
+
+func TestLoadConfigFromFile_RequiredVariable(t *testing.T) {
+	path := writeConfig(t, `services:
+  postgres:
+    image: postgres:16
+    env:
+      POSTGRES_PASSWORD: ${NIZAM_TEST_REQUIRED:?set a database password}
+`)
+
+	_, err := LoadConfigFromFile(path)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "set a database password")
+}
+
+func TestLoadConfigFromFile_Resources(t *testing.T) {
+	path := writeConfig(t, `services:
+  postgres:
+    image: postgres:16
+    resources:
+      cpus: "2"
+      memory: 1g
+      ulimits:
+        nproc: 65535
+        nofile:
+          soft: 20000
+          hard: 40000
+`)
+
+	cfg, err := LoadConfigFromFile(path)
+	require.NoError(t, err)
+
+	pg, _ := cfg.GetService("postgres")
+	require.NotNil(t, pg.Resources)
+	assert.True(t, pg.Resources.HasLimits())
+	assert.Equal(t, Ulimit{Soft: 65535, Hard: 65535}, pg.Resources.Ulimits["nproc"])
+	assert.Equal(t, Ulimit{Soft: 20000, Hard: 40000}, pg.Resources.Ulimits["nofile"])
+}
@@ -0,0 +1,175 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Dependency conditions a dependent service can wait for
+const (
+	ConditionStarted  = "started"
+	ConditionHealthy  = "healthy"
+	ConditionPortOpen = "port-open"
+)
+
+// Dependency describes how a service depends on another one
+type Dependency struct {
+	Condition string `yaml:"condition,omitempty" mapstructure:"condition"`
+}
+
+// Dependencies maps the names of the services a service depends on to the
+// condition that must hold before it is started. In YAML it may be written
+// either as a list of service names (condition "started") or as a mapping:
+//
+//	depends_on:
+//	  postgres:
+//	    condition: healthy
+type Dependencies map[string]Dependency
+
+// UnmarshalYAML accepts both the list and the mapping form
+func (d *Dependencies) UnmarshalYAML(node *yaml.Node) error {
+	switch node.Kind {
+	case yaml.SequenceNode:
+		var names []string
+		if err := node.Decode(&names); err != nil {
+			return err
+		}
+		deps := make(Dependencies, len(names))
+		for _, name := range names {
+			deps[name] = Dependency{Condition: ConditionStarted}
+		}
+		*d = deps
+		return nil
+	case yaml.MappingNode:
+		// Merge into an existing map so profile overrides can add dependencies
+		deps := *d
+		if deps == nil {
+			deps = Dependencies{}
+		}
+		var parsed map[string]Dependency
+		if err := node.Decode(&parsed); err != nil {
+			return err
+		}
+		for name, dep := range parsed {
+			deps[name] = dep
+		}
+		*d = deps
+		return nil
+	default:
+		return fmt.Errorf("depends_on must be a list or a mapping")
+	}
+}
+
+// GetCondition returns the dependency condition, defaulting to "started"
+func (d Dependency) GetCondition() string {
+	if d.Condition == "" {
+		return ConditionStarted
+	}
+	return d.Condition
+}
+
+// Names returns the sorted names of the dependencies
+func (d Dependencies) Names() []string {
+	names := make([]string, 0, len(d))
+	for name := range d {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ValidateDependencies checks that every dependency refers to a defined
+// service, uses a known condition and that there are no cycles
+func (c *Config) ValidateDependencies() error {
+	for _, name := range c.sortedServiceNames() {
+		for _, depName := range c.Services[name].DependsOn.Names() {
+			if _, exists := c.Services[depName]; !exists {
+				return fmt.Errorf("service '%s' depends on undefined service '%s'", name, depName)
+			}
+			switch condition := c.Services[name].DependsOn[depName].GetCondition(); condition {
+			case ConditionStarted, ConditionHealthy, ConditionPortOpen:
+			default:
+				return fmt.Errorf("service '%s': unknown condition '%s' for dependency '%s' (use %s, %s or %s)",
+					name, condition, depName, ConditionStarted, ConditionHealthy, ConditionPortOpen)
+			}
+		}
+	}
+
+	_, err := c.StartOrder(nil)
+	return err
+}
+
+// StartOrder returns the given services plus everything they transitively
+// depend on, in an order where every service comes after its dependencies.
+// Services without an ordering constraint keep a stable alphabetical order.
+// An empty list means all services.
+func (c *Config) StartOrder(serviceNames []string) ([]string, error) {
+	if len(serviceNames) == 0 {
+		serviceNames = c.sortedServiceNames()
+	}
+
+	const (
+		unvisited = iota
+		visiting
+		done
+	)
+	state := map[string]int{}
+	var order []string
+	var path []string
+
+	var visit func(name string) error
+	visit = func(name string) error {
+		switch state[name] {
+		case done:
+			return nil
+		case visiting:
+			cycle := append(path[indexOf(path, name):], name)
+			return fmt.Errorf("dependency cycle detected: %s", strings.Join(cycle, " -> "))
+		}
+
+		service, exists := c.Services[name]
+		if !exists {
+			if len(path) > 0 {
+				return fmt.Errorf("service '%s' depends on undefined service '%s'", path[len(path)-1], name)
+			}
+			return fmt.Errorf("service '%s' not found in configuration", name)
+		}
+
+		state[name] = visiting
+		path = append(path, name)
+		for _, dep := range service.DependsOn.Names() {
+			if err := visit(dep); err != nil {
+				return err
+			}
+		}
+		path = path[:len(path)-1]
+		state[name] = done
+		order = append(order, name)
+		return nil
+	}
+
+	for _, name := range serviceNames {
+		if err := visit(name); err != nil {
+			return nil, err
+		}
+	}
+	return order, nil
+}
+
+func (c *Config) sortedServiceNames() []string {
+	names := c.GetServiceNames()
+	sort.Strings(names)
+	return names
+}
+
+func indexOf(list []string, value string) int {
+	for i, item := range list {
+		if item == value {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,87 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+func TestDependencies_UnmarshalYAML(t *testing.T) {
+	var svc Service
+	require.NoError(t, yaml.Unmarshal([]byte("depends_on: [postgres, redis]"), &svc))
+	assert.Equal(t, ConditionStarted, svc.DependsOn["postgres"].GetCondition())
+	assert.Equal(t, []string{"postgres", "redis"}, svc.DependsOn.Names())
+
+	svc = Service{}
+	require.NoError(t, yaml.Unmarshal([]byte(`depends_on:
+  postgres:
+    condition: healthy
+  redis: {}
+`), &svc))
+	assert.Equal(t, ConditionHealthy, svc.DependsOn["postgres"].GetCondition())
+	assert.Equal(t, ConditionStarted, svc.DependsOn["redis"].GetCondition())
+
+	svc = Service{}
+	assert.Error(t, yaml.Unmarshal([]byte("depends_on: postgres"), &svc))
+}
+
+func TestStartOrder(t *testing.T) {
+	cfg := &Config{Services: map[string]Service{
+		"postgres": {Image: "postgres:16"},
+		"redis":    {Image: "redis:7"},
+		"api": {Image: "api:1", DependsOn: Dependencies{
+			"postgres": {Condition: ConditionHealthy},
+			"redis":    {},
+		}},
+		"worker": {Image: "worker:1", DependsOn: Dependencies{"api": {}}},
+	}}
+
+	order, err := cfg.StartOrder(nil)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"postgres", "redis", "api", "worker"}, order)
+
+	order, err = cfg.StartOrder([]string{"worker"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"postgres", "redis", "api", "worker"}, order)
+
+	order, err = cfg.StartOrder([]string{"redis"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"redis"}, order)
+
+	_, err = cfg.StartOrder([]string{"missing"})
+	assert.Error(t, err)
+}
+
+func TestValidateDependencies(t *testing.T) {
+	cfg := &Config{Services: map[string]Service{
+		"a": {DependsOn: Dependencies{"b": {}}},
+		"b": {DependsOn: Dependencies{"c": {}}},
+		"c": {DependsOn: Dependencies{"a": {}}},
+	}}
+	err := cfg.ValidateDependencies()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "a -> b -> c -> a")
+
+	cfg = &Config{Services: map[string]Service{
+		"api": {DependsOn: Dependencies{"db": {}}},
+	}}
+	err = cfg.ValidateDependencies()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "undefined service 'db'")
+
+	cfg = &Config{Services: map[string]Service{
+		"api": {DependsOn: Dependencies{"db": {Condition: "ready"}}},
+		"db":  {},
+	}}
+	err = cfg.ValidateDependencies()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "unknown condition")
+
+	cfg = &Config{Services: map[string]Service{
+		"api": {DependsOn: Dependencies{"db": {Condition: ConditionPortOpen}}},
+		"db":  {},
+	}}
+	assert.NoError(t, cfg.ValidateDependencies())
+}
@@ -0,0 +1,81 @@
+package config
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+)
+
+// Hash returns a stable hash of the parts of the service that determine how
+// its container is created. Fields that only affect nizam's orchestration,
+// such as depends_on, enabled, env_prefix and migrations, are excluded so
+// that changing them does not force a container to be recreated. Unset
+// fields are left out of the hashed form, so adding an optional field to
+// Service keeps the hashes of configs that don't use it.
+func (s Service) Hash() string {
+	s.DependsOn = nil
+	s.Enabled = nil
+	s.EnvPrefix = ""
+	s.Migrations = ""
+
+	data, err := json.Marshal(s)
+	if err != nil {
+		return ""
+	}
+	var canonical interface{}
+	if err := json.Unmarshal(data, &canonical); err != nil {
+		return ""
+	}
+
+	// encoding/json sorts map keys, which keeps the output deterministic
+	data, err = json.Marshal(pruneZero(canonical))
+	if err != nil {
+		return ""
+	}
+
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// pruneZero removes object members whose value is null, false, zero or
+// empty from decoded JSON, at every level. Array elements are kept, as
+// their position is significant.
+func pruneZero(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, member := range v {
+			member = pruneZero(member)
+			if isZero(member) {
+				delete(v, key)
+				continue
+			}
+			v[key] = member
+		}
+		return v
+	case []interface{}:
+		for i, element := range v {
+			v[i] = pruneZero(element)
+		}
+		return v
+	}
+	return value
+}
+
+// isZero reports whether a decoded JSON value is null, false, zero or empty
+func isZero(value interface{}) bool {
+	switch v := value.(type) {
+	case nil:
+		return true
+	case bool:
+		return !v
+	case float64:
+		return v == 0
+	case string:
+		return v == ""
+	case map[string]interface{}:
+		return len(v) == 0
+	case []interface{}:
+		return len(v) == 0
+	}
+	return false
+}
@@ -0,0 +1,79 @@
+package config
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServiceHash(t *testing.T) {
+	base := Service{
+		Image: "postgres:16",
+		Ports: []string{"5432:5432"},
+		Environment: map[string]string{
+			"POSTGRES_USER":     "user",
+			"POSTGRES_PASSWORD": "password",
+		},
+	}
+
+	same := Service{
+		Image: "postgres:16",
+		Ports: []string{"5432:5432"},
+		Environment: map[string]string{
+			"POSTGRES_PASSWORD": "password",
+			"POSTGRES_USER":     "user",
+		},
+		DependsOn: Dependencies{"redis": {}},
+	}
+	assert.Equal(t, base.Hash(), same.Hash())
+	assert.Len(t, base.Hash(), 64)
+
+	changed := same
+	changed.Environment = map[string]string{"POSTGRES_USER": "other"}
+	assert.NotEqual(t, base.Hash(), changed.Hash())
+
+	changed = same
+	changed.Image = "postgres:17"
+	assert.NotEqual(t, base.Hash(), changed.Hash())
+
+	changed = same
+	changed.Command = []string{"postgres", "-c", "log_statement=all"}
+	assert.NotEqual(t, base.Hash(), changed.Hash())
+}
+
+func TestServiceHash_IgnoresUnsetFields(t *testing.T) {
+	service := Service{
+		Image: "postgres:16",
+		Ports: []string{"5432:5432"},
+	}
+	hash := service.Hash()
+
+	// The hashed form holds set fields only, so new optional fields keep
+	// existing hashes stable
+	type withNewField struct {
+		Service
+		Added *Resources
+	}
+	data, err := json.Marshal(withNewField{Service: service})
+	require.NoError(t, err)
+	var canonical interface{}
+	require.NoError(t, json.Unmarshal(data, &canonical))
+	pruned, err := json.Marshal(pruneZero(canonical))
+	require.NoError(t, err)
+	sum := sha256.Sum256(pruned)
+	assert.Equal(t, hash, hex.EncodeToString(sum[:]))
+
+	// Empty and unset values hash alike
+	empty := service
+	empty.Environment = map[string]string{}
+	empty.Volumes = []VolumeMount{}
+	empty.HealthCheck = &HealthCheck{}
+	empty.Resources = &Resources{}
+	empty.EnvPrefix = "DB"
+	empty.Migrations = "migrations"
+	assert.Equal(t, hash, empty.Hash())
+}
@@ -0,0 +1,253 @@
+package config
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// EnvFiles are the dotenv files read from the config file's directory, in
+// increasing order of precedence. Process environment variables always win.
+var EnvFiles = []string{".env", ".nizam.env"}
+
+// Interpolate expands ${VAR}, ${VAR:-default}, ${VAR-default}, ${VAR:?error}
+// and ${VAR?error} in every string field of every service. Values come from
+// the process environment and the dotenv files next to the config file.
+// Use $$ for a literal dollar sign.
+func (c *Config) Interpolate() error {
+	dir := "."
+	if c.FilePath != "" {
+		dir = filepath.Dir(c.FilePath)
+	}
+
+	vars, err := LoadEnvFiles(dir)
+	if err != nil {
+		return err
+	}
+
+	lookup := func(name string) (string, bool) {
+		if value, ok := os.LookupEnv(name); ok {
+			return value, true
+		}
+		value, ok := vars[name]
+		return value, ok
+	}
+
+	unresolved := map[string]bool{}
+	for name, service := range c.Services {
+		err := interpolateValue(reflect.ValueOf(&service).Elem(), func(s string) (string, error) {
+			return interpolateString(s, lookup, unresolved)
+		})
+		if err != nil {
+			return fmt.Errorf("service '%s': %w", name, err)
+		}
+		c.Services[name] = service
+	}
+
+	c.UnresolvedVars = c.UnresolvedVars[:0]
+	for name := range unresolved {
+		c.UnresolvedVars = append(c.UnresolvedVars, name)
+	}
+	sort.Strings(c.UnresolvedVars)
+
+	return nil
+}
+
+// LoadEnvFiles reads the dotenv files listed in EnvFiles from dir. Missing
+// files are ignored; later files override earlier ones.
+func LoadEnvFiles(dir string) (map[string]string, error) {
+	vars := map[string]string{}
+	for _, name := range EnvFiles {
+		path := filepath.Join(dir, name)
+		file, err := os.Open(path)
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return nil, fmt.Errorf("failed to open %s: %w", path, err)
+		}
+
+		err = parseEnvFile(file, vars)
+		file.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+		}
+	}
+	return vars, nil
+}
+
+// parseEnvFile parses KEY=VALUE lines, ignoring blank lines, comments and
+// an optional leading "export". Single or double quotes around the value
+// are stripped.
+func parseEnvFile(file *os.File, vars map[string]string) error {
+	scanner := bufio.NewScanner(file)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimSpace(strings.TrimPrefix(line, "export "))
+
+		key, value, found := strings.Cut(line, "=")
+		if !found {
+			return fmt.Errorf("line %d: expected KEY=VALUE", lineNo)
+		}
+		key = strings.TrimSpace(key)
+		value = strings.TrimSpace(value)
+
+		if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+			value = value[1 : len(value)-1]
+		} else if idx := strings.Index(value, " #"); idx >= 0 {
+			value = strings.TrimSpace(value[:idx])
+		}
+
+		vars[key] = value
+	}
+	return scanner.Err()
+}
+
+// interpolateString expands variable references in s. Plain references to
+// unset variables expand to "" and are recorded in unresolved.
+func interpolateString(s string, lookup func(string) (string, bool), unresolved map[string]bool) (string, error) {
+	if !strings.Contains(s, "$") {
+		return s, nil
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '$' || i+1 >= len(s) {
+			b.WriteByte(s[i])
+			continue
+		}
+
+		switch s[i+1] {
+		case '$':
+			b.WriteByte('$')
+			i++
+		case '{':
+			end := matchingBrace(s, i+1)
+			if end < 0 {
+				return "", fmt.Errorf("unterminated variable reference in %q", s)
+			}
+			value, err := expandReference(s[i+2:end], lookup, unresolved)
+			if err != nil {
+				return "", err
+			}
+			b.WriteString(value)
+			i = end
+		default:
+			b.WriteByte('$')
+		}
+	}
+	return b.String(), nil
+}
+
+// expandReference expands the body of a ${...} reference
+func expandReference(ref string, lookup func(string) (string, bool), unresolved map[string]bool) (string, error) {
+	name, op, arg := ref, "", ""
+	if idx := strings.IndexAny(ref, ":-?"); idx >= 0 {
+		name = ref[:idx]
+		rest := ref[idx:]
+		switch {
+		case strings.HasPrefix(rest, ":-"), strings.HasPrefix(rest, ":?"):
+			op, arg = rest[:2], rest[2:]
+		case strings.HasPrefix(rest, "-"), strings.HasPrefix(rest, "?"):
+			op, arg = rest[:1], rest[1:]
+		default:
+			return "", fmt.Errorf("invalid variable reference ${%s}", ref)
+		}
+	}
+	if name == "" {
+		return "", fmt.Errorf("invalid variable reference ${%s}", ref)
+	}
+
+	value, set := lookup(name)
+	switch op {
+	case "":
+		if !set {
+			unresolved[name] = true
+		}
+		return value, nil
+	case ":-", "-":
+		if !set || (op == ":-" && value == "") {
+			return interpolateString(arg, lookup, unresolved)
+		}
+		return value, nil
+	default: // ":?", "?"
+		if !set || (op == ":?" && value == "") {
+			msg, err := interpolateString(arg, lookup, unresolved)
+			if err != nil {
+				return "", err
+			}
+			if msg == "" {
+				msg = "required variable is not set"
+			}
+			return "", fmt.Errorf("%s: %s", name, msg)
+		}
+		return value, nil
+	}
+}
+
+// matchingBrace returns the index of the brace closing the one at open
+func matchingBrace(s string, open int) int {
+	depth := 0
+	for i := open; i < len(s); i++ {
+		switch s[i] {
+		case '{':
+			depth++
+		case '}':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// interpolateValue applies fn to every string reachable from v
+func interpolateValue(v reflect.Value, fn func(string) (string, error)) error {
+	switch v.Kind() {
+	case reflect.String:
+		expanded, err := fn(v.String())
+		if err != nil {
+			return err
+		}
+		v.SetString(expanded)
+	case reflect.Ptr, reflect.Interface:
+		if !v.IsNil() {
+			return interpolateValue(v.Elem(), fn)
+		}
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			if v.Type().Field(i).IsExported() {
+				if err := interpolateValue(v.Field(i), fn); err != nil {
+					return err
+				}
+			}
+		}
+	case reflect.Slice:
+		for i := 0; i < v.Len(); i++ {
+			if err := interpolateValue(v.Index(i), fn); err != nil {
+				return err
+			}
+		}
+	case reflect.Map:
+		iter := v.MapRange()
+		for iter.Next() {
+			elem := reflect.New(v.Type().Elem()).Elem()
+			elem.Set(iter.Value())
+			if err := interpolateValue(elem, fn); err != nil {
+				return fmt.Errorf("%v: %w", iter.Key(), err)
+			}
+			v.SetMapIndex(iter.Key(), elem)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,154 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// LockFileName is the name of the lockfile written next to the config
+const LockFileName = ".nizam.lock"
+
+// lockHeader is written at the top of every lockfile
+const lockHeader = "# Generated by 'nizam lock'. Do not edit by hand.\n"
+
+// Lock pins service images to registry digests so that every checkout
+// runs exactly the same images
+type Lock struct {
+	Version int                    `yaml:"version"`
+	Images  map[string]LockedImage `yaml:"images"`
+}
+
+// LockedImage records the digest an image reference resolved to
+type LockedImage struct {
+	Image  string `yaml:"image"`
+	Digest string `yaml:"digest"`
+}
+
+// Reference returns the image reference pinned to its digest
+func (l LockedImage) Reference() string {
+	image := l.Image
+	if i := strings.Index(image, "@"); i >= 0 {
+		image = image[:i]
+	}
+	return image + "@" + l.Digest
+}
+
+// LockPath returns the path of the lockfile belonging to the config
+func (c *Config) LockPath() string {
+	if c.FilePath == "" {
+		return LockFileName
+	}
+	return filepath.Join(filepath.Dir(c.FilePath), LockFileName)
+}
+
+// LoadLock reads the config's lockfile. It returns nil without an error if
+// the project has no lockfile.
+func (c *Config) LoadLock() (*Lock, error) {
+	data, err := os.ReadFile(c.LockPath())
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read lockfile: %w", err)
+	}
+
+	var lock Lock
+	if err := yaml.Unmarshal(data, &lock); err != nil {
+		return nil, fmt.Errorf("failed to parse lockfile: %w", err)
+	}
+	return &lock, nil
+}
+
+// SaveLock writes the lockfile next to the config
+func (c *Config) SaveLock(lock *Lock) error {
+	data, err := yaml.Marshal(lock)
+	if err != nil {
+		return fmt.Errorf("failed to marshal lockfile: %w", err)
+	}
+
+	if err := os.WriteFile(c.LockPath(), append([]byte(lockHeader), data...), 0644); err != nil {
+		return fmt.Errorf("failed to write lockfile: %w", err)
+	}
+	return nil
+}
+
+// LockableServices returns the services whose images are pulled from a
+// registry, in sorted order. Services built from a Dockerfile are not locked.
+func (c *Config) LockableServices() []string {
+	var names []string
+	for name, service := range c.Services {
+		if service.Build == nil && service.Image != "" {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// StaleLockEntry explains why the lock entry of a service is out of date
+type StaleLockEntry struct {
+	Service string
+	Reason  string
+}
+
+func (e StaleLockEntry) String() string {
+	return e.Service + ": " + e.Reason
+}
+
+// StaleEntries lists why the lock no longer matches the config: services
+// whose image changed or that are missing from the lock, and locked
+// services that were removed. An empty result means the lock is current.
+func (l *Lock) StaleEntries(c *Config) []StaleLockEntry {
+	var stale []StaleLockEntry
+
+	lockable := make(map[string]bool)
+	for _, name := range c.LockableServices() {
+		lockable[name] = true
+		locked, ok := l.Images[name]
+		image := c.Services[name].Image
+		switch {
+		case !ok:
+			stale = append(stale, StaleLockEntry{Service: name, Reason: "not locked"})
+		case locked.Image != image:
+			stale = append(stale, StaleLockEntry{
+				Service: name,
+				Reason:  fmt.Sprintf("image changed from %s to %s", locked.Image, image),
+			})
+		}
+	}
+
+	var removed []string
+	for name := range l.Images {
+		if !lockable[name] {
+			removed = append(removed, name)
+		}
+	}
+	sort.Strings(removed)
+	for _, name := range removed {
+		stale = append(stale, StaleLockEntry{Service: name, Reason: "locked but no longer in the config"})
+	}
+
+	return stale
+}
+
+// ApplyLock replaces the images of services whose lock entry still matches
+// the config with their digest-pinned references. Services with a stale or
+// missing entry keep their configured image.
+func (c *Config) ApplyLock(lock *Lock) {
+	if lock == nil {
+		return
+	}
+	for name, service := range c.Services {
+		locked, ok := lock.Images[name]
+		if !ok || service.Build != nil || locked.Image != service.Image || locked.Digest == "" {
+			continue
+		}
+		service.Image = locked.Reference()
+		c.Services[name] = service
+	}
+}
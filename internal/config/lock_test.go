@@ -0,0 +1,87 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testDigest = "sha256:4f53cda18c2baa0c0354bb5f9a3ecbe5ed12ab4d8e11ba873c2f11161202b945"
+
+func lockTestConfig(t *testing.T) *Config {
+	t.Helper()
+	return &Config{
+		FilePath: filepath.Join(t.TempDir(), ".nizam.yaml"),
+		Services: map[string]Service{
+			"postgres": {Image: "postgres:16"},
+			"redis":    {Image: "redis:7"},
+			"api":      {Build: &Build{Context: "."}},
+		},
+	}
+}
+
+func TestLockableServices(t *testing.T) {
+	cfg := lockTestConfig(t)
+	assert.Equal(t, []string{"postgres", "redis"}, cfg.LockableServices())
+}
+
+func TestLock_SaveAndLoad(t *testing.T) {
+	cfg := lockTestConfig(t)
+
+	lock, err := cfg.LoadLock()
+	require.NoError(t, err)
+	assert.Nil(t, lock)
+
+	want := &Lock{Version: 1, Images: map[string]LockedImage{
+		"postgres": {Image: "postgres:16", Digest: testDigest},
+	}}
+	require.NoError(t, cfg.SaveLock(want))
+
+	data, err := os.ReadFile(filepath.Join(filepath.Dir(cfg.FilePath), LockFileName))
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "nizam lock")
+
+	got, err := cfg.LoadLock()
+	require.NoError(t, err)
+	assert.Equal(t, want, got)
+}
+
+func TestLock_StaleEntries(t *testing.T) {
+	cfg := lockTestConfig(t)
+	lock := &Lock{Version: 1, Images: map[string]LockedImage{
+		"postgres": {Image: "postgres:15", Digest: testDigest},
+		"mongo":    {Image: "mongo:7", Digest: testDigest},
+	}}
+
+	assert.Equal(t, []StaleLockEntry{
+		{Service: "postgres", Reason: "image changed from postgres:15 to postgres:16"},
+		{Service: "redis", Reason: "not locked"},
+		{Service: "mongo", Reason: "locked but no longer in the config"},
+	}, lock.StaleEntries(cfg))
+
+	current := &Lock{Version: 1, Images: map[string]LockedImage{
+		"postgres": {Image: "postgres:16", Digest: testDigest},
+		"redis":    {Image: "redis:7", Digest: testDigest},
+	}}
+	assert.Empty(t, current.StaleEntries(cfg))
+}
+
+func TestApplyLock(t *testing.T) {
+	cfg := lockTestConfig(t)
+	cfg.ApplyLock(&Lock{Version: 1, Images: map[string]LockedImage{
+		"postgres": {Image: "postgres:16", Digest: testDigest},
+		"redis":    {Image: "redis:6", Digest: testDigest},
+	}})
+
+	assert.Equal(t, "postgres:16@"+testDigest, cfg.Services["postgres"].Image)
+	assert.Equal(t, "redis:7", cfg.Services["redis"].Image, "stale entries are not applied")
+	assert.Empty(t, cfg.Services["api"].Image)
+}
+
+func TestLockedImage_Reference(t *testing.T) {
+	assert.Equal(t, "postgres:16@"+testDigest, LockedImage{Image: "postgres:16", Digest: testDigest}.Reference())
+	assert.Equal(t, "postgres@"+testDigest, LockedImage{Image: "postgres@sha256:old", Digest: testDigest}.Reference())
+}
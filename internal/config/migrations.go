@@ -0,0 +1,20 @@
+package config
+
+import "path/filepath"
+
+// resolveMigrationDirs makes migration directories absolute, relative to the
+// directory of the config file
+func (c *Config) resolveMigrationDirs() {
+	baseDir := "."
+	if c.FilePath != "" {
+		baseDir = filepath.Dir(c.FilePath)
+	}
+
+	for name, service := range c.Services {
+		if service.Migrations == "" || filepath.IsAbs(service.Migrations) {
+			continue
+		}
+		service.Migrations = filepath.Join(baseDir, service.Migrations)
+		c.Services[name] = service
+	}
+}
@@ -0,0 +1,35 @@
+package config
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadConfig_Migrations(t *testing.T) {
+	path := writeConfig(t, `
+services:
+  postgres:
+    image: postgres:16
+    migrations: db/migrations
+  mongo:
+    image: mongo:7
+    migrations: /srv/migrations
+  redis:
+    image: redis:7
+`)
+
+	cfg, err := LoadConfigFromFile(path)
+	require.NoError(t, err)
+
+	assert.Equal(t, filepath.Join(filepath.Dir(path), "db/migrations"), cfg.Services["postgres"].Migrations)
+	assert.Equal(t, "/srv/migrations", cfg.Services["mongo"].Migrations)
+	assert.Empty(t, cfg.Services["redis"].Migrations)
+
+	// Migrations do not affect the container
+	withoutMigrations := cfg.Services["postgres"]
+	withoutMigrations.Migrations = ""
+	assert.Equal(t, withoutMigrations.Hash(), cfg.Services["postgres"].Hash())
+}
@@ -0,0 +1,106 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// AutoPort as the host side of a port mapping, as in "auto:5432", lets nizam
+// pick a free host port when the service's container is created
+const AutoPort = "auto"
+
+// PortsLabel is the container label recording the host ports a service was
+// published on, as comma-separated "host:container" mappings
+const PortsLabel = "nizam.ports"
+
+// Port strategies decide what happens when a fixed host port is taken
+const (
+	// PortStrategyFixed publishes services on their configured host ports
+	// and fails if one is taken
+	PortStrategyFixed = "fixed"
+	// PortStrategyNextFree publishes a service whose host port is taken on
+	// the next free port above it
+	PortStrategyNextFree = "next-free"
+)
+
+// PortMapping is a parsed "host:container" port mapping
+type PortMapping struct {
+	// HostPort is zero for an "auto" mapping that has not been allocated
+	HostPort      int
+	ContainerPort int
+	Auto          bool
+}
+
+// ParsePort parses a "host:container" or "auto:container" port mapping
+func ParsePort(spec string) (PortMapping, error) {
+	host, container, found := strings.Cut(strings.TrimSpace(spec), ":")
+	if !found {
+		return PortMapping{}, fmt.Errorf("invalid port mapping '%s': must be 'host:container'", spec)
+	}
+
+	containerPort, err := parsePortNumber(container)
+	if err != nil {
+		return PortMapping{}, fmt.Errorf("invalid container port in '%s': %w", spec, err)
+	}
+
+	if host == AutoPort {
+		return PortMapping{ContainerPort: containerPort, Auto: true}, nil
+	}
+
+	hostPort, err := parsePortNumber(host)
+	if err != nil {
+		return PortMapping{}, fmt.Errorf("invalid host port in '%s': %w", spec, err)
+	}
+	return PortMapping{HostPort: hostPort, ContainerPort: containerPort}, nil
+}
+
+func parsePortNumber(value string) (int, error) {
+	port, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("'%s' is not a number", value)
+	}
+	if port < 1 || port > 65535 {
+		return 0, fmt.Errorf("%d is out of range", port)
+	}
+	return port, nil
+}
+
+// String formats the mapping as it is written in the config
+func (p PortMapping) String() string {
+	if p.Auto && p.HostPort == 0 {
+		return fmt.Sprintf("%s:%d", AutoPort, p.ContainerPort)
+	}
+	return fmt.Sprintf("%d:%d", p.HostPort, p.ContainerPort)
+}
+
+// FormatPortsLabel formats allocated mappings as a PortsLabel value
+func FormatPortsLabel(ports []PortMapping) string {
+	parts := make([]string, len(ports))
+	for i, port := range ports {
+		parts[i] = fmt.Sprintf("%d:%d", port.HostPort, port.ContainerPort)
+	}
+	return strings.Join(parts, ",")
+}
+
+// ParsePortsLabel parses a PortsLabel value, skipping malformed entries
+func ParsePortsLabel(value string) []PortMapping {
+	var ports []PortMapping
+	for _, part := range strings.Split(value, ",") {
+		port, err := ParsePort(part)
+		if err != nil || port.Auto {
+			continue
+		}
+		ports = append(ports, port)
+	}
+	return ports
+}
+
+// ValidatePortStrategy checks a --port-strategy value
+func ValidatePortStrategy(strategy string) error {
+	switch strategy {
+	case "", PortStrategyFixed, PortStrategyNextFree:
+		return nil
+	}
+	return fmt.Errorf("unknown port strategy '%s' (expected %s or %s)", strategy, PortStrategyFixed, PortStrategyNextFree)
+}
@@ -0,0 +1,49 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParsePort(t *testing.T) {
+	port, err := ParsePort("15432:5432")
+	require.NoError(t, err)
+	assert.Equal(t, PortMapping{HostPort: 15432, ContainerPort: 5432}, port)
+	assert.Equal(t, "15432:5432", port.String())
+
+	port, err = ParsePort("auto:5432")
+	require.NoError(t, err)
+	assert.Equal(t, PortMapping{ContainerPort: 5432, Auto: true}, port)
+	assert.Equal(t, "auto:5432", port.String())
+
+	for _, spec := range []string{"5432", "auto", "x:5432", "5432:x", "0:5432", "5432:70000", "8000-8010:80"} {
+		_, err := ParsePort(spec)
+		assert.Error(t, err, spec)
+	}
+}
+
+func TestPortsLabel(t *testing.T) {
+	ports := []PortMapping{
+		{HostPort: 5433, ContainerPort: 5432, Auto: true},
+		{HostPort: 8080, ContainerPort: 80},
+	}
+
+	label := FormatPortsLabel(ports)
+	assert.Equal(t, "5433:5432,8080:80", label)
+	assert.Equal(t, []PortMapping{
+		{HostPort: 5433, ContainerPort: 5432},
+		{HostPort: 8080, ContainerPort: 80},
+	}, ParsePortsLabel(label))
+
+	assert.Empty(t, ParsePortsLabel(""))
+	assert.Len(t, ParsePortsLabel("5433:5432,bogus"), 1)
+}
+
+func TestValidatePortStrategy(t *testing.T) {
+	assert.NoError(t, ValidatePortStrategy(""))
+	assert.NoError(t, ValidatePortStrategy(PortStrategyFixed))
+	assert.NoError(t, ValidatePortStrategy(PortStrategyNextFree))
+	assert.ErrorContains(t, ValidatePortStrategy("random"), "unknown port strategy")
+}
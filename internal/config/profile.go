@@ -0,0 +1,125 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultProfile is the profile used when none is configured
+const DefaultProfile = "dev"
+
+// Profile holds per-profile service overrides. Each entry under services
+// uses the same keys as a regular service; only the keys that are present
+// override the base service (env entries are merged key by key). A profile
+// may also add services that are not defined in the base configuration and
+// toggle services with `enabled: true|false`.
+type Profile struct {
+	Services map[string]yaml.Node `yaml:"services,omitempty" mapstructure:"services"`
+}
+
+// profileOverride is set from the --profile flag or NIZAM_PROFILE and takes
+// precedence over the profile field in the config file
+var profileOverride string
+
+// SetProfile selects the profile to apply when loading configuration,
+// overriding the profile named in the config file
+func SetProfile(name string) {
+	profileOverride = name
+}
+
+// ActiveProfile returns the profile that should be applied to cfg
+func ActiveProfile(cfg *Config) string {
+	if profileOverride != "" {
+		return profileOverride
+	}
+	if cfg.Profile != "" {
+		return cfg.Profile
+	}
+	return DefaultProfile
+}
+
+// GetProfileNames returns the sorted names of all defined profiles
+func (c *Config) GetProfileNames() []string {
+	names := make([]string, 0, len(c.Profiles))
+	for name := range c.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ApplyProfile merges the overrides of the named profile into Services and
+// drops services that end up disabled. Requesting a profile that is not
+// defined is an error, except for the default profile, which may be omitted.
+func (c *Config) ApplyProfile(name string) error {
+	c.Profile = name
+
+	profile, exists := c.Profiles[name]
+	if !exists {
+		if len(c.Profiles) > 0 && name != DefaultProfile {
+			return fmt.Errorf("profile '%s' is not defined (available: %v)", name, c.GetProfileNames())
+		}
+		c.dropDisabledServices()
+		return nil
+	}
+
+	merged := make(map[string]Service, len(c.Services))
+	for serviceName, service := range c.Services {
+		merged[serviceName] = service
+	}
+
+	for serviceName, override := range profile.Services {
+		service, err := mergeService(c.Services[serviceName], &override)
+		if err != nil {
+			return fmt.Errorf("profile '%s': invalid override for service '%s': %w", name, serviceName, err)
+		}
+		merged[serviceName] = service
+	}
+
+	c.Services = merged
+	c.AppliedProfile = name
+	c.dropDisabledServices()
+	return nil
+}
+
+// IsEnabled reports whether the service should be managed by nizam
+func (s Service) IsEnabled() bool {
+	return s.Enabled == nil || *s.Enabled
+}
+
+func (c *Config) dropDisabledServices() {
+	for name, service := range c.Services {
+		if !service.IsEnabled() {
+			delete(c.Services, name)
+		}
+	}
+}
+
+// mergeService decodes override on top of a deep copy of base, so that only
+// the keys present in the override replace base values
+func mergeService(base Service, override *yaml.Node) (Service, error) {
+	if override.Kind == 0 || override.Tag == "!!null" {
+		return base, nil
+	}
+	if override.Kind != yaml.MappingNode {
+		return Service{}, fmt.Errorf("expected a mapping, got %s", override.Tag)
+	}
+
+	data, err := yaml.Marshal(&base)
+	if err != nil {
+		return Service{}, err
+	}
+
+	var merged Service
+	if err := yaml.Unmarshal(data, &merged); err != nil {
+		return Service{}, err
+	}
+
+	if err := override.Decode(&merged); err != nil {
+		return Service{}, err
+	}
+
+	return merged, nil
+}
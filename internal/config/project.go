@@ -0,0 +1,79 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// projectOverride is set from the --project flag or NIZAM_PROJECT and takes
+// precedence over the project field in the config file
+var projectOverride string
+
+// SetProject selects the project name, overriding the config file and the
+// directory name
+func SetProject(name string) {
+	projectOverride = name
+}
+
+var invalidProjectChars = regexp.MustCompile(`[^a-z0-9_-]+`)
+
+// NormalizeProjectName turns an arbitrary string into a name that is valid
+// as part of Docker container, volume and network names
+func NormalizeProjectName(name string) string {
+	name = strings.ToLower(strings.TrimSpace(name))
+	name = invalidProjectChars.ReplaceAllString(name, "-")
+	return strings.Trim(name, "-_")
+}
+
+// ProjectName returns the project that scopes this config's containers,
+// volumes and networks: the --project override, the project field, or the
+// name of the directory containing the config file, in that order
+func (c *Config) ProjectName() string {
+	if projectOverride != "" {
+		return NormalizeProjectName(projectOverride)
+	}
+	if c != nil && c.Project != "" {
+		return NormalizeProjectName(c.Project)
+	}
+
+	dir := ""
+	if c != nil && c.FilePath != "" {
+		dir = filepath.Dir(c.FilePath)
+	} else if wd, err := os.Getwd(); err == nil {
+		dir = wd
+	}
+
+	if name := NormalizeProjectName(filepath.Base(dir)); name != "" && name != "." {
+		return name
+	}
+	return "default"
+}
+
+// CurrentProjectName resolves the project name for commands that can run
+// without a config file, such as 'nizam down' and 'nizam status'
+func CurrentProjectName() string {
+	if ConfigExists() {
+		if cfg, err := LoadRawConfig(); err == nil {
+			return cfg.ProjectName()
+		}
+	}
+	return (*Config)(nil).ProjectName()
+}
+
+// ContainerName returns the Docker container name of a service. An empty
+// project yields the unscoped name used before projects existed.
+func ContainerName(project, serviceName string) string {
+	if project == "" {
+		return fmt.Sprintf("nizam_%s", serviceName)
+	}
+	return fmt.Sprintf("nizam_%s_%s", project, serviceName)
+}
+
+// ContainerName returns the Docker container name of a service in this
+// config's project
+func (c *Config) ContainerName(serviceName string) string {
+	return ContainerName(c.ProjectName(), serviceName)
+}
@@ -0,0 +1,42 @@
+package config
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalizeProjectName(t *testing.T) {
+	tests := map[string]string{
+		"myapp":        "myapp",
+		"My App":       "my-app",
+		"api.service":  "api-service",
+		"--weird--":    "weird",
+		"snake_case_1": "snake_case_1",
+	}
+
+	for input, expected := range tests {
+		assert.Equal(t, expected, NormalizeProjectName(input), input)
+	}
+}
+
+func TestProjectName(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "Billing Service")
+
+	cfg := &Config{FilePath: filepath.Join(dir, ".nizam.yaml")}
+	assert.Equal(t, "billing-service", cfg.ProjectName())
+	assert.Equal(t, "nizam_billing-service_postgres", cfg.ContainerName("postgres"))
+
+	cfg.Project = "billing"
+	assert.Equal(t, "billing", cfg.ProjectName())
+
+	SetProject("override")
+	defer SetProject("")
+	assert.Equal(t, "override", cfg.ProjectName())
+}
+
+func TestContainerName(t *testing.T) {
+	assert.Equal(t, "nizam_postgres", ContainerName("", "postgres"))
+	assert.Equal(t, "nizam_myapp_postgres", ContainerName("myapp", "postgres"))
+}
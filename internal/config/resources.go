@@ -0,0 +1,61 @@
+package config
+
+import (
+	"fmt"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Resources holds container resource limits. Sizes use Docker notation
+// such as "512m" or "2g".
+//
+//	resources:
+//	  cpus: "1.5"
+//	  memory: 1g
+//	  memory_reservation: 512m
+//	  pids: 200
+//	  shm_size: 256m
+//	  ulimits:
+//	    nproc: 65535
+//	    nofile:
+//	      soft: 20000
+//	      hard: 40000
+type Resources struct {
+	CPUs              string            `yaml:"cpus,omitempty" mapstructure:"cpus"`
+	Memory            string            `yaml:"memory,omitempty" mapstructure:"memory"`
+	MemoryReservation string            `yaml:"memory_reservation,omitempty" mapstructure:"memory_reservation"`
+	Pids              int64             `yaml:"pids,omitempty" mapstructure:"pids"`
+	ShmSize           string            `yaml:"shm_size,omitempty" mapstructure:"shm_size"`
+	Ulimits           map[string]Ulimit `yaml:"ulimits,omitempty" mapstructure:"ulimits"`
+}
+
+// Ulimit is a soft/hard limit pair. A single number sets both.
+type Ulimit struct {
+	Soft int64 `yaml:"soft" mapstructure:"soft"`
+	Hard int64 `yaml:"hard" mapstructure:"hard"`
+}
+
+// UnmarshalYAML accepts either a single number or a soft/hard mapping
+func (u *Ulimit) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		var limit int64
+		if err := node.Decode(&limit); err != nil {
+			return fmt.Errorf("ulimit must be a number or a soft/hard mapping: %w", err)
+		}
+		u.Soft, u.Hard = limit, limit
+		return nil
+	}
+
+	type plain Ulimit
+	var limit plain
+	if err := node.Decode(&limit); err != nil {
+		return err
+	}
+	*u = Ulimit(limit)
+	return nil
+}
+
+// HasLimits reports whether both a CPU and a memory limit are set
+func (r *Resources) HasLimits() bool {
+	return r != nil && r.CPUs != "" && r.Memory != ""
+}
@@ -0,0 +1,201 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Snapshots configures 'nizam snapshot' for the project
+//
+//	snapshots:
+//	  storage: chunked              # deduplicate snapshots in .nizam/chunks/
+//	  encryption:
+//	    recipients: [age1...]       # keys 'snapshot create --encrypt' uses
+//	    identity: ~/.config/nizam/age.key
+//	  retention:
+//	    keep_last: 5
+//	    keep_daily: 7
+//	    keep_weekly: 4
+//	    max_size: 2g
+//	    services:
+//	      postgres:
+//	        keep_last: 10
+//	  remote:
+//	    type: s3                    # or dir, with path: /mnt/team/snapshots
+//	    endpoint: localhost:9000
+//	    bucket: nizam-snapshots
+//	    insecure: true
+type Snapshots struct {
+	// Storage is "files" (the default) or "chunked"
+	Storage    string              `yaml:"storage,omitempty" mapstructure:"storage"`
+	Encryption *SnapshotEncryption `yaml:"encryption,omitempty" mapstructure:"encryption"`
+	Retention  *SnapshotRetention  `yaml:"retention,omitempty" mapstructure:"retention"`
+	Remote     *SnapshotRemote     `yaml:"remote,omitempty" mapstructure:"remote"`
+}
+
+// Remote snapshot storage types
+const (
+	RemoteDir = "dir"
+	RemoteS3  = "s3"
+)
+
+// SnapshotRemote is where 'nizam snapshot push' and 'pull' exchange
+// snapshots: a shared directory or an S3-compatible bucket
+type SnapshotRemote struct {
+	// Type is RemoteDir or RemoteS3
+	Type string `yaml:"type" mapstructure:"type"`
+
+	// Path is the shared directory, relative to the config file
+	Path string `yaml:"path,omitempty" mapstructure:"path"`
+
+	// Endpoint is the S3 server's host and port (default: AWS S3)
+	Endpoint string `yaml:"endpoint,omitempty" mapstructure:"endpoint"`
+	Bucket   string `yaml:"bucket,omitempty" mapstructure:"bucket"`
+	// Prefix is prepended to every object key, so projects can share a
+	// bucket
+	Prefix string `yaml:"prefix,omitempty" mapstructure:"prefix"`
+	Region string `yaml:"region,omitempty" mapstructure:"region"`
+	// AccessKey and SecretKey default to AWS_ACCESS_KEY_ID and
+	// AWS_SECRET_ACCESS_KEY
+	AccessKey string `yaml:"access_key,omitempty" mapstructure:"access_key"`
+	SecretKey string `yaml:"secret_key,omitempty" mapstructure:"secret_key"`
+	// Insecure uses plain HTTP, as a local MinIO service does
+	Insecure bool `yaml:"insecure,omitempty" mapstructure:"insecure"`
+}
+
+// SnapshotRetention decides which snapshots 'nizam snapshot prune' keeps.
+// A snapshot is kept if any rule keeps it; tagged and pinned snapshots are
+// always kept. Zero values disable a rule.
+type SnapshotRetention struct {
+	// KeepLast keeps the most recent snapshots
+	KeepLast int `yaml:"keep_last,omitempty" mapstructure:"keep_last"`
+	// KeepDaily keeps the newest snapshot of each of the last days
+	KeepDaily int `yaml:"keep_daily,omitempty" mapstructure:"keep_daily"`
+	// KeepWeekly keeps the newest snapshot of each of the last weeks
+	KeepWeekly int `yaml:"keep_weekly,omitempty" mapstructure:"keep_weekly"`
+	// MaxSize caps the total size of a service's snapshots, in Docker
+	// notation such as "500m" or "2g"
+	MaxSize string `yaml:"max_size,omitempty" mapstructure:"max_size"`
+
+	// Services overrides rules for individual services
+	Services map[string]SnapshotRetention `yaml:"services,omitempty" mapstructure:"services"`
+}
+
+// SnapshotEncryption holds the age keys snapshots are encrypted to and
+// restored with
+type SnapshotEncryption struct {
+	// Recipients are age public keys snapshots created with --encrypt are
+	// encrypted to. Snapshots are not encrypted without the flag.
+	Recipients []string `yaml:"recipients,omitempty" mapstructure:"recipients"`
+
+	// Identity is the age identity file used to decrypt snapshots on
+	// restore, relative to the config file
+	Identity string `yaml:"identity,omitempty" mapstructure:"identity"`
+}
+
+// EncryptionRecipients returns the configured age recipients, if any
+func (c *Config) EncryptionRecipients() []string {
+	if c.Snapshots == nil || c.Snapshots.Encryption == nil {
+		return nil
+	}
+	return c.Snapshots.Encryption.Recipients
+}
+
+// EncryptionIdentity returns the configured age identity file, if any
+func (c *Config) EncryptionIdentity() string {
+	if c.Snapshots == nil || c.Snapshots.Encryption == nil {
+		return ""
+	}
+	return c.Snapshots.Encryption.Identity
+}
+
+// SnapshotStorage returns the configured storage for new snapshots, or an
+// empty string for the default
+func (c *Config) SnapshotStorage() string {
+	if c.Snapshots == nil {
+		return ""
+	}
+	return c.Snapshots.Storage
+}
+
+// SnapshotRemote returns the configured remote snapshot storage, or nil
+func (c *Config) SnapshotRemote() *SnapshotRemote {
+	if c.Snapshots == nil {
+		return nil
+	}
+	return c.Snapshots.Remote
+}
+
+// RetentionFor returns the retention rules for a service: the project rules
+// with the service's overrides applied. It returns nil if no rules are
+// configured.
+func (c *Config) RetentionFor(service string) *SnapshotRetention {
+	if c.Snapshots == nil || c.Snapshots.Retention == nil {
+		return nil
+	}
+
+	retention := *c.Snapshots.Retention
+	retention.Services = nil
+	if override, ok := c.Snapshots.Retention.Services[service]; ok {
+		if override.KeepLast != 0 {
+			retention.KeepLast = override.KeepLast
+		}
+		if override.KeepDaily != 0 {
+			retention.KeepDaily = override.KeepDaily
+		}
+		if override.KeepWeekly != 0 {
+			retention.KeepWeekly = override.KeepWeekly
+		}
+		if override.MaxSize != "" {
+			retention.MaxSize = override.MaxSize
+		}
+	}
+	return &retention
+}
+
+// resolveSnapshotPaths makes the identity file and the remote directory
+// absolute, relative to the directory of the config file
+func (c *Config) resolveSnapshotPaths() error {
+	if c.Snapshots == nil {
+		return nil
+	}
+
+	if c.Snapshots.Encryption != nil && c.Snapshots.Encryption.Identity != "" {
+		identity, err := c.resolveSnapshotPath(c.Snapshots.Encryption.Identity)
+		if err != nil {
+			return err
+		}
+		c.Snapshots.Encryption.Identity = identity
+	}
+
+	if c.Snapshots.Remote != nil && c.Snapshots.Remote.Type == RemoteDir && c.Snapshots.Remote.Path != "" {
+		remotePath, err := c.resolveSnapshotPath(c.Snapshots.Remote.Path)
+		if err != nil {
+			return err
+		}
+		c.Snapshots.Remote.Path = remotePath
+	}
+	return nil
+}
+
+// resolveSnapshotPath expands ~ and makes a relative path relative to the
+// directory of the config file
+func (c *Config) resolveSnapshotPath(p string) (string, error) {
+	switch {
+	case strings.HasPrefix(p, "~"):
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", fmt.Errorf("snapshots: failed to resolve home directory: %w", err)
+		}
+		return filepath.Join(home, p[1:]), nil
+	case !filepath.IsAbs(p):
+		baseDir := "."
+		if c.FilePath != "" {
+			baseDir = filepath.Dir(c.FilePath)
+		}
+		return filepath.Join(baseDir, p), nil
+	}
+	return p, nil
+}
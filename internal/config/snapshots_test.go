@@ -0,0 +1,120 @@
+package config
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadConfig_SnapshotEncryption(t *testing.T) {
+	path := writeConfig(t, `
+snapshots:
+  storage: chunked
+  encryption:
+    recipients:
+      - age1ql3z7hjy54pw3hyww5ayyfg7zqgvc7w3j2elw8zmrj2kg5sfn9aqmcac8p
+    identity: keys/age.key
+services:
+  postgres:
+    image: postgres:16
+`)
+
+	cfg, err := LoadConfigFromFile(path)
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"age1ql3z7hjy54pw3hyww5ayyfg7zqgvc7w3j2elw8zmrj2kg5sfn9aqmcac8p"}, cfg.EncryptionRecipients())
+	assert.Equal(t, filepath.Join(filepath.Dir(path), "keys/age.key"), cfg.EncryptionIdentity())
+	assert.Equal(t, "chunked", cfg.SnapshotStorage())
+}
+
+func TestLoadConfig_NoSnapshots(t *testing.T) {
+	path := writeConfig(t, `
+services:
+  postgres:
+    image: postgres:16
+`)
+
+	cfg, err := LoadConfigFromFile(path)
+	require.NoError(t, err)
+
+	assert.Nil(t, cfg.EncryptionRecipients())
+	assert.Empty(t, cfg.EncryptionIdentity())
+	assert.Empty(t, cfg.SnapshotStorage())
+	assert.Nil(t, cfg.SnapshotRemote())
+}
+
+func TestLoadConfig_SnapshotRemote(t *testing.T) {
+	path := writeConfig(t, `
+snapshots:
+  remote:
+    type: dir
+    path: shared/snapshots
+services:
+  postgres:
+    image: postgres:16
+`)
+
+	cfg, err := LoadConfigFromFile(path)
+	require.NoError(t, err)
+
+	remote := cfg.SnapshotRemote()
+	require.NotNil(t, remote)
+	assert.Equal(t, RemoteDir, remote.Type)
+	assert.Equal(t, filepath.Join(filepath.Dir(path), "shared/snapshots"), remote.Path)
+
+	path = writeConfig(t, `
+snapshots:
+  remote:
+    type: s3
+    endpoint: localhost:9000
+    bucket: nizam-snapshots
+    prefix: team
+    access_key: admin
+    insecure: true
+services:
+  postgres:
+    image: postgres:16
+`)
+
+	cfg, err = LoadConfigFromFile(path)
+	require.NoError(t, err)
+
+	assert.Equal(t, &SnapshotRemote{
+		Type:      RemoteS3,
+		Endpoint:  "localhost:9000",
+		Bucket:    "nizam-snapshots",
+		Prefix:    "team",
+		AccessKey: "admin",
+		Insecure:  true,
+	}, cfg.SnapshotRemote())
+}
+
+func TestConfig_RetentionFor(t *testing.T) {
+	path := writeConfig(t, `
+snapshots:
+  retention:
+    keep_last: 5
+    keep_daily: 7
+    max_size: 2g
+    services:
+      postgres:
+        keep_last: 10
+        max_size: 500m
+services:
+  postgres:
+    image: postgres:16
+  redis:
+    image: redis:7
+`)
+
+	cfg, err := LoadConfigFromFile(path)
+	require.NoError(t, err)
+
+	assert.Equal(t, &SnapshotRetention{KeepLast: 10, KeepDaily: 7, MaxSize: "500m"}, cfg.RetentionFor("postgres"))
+	assert.Equal(t, &SnapshotRetention{KeepLast: 5, KeepDaily: 7, MaxSize: "2g"}, cfg.RetentionFor("redis"))
+
+	cfg.Snapshots = nil
+	assert.Nil(t, cfg.RetentionFor("postgres"))
+}
@@ -0,0 +1,159 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Volume mount types
+const (
+	VolumeTypeVolume = "volume"
+	VolumeTypeBind   = "bind"
+	VolumeTypeTmpfs  = "tmpfs"
+)
+
+// VolumeMount describes a mount into a service container. In YAML it may be
+// written in the short "source:target[:ro]" form or as a mapping:
+//
+//	volumes:
+//	  - pgdata:/var/lib/postgresql/data
+//	  - ./init:/docker-entrypoint-initdb.d:ro
+//	  - type: tmpfs
+//	    target: /tmp
+//	    size: 64m
+//
+// Named volumes are created as nizam_<project>_<service>_<source>; bind mount sources
+// are resolved relative to the config file.
+type VolumeMount struct {
+	Type     string `yaml:"type,omitempty" mapstructure:"type"`
+	Source   string `yaml:"source,omitempty" mapstructure:"source"`
+	Target   string `yaml:"target" mapstructure:"target"`
+	ReadOnly bool   `yaml:"read_only,omitempty" mapstructure:"read_only"`
+	Size     string `yaml:"size,omitempty" mapstructure:"size"` // tmpfs only
+}
+
+// UnmarshalYAML accepts both the short string and the mapping form
+func (v *VolumeMount) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		mount, err := ParseVolumeSpec(node.Value)
+		if err != nil {
+			return err
+		}
+		*v = mount
+		return nil
+	}
+
+	type plain VolumeMount
+	var mount plain
+	if err := node.Decode(&mount); err != nil {
+		return err
+	}
+	*v = VolumeMount(mount)
+	if v.Type == "" {
+		v.Type = inferVolumeType(v.Source)
+	}
+	return nil
+}
+
+// ParseVolumeSpec parses the short "source:target[:ro|rw]" volume syntax
+func ParseVolumeSpec(spec string) (VolumeMount, error) {
+	parts := strings.Split(spec, ":")
+	var mount VolumeMount
+
+	switch len(parts) {
+	case 2:
+		mount.Source, mount.Target = parts[0], parts[1]
+	case 3:
+		mount.Source, mount.Target = parts[0], parts[1]
+		switch parts[2] {
+		case "ro":
+			mount.ReadOnly = true
+		case "rw":
+		default:
+			return VolumeMount{}, fmt.Errorf("invalid volume mode '%s' in '%s' (use ro or rw)", parts[2], spec)
+		}
+	default:
+		return VolumeMount{}, fmt.Errorf("invalid volume '%s': expected source:target[:ro]", spec)
+	}
+
+	if mount.Source == "" || mount.Target == "" {
+		return VolumeMount{}, fmt.Errorf("invalid volume '%s': source and target are required", spec)
+	}
+	mount.Type = inferVolumeType(mount.Source)
+	return mount, nil
+}
+
+// Validate checks that the mount is well-formed
+func (v VolumeMount) Validate() error {
+	if !strings.HasPrefix(v.Target, "/") {
+		return fmt.Errorf("volume target '%s' must be an absolute container path", v.Target)
+	}
+	switch v.Type {
+	case VolumeTypeVolume, VolumeTypeBind:
+		if v.Source == "" {
+			return fmt.Errorf("%s mount for '%s' requires a source", v.Type, v.Target)
+		}
+	case VolumeTypeTmpfs:
+		if v.Source != "" {
+			return fmt.Errorf("tmpfs mount for '%s' cannot have a source", v.Target)
+		}
+	default:
+		return fmt.Errorf("unknown volume type '%s' (use volume, bind or tmpfs)", v.Type)
+	}
+	return nil
+}
+
+// inferVolumeType treats sources that look like paths as bind mounts and
+// everything else as named volumes
+func inferVolumeType(source string) string {
+	if source == "" {
+		return VolumeTypeTmpfs
+	}
+	if strings.HasPrefix(source, ".") || strings.HasPrefix(source, "/") || strings.HasPrefix(source, "~") {
+		return VolumeTypeBind
+	}
+	return VolumeTypeVolume
+}
+
+// resolveBindMounts makes bind mount sources absolute, relative to the
+// directory of the config file
+func (c *Config) resolveBindMounts() error {
+	baseDir := "."
+	if c.FilePath != "" {
+		baseDir = filepath.Dir(c.FilePath)
+	}
+
+	for name, service := range c.Services {
+		if len(service.Volumes) == 0 {
+			continue
+		}
+
+		volumes := make([]VolumeMount, len(service.Volumes))
+		for i, mount := range service.Volumes {
+			if err := mount.Validate(); err != nil {
+				return fmt.Errorf("service '%s': %w", name, err)
+			}
+			if mount.Type == VolumeTypeBind {
+				source := mount.Source
+				if strings.HasPrefix(source, "~") {
+					home, err := os.UserHomeDir()
+					if err != nil {
+						return fmt.Errorf("service '%s': failed to resolve home directory: %w", name, err)
+					}
+					source = filepath.Join(home, source[1:])
+				} else if !filepath.IsAbs(source) {
+					source = filepath.Join(baseDir, source)
+				}
+				mount.Source = source
+			}
+			volumes[i] = mount
+		}
+		service.Volumes = volumes
+		c.Services[name] = service
+	}
+	return nil
+}
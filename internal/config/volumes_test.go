@@ -0,0 +1,71 @@
+package config
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseVolumeSpec(t *testing.T) {
+	tests := []struct {
+		spec     string
+		expected VolumeMount
+		wantErr  bool
+	}{
+		{"pgdata:/var/lib/postgresql/data", VolumeMount{Type: VolumeTypeVolume, Source: "pgdata", Target: "/var/lib/postgresql/data"}, false},
+		{"./init:/docker-entrypoint-initdb.d:ro", VolumeMount{Type: VolumeTypeBind, Source: "./init", Target: "/docker-entrypoint-initdb.d", ReadOnly: true}, false},
+		{"/srv/data:/data:rw", VolumeMount{Type: VolumeTypeBind, Source: "/srv/data", Target: "/data"}, false},
+		{"pgdata", VolumeMount{}, true},
+		{"pgdata:/data:rx", VolumeMount{}, true},
+		{":/data", VolumeMount{}, true},
+	}
+
+	for _, test := range tests {
+		result, err := ParseVolumeSpec(test.spec)
+		if test.wantErr {
+			assert.Error(t, err, test.spec)
+			continue
+		}
+		require.NoError(t, err, test.spec)
+		assert.Equal(t, test.expected, result, test.spec)
+	}
+}
+
+func TestLoadConfigFromFile_Volumes(t *testing.T) {
+	path := writeConfig(t, `services:
+  postgres:
+    image: postgres:16
+    volumes:
+      - pgdata:/var/lib/postgresql/data
+      - ./init:/docker-entrypoint-initdb.d:ro
+      - type: tmpfs
+        target: /tmp
+        size: 64m
+`)
+
+	cfg, err := LoadConfigFromFile(path)
+	require.NoError(t, err)
+
+	pg, _ := cfg.GetService("postgres")
+	require.Len(t, pg.Volumes, 3)
+	assert.Equal(t, VolumeTypeVolume, pg.Volumes[0].Type)
+	assert.Equal(t, VolumeTypeBind, pg.Volumes[1].Type)
+	assert.Equal(t, filepath.Join(filepath.Dir(path), "init"), pg.Volumes[1].Source)
+	assert.True(t, pg.Volumes[1].ReadOnly)
+	assert.Equal(t, VolumeMount{Type: VolumeTypeTmpfs, Target: "/tmp", Size: "64m"}, pg.Volumes[2])
+}
+
+func TestLoadConfigFromFile_InvalidVolume(t *testing.T) {
+	path := writeConfig(t, `services:
+  postgres:
+    image: postgres:16
+    volumes:
+      - pgdata:relative/path
+`)
+
+	_, err := LoadConfigFromFile(path)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "absolute")
+}
@@ -0,0 +1,389 @@
+package docker
+
+import (
+	"archive/tar"
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/abdultolba/nizam/internal/backend"
+	"github.com/abdultolba/nizam/internal/config"
+	"github.com/docker/docker/api/types"
+	"github.com/rs/zerolog/log"
+)
+
+// buildHashLabel stores the context hash an image was built from
+const buildHashLabel = "nizam.build-hash"
+
+// BuildOptions holds options for BuildService
+type BuildOptions struct {
+	// Force rebuilds the image even if one exists for the current context
+	Force bool
+	// NoCache disables the Docker build cache
+	NoCache bool
+	// Progress, if set, receives the build output line by line
+	Progress ProgressFunc
+}
+
+// BuildResult describes the outcome of BuildService
+type BuildResult struct {
+	// Image is the deterministic tag of the built image
+	Image string
+	// Built is false when an up-to-date image already existed
+	Built bool
+}
+
+// BuildImageName returns the image tag for a service built from a context
+// with the given hash. The tag only changes when the context does, so an
+// existing image with the same tag is known to be up to date.
+func BuildImageName(project, serviceName, contextHash string) string {
+	repo := config.ContainerName(project, config.NormalizeProjectName(serviceName))
+	if len(contextHash) > 12 {
+		contextHash = contextHash[:12]
+	}
+	return repo + ":" + contextHash
+}
+
+// BuildContextHash hashes everything that affects the image built from a
+// build block: the files in the context not excluded by .dockerignore, the
+// Dockerfile path, the build args and the target stage
+func BuildContextHash(build *config.Build) (string, error) {
+	files, err := contextFiles(build.Context, build.DockerfilePath())
+	if err != nil {
+		return "", err
+	}
+
+	h := sha256.New()
+	fmt.Fprintf(h, "dockerfile=%s\ntarget=%s\n", build.DockerfilePath(), build.Target)
+
+	argNames := make([]string, 0, len(build.Args))
+	for name := range build.Args {
+		argNames = append(argNames, name)
+	}
+	sort.Strings(argNames)
+	for _, name := range argNames {
+		fmt.Fprintf(h, "arg %s=%s\n", name, build.Args[name])
+	}
+
+	for _, rel := range files {
+		fullPath := filepath.Join(build.Context, filepath.FromSlash(rel))
+		info, err := os.Lstat(fullPath)
+		if err != nil {
+			return "", fmt.Errorf("failed to stat %s: %w", fullPath, err)
+		}
+		fmt.Fprintf(h, "file %s %o\n", rel, info.Mode())
+
+		switch {
+		case info.Mode()&os.ModeSymlink != 0:
+			target, err := os.Readlink(fullPath)
+			if err != nil {
+				return "", fmt.Errorf("failed to read link %s: %w", fullPath, err)
+			}
+			fmt.Fprintf(h, "link %s\n", target)
+		case info.Mode().IsRegular():
+			if err := hashFile(h, fullPath); err != nil {
+				return "", err
+			}
+		}
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+func hashFile(w io.Writer, path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %w", path, err)
+	}
+	defer file.Close()
+
+	if _, err := io.Copy(w, file); err != nil {
+		return fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	return nil
+}
+
+// BuildService builds the image of a service with a build block and
+// returns its deterministic tag. The build is skipped when an image for
+// the current context already exists, unless opts.Force is set.
+func (c *Client) BuildService(ctx context.Context, serviceName string, serviceConfig config.Service, opts BuildOptions) (BuildResult, error) {
+	build := serviceConfig.Build
+	if build == nil {
+		return BuildResult{}, fmt.Errorf("service %s has no build configuration", serviceName)
+	}
+
+	contextHash, err := BuildContextHash(build)
+	if err != nil {
+		return BuildResult{}, fmt.Errorf("failed to hash build context: %w", err)
+	}
+	imageName := BuildImageName(c.project, serviceName, contextHash)
+	result := BuildResult{Image: imageName}
+
+	if !opts.Force && !opts.NoCache {
+		if _, _, err := c.cli.ImageInspectWithRaw(ctx, imageName); err == nil {
+			return result, nil
+		}
+	}
+
+	if build.Target != "" {
+		if err := c.supports(ctx, backend.FeatureBuildTarget); err != nil {
+			return BuildResult{}, err
+		}
+	}
+
+	log.Info().Str("service", serviceName).Str("image", imageName).Msg("Building image")
+
+	files, err := contextFiles(build.Context, build.DockerfilePath())
+	if err != nil {
+		return BuildResult{}, err
+	}
+
+	buildArgs := make(map[string]*string, len(build.Args))
+	for name, value := range build.Args {
+		value := value
+		buildArgs[name] = &value
+	}
+
+	labels := c.labels(serviceName)
+	labels[buildHashLabel] = contextHash
+
+	tags := []string{imageName}
+	if serviceConfig.Image != "" {
+		tags = append(tags, serviceConfig.Image)
+	}
+
+	reader, writer := io.Pipe()
+	go func() {
+		writer.CloseWithError(writeContextTar(writer, build.Context, files))
+	}()
+	defer reader.Close()
+
+	resp, err := c.cli.ImageBuild(ctx, reader, types.ImageBuildOptions{
+		Tags:        tags,
+		Dockerfile:  filepath.ToSlash(build.DockerfilePath()),
+		BuildArgs:   buildArgs,
+		Target:      build.Target,
+		Labels:      labels,
+		NoCache:     opts.NoCache,
+		Remove:      true,
+		ForceRemove: true,
+	})
+	if err != nil {
+		return BuildResult{}, fmt.Errorf("failed to build image: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if err := readProgress(resp.Body, opts.Progress); err != nil {
+		return BuildResult{}, fmt.Errorf("failed to build image: %w", err)
+	}
+
+	result.Built = true
+	return result, nil
+}
+
+// contextFiles lists the files of a build context, as slash-separated
+// paths relative to it, that are not excluded by its .dockerignore. The
+// Dockerfile and .dockerignore are always included, as Docker requires.
+func contextFiles(contextDir, dockerfile string) ([]string, error) {
+	info, err := os.Stat(contextDir)
+	if err != nil {
+		return nil, fmt.Errorf("build context %s: %w", contextDir, err)
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("build context %s is not a directory", contextDir)
+	}
+	if _, err := os.Stat(filepath.Join(contextDir, dockerfile)); err != nil {
+		return nil, fmt.Errorf("dockerfile %s not found in build context %s", dockerfile, contextDir)
+	}
+
+	ignore, err := readDockerignore(contextDir)
+	if err != nil {
+		return nil, err
+	}
+	always := map[string]bool{
+		filepath.ToSlash(filepath.Clean(dockerfile)): true,
+		".dockerignore": true,
+	}
+
+	var files []string
+	err = filepath.Walk(contextDir, func(fullPath string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(contextDir, fullPath)
+		if err != nil || rel == "." {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+
+		if ignore.excludes(rel) && !always[rel] {
+			// Keep walking excluded directories only if a negated pattern
+			// could re-include something inside them
+			if info.IsDir() && !ignore.hasExceptions {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		files = append(files, rel)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read build context: %w", err)
+	}
+
+	sort.Strings(files)
+	return files, nil
+}
+
+// writeContextTar writes the given context files to w as a tar archive
+func writeContextTar(w io.Writer, contextDir string, files []string) error {
+	tw := tar.NewWriter(w)
+
+	for _, rel := range files {
+		fullPath := filepath.Join(contextDir, filepath.FromSlash(rel))
+		info, err := os.Lstat(fullPath)
+		if err != nil {
+			return err
+		}
+
+		link := ""
+		if info.Mode()&os.ModeSymlink != 0 {
+			if link, err = os.Readlink(fullPath); err != nil {
+				return err
+			}
+		}
+
+		header, err := tar.FileInfoHeader(info, link)
+		if err != nil {
+			return err
+		}
+		header.Name = rel
+		if info.IsDir() {
+			header.Name += "/"
+		}
+		if err := tw.WriteHeader(header); err != nil {
+			return err
+		}
+
+		if info.Mode().IsRegular() {
+			file, err := os.Open(fullPath)
+			if err != nil {
+				return err
+			}
+			_, err = io.Copy(tw, file)
+			file.Close()
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return tw.Close()
+}
+
+// dockerignore holds the patterns of a .dockerignore file
+type dockerignore struct {
+	patterns      []ignorePattern
+	hasExceptions bool
+}
+
+type ignorePattern struct {
+	pattern string
+	negate  bool
+}
+
+func readDockerignore(contextDir string) (*dockerignore, error) {
+	ignore := &dockerignore{}
+
+	file, err := os.Open(filepath.Join(contextDir, ".dockerignore"))
+	if os.IsNotExist(err) {
+		return ignore, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read .dockerignore: %w", err)
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		p := ignorePattern{}
+		if strings.HasPrefix(line, "!") {
+			p.negate = true
+			ignore.hasExceptions = true
+			line = strings.TrimSpace(line[1:])
+		}
+		p.pattern = strings.TrimPrefix(path.Clean(filepath.ToSlash(line)), "/")
+		ignore.patterns = append(ignore.patterns, p)
+	}
+	return ignore, scanner.Err()
+}
+
+// excludes reports whether a context path is excluded. Like Docker, the
+// last matching pattern wins and a pattern matching a directory excludes
+// everything below it.
+func (d *dockerignore) excludes(rel string) bool {
+	excluded := false
+	for _, p := range d.patterns {
+		if matchIgnorePattern(p.pattern, rel) {
+			excluded = !p.negate
+		}
+	}
+	return excluded
+}
+
+func matchIgnorePattern(pattern, rel string) bool {
+	// Match the path itself and each of its parent directories
+	for candidate := rel; candidate != "."; candidate = path.Dir(candidate) {
+		if matchGlob(pattern, candidate) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchGlob matches a slash-separated path against a pattern in which
+// "**" matches any number of directories
+func matchGlob(pattern, name string) bool {
+	if !strings.Contains(pattern, "**") {
+		matched, _ := path.Match(pattern, name)
+		return matched
+	}
+
+	patternParts := strings.Split(pattern, "/")
+	nameParts := strings.Split(name, "/")
+	return matchParts(patternParts, nameParts)
+}
+
+func matchParts(pattern, name []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			for i := 0; i <= len(name); i++ {
+				if matchParts(pattern[1:], name[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(name) == 0 {
+			return false
+		}
+		if matched, _ := path.Match(pattern[0], name[0]); !matched {
+			return false
+		}
+		pattern, name = pattern[1:], name[1:]
+	}
+	return len(name) == 0
+}
@@ -0,0 +1,84 @@
+package docker
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/abdultolba/nizam/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeContext(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	}
+	return dir
+}
+
+func TestContextFiles_Dockerignore(t *testing.T) {
+	dir := writeContext(t, map[string]string{
+		"Dockerfile":        "FROM postgres:16",
+		".dockerignore":     "*.log\nnode_modules\n**/*.tmp\n!keep.log\n",
+		"init.sql":          "select 1;",
+		"debug.log":         "noise",
+		"keep.log":          "kept",
+		"node_modules/a.js": "x",
+		"sql/b.tmp":         "x",
+		"sql/b.sql":         "select 2;",
+	})
+
+	files, err := contextFiles(dir, "Dockerfile")
+	require.NoError(t, err)
+	assert.Equal(t, []string{".dockerignore", "Dockerfile", "init.sql", "keep.log", "sql", "sql/b.sql"}, files)
+}
+
+func TestContextFiles_MissingDockerfile(t *testing.T) {
+	dir := writeContext(t, map[string]string{"init.sql": "select 1;"})
+
+	_, err := contextFiles(dir, "Dockerfile")
+	assert.ErrorContains(t, err, "dockerfile Dockerfile not found")
+}
+
+func TestBuildContextHash(t *testing.T) {
+	dir := writeContext(t, map[string]string{
+		"Dockerfile":    "FROM postgres:16",
+		".dockerignore": "*.log",
+		"init.sql":      "select 1;",
+	})
+	build := &config.Build{Context: dir}
+
+	base, err := BuildContextHash(build)
+	require.NoError(t, err)
+
+	again, err := BuildContextHash(build)
+	require.NoError(t, err)
+	assert.Equal(t, base, again)
+
+	// Ignored files don't affect the hash
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "debug.log"), []byte("noise"), 0644))
+	hash, err := BuildContextHash(build)
+	require.NoError(t, err)
+	assert.Equal(t, base, hash)
+
+	// Build args do
+	hash, err = BuildContextHash(&config.Build{Context: dir, Args: map[string]string{"PG": "16"}})
+	require.NoError(t, err)
+	assert.NotEqual(t, base, hash)
+
+	// And so does file content
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "init.sql"), []byte("select 2;"), 0644))
+	hash, err = BuildContextHash(build)
+	require.NoError(t, err)
+	assert.NotEqual(t, base, hash)
+}
+
+func TestBuildImageName(t *testing.T) {
+	assert.Equal(t, "nizam_myapp_postgres:0123456789ab", BuildImageName("myapp", "postgres", "0123456789abcdef"))
+	assert.Equal(t, "nizam_myapp_pg_ext:abc", BuildImageName("myapp", "PG_Ext", "abc"))
+}
@@ -4,43 +4,127 @@ import (
 	"context"
 	"fmt"
 	"io"
-	"os"
-	"strings"
+	"sync"
 	"time"
 
+	"github.com/abdultolba/nizam/internal/backend"
 	"github.com/abdultolba/nizam/internal/config"
+	"github.com/abdultolba/nizam/internal/runtime"
 	"github.com/docker/docker/api/types"
 	"github.com/docker/docker/api/types/container"
 	"github.com/docker/docker/api/types/network"
 	"github.com/docker/docker/client"
-	"github.com/docker/go-connections/nat"
 	"github.com/rs/zerolog/log"
 )
 
 // Client wraps the Docker client with nizam-specific functionality
 type Client struct {
 	cli *client.Client
+
+	// project scopes container, volume and network names and filters the
+	// containers the client reports; empty means unscoped
+	project string
+	// configPath is recorded on containers so 'nizam ls' can show where a
+	// project is defined
+	configPath string
+
+	// endpoint is the engine the client is connected to
+	endpoint backend.Endpoint
+	// info is queried from the engine on first use
+	infoOnce sync.Once
+	info     backend.Info
+	infoErr  error
+
+	// networkMu serializes network creation when services start in parallel
+	networkMu sync.Mutex
+
+	// reservedPorts holds the host ports allocated by this client
+	portMu        sync.Mutex
+	reservedPorts map[int]bool
 }
 
 // ContainerInfo holds information about a running container
 type ContainerInfo struct {
-	ID      string
-	Name    string
-	Image   string
-	Status  string
-	Ports   []string
-	Health  string
-	Service string
+	ID       string
+	Name     string
+	Image    string
+	Status   string
+	Ports    []string
+	Health   string
+	Service  string
+	Project  string
+	Networks []string
+
+	// ConfigHash is the hash of the service config the container was
+	// created from, empty for containers created by older nizam versions
+	ConfigHash string
 }
 
-// NewClient creates a new Docker client
+// NewClient creates a new Docker client for the backend selected with
+// --backend or in the config file
 func NewClient() (*Client, error) {
-	cli, err := client.NewClientWithOpts(client.FromEnv, client.WithAPIVersionNegotiation())
+	cli, endpoint, err := backend.NewCurrentClient()
 	if err != nil {
 		return nil, fmt.Errorf("failed to create Docker client: %w", err)
 	}
 
-	return &Client{cli: cli}, nil
+	return &Client{cli: cli, endpoint: endpoint}, nil
+}
+
+// Endpoint returns the engine endpoint the client is connected to
+func (c *Client) Endpoint() backend.Endpoint {
+	return c.endpoint
+}
+
+// BackendInfo returns the engine's version and the features it lacks
+func (c *Client) BackendInfo(ctx context.Context) (backend.Info, error) {
+	c.infoOnce.Do(func() {
+		c.info, c.infoErr = backend.Inspect(ctx, c.cli, c.endpoint)
+	})
+	return c.info, c.infoErr
+}
+
+// supports returns a *backend.UnsupportedError if the engine lacks the
+// feature. Engines whose version cannot be read are assumed to support it.
+func (c *Client) supports(ctx context.Context, feature backend.Feature) error {
+	info, err := c.BackendInfo(ctx)
+	if err != nil {
+		return nil
+	}
+	return backend.Supports(info.Engine, info.APIVersion, feature)
+}
+
+// SetProject scopes the client to a nizam project
+func (c *Client) SetProject(project string) {
+	c.project = project
+}
+
+// UseConfig scopes the client to the project of a loaded config
+func (c *Client) UseConfig(cfg *config.Config) {
+	c.project = cfg.ProjectName()
+	c.configPath = cfg.FilePath
+}
+
+// Project returns the project the client is scoped to
+func (c *Client) Project() string {
+	return c.project
+}
+
+// containerName returns the container name of a service in the client's project
+func (c *Client) containerName(serviceName string) string {
+	return config.ContainerName(c.project, serviceName)
+}
+
+// labels returns the labels applied to everything nizam creates for a service
+func (c *Client) labels(serviceName string) map[string]string {
+	labels := map[string]string{
+		"nizam.service": serviceName,
+		"nizam.managed": "true",
+	}
+	if c.project != "" {
+		labels["nizam.project"] = c.project
+	}
+	return labels
 }
 
 // Close closes the Docker client connection
@@ -48,31 +132,131 @@ func (c *Client) Close() error {
 	return c.cli.Close()
 }
 
-// StartService starts a Docker container for the given service
+// RecreatePolicy controls what happens to an existing service container
+type RecreatePolicy int
+
+const (
+	// RecreateIfChanged recreates the container when the service config
+	// differs from the one the container was created with
+	RecreateIfChanged RecreatePolicy = iota
+	// RecreateNever always reuses an existing container
+	RecreateNever
+	// RecreateAlways always replaces an existing container
+	RecreateAlways
+)
+
+// StartOptions holds options for StartServiceWithOptions
+type StartOptions struct {
+	Recreate RecreatePolicy
+	// PortStrategy is config.PortStrategyFixed (the default) or
+	// config.PortStrategyNextFree
+	PortStrategy string
+	// Progress, if set, receives image build and pull output
+	Progress ProgressFunc
+	// AdoptLegacy replaces the service's container from before projects
+	// and mounts its volumes, see removeLegacyContainer
+	AdoptLegacy bool
+}
+
+// StartAction describes what StartServiceWithOptions did
+type StartAction string
+
+const (
+	ActionCreated   StartAction = "created"
+	ActionStarted   StartAction = "started"
+	ActionRecreated StartAction = "recreated"
+)
+
+// configHashLabel stores the hash of the service config a container was
+// created from, used to detect config drift
+const configHashLabel = "nizam.config-hash"
+
+// StartService starts a Docker container for the given service, recreating
+// it if its configuration changed
 func (c *Client) StartService(ctx context.Context, serviceName string, serviceConfig config.Service) error {
-	containerName := fmt.Sprintf("nizam_%s", serviceName)
+	_, err := c.StartServiceWithOptions(ctx, serviceName, serviceConfig, StartOptions{})
+	return err
+}
+
+// StartServiceWithOptions starts a Docker container for the given service
+// and reports whether it was created, recreated or an existing container
+// was started
+func (c *Client) StartServiceWithOptions(ctx context.Context, serviceName string, serviceConfig config.Service, opts StartOptions) (StartAction, error) {
+	containerName := c.containerName(serviceName)
+
+	// Services with a build block run the image built from their context.
+	// Its tag changes with the context, which also changes the config hash
+	// and recreates the container after a rebuild.
+	if serviceConfig.Build != nil {
+		result, err := c.BuildService(ctx, serviceName, serviceConfig, BuildOptions{Progress: opts.Progress})
+		if err != nil {
+			return "", err
+		}
+		serviceConfig.Image = result.Image
+	}
+
+	configHash := serviceConfig.Hash()
+	action := ActionCreated
+
+	// Make sure the service's networks exist
+	var networkNames []string
+	for _, name := range ServiceNetworks(serviceConfig) {
+		networkName, err := c.EnsureNetwork(ctx, name)
+		if err != nil {
+			return "", err
+		}
+		networkNames = append(networkNames, networkName)
+	}
+
+	// The container from before projects holds the service's ports
+	if removed, err := removeLegacyContainer(ctx, c, c.project, serviceName, opts.AdoptLegacy); err != nil {
+		return "", err
+	} else if removed && opts.Progress != nil {
+		opts.Progress("removed container " + config.ContainerName("", serviceName) + " from before project scoping")
+	}
 
 	// Check if container already exists
-	if exists, err := c.containerExists(ctx, containerName); err != nil {
-		return fmt.Errorf("failed to check if container exists: %w", err)
+	if exists, err := runtime.Exists(ctx, c, containerName); err != nil {
+		return "", fmt.Errorf("failed to check if container exists: %w", err)
 	} else if exists {
-		// Try to start existing container
-		if err := c.cli.ContainerStart(ctx, containerName, types.ContainerStartOptions{}); err != nil {
-			return fmt.Errorf("failed to start existing container: %w", err)
+		recreate, err := c.needsRecreate(ctx, containerName, configHash, opts.Recreate)
+		if err != nil {
+			return "", err
 		}
-		log.Info().Str("service", serviceName).Msg("Started existing container")
-		return nil
+
+		if !recreate {
+			if err := c.connectNetworks(ctx, containerName, serviceName, networkNames); err != nil {
+				return "", err
+			}
+
+			// Try to start existing container
+			if err := c.StartContainer(ctx, containerName); err != nil {
+				return "", fmt.Errorf("failed to start existing container: %w", err)
+			}
+			log.Info().Str("service", serviceName).Msg("Started existing container")
+			return ActionStarted, nil
+		}
+
+		log.Info().Str("service", serviceName).Msg("Recreating container")
+		if err := c.removeContainer(ctx, containerName); err != nil {
+			return "", err
+		}
+		action = ActionRecreated
 	}
 
 	// Pull image if not present
-	if err := c.pullImageIfNeeded(ctx, serviceConfig.Image); err != nil {
-		return fmt.Errorf("failed to pull image: %w", err)
+	if err := c.pullImageIfNeeded(ctx, serviceConfig.Image, opts.Progress); err != nil {
+		return "", err
 	}
 
-	// Create port bindings
-	portBindings, exposedPorts, err := c.createPortBindings(serviceConfig.Ports)
+	// Pick host ports and create port bindings
+	ports, err := c.allocatePorts(ctx, serviceName, serviceConfig.Ports, opts.PortStrategy, opts.Progress)
+	if err != nil {
+		return "", fmt.Errorf("failed to allocate ports: %w", err)
+	}
+	portBindings, exposedPorts, err := createPortBindings(ports)
 	if err != nil {
-		return fmt.Errorf("failed to create port bindings: %w", err)
+		return "", fmt.Errorf("failed to create port bindings: %w", err)
 	}
 
 	// Create environment variables
@@ -86,14 +270,29 @@ func (c *Client) StartService(ctx context.Context, serviceName string, serviceCo
 		Image:        serviceConfig.Image,
 		ExposedPorts: exposedPorts,
 		Env:          env,
-		Labels: map[string]string{
-			"nizam.service": serviceName,
-			"nizam.managed": "true",
-		},
+		Labels:       c.labels(serviceName),
+	}
+	containerConfig.Labels[configHashLabel] = configHash
+	if len(ports) > 0 {
+		containerConfig.Labels[config.PortsLabel] = config.FormatPortsLabel(ports)
+	}
+	if c.configPath != "" {
+		containerConfig.Labels["nizam.project.config"] = c.configPath
 	}
 
 	// Add health check if configured
-	if serviceConfig.HealthCheck != nil && len(serviceConfig.HealthCheck.Test) > 0 {
+	healthcheck := serviceConfig.HealthCheck != nil && len(serviceConfig.HealthCheck.Test) > 0
+	if healthcheck {
+		// Skip the healthcheck rather than failing on engines without support
+		if err := c.supports(ctx, backend.FeatureHealthcheck); err != nil {
+			log.Warn().Str("service", serviceName).Err(err).Msg("Skipping health check")
+			if opts.Progress != nil {
+				opts.Progress("health check skipped: " + err.Error())
+			}
+			healthcheck = false
+		}
+	}
+	if healthcheck {
 		healthConfig := &container.HealthConfig{
 			Test: serviceConfig.HealthCheck.Test,
 		}
@@ -143,33 +342,80 @@ func (c *Client) StartService(ctx context.Context, serviceName string, serviceCo
 		},
 	}
 
+	if err := applyResources(hostConfig, serviceConfig.Resources); err != nil {
+		return "", fmt.Errorf("failed to configure resources: %w", err)
+	}
+
 	// Handle volumes
-	if serviceConfig.Volume != "" {
-		volumeName := fmt.Sprintf("nizam_%s_%s", serviceName, serviceConfig.Volume)
-		hostConfig.Binds = []string{fmt.Sprintf("%s:/var/lib/postgresql/data", volumeName)}
+	mounts, err := c.buildMounts(ctx, serviceName, serviceConfig, opts.AdoptLegacy)
+	if err != nil {
+		return "", fmt.Errorf("failed to configure volumes: %w", err)
 	}
+	hostConfig.Mounts = mounts
 
-	// Create the container
-	resp, err := c.cli.ContainerCreate(ctx, containerConfig, hostConfig, &network.NetworkingConfig{}, nil, containerName)
+	// Create the container on its first network; older daemons only accept
+	// one network at creation, so the rest are connected afterwards
+	networkingConfig := &network.NetworkingConfig{
+		EndpointsConfig: map[string]*network.EndpointSettings{
+			networkNames[0]: endpointSettings(serviceName),
+		},
+	}
+	containerID, err := c.CreateContainer(ctx, runtime.ContainerSpec{
+		Name:       containerName,
+		Config:     containerConfig,
+		HostConfig: hostConfig,
+		Networking: networkingConfig,
+	})
 	if err != nil {
-		return fmt.Errorf("failed to create container: %w", err)
+		return "", err
+	}
+
+	if err := c.connectNetworks(ctx, containerID, serviceName, networkNames[1:]); err != nil {
+		return "", err
 	}
 
 	// Start the container
-	if err := c.cli.ContainerStart(ctx, resp.ID, types.ContainerStartOptions{}); err != nil {
-		return fmt.Errorf("failed to start container: %w", err)
+	if err := c.StartContainer(ctx, containerID); err != nil {
+		return "", fmt.Errorf("failed to start container: %w", err)
 	}
 
-	log.Info().Str("service", serviceName).Str("container_id", resp.ID[:12]).Msg("Started service")
+	log.Info().Str("service", serviceName).Str("container_id", containerID[:12]).Msg("Started service")
+	return action, nil
+}
+
+// needsRecreate decides whether an existing container must be replaced
+func (c *Client) needsRecreate(ctx context.Context, containerName, configHash string, policy RecreatePolicy) (bool, error) {
+	switch policy {
+	case RecreateNever:
+		return false, nil
+	case RecreateAlways:
+		return true, nil
+	}
+
+	inspect, err := c.InspectContainer(ctx, containerName)
+	if err != nil {
+		return false, fmt.Errorf("failed to inspect container: %w", err)
+	}
+	return inspect.Labels[configHashLabel] != configHash, nil
+}
+
+// removeContainer stops and removes a container
+func (c *Client) removeContainer(ctx context.Context, containerName string) error {
+	if err := c.cli.ContainerStop(ctx, containerName, container.StopOptions{}); err != nil {
+		return fmt.Errorf("failed to stop container: %w", err)
+	}
+	if err := c.cli.ContainerRemove(ctx, containerName, types.ContainerRemoveOptions{}); err != nil {
+		return fmt.Errorf("failed to remove container: %w", err)
+	}
 	return nil
 }
 
 // StopService stops and removes a service container
 func (c *Client) StopService(ctx context.Context, serviceName string) error {
-	containerName := fmt.Sprintf("nizam_%s", serviceName)
+	containerName := c.containerName(serviceName)
 
 	// Check if container exists
-	exists, err := c.containerExists(ctx, containerName)
+	exists, err := runtime.Exists(ctx, c, containerName)
 	if err != nil {
 		return fmt.Errorf("failed to check if container exists: %w", err)
 	}
@@ -184,7 +430,7 @@ func (c *Client) StopService(ctx context.Context, serviceName string) error {
 	}
 
 	// Remove the container
-	if err := c.cli.ContainerRemove(ctx, containerName, types.ContainerRemoveOptions{}); err != nil {
+	if err := c.RemoveContainer(ctx, containerName); err != nil {
 		log.Warn().Err(err).Str("service", serviceName).Msg("Failed to remove container")
 	}
 
@@ -192,171 +438,134 @@ func (c *Client) StopService(ctx context.Context, serviceName string) error {
 	return nil
 }
 
-// GetServiceStatus returns the status of all nizam-managed containers
-func (c *Client) GetServiceStatus(ctx context.Context) ([]ContainerInfo, error) {
-	containers, err := c.cli.ContainerList(ctx, types.ContainerListOptions{
-		All: true,
-	})
+// RestartService restarts the container of a service
+func (c *Client) RestartService(ctx context.Context, serviceName string) error {
+	containerName := c.containerName(serviceName)
+
+	exists, err := runtime.Exists(ctx, c, containerName)
 	if err != nil {
-		return nil, fmt.Errorf("failed to list containers: %w", err)
+		return fmt.Errorf("failed to check if container exists: %w", err)
+	}
+	if !exists {
+		return fmt.Errorf("container %s does not exist; run 'nizam up %s' first", containerName, serviceName)
 	}
 
-	var nizamContainers []ContainerInfo
-	for _, container := range containers {
-		if container.Labels["nizam.managed"] == "true" {
-			serviceName := container.Labels["nizam.service"]
-			if serviceName == "" {
-				serviceName = "unknown"
-			}
-
-			// Extract port information
-			var ports []string
-			for _, port := range container.Ports {
-				if port.PublicPort > 0 {
-					ports = append(ports, fmt.Sprintf("%d:%d", port.PublicPort, port.PrivatePort))
-				}
-			}
-
-			// Get container name (remove leading slash)
-			name := strings.TrimPrefix(container.Names[0], "/")
-
-			nizamContainers = append(nizamContainers, ContainerInfo{
-				ID:      container.ID[:12],
-				Name:    name,
-				Image:   container.Image,
-				Status:  container.Status,
-				Ports:   ports,
-				Service: serviceName,
-			})
-		}
+	if err := c.cli.ContainerRestart(ctx, containerName, container.StopOptions{}); err != nil {
+		return fmt.Errorf("failed to restart container: %w", err)
 	}
 
-	return nizamContainers, nil
+	log.Info().Str("service", serviceName).Msg("Restarted service")
+	return nil
 }
 
-// GetServiceLogs returns logs for a specific service
-func (c *Client) GetServiceLogs(ctx context.Context, serviceName string, follow bool, tail string) (io.ReadCloser, error) {
-	containerName := fmt.Sprintf("nizam_%s", serviceName)
+// PullImage pulls an image, even if it is already present locally, sending
+// its output to progress
+func (c *Client) PullImage(ctx context.Context, image string, progress ProgressFunc) error {
+	log.Info().Str("image", image).Msg("Pulling Docker image")
 
-	options := types.ContainerLogsOptions{
-		ShowStdout: true,
-		ShowStderr: true,
-		Follow:     follow,
-		Timestamps: true,
-		Tail:       tail,
+	reader, err := c.cli.ImagePull(ctx, image, types.ImagePullOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to pull image %s: %w", image, err)
 	}
+	defer reader.Close()
 
-	logs, err := c.cli.ContainerLogs(ctx, containerName, options)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get logs for service %s: %w", serviceName, err)
+	if err := readProgress(reader, progress); err != nil {
+		return fmt.Errorf("failed to pull image %s: %w", image, err)
 	}
+	return nil
+}
 
-	return logs, nil
+// EnsureImage pulls an image unless it is already present locally
+func (c *Client) EnsureImage(ctx context.Context, image string) error {
+	return c.pullImageIfNeeded(ctx, image, nil)
 }
 
-// ExecInService executes a command in a service container
-func (c *Client) ExecInService(ctx context.Context, serviceName string, cmd []string) error {
-	containerName := fmt.Sprintf("nizam_%s", serviceName)
+// GetServiceStatus returns the status of the nizam-managed containers in the
+// client's project, or of all of them if the client is not scoped
+func (c *Client) GetServiceStatus(ctx context.Context) ([]ContainerInfo, error) {
+	return ListServices(ctx, c, c.project)
+}
 
-	// Create exec configuration
-	execConfig := types.ExecConfig{
-		AttachStdout: true,
-		AttachStderr: true,
-		AttachStdin:  true,
-		Tty:          true,
-		Cmd:          cmd,
+// ListServices returns the nizam-managed containers of a runtime in the
+// given project, or all of them if project is empty
+func ListServices(ctx context.Context, rt runtime.Runtime, project string) ([]ContainerInfo, error) {
+	labels := map[string]string{"nizam.managed": "true"}
+	if project != "" {
+		labels["nizam.project"] = project
 	}
 
-	// Create the exec instance
-	execResp, err := c.cli.ContainerExecCreate(ctx, containerName, execConfig)
+	containers, err := rt.ListContainers(ctx, labels)
 	if err != nil {
-		return fmt.Errorf("failed to create exec instance: %w", err)
+		return nil, err
 	}
 
-	// Attach to the exec instance
-	attachResp, err := c.cli.ContainerExecAttach(ctx, execResp.ID, types.ExecStartCheck{
-		Tty: true,
-	})
-	if err != nil {
-		return fmt.Errorf("failed to attach to exec instance: %w", err)
-	}
-	defer attachResp.Close()
+	nizamContainers := make([]ContainerInfo, 0, len(containers))
+	for _, container := range containers {
+		serviceName := container.Labels["nizam.service"]
+		if serviceName == "" {
+			serviceName = "unknown"
+		}
+
+		id := container.ID
+		if len(id) > 12 {
+			id = id[:12]
+		}
 
-	// Copy streams
-	go io.Copy(attachResp.Conn, os.Stdin)
-	io.Copy(os.Stdout, attachResp.Reader)
+		nizamContainers = append(nizamContainers, ContainerInfo{
+			ID:       id,
+			Name:     container.Name,
+			Image:    container.Image,
+			Status:   container.Status,
+			Ports:    container.Ports,
+			Health:   container.Health,
+			Service:  serviceName,
+			Project:  container.Labels["nizam.project"],
+			Networks: container.Networks,
+
+			ConfigHash: container.Labels[configHashLabel],
+		})
+	}
 
-	return nil
+	return nizamContainers, nil
 }
 
-// Helper functions
-
-func (c *Client) containerExists(ctx context.Context, name string) (bool, error) {
-	containers, err := c.cli.ContainerList(ctx, types.ContainerListOptions{All: true})
+// GetServiceLogs returns logs for a specific service
+func (c *Client) GetServiceLogs(ctx context.Context, serviceName string, follow bool, tail string) (io.ReadCloser, error) {
+	logs, err := c.Logs(ctx, c.containerName(serviceName), runtime.LogOptions{Follow: follow, Tail: tail})
 	if err != nil {
-		return false, err
+		return nil, fmt.Errorf("failed to get logs for service %s: %w", serviceName, err)
 	}
 
-	for _, container := range containers {
-		for _, containerName := range container.Names {
-			if strings.TrimPrefix(containerName, "/") == name {
-				return true, nil
-			}
-		}
-	}
-	return false, nil
+	return logs, nil
+}
+
+// ExecInService executes a command in a service container
+func (c *Client) ExecInService(ctx context.Context, serviceName string, cmd []string) error {
+	return c.ExecTTY(ctx, c.containerName(serviceName), cmd)
 }
 
-func (c *Client) pullImageIfNeeded(ctx context.Context, image string) error {
+// Helper functions
+
+func (c *Client) pullImageIfNeeded(ctx context.Context, image string, progress ProgressFunc) error {
 	// Check if image exists locally
 	_, _, err := c.cli.ImageInspectWithRaw(ctx, image)
 	if err == nil {
 		return nil // Image exists
 	}
 
-	log.Info().Str("image", image).Msg("Pulling Docker image")
-
-	reader, err := c.cli.ImagePull(ctx, image, types.ImagePullOptions{})
-	if err != nil {
-		return err
-	}
-	defer reader.Close()
-
-	// Copy the pull output to discard (we could show progress here)
-	io.Copy(io.Discard, reader)
-	return nil
+	return c.PullImage(ctx, image, progress)
 }
 
-func (c *Client) createPortBindings(ports []string) (nat.PortMap, nat.PortSet, error) {
-	portBindings := nat.PortMap{}
-	exposedPorts := nat.PortSet{}
-
-	for _, portMapping := range ports {
-		parts := strings.Split(portMapping, ":")
-		if len(parts) != 2 {
-			return nil, nil, fmt.Errorf("invalid port mapping: %s", portMapping)
-		}
-
-		hostPort := parts[0]
-		containerPort := parts[1]
-
-		// Create nat.Port for the container port
-		natPort, err := nat.NewPort("tcp", containerPort)
-		if err != nil {
-			return nil, nil, fmt.Errorf("invalid container port %s: %w", containerPort, err)
-		}
-
-		// Add to exposed ports
-		exposedPorts[natPort] = struct{}{}
-
-		// Add to port bindings
-		portBindings[natPort] = []nat.PortBinding{
-			{
-				HostIP:   "0.0.0.0",
-				HostPort: hostPort,
-			},
-		}
+// ResolveDigest returns the registry digest an image reference currently
+// points to, without pulling the image
+func (c *Client) ResolveDigest(ctx context.Context, image string) (string, error) {
+	if err := c.supports(ctx, backend.FeatureDigestResolution); err != nil {
+		return "", err
 	}
 
-	return portBindings, exposedPorts, nil
+	inspect, err := c.cli.DistributionInspect(ctx, image, "")
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve digest of %s: %w", image, err)
+	}
+	return inspect.Descriptor.Digest.String(), nil
 }
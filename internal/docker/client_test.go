@@ -0,0 +1,49 @@
+package docker
+
+import (
+	"context"
+	"testing"
+
+	"github.com/abdultolba/nizam/internal/runtime"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestListServices(t *testing.T) {
+	fake := runtime.NewFake()
+	fake.AddContainer(runtime.Container{
+		ID:    "0123456789abcdef",
+		Name:  "nizam_app_postgres",
+		Image: "postgres:16",
+		Ports: []string{"5432:5432"},
+		Labels: map[string]string{
+			"nizam.managed": "true",
+			"nizam.project": "app",
+			"nizam.service": "postgres",
+			configHashLabel: "abc",
+		},
+	})
+	fake.AddContainer(runtime.Container{
+		Name:   "nizam_other_redis",
+		Labels: map[string]string{"nizam.managed": "true", "nizam.project": "other", "nizam.service": "redis"},
+	})
+	fake.AddContainer(runtime.Container{Name: "unrelated"})
+
+	services, err := ListServices(context.Background(), fake, "app")
+	require.NoError(t, err)
+	require.Len(t, services, 1)
+	assert.Equal(t, ContainerInfo{
+		ID:         "0123456789ab",
+		Name:       "nizam_app_postgres",
+		Image:      "postgres:16",
+		Status:     "Up Less than a second",
+		Ports:      []string{"5432:5432"},
+		Service:    "postgres",
+		Project:    "app",
+		ConfigHash: "abc",
+	}, services[0])
+
+	services, err = ListServices(context.Background(), fake, "")
+	require.NoError(t, err)
+	assert.Len(t, services, 2)
+}
@@ -0,0 +1,142 @@
+package docker
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/abdultolba/nizam/internal/config"
+	"github.com/abdultolba/nizam/internal/runtime"
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/docker/docker/client"
+	"github.com/rs/zerolog/log"
+)
+
+// Before projects scoped names, a service's container was nizam_<service>
+// and its volumes nizam_<service>_<volume>, without a nizam.project label.
+// Nothing records which checkout created them, so they are only adopted
+// when the service is started with StartOptions.AdoptLegacy: the old
+// container is removed, as it still holds the service's ports, and the old
+// volumes are mounted in place of new, empty ones.
+
+// legacyStopTimeout is how long an unscoped container gets to shut down
+const legacyStopTimeout = 10 * time.Second
+
+// removeLegacyContainer removes the unscoped container a service had before
+// projects, if there is one and adopt is set, and reports whether it did.
+// Without adopt, a running one is reported as holding the service's ports.
+// Containers that belong to a project, or that nizam did not create, are
+// left alone.
+func removeLegacyContainer(ctx context.Context, rt runtime.Runtime, project, serviceName string, adopt bool) (bool, error) {
+	if project == "" {
+		return false, nil
+	}
+
+	name := config.ContainerName("", serviceName)
+	existing, err := rt.InspectContainer(ctx, name)
+	if runtime.IsNotFound(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to inspect container %s: %w", name, err)
+	}
+	if existing.Labels["nizam.managed"] != "true" || existing.Labels["nizam.project"] != "" {
+		return false, nil
+	}
+	if !adopt {
+		if existing.Running() {
+			return false, fmt.Errorf("container %s from before project scoping is running and may hold the ports of %s; stop it, or run 'nizam up --adopt-legacy' to replace it", name, serviceName)
+		}
+		log.Warn().
+			Str("service", serviceName).
+			Str("container", name).
+			Msg("Leaving container created before project scoping; run 'nizam up --adopt-legacy' to replace it")
+		return false, nil
+	}
+
+	log.Warn().
+		Str("service", serviceName).
+		Str("container", name).
+		Msg("Removing container created before project scoping")
+	if existing.Running() {
+		if err := rt.StopContainer(ctx, name, legacyStopTimeout); err != nil {
+			return false, fmt.Errorf("failed to stop container %s: %w", name, err)
+		}
+	}
+	if err := rt.RemoveContainer(ctx, name); err != nil {
+		return false, fmt.Errorf("failed to remove container %s: %w", name, err)
+	}
+	return true, nil
+}
+
+// volumeSource returns the Docker volume to mount for a service's named
+// volume: the project-scoped one, unless adopt is set and only the unscoped
+// volume from before projects exists, which is adopted with its data. A
+// volume another project's container uses is not adopted twice.
+func (c *Client) volumeSource(ctx context.Context, serviceName, volumeName string, adopt bool) (string, error) {
+	scoped := VolumeName(c.project, serviceName, volumeName)
+	if c.project == "" {
+		return scoped, nil
+	}
+
+	exists, err := c.volumeExists(ctx, scoped)
+	if err != nil || exists {
+		return scoped, err
+	}
+
+	legacy := VolumeName("", serviceName, volumeName)
+	exists, err = c.volumeExists(ctx, legacy)
+	if err != nil || !exists {
+		return scoped, err
+	}
+	if !adopt {
+		log.Warn().
+			Str("service", serviceName).
+			Str("volume", legacy).
+			Msg("Volume created before project scoping exists; run 'nizam up --adopt-legacy' to use its data")
+		return scoped, nil
+	}
+
+	users, err := c.cli.ContainerList(ctx, types.ContainerListOptions{
+		All:     true,
+		Filters: filters.NewArgs(filters.Arg("volume", legacy)),
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to list containers using volume %s: %w", legacy, err)
+	}
+	for _, user := range users {
+		if project := user.Labels["nizam.project"]; project != "" && project != c.project {
+			log.Warn().
+				Str("service", serviceName).
+				Str("volume", legacy).
+				Str("project", project).
+				Msg("Volume created before project scoping is used by another project; starting with an empty volume")
+			return scoped, nil
+		}
+	}
+
+	log.Warn().
+		Str("service", serviceName).
+		Str("volume", legacy).
+		Msg("Using volume created before project scoping")
+	return legacy, nil
+}
+
+// isLegacyVolume reports whether v is an unlabeled volume from before
+// projects named like one of the service's volumes, nizam_<service>_<volume>
+func isLegacyVolume(v VolumeInfo, serviceName string) bool {
+	return v.legacy && v.Service == "" && strings.HasPrefix(v.Name, VolumeName("", serviceName, ""))
+}
+
+// volumeExists reports whether a Docker volume exists
+func (c *Client) volumeExists(ctx context.Context, name string) (bool, error) {
+	if _, err := c.cli.VolumeInspect(ctx, name); err != nil {
+		if client.IsErrNotFound(err) {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to inspect volume %s: %w", name, err)
+	}
+	return true, nil
+}
@@ -0,0 +1,69 @@
+package docker
+
+import (
+	"context"
+	"testing"
+
+	"github.com/abdultolba/nizam/internal/runtime"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsLegacyVolume(t *testing.T) {
+	legacy := VolumeInfo{Name: "nizam_postgres_pgdata", legacy: true}
+	assert.True(t, isLegacyVolume(legacy, "postgres"))
+	assert.False(t, isLegacyVolume(legacy, "redis"))
+	assert.False(t, isLegacyVolume(legacy, "post"))
+
+	// Labeled volumes are matched by their service label instead
+	labeled := VolumeInfo{Name: "nizam_postgres_pgdata", Service: "postgres"}
+	assert.False(t, isLegacyVolume(labeled, "postgres"))
+	scoped := VolumeInfo{Name: "nizam_app_postgres_pgdata", Service: "postgres"}
+	assert.False(t, isLegacyVolume(scoped, "app"))
+}
+
+func TestRemoveLegacyContainer(t *testing.T) {
+	ctx := context.Background()
+	fake := runtime.NewFake()
+	fake.AddContainer(runtime.Container{
+		Name:   "nizam_postgres",
+		State:  "exited",
+		Labels: map[string]string{"nizam.managed": "true", "nizam.service": "postgres"},
+	})
+	fake.AddContainer(runtime.Container{Name: "nizam_redis"})
+
+	fake.AddContainer(runtime.Container{
+		Name:   "nizam_mongo",
+		State:  "running",
+		Labels: map[string]string{"nizam.managed": "true", "nizam.service": "mongo"},
+	})
+
+	// Unscoped clients have nothing to migrate
+	removed, err := removeLegacyContainer(ctx, fake, "", "postgres", true)
+	require.NoError(t, err)
+	assert.False(t, removed)
+
+	// Without adopt, a stopped container is kept and a running one fails
+	removed, err = removeLegacyContainer(ctx, fake, "app", "postgres", false)
+	require.NoError(t, err)
+	assert.False(t, removed)
+	_, err = removeLegacyContainer(ctx, fake, "app", "mongo", false)
+	assert.ErrorContains(t, err, "--adopt-legacy")
+	exists, _ := runtime.Exists(ctx, fake, "nizam_mongo")
+	assert.True(t, exists)
+
+	removed, err = removeLegacyContainer(ctx, fake, "app", "postgres", true)
+	require.NoError(t, err)
+	assert.True(t, removed)
+	exists, _ = runtime.Exists(ctx, fake, "nizam_postgres")
+	assert.False(t, exists)
+
+	// Containers nizam did not create are left alone
+	removed, err = removeLegacyContainer(ctx, fake, "app", "redis", true)
+	require.NoError(t, err)
+	assert.False(t, removed)
+
+	removed, err = removeLegacyContainer(ctx, fake, "app", "mysql", true)
+	require.NoError(t, err)
+	assert.False(t, removed)
+}
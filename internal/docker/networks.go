@@ -0,0 +1,133 @@
+package docker
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/abdultolba/nizam/internal/config"
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/docker/docker/api/types/network"
+	"github.com/rs/zerolog/log"
+)
+
+// DefaultNetwork is the network services join when they don't list any
+const DefaultNetwork = "default"
+
+// NetworkName returns the Docker network name for a nizam network in a
+// project
+func NetworkName(project, name string) string {
+	return config.ContainerName(project, name)
+}
+
+// ServiceNetworks returns the nizam networks a service should be attached
+// to: the ones listed in its config, or the default network
+func ServiceNetworks(serviceConfig config.Service) []string {
+	if len(serviceConfig.Networks) == 0 {
+		return []string{DefaultNetwork}
+	}
+	return serviceConfig.Networks
+}
+
+// EnsureNetwork creates the bridge network for a nizam network if it does
+// not exist yet and returns its Docker name
+func (c *Client) EnsureNetwork(ctx context.Context, name string) (string, error) {
+	c.networkMu.Lock()
+	defer c.networkMu.Unlock()
+
+	networkName := NetworkName(c.project, name)
+	existing, err := c.cli.NetworkList(ctx, types.NetworkListOptions{
+		Filters: filters.NewArgs(filters.Arg("name", networkName)),
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to list networks: %w", err)
+	}
+	for _, n := range existing {
+		// The name filter matches substrings, so compare exactly
+		if n.Name == networkName {
+			return networkName, nil
+		}
+	}
+
+	_, err = c.cli.NetworkCreate(ctx, networkName, types.NetworkCreate{
+		Driver: "bridge",
+		Labels: c.networkLabels(name),
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to create network %s: %w", networkName, err)
+	}
+
+	log.Info().Str("network", networkName).Msg("Created network")
+	return networkName, nil
+}
+
+func (c *Client) networkLabels(name string) map[string]string {
+	labels := map[string]string{
+		"nizam.managed": "true",
+		"nizam.network": name,
+	}
+	if c.project != "" {
+		labels["nizam.project"] = c.project
+	}
+	return labels
+}
+
+// endpointSettings returns the endpoint configuration that makes a service
+// reachable by its name on a network
+func endpointSettings(serviceName string) *network.EndpointSettings {
+	return &network.EndpointSettings{Aliases: []string{serviceName}}
+}
+
+// connectNetworks attaches a container to every network in networkNames it
+// is not already attached to
+func (c *Client) connectNetworks(ctx context.Context, containerName, serviceName string, networkNames []string) error {
+	inspect, err := c.cli.ContainerInspect(ctx, containerName)
+	if err != nil {
+		return fmt.Errorf("failed to inspect container: %w", err)
+	}
+
+	for _, networkName := range networkNames {
+		if inspect.NetworkSettings != nil {
+			if _, attached := inspect.NetworkSettings.Networks[networkName]; attached {
+				continue
+			}
+		}
+		if err := c.cli.NetworkConnect(ctx, networkName, containerName, endpointSettings(serviceName)); err != nil {
+			return fmt.Errorf("failed to connect to network %s: %w", networkName, err)
+		}
+	}
+	return nil
+}
+
+// RemoveUnusedNetworks removes the nizam-managed networks of the client's
+// project that no container is attached to and returns their names
+func (c *Client) RemoveUnusedNetworks(ctx context.Context) ([]string, error) {
+	args := filters.NewArgs(filters.Arg("label", "nizam.managed=true"))
+	if c.project != "" {
+		args.Add("label", "nizam.project="+c.project)
+	}
+	networks, err := c.cli.NetworkList(ctx, types.NetworkListOptions{Filters: args})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list networks: %w", err)
+	}
+
+	var removed []string
+	for _, n := range networks {
+		// NetworkList does not populate Containers, so inspect each network
+		inspect, err := c.cli.NetworkInspect(ctx, n.ID, types.NetworkInspectOptions{})
+		if err != nil {
+			return removed, fmt.Errorf("failed to inspect network %s: %w", n.Name, err)
+		}
+		if len(inspect.Containers) > 0 {
+			continue
+		}
+		if err := c.cli.NetworkRemove(ctx, n.ID); err != nil {
+			return removed, fmt.Errorf("failed to remove network %s: %w", n.Name, err)
+		}
+		removed = append(removed, n.Name)
+	}
+
+	sort.Strings(removed)
+	return removed, nil
+}
@@ -0,0 +1,147 @@
+package docker
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+
+	"github.com/abdultolba/nizam/internal/config"
+	"github.com/abdultolba/nizam/internal/runtime"
+	"github.com/docker/go-connections/nat"
+	"github.com/rs/zerolog/log"
+)
+
+// portAvailable reports whether a host port can be bound on this machine,
+// which is where a local engine publishes ports
+var portAvailable = func(port int) bool {
+	ln, err := net.Listen("tcp", fmt.Sprintf(":%d", port))
+	if err != nil {
+		return false
+	}
+	ln.Close()
+	return true
+}
+
+// PortProbe returns a function reporting whether a host port is free on the
+// engine's host. Local engines are probed with a listener on this machine.
+// The host of a remote engine can't be probed from here, so the ports its
+// containers publish count as taken and other conflicts surface as the
+// engine's bind error.
+func (c *Client) PortProbe(ctx context.Context) (func(port int) bool, error) {
+	if c.endpoint.IsLocal() {
+		return portAvailable, nil
+	}
+	published, err := publishedPorts(ctx, c)
+	if err != nil {
+		return nil, err
+	}
+	return func(port int) bool { return !published[port] }, nil
+}
+
+// publishedPorts returns the host ports the containers of a runtime publish
+func publishedPorts(ctx context.Context, rt runtime.Runtime) (map[int]bool, error) {
+	containers, err := rt.ListContainers(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	published := make(map[int]bool)
+	for _, container := range containers {
+		for _, spec := range container.Ports {
+			hostPort, _, _ := strings.Cut(spec, ":")
+			if port, err := strconv.Atoi(hostPort); err == nil {
+				published[port] = true
+			}
+		}
+	}
+	return published, nil
+}
+
+// allocatePorts decides the host ports a service is published on. "auto"
+// mappings get the first free port from their container port upwards, and
+// with the next-free strategy so do fixed ports that are taken. Allocated
+// ports stay reserved for the client's lifetime so services started in
+// parallel do not pick the same one.
+func (c *Client) allocatePorts(ctx context.Context, serviceName string, ports []string, strategy string, progress ProgressFunc) ([]config.PortMapping, error) {
+	available, err := c.PortProbe(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	c.portMu.Lock()
+	defer c.portMu.Unlock()
+
+	if c.reservedPorts == nil {
+		c.reservedPorts = make(map[int]bool)
+	}
+
+	mappings := make([]config.PortMapping, 0, len(ports))
+	for _, spec := range ports {
+		mapping, err := config.ParsePort(spec)
+		if err != nil {
+			return nil, err
+		}
+
+		switch {
+		case mapping.Auto:
+			if mapping.HostPort, err = c.nextFreePort(mapping.ContainerPort, available); err != nil {
+				return nil, err
+			}
+			if progress != nil {
+				progress(fmt.Sprintf("publishing port %d on %d", mapping.ContainerPort, mapping.HostPort))
+			}
+		case strategy == config.PortStrategyNextFree && !c.portFree(mapping.HostPort, available):
+			configured := mapping.HostPort
+			if mapping.HostPort, err = c.nextFreePort(configured+1, available); err != nil {
+				return nil, err
+			}
+			log.Warn().Str("service", serviceName).Int("port", configured).Int("published", mapping.HostPort).
+				Msg("Host port is taken, using the next free port")
+			if progress != nil {
+				progress(fmt.Sprintf("port %d is taken, publishing on %d", configured, mapping.HostPort))
+			}
+		}
+
+		c.reservedPorts[mapping.HostPort] = true
+		mappings = append(mappings, mapping)
+	}
+	return mappings, nil
+}
+
+// portFree reports whether a port is neither reserved nor taken
+func (c *Client) portFree(port int, available func(int) bool) bool {
+	return !c.reservedPorts[port] && available(port)
+}
+
+// nextFreePort returns the first free port from start upwards
+func (c *Client) nextFreePort(start int, available func(int) bool) (int, error) {
+	for port := start; port <= 65535; port++ {
+		if c.portFree(port, available) {
+			return port, nil
+		}
+	}
+	return 0, fmt.Errorf("no free host port at or above %d", start)
+}
+
+// createPortBindings turns allocated mappings into Docker port bindings
+func createPortBindings(ports []config.PortMapping) (nat.PortMap, nat.PortSet, error) {
+	portBindings := nat.PortMap{}
+	exposedPorts := nat.PortSet{}
+
+	for _, mapping := range ports {
+		natPort, err := nat.NewPort("tcp", strconv.Itoa(mapping.ContainerPort))
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid container port %d: %w", mapping.ContainerPort, err)
+		}
+
+		exposedPorts[natPort] = struct{}{}
+		portBindings[natPort] = append(portBindings[natPort], nat.PortBinding{
+			HostIP:   "0.0.0.0",
+			HostPort: strconv.Itoa(mapping.HostPort),
+		})
+	}
+
+	return portBindings, exposedPorts, nil
+}
@@ -0,0 +1,84 @@
+package docker
+
+import (
+	"context"
+	"testing"
+
+	"github.com/abdultolba/nizam/internal/config"
+	"github.com/abdultolba/nizam/internal/runtime"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// stubPorts marks the given host ports as bound for the test
+func stubPorts(t *testing.T, busy ...int) {
+	t.Helper()
+	original := portAvailable
+	t.Cleanup(func() { portAvailable = original })
+
+	taken := make(map[int]bool)
+	for _, port := range busy {
+		taken[port] = true
+	}
+	portAvailable = func(port int) bool { return !taken[port] }
+}
+
+func TestAllocatePorts_Fixed(t *testing.T) {
+	stubPorts(t, 5432)
+	c := &Client{}
+
+	ports, err := c.allocatePorts(context.Background(), "postgres", []string{"5432:5432", "8080:80"}, config.PortStrategyFixed, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []config.PortMapping{
+		{HostPort: 5432, ContainerPort: 5432},
+		{HostPort: 8080, ContainerPort: 80},
+	}, ports)
+}
+
+func TestAllocatePorts_NextFree(t *testing.T) {
+	stubPorts(t, 5432, 5433)
+	c := &Client{}
+
+	var messages []string
+	ports, err := c.allocatePorts(context.Background(), "postgres", []string{"5432:5432"}, config.PortStrategyNextFree, func(msg string) {
+		messages = append(messages, msg)
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 5434, ports[0].HostPort)
+	assert.Equal(t, []string{"port 5432 is taken, publishing on 5434"}, messages)
+}
+
+func TestAllocatePorts_Auto(t *testing.T) {
+	stubPorts(t, 5432)
+	c := &Client{}
+
+	first, err := c.allocatePorts(context.Background(), "pg1", []string{"auto:5432"}, config.PortStrategyFixed, nil)
+	require.NoError(t, err)
+	assert.Equal(t, config.PortMapping{HostPort: 5433, ContainerPort: 5432, Auto: true}, first[0])
+
+	// Ports allocated earlier are not handed out again
+	second, err := c.allocatePorts(context.Background(), "pg2", []string{"auto:5432"}, config.PortStrategyFixed, nil)
+	require.NoError(t, err)
+	assert.Equal(t, 5434, second[0].HostPort)
+
+	assert.Equal(t, "5433:5432", config.FormatPortsLabel(first))
+}
+
+func TestAllocatePorts_Invalid(t *testing.T) {
+	stubPorts(t)
+	c := &Client{}
+
+	_, err := c.allocatePorts(context.Background(), "web", []string{"8080"}, config.PortStrategyFixed, nil)
+	assert.ErrorContains(t, err, "invalid port mapping")
+}
+
+func TestPublishedPorts(t *testing.T) {
+	fake := runtime.NewFake()
+	fake.AddContainer(runtime.Container{Name: "db", Ports: []string{"5432:5432", "15432:5433"}})
+	fake.AddContainer(runtime.Container{Name: "cache", Ports: []string{"6379:6379"}})
+	fake.AddContainer(runtime.Container{Name: "worker"})
+
+	published, err := publishedPorts(context.Background(), fake)
+	require.NoError(t, err)
+	assert.Equal(t, map[int]bool{5432: true, 15432: true, 6379: true}, published)
+}
@@ -0,0 +1,89 @@
+package docker
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/docker/go-units"
+)
+
+// ProgressFunc receives human-readable progress lines from image pulls and
+// builds
+type ProgressFunc func(message string)
+
+// progressMessage is a message of the JSON stream returned by the image
+// pull and build APIs
+type progressMessage struct {
+	Stream         string `json:"stream"`
+	Status         string `json:"status"`
+	ID             string `json:"id"`
+	Progress       string `json:"progress"`
+	ProgressDetail struct {
+		Current int64 `json:"current"`
+		Total   int64 `json:"total"`
+	} `json:"progressDetail"`
+	Error       string `json:"error"`
+	ErrorDetail *struct {
+		Message string `json:"message"`
+	} `json:"errorDetail"`
+}
+
+// progressStep is the percentage step at which layer transfers are reported
+const progressStep = 25
+
+// readProgress consumes a pull or build stream, forwarding its output to
+// progress, and returns the error reported in the stream, if any. Layer
+// status changes are forwarded as they happen; transfers are reported per
+// layer every progressStep percent rather than on every update.
+func readProgress(r io.Reader, progress ProgressFunc) error {
+	decoder := json.NewDecoder(r)
+	reported := make(map[string]int64)
+	for {
+		var msg progressMessage
+		if err := decoder.Decode(&msg); err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return fmt.Errorf("failed to read progress: %w", err)
+		}
+
+		if msg.ErrorDetail != nil && msg.ErrorDetail.Message != "" {
+			return errors.New(msg.ErrorDetail.Message)
+		}
+		if msg.Error != "" {
+			return errors.New(msg.Error)
+		}
+		if progress == nil {
+			continue
+		}
+
+		switch {
+		case msg.Stream != "":
+			for _, line := range strings.Split(strings.TrimRight(msg.Stream, "\n"), "\n") {
+				if line = strings.TrimRight(line, "\r "); line != "" {
+					progress(line)
+				}
+			}
+		case msg.ID != "" && msg.ProgressDetail.Total > 0:
+			detail := msg.ProgressDetail
+			percent := detail.Current * 100 / detail.Total
+			step := percent / progressStep * progressStep
+			key := msg.ID + " " + msg.Status
+			if step == 0 || step >= 100 || step <= reported[key] {
+				continue
+			}
+			reported[key] = step
+			progress(fmt.Sprintf("%s: %s %s/%s (%d%%)", msg.ID, msg.Status,
+				units.HumanSize(float64(detail.Current)), units.HumanSize(float64(detail.Total)), step))
+		case msg.Status != "" && msg.Progress == "":
+			if msg.ID != "" {
+				progress(msg.ID + ": " + msg.Status)
+			} else {
+				progress(msg.Status)
+			}
+		}
+	}
+}
@@ -0,0 +1,35 @@
+package docker
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadProgress(t *testing.T) {
+	stream := `{"stream":"Step 1/2 : FROM postgres:16\n"}
+{"status":"Pulling fs layer","id":"abc"}
+{"status":"Downloading","id":"abc","progress":"[>  ]","progressDetail":{"current":100,"total":1000}}
+{"status":"Downloading","id":"abc","progress":"[==>  ]","progressDetail":{"current":300,"total":1000}}
+{"status":"Downloading","id":"abc","progress":"[==>  ]","progressDetail":{"current":400,"total":1000}}
+{"status":"Download complete","id":"abc"}
+{"status":"Pull complete","id":"abc"}
+{"stream":"Successfully built 123\n"}
+`
+	var lines []string
+	err := readProgress(strings.NewReader(stream), func(line string) { lines = append(lines, line) })
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"Step 1/2 : FROM postgres:16",
+		"abc: Pulling fs layer",
+		"abc: Downloading 300B/1kB (25%)",
+		"abc: Download complete",
+		"abc: Pull complete",
+		"Successfully built 123",
+	}, lines)
+
+	err = readProgress(strings.NewReader(`{"errorDetail":{"message":"no such file"},"error":"no such file"}`), nil)
+	assert.EqualError(t, err, "no such file")
+}
@@ -0,0 +1,65 @@
+package docker
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/filters"
+)
+
+// ProjectInfo summarizes the containers of one nizam project on the host
+type ProjectInfo struct {
+	Name       string   `json:"name"`
+	ConfigPath string   `json:"config_path,omitempty"`
+	Services   []string `json:"services"`
+	Running    int      `json:"running"`
+	Total      int      `json:"total"`
+}
+
+// LegacyProject is reported for containers created before projects existed
+const LegacyProject = "(none)"
+
+// ListProjects returns every nizam project that has containers on the host,
+// regardless of the project the client is scoped to
+func (c *Client) ListProjects(ctx context.Context) ([]ProjectInfo, error) {
+	containers, err := c.cli.ContainerList(ctx, types.ContainerListOptions{
+		All:     true,
+		Filters: filters.NewArgs(filters.Arg("label", "nizam.managed=true")),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list containers: %w", err)
+	}
+
+	projects := map[string]*ProjectInfo{}
+	for _, container := range containers {
+		name := container.Labels["nizam.project"]
+		if name == "" {
+			name = LegacyProject
+		}
+
+		project, exists := projects[name]
+		if !exists {
+			project = &ProjectInfo{Name: name}
+			projects[name] = project
+		}
+
+		if path := container.Labels["nizam.project.config"]; path != "" {
+			project.ConfigPath = path
+		}
+		project.Services = append(project.Services, container.Labels["nizam.service"])
+		project.Total++
+		if container.State == "running" {
+			project.Running++
+		}
+	}
+
+	result := make([]ProjectInfo, 0, len(projects))
+	for _, project := range projects {
+		sort.Strings(project.Services)
+		result = append(result, *project)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Name < result[j].Name })
+	return result, nil
+}
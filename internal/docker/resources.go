@@ -0,0 +1,76 @@
+package docker
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+
+	"github.com/abdultolba/nizam/internal/config"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/go-units"
+)
+
+// applyResources translates a service's resources block into the host
+// configuration of its container
+func applyResources(hostConfig *container.HostConfig, resources *config.Resources) error {
+	if resources == nil {
+		return nil
+	}
+
+	if resources.CPUs != "" {
+		cpus, err := strconv.ParseFloat(resources.CPUs, 64)
+		if err != nil || cpus <= 0 {
+			return fmt.Errorf("invalid cpus value '%s': must be a positive number", resources.CPUs)
+		}
+		hostConfig.NanoCPUs = int64(cpus * 1e9)
+	}
+
+	var err error
+	if hostConfig.Memory, err = parseSize("memory", resources.Memory); err != nil {
+		return err
+	}
+	if hostConfig.MemoryReservation, err = parseSize("memory_reservation", resources.MemoryReservation); err != nil {
+		return err
+	}
+	if hostConfig.ShmSize, err = parseSize("shm_size", resources.ShmSize); err != nil {
+		return err
+	}
+	if hostConfig.Memory > 0 && hostConfig.MemoryReservation > hostConfig.Memory {
+		return fmt.Errorf("memory_reservation (%s) must not exceed memory (%s)", resources.MemoryReservation, resources.Memory)
+	}
+
+	if resources.Pids != 0 {
+		pids := resources.Pids
+		hostConfig.PidsLimit = &pids
+	}
+
+	names := make([]string, 0, len(resources.Ulimits))
+	for name := range resources.Ulimits {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		limit := resources.Ulimits[name]
+		if limit.Soft > limit.Hard {
+			return fmt.Errorf("ulimit %s: soft limit %d exceeds hard limit %d", name, limit.Soft, limit.Hard)
+		}
+		hostConfig.Ulimits = append(hostConfig.Ulimits, &units.Ulimit{
+			Name: name,
+			Soft: limit.Soft,
+			Hard: limit.Hard,
+		})
+	}
+
+	return nil
+}
+
+func parseSize(field, value string) (int64, error) {
+	if value == "" {
+		return 0, nil
+	}
+	size, err := units.RAMInBytes(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s value '%s': %w", field, value, err)
+	}
+	return size, nil
+}
@@ -0,0 +1,59 @@
+package docker
+
+import (
+	"testing"
+
+	"github.com/abdultolba/nizam/internal/config"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/go-units"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestApplyResources(t *testing.T) {
+	hostConfig := &container.HostConfig{}
+	err := applyResources(hostConfig, &config.Resources{
+		CPUs:              "1.5",
+		Memory:            "1g",
+		MemoryReservation: "512m",
+		Pids:              200,
+		ShmSize:           "256m",
+		Ulimits: map[string]config.Ulimit{
+			"nproc":  {Soft: 65535, Hard: 65535},
+			"nofile": {Soft: 20000, Hard: 40000},
+		},
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, int64(1_500_000_000), hostConfig.NanoCPUs)
+	assert.Equal(t, int64(1<<30), hostConfig.Memory)
+	assert.Equal(t, int64(512<<20), hostConfig.MemoryReservation)
+	assert.Equal(t, int64(256<<20), hostConfig.ShmSize)
+	require.NotNil(t, hostConfig.PidsLimit)
+	assert.Equal(t, int64(200), *hostConfig.PidsLimit)
+	assert.Equal(t, []*units.Ulimit{
+		{Name: "nofile", Soft: 20000, Hard: 40000},
+		{Name: "nproc", Soft: 65535, Hard: 65535},
+	}, hostConfig.Ulimits)
+}
+
+func TestApplyResources_Nil(t *testing.T) {
+	hostConfig := &container.HostConfig{}
+	require.NoError(t, applyResources(hostConfig, nil))
+	assert.Equal(t, container.HostConfig{}, *hostConfig)
+}
+
+func TestApplyResources_Invalid(t *testing.T) {
+	tests := []config.Resources{
+		{CPUs: "lots"},
+		{CPUs: "-1"},
+		{Memory: "12 parsecs"},
+		{Memory: "256m", MemoryReservation: "512m"},
+		{Ulimits: map[string]config.Ulimit{"nofile": {Soft: 10, Hard: 5}}},
+	}
+
+	for _, resources := range tests {
+		err := applyResources(&container.HostConfig{}, &resources)
+		assert.Error(t, err, "%+v", resources)
+	}
+}
@@ -0,0 +1,310 @@
+package docker
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/abdultolba/nizam/internal/runtime"
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/docker/docker/client"
+	"github.com/docker/docker/pkg/stdcopy"
+	"github.com/rs/zerolog/log"
+)
+
+// Client implements the container runtime on the Docker API
+var _ runtime.Runtime = (*Client)(nil)
+
+// wrapNotFound turns Docker's not-found errors into runtime.ErrNotFound
+func wrapNotFound(name string, err error) error {
+	if client.IsErrNotFound(err) {
+		return runtime.NotFound(name)
+	}
+	return err
+}
+
+// CreateContainer creates a container and returns its ID
+func (c *Client) CreateContainer(ctx context.Context, spec runtime.ContainerSpec) (string, error) {
+	resp, err := c.cli.ContainerCreate(ctx, spec.Config, spec.HostConfig, spec.Networking, nil, spec.Name)
+	if err != nil {
+		return "", fmt.Errorf("failed to create container: %w", err)
+	}
+	return resp.ID, nil
+}
+
+// StartContainer starts a created or stopped container
+func (c *Client) StartContainer(ctx context.Context, name string) error {
+	if err := c.cli.ContainerStart(ctx, name, types.ContainerStartOptions{}); err != nil {
+		return wrapNotFound(name, err)
+	}
+	return nil
+}
+
+// StopContainer stops a container with a timeout
+func (c *Client) StopContainer(ctx context.Context, name string, timeout time.Duration) error {
+	timeoutSec := int(timeout.Seconds())
+	if err := c.cli.ContainerStop(ctx, name, container.StopOptions{Timeout: &timeoutSec}); err != nil {
+		return wrapNotFound(name, err)
+	}
+	return nil
+}
+
+// RemoveContainer force-removes a container and its anonymous volumes
+func (c *Client) RemoveContainer(ctx context.Context, name string) error {
+	err := c.cli.ContainerRemove(ctx, name, types.ContainerRemoveOptions{Force: true, RemoveVolumes: true})
+	if err != nil {
+		return wrapNotFound(name, err)
+	}
+	return nil
+}
+
+// InspectContainer returns the state of a container
+func (c *Client) InspectContainer(ctx context.Context, name string) (*runtime.Container, error) {
+	inspect, err := c.cli.ContainerInspect(ctx, name)
+	if err != nil {
+		return nil, wrapNotFound(name, err)
+	}
+
+	result := &runtime.Container{
+		ID:   inspect.ID,
+		Name: strings.TrimPrefix(inspect.Name, "/"),
+	}
+	if inspect.Config != nil {
+		result.Image = inspect.Config.Image
+		result.Labels = inspect.Config.Labels
+	}
+	if inspect.State != nil {
+		result.State = inspect.State.Status
+		result.Status = inspect.State.Status
+		if inspect.State.Running {
+			result.Status = "Up since " + inspect.State.StartedAt
+		}
+		if inspect.State.Health != nil {
+			result.Health = inspect.State.Health.Status
+		}
+	}
+	if inspect.NetworkSettings != nil {
+		for port, bindings := range inspect.NetworkSettings.Ports {
+			for _, binding := range bindings {
+				if binding.HostPort != "" {
+					result.Ports = append(result.Ports, binding.HostPort+":"+port.Port())
+				}
+			}
+		}
+		sort.Strings(result.Ports)
+		for networkName := range inspect.NetworkSettings.Networks {
+			result.Networks = append(result.Networks, networkName)
+		}
+		sort.Strings(result.Networks)
+	}
+	return result, nil
+}
+
+// ListContainers returns all containers carrying every given label
+func (c *Client) ListContainers(ctx context.Context, labels map[string]string) ([]runtime.Container, error) {
+	args := filters.NewArgs()
+	for key, value := range labels {
+		args.Add("label", key+"="+value)
+	}
+
+	containers, err := c.cli.ContainerList(ctx, types.ContainerListOptions{All: true, Filters: args})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list containers: %w", err)
+	}
+
+	result := make([]runtime.Container, 0, len(containers))
+	for _, ctr := range containers {
+		var ports []string
+		for _, port := range ctr.Ports {
+			if port.PublicPort > 0 {
+				ports = append(ports, fmt.Sprintf("%d:%d", port.PublicPort, port.PrivatePort))
+			}
+		}
+
+		var networks []string
+		if ctr.NetworkSettings != nil {
+			for networkName := range ctr.NetworkSettings.Networks {
+				networks = append(networks, networkName)
+			}
+			sort.Strings(networks)
+		}
+
+		name := ""
+		if len(ctr.Names) > 0 {
+			name = strings.TrimPrefix(ctr.Names[0], "/")
+		}
+
+		result = append(result, runtime.Container{
+			ID:       ctr.ID,
+			Name:     name,
+			Image:    ctr.Image,
+			Labels:   ctr.Labels,
+			State:    ctr.State,
+			Status:   ctr.Status,
+			Ports:    ports,
+			Networks: networks,
+		})
+	}
+	return result, nil
+}
+
+// ExecCommand executes a command in a container and returns the result
+func (c *Client) ExecCommand(ctx context.Context, name string, cmd []string) (*runtime.ExecResult, error) {
+	execID, attachResp, err := c.execAttach(ctx, name, cmd, false)
+	if err != nil {
+		return nil, err
+	}
+	defer attachResp.Close()
+
+	// Without a TTY, Docker multiplexes stdout and stderr on one stream
+	var stdout, stderr bytes.Buffer
+	if _, err := stdcopy.StdCopy(&stdout, &stderr, attachResp.Reader); err != nil {
+		return nil, fmt.Errorf("failed to read exec output: %w", err)
+	}
+
+	inspectResp, err := c.cli.ContainerExecInspect(ctx, execID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to inspect exec instance: %w", err)
+	}
+
+	return &runtime.ExecResult{
+		ExitCode: inspectResp.ExitCode,
+		Stdout:   stdout.String(),
+		Stderr:   stderr.String(),
+	}, nil
+}
+
+// ExecStreaming executes a command in a container with streaming I/O. The
+// returned stream carries the command's stdout; stderr is kept to report
+// a failed command once the stream ends.
+func (c *Client) ExecStreaming(ctx context.Context, name string, cmd []string, stdin io.Reader) (io.ReadCloser, error) {
+	execID, attachResp, err := c.execAttach(ctx, name, cmd, stdin != nil)
+	if err != nil {
+		return nil, err
+	}
+
+	// Stream stdin if provided
+	if stdin != nil {
+		go func() {
+			defer attachResp.CloseWrite()
+			if _, copyErr := io.Copy(attachResp.Conn, stdin); copyErr != nil {
+				log.Debug().Err(copyErr).Msg("Error copying stdin to exec")
+			}
+		}()
+	}
+
+	reader, writer := io.Pipe()
+	go func() {
+		defer attachResp.Close()
+
+		var stderr bytes.Buffer
+		if _, err := stdcopy.StdCopy(writer, &stderr, attachResp.Reader); err != nil {
+			writer.CloseWithError(fmt.Errorf("failed to read exec output: %w", err))
+			return
+		}
+
+		inspectResp, err := c.cli.ContainerExecInspect(ctx, execID)
+		if err != nil {
+			writer.CloseWithError(fmt.Errorf("failed to inspect exec instance: %w", err))
+			return
+		}
+		if inspectResp.ExitCode != 0 {
+			writer.CloseWithError(&runtime.ExitError{ExitCode: inspectResp.ExitCode, Stderr: strings.TrimSpace(stderr.String())})
+			return
+		}
+		writer.Close()
+	}()
+
+	return reader, nil
+}
+
+// ExecTTY executes a command in a container attached to the terminal
+func (c *Client) ExecTTY(ctx context.Context, name string, cmd []string) error {
+	execConfig := types.ExecConfig{
+		Cmd:          cmd,
+		AttachStdout: true,
+		AttachStderr: true,
+		AttachStdin:  true,
+		Tty:          true,
+	}
+
+	execResp, err := c.cli.ContainerExecCreate(ctx, name, execConfig)
+	if err != nil {
+		return fmt.Errorf("failed to create exec instance: %w", wrapNotFound(name, err))
+	}
+
+	attachResp, err := c.cli.ContainerExecAttach(ctx, execResp.ID, types.ExecStartCheck{Tty: true})
+	if err != nil {
+		return fmt.Errorf("failed to attach to exec instance: %w", err)
+	}
+	defer attachResp.Close()
+
+	go func() {
+		if _, copyErr := io.Copy(attachResp.Conn, os.Stdin); copyErr != nil {
+			log.Debug().Err(copyErr).Msg("Error copying stdin")
+		}
+	}()
+
+	if _, err := io.Copy(os.Stdout, attachResp.Reader); err != nil {
+		return fmt.Errorf("failed to copy output: %w", err)
+	}
+	return nil
+}
+
+// execAttach creates an exec instance and attaches to it
+func (c *Client) execAttach(ctx context.Context, name string, cmd []string, withStdin bool) (string, types.HijackedResponse, error) {
+	execResp, err := c.cli.ContainerExecCreate(ctx, name, types.ExecConfig{
+		Cmd:          cmd,
+		AttachStdout: true,
+		AttachStderr: true,
+		AttachStdin:  withStdin,
+	})
+	if err != nil {
+		return "", types.HijackedResponse{}, fmt.Errorf("failed to create exec instance: %w", wrapNotFound(name, err))
+	}
+
+	attachResp, err := c.cli.ContainerExecAttach(ctx, execResp.ID, types.ExecStartCheck{})
+	if err != nil {
+		return "", types.HijackedResponse{}, fmt.Errorf("failed to attach to exec instance: %w", err)
+	}
+	return execResp.ID, attachResp, nil
+}
+
+// Logs returns a container's log stream
+func (c *Client) Logs(ctx context.Context, name string, opts runtime.LogOptions) (io.ReadCloser, error) {
+	logs, err := c.cli.ContainerLogs(ctx, name, types.ContainerLogsOptions{
+		ShowStdout: true,
+		ShowStderr: true,
+		Follow:     opts.Follow,
+		Timestamps: true,
+		Tail:       opts.Tail,
+	})
+	if err != nil {
+		return nil, wrapNotFound(name, err)
+	}
+	return logs, nil
+}
+
+// CopyTo extracts a tar archive into a directory of a container
+func (c *Client) CopyTo(ctx context.Context, name, dstDir string, archive io.Reader) error {
+	if err := c.cli.CopyToContainer(ctx, name, dstDir, archive, types.CopyToContainerOptions{}); err != nil {
+		return fmt.Errorf("failed to copy into container %s: %w", name, wrapNotFound(name, err))
+	}
+	return nil
+}
+
+// CopyFrom returns a tar archive of a path in a container
+func (c *Client) CopyFrom(ctx context.Context, name, srcPath string) (io.ReadCloser, error) {
+	reader, _, err := c.cli.CopyFromContainer(ctx, name, srcPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to copy from container %s: %w", name, wrapNotFound(name, err))
+	}
+	return reader, nil
+}
@@ -0,0 +1,167 @@
+package docker
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/abdultolba/nizam/internal/config"
+	"github.com/abdultolba/nizam/internal/resolve"
+	"github.com/docker/docker/api/types/mount"
+	"github.com/docker/docker/api/types/volume"
+	"github.com/docker/go-units"
+)
+
+// VolumeInfo holds information about a nizam-managed volume
+type VolumeInfo struct {
+	Name       string
+	Service    string
+	Driver     string
+	Mountpoint string
+	CreatedAt  string
+
+	// legacy marks volumes from before labels, recognized by name only
+	legacy bool
+}
+
+// VolumeName returns the Docker volume name used for a service's named volume
+func VolumeName(project, serviceName, volumeName string) string {
+	return fmt.Sprintf("%s_%s", config.ContainerName(project, serviceName), volumeName)
+}
+
+// buildMounts translates the volume and volumes fields of a service into
+// Docker mounts. The legacy volume field is mounted at the service's data
+// path, which defaults to the engine's data directory. adoptLegacy mounts
+// volumes from before projects, see volumeSource.
+func (c *Client) buildMounts(ctx context.Context, serviceName string, serviceConfig config.Service, adoptLegacy bool) ([]mount.Mount, error) {
+	var mounts []mount.Mount
+
+	if serviceConfig.Volume != "" {
+		dataPath := serviceConfig.DataPath
+		if dataPath == "" {
+			dataPath = resolve.DefaultDataPath(serviceConfig.Image, serviceName)
+		}
+		if dataPath == "" {
+			return nil, fmt.Errorf("don't know where %s stores its data; set data_path or use volumes", serviceConfig.Image)
+		}
+		m, err := c.namedVolumeMount(ctx, serviceName, serviceConfig.Volume, dataPath, false, adoptLegacy)
+		if err != nil {
+			return nil, err
+		}
+		mounts = append(mounts, m)
+	}
+
+	for _, v := range serviceConfig.Volumes {
+		if err := v.Validate(); err != nil {
+			return nil, err
+		}
+
+		switch v.Type {
+		case config.VolumeTypeVolume:
+			m, err := c.namedVolumeMount(ctx, serviceName, v.Source, v.Target, v.ReadOnly, adoptLegacy)
+			if err != nil {
+				return nil, err
+			}
+			mounts = append(mounts, m)
+		case config.VolumeTypeBind:
+			mounts = append(mounts, mount.Mount{
+				Type:     mount.TypeBind,
+				Source:   v.Source,
+				Target:   v.Target,
+				ReadOnly: v.ReadOnly,
+			})
+		case config.VolumeTypeTmpfs:
+			m := mount.Mount{
+				Type:   mount.TypeTmpfs,
+				Target: v.Target,
+			}
+			if v.Size != "" {
+				size, err := units.RAMInBytes(v.Size)
+				if err != nil {
+					return nil, fmt.Errorf("invalid tmpfs size '%s' for %s: %w", v.Size, v.Target, err)
+				}
+				m.TmpfsOptions = &mount.TmpfsOptions{SizeBytes: size}
+			}
+			mounts = append(mounts, m)
+		}
+	}
+
+	return mounts, nil
+}
+
+func (c *Client) namedVolumeMount(ctx context.Context, serviceName, volumeName, target string, readOnly, adoptLegacy bool) (mount.Mount, error) {
+	source, err := c.volumeSource(ctx, serviceName, volumeName, adoptLegacy)
+	if err != nil {
+		return mount.Mount{}, err
+	}
+	return mount.Mount{
+		Type:     mount.TypeVolume,
+		Source:   source,
+		Target:   target,
+		ReadOnly: readOnly,
+		VolumeOptions: &mount.VolumeOptions{
+			Labels: c.labels(serviceName),
+		},
+	}, nil
+}
+
+// ListVolumes returns the volumes nizam created for the client's project
+// (or all of them if the client is not scoped), sorted by name. Volumes
+// from before projects belong to no project and are listed for every one.
+func (c *Client) ListVolumes(ctx context.Context) ([]VolumeInfo, error) {
+	resp, err := c.cli.VolumeList(ctx, volume.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list volumes: %w", err)
+	}
+
+	var volumes []VolumeInfo
+	for _, v := range resp.Volumes {
+		// Volumes created before labels were added are recognized by name
+		if v.Labels["nizam.managed"] != "true" && !strings.HasPrefix(v.Name, "nizam_") {
+			continue
+		}
+		if c.project != "" && v.Labels["nizam.project"] != c.project && v.Labels["nizam.project"] != "" {
+			continue
+		}
+		volumes = append(volumes, VolumeInfo{
+			Name:       v.Name,
+			Service:    v.Labels["nizam.service"],
+			Driver:     v.Driver,
+			Mountpoint: v.Mountpoint,
+			CreatedAt:  v.CreatedAt,
+			legacy:     v.Labels["nizam.managed"] != "true",
+		})
+	}
+
+	sort.Slice(volumes, func(i, j int) bool { return volumes[i].Name < volumes[j].Name })
+	return volumes, nil
+}
+
+// ServiceVolumes returns the nizam volumes belonging to a service, including
+// unlabeled ones from before projects named after it
+func (c *Client) ServiceVolumes(ctx context.Context, serviceName string) ([]VolumeInfo, error) {
+	volumes, err := c.ListVolumes(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var result []VolumeInfo
+	for _, v := range volumes {
+		if v.Service == serviceName {
+			result = append(result, v)
+		} else if isLegacyVolume(v, serviceName) {
+			v.Service = serviceName
+			result = append(result, v)
+		}
+	}
+	return result, nil
+}
+
+// RemoveVolume removes a volume by name
+func (c *Client) RemoveVolume(ctx context.Context, name string, force bool) error {
+	if err := c.cli.VolumeRemove(ctx, name, force); err != nil {
+		return fmt.Errorf("failed to remove volume %s: %w", name, err)
+	}
+	return nil
+}
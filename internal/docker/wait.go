@@ -0,0 +1,104 @@
+package docker
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/abdultolba/nizam/internal/config"
+	"github.com/abdultolba/nizam/internal/readiness"
+	"github.com/abdultolba/nizam/internal/resolve"
+	"github.com/abdultolba/nizam/internal/runtime"
+)
+
+// ContainerState holds the runtime state of a service container
+type ContainerState struct {
+	Exists  bool
+	Running bool
+	Status  string
+	Health  string // "", "starting", "healthy" or "unhealthy"
+}
+
+// GetContainerState inspects the container of a service
+func (c *Client) GetContainerState(ctx context.Context, serviceName string) (ContainerState, error) {
+	containerName := c.containerName(serviceName)
+
+	inspect, err := c.InspectContainer(ctx, containerName)
+	if runtime.IsNotFound(err) {
+		return ContainerState{}, nil
+	}
+	if err != nil {
+		return ContainerState{}, fmt.Errorf("failed to inspect container: %w", err)
+	}
+
+	state := ContainerState{
+		Exists:  true,
+		Running: inspect.Running(),
+		Status:  inspect.State,
+		Health:  inspect.Health,
+	}
+	return state, nil
+}
+
+// WaitForCondition blocks until the service satisfies the given dependency
+// condition, the context is done or the container stops running.
+//
+//   - started: the container is running
+//   - healthy: the Docker health check reports healthy; containers without a
+//     health check fall back to the same readiness probe as 'nizam wait-for'
+//   - port-open: one of the published host ports accepts TCP connections
+func (c *Client) WaitForCondition(ctx context.Context, serviceName string, service config.Service, condition string, interval time.Duration) error {
+	for {
+		ready, err := c.checkCondition(ctx, serviceName, service, condition)
+		if err != nil {
+			return err
+		}
+		if ready {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("timed out waiting for %s to be %s", serviceName, condition)
+		case <-time.After(interval):
+		}
+	}
+}
+
+func (c *Client) checkCondition(ctx context.Context, serviceName string, service config.Service, condition string) (bool, error) {
+	state, err := c.GetContainerState(ctx, serviceName)
+	if err != nil {
+		return false, err
+	}
+	if !state.Exists {
+		return false, fmt.Errorf("container for %s does not exist", serviceName)
+	}
+	if !state.Running {
+		if state.Status == "exited" || state.Status == "dead" {
+			return false, fmt.Errorf("%s is not running (status: %s)", serviceName, state.Status)
+		}
+		return false, nil
+	}
+
+	// Probe the host ports the container was actually published on
+	service = resolve.WithPublishedPorts(ctx, c, c.containerName(serviceName), service)
+
+	switch condition {
+	case config.ConditionStarted, "":
+		return true, nil
+	case config.ConditionHealthy:
+		switch state.Health {
+		case "healthy":
+			return true, nil
+		case "unhealthy":
+			return false, fmt.Errorf("%s is unhealthy", serviceName)
+		case "":
+			return readiness.CheckService(service).Ready, nil
+		}
+		return false, nil
+	case config.ConditionPortOpen:
+		return readiness.CheckPorts(service).Ready, nil
+	default:
+		return false, fmt.Errorf("unknown dependency condition '%s'", condition)
+	}
+}
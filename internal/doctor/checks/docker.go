@@ -3,10 +3,13 @@ package checks
 import (
 	"context"
 	"errors"
+	"fmt"
 	"os/exec"
 	"strings"
 	"time"
 
+	"github.com/abdultolba/nizam/internal/backend"
+	"github.com/abdultolba/nizam/internal/config"
 	"github.com/abdultolba/nizam/internal/doctor"
 	"github.com/docker/docker/client"
 )
@@ -18,39 +21,79 @@ func (c DockerDaemon) ID() string { return "docker.daemon" }
 func (c DockerDaemon) Run(ctx context.Context) (doctor.Result, error) {
 	ctx, cancel := context.WithTimeout(ctx, 4*time.Second)
 	defer cancel()
-	cli, err := client.NewClientWithOpts(client.FromEnv, client.WithAPIVersionNegotiation())
+	cli, endpoint, err := backend.NewCurrentClient()
 	if err != nil {
-		return doctor.Result{ID: c.ID(), Status: doctor.Fail, Severity: "required", Message: "Docker client initialization failed"}, nil
+		return doctor.Result{ID: c.ID(), Status: doctor.Fail, Severity: "required", Message: "Container engine client initialization failed: " + err.Error()}, nil
 	}
 	defer cli.Close()
 
 	if _, err := cli.Ping(ctx); err != nil {
-		return doctor.Result{ID: c.ID(), Status: doctor.Fail, Severity: "required", Message: "Docker daemon unreachable. Start Docker Desktop or dockerd"}, nil
+		return doctor.Result{
+			ID: c.ID(), Status: doctor.Fail, Severity: "required",
+			Message: fmt.Sprintf("Container engine unreachable at %s", endpoint),
+			Hints:   unreachableHints(endpoint),
+		}, nil
 	}
-	sv, err := cli.ServerVersion(ctx)
+	info, err := backend.Inspect(ctx, cli, endpoint)
 	if err != nil {
 		return doctor.Result{ID: c.ID(), Status: doctor.Warn, Severity: "advisory", Message: "Cannot read Docker version"}, nil
 	}
-	
-	// Provide version info and compatibility hints
+
+	// Provide backend and version info and compatibility hints
 	details := map[string]interface{}{
-		"version": sv.Version,
-		"api": sv.APIVersion,
+		"backend": info.Engine,
+		"host":    endpointHost(endpoint),
+		"source":  endpoint.Source,
+		"version": info.Version,
+		"api":     info.APIVersion,
 	}
-	
+	if endpoint.Context != "" {
+		details["context"] = endpoint.Context
+	}
+
 	// Add recommendations based on version
 	var hints []string
-	if sv.Version != "" {
+	if info.Engine == config.BackendDocker && info.Version != "" {
 		// Parse major version for compatibility recommendations
-		if strings.HasPrefix(sv.Version, "20.") || strings.HasPrefix(sv.Version, "23.") {
+		if strings.HasPrefix(info.Version, "20.") || strings.HasPrefix(info.Version, "23.") {
 			hints = append(hints, "Consider updating to Docker 24.0.7+ for latest security fixes")
 		}
-		if !strings.Contains(sv.Version, "buildx") {
+		if !strings.Contains(info.Version, "buildx") {
 			hints = append(hints, "Consider enabling Docker BuildKit for faster builds")
 		}
 	}
-	
-	return doctor.Result{ID: c.ID(), Status: doctor.OK, Severity: "required", Details: details, Hints: hints}, nil
+
+	// Feature gaps don't stop nizam from working, but are worth knowing
+	status := doctor.OK
+	for _, gap := range info.Gaps {
+		status = doctor.Warn
+		hints = append(hints, fmt.Sprintf("No %s: %s", gap.Feature, gap.Reason))
+	}
+	if len(info.Gaps) > 0 {
+		details["gaps"] = info.Gaps
+	}
+
+	return doctor.Result{ID: c.ID(), Status: status, Severity: "required", Details: details, Hints: hints}, nil
+}
+
+func endpointHost(endpoint backend.Endpoint) string {
+	if endpoint.Host == "" {
+		return client.DefaultDockerHost
+	}
+	return endpoint.Host
+}
+
+func unreachableHints(endpoint backend.Endpoint) []string {
+	switch {
+	case endpoint.Type == config.BackendPodman:
+		return []string{"Start the Podman socket with 'systemctl --user enable --now podman.socket' or 'podman machine start'"}
+	case strings.HasPrefix(endpoint.Host, "ssh://"):
+		return []string{"Check that 'ssh " + strings.TrimPrefix(endpoint.Host, "ssh://") + " docker version' works without a password prompt"}
+	case endpoint.Context != "":
+		return []string{"Check the context with 'docker --context " + endpoint.Context + " version', or switch with 'docker context use'"}
+	default:
+		return []string{"Start Docker Desktop or dockerd"}
+	}
 }
 
 func (c DockerDaemon) Fix(context.Context) error { return errors.New("no automatic fix") }
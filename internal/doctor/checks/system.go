@@ -92,6 +92,7 @@ func (c PortInUse) Run(ctx context.Context) (doctor.Result, error) {
 			Message: "port in use", Details: map[string]any{"addr": addr},
 			Hints: []string{
 				fmt.Sprintf("Change host port for service %s in .nizam.yaml", c.Svc),
+				"Or let nizam pick a free one with 'auto:<container port>' or 'nizam up --port-strategy=next-free'",
 				"Or stop the process using the port",
 			},
 		}, nil
@@ -240,6 +240,11 @@ func getCheckDescription(c Result) string {
 	
 	switch c.ID {
 	case "docker.daemon":
+		if details, ok := c.Details.(map[string]interface{}); ok {
+			if engine, exists := details["backend"]; exists {
+				return fmt.Sprintf("Container engine connectivity: %v%s (API %v) at %v", engine, versionInfo, details["api"], details["host"])
+			}
+		}
 		return fmt.Sprintf("Docker daemon connectivity%s", versionInfo)
 	case "docker.compose":
 		return "Docker Compose plugin available"
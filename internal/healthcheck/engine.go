@@ -11,6 +11,7 @@ import (
 
 	"github.com/abdultolba/nizam/internal/config"
 	"github.com/abdultolba/nizam/internal/docker"
+	"github.com/abdultolba/nizam/internal/runtime"
 	"github.com/rs/zerolog/log"
 )
 
@@ -63,28 +64,31 @@ type ServiceHealthInfo struct {
 
 // Engine manages health checks for all services
 type Engine struct {
-	dockerClient *docker.Client
-	services     map[string]*ServiceHealthInfo
-	mutex        sync.RWMutex
-	ticker       *time.Ticker
-	stopChan     chan struct{}
-	config       *config.Config
+	rt       runtime.Runtime
+	project  string
+	services map[string]*ServiceHealthInfo
+	mutex    sync.RWMutex
+	ticker   *time.Ticker
+	stopChan chan struct{}
+	config   *config.Config
 }
 
 // NewEngine creates a new health check engine
-func NewEngine(dockerClient *docker.Client, cfg *config.Config) (*Engine, error) {
-	if dockerClient == nil {
-		return nil, fmt.Errorf("docker client is required")
+func NewEngine(rt runtime.Runtime, cfg *config.Config) (*Engine, error) {
+	if rt == nil {
+		return nil, fmt.Errorf("container runtime is required")
 	}
 	if cfg == nil {
 		return nil, fmt.Errorf("config is required")
 	}
 
 	return &Engine{
-		dockerClient: dockerClient,
-		services:     make(map[string]*ServiceHealthInfo),
-		stopChan:     make(chan struct{}),
-		config:       cfg,
+		rt: rt,
+		// Only consider containers that belong to the config's project
+		project:  cfg.ProjectName(),
+		services: make(map[string]*ServiceHealthInfo),
+		stopChan: make(chan struct{}),
+		config:   cfg,
 	}, nil
 }
 
@@ -129,7 +133,7 @@ func (e *Engine) healthCheckLoop(ctx context.Context) {
 // checkAllServices performs health checks on all configured services
 func (e *Engine) checkAllServices(ctx context.Context) {
 	// Get current container status
-	containers, err := e.dockerClient.GetServiceStatus(ctx)
+	containers, err := docker.ListServices(ctx, e.rt, e.project)
 	if err != nil {
 		log.Error().Err(err).Msg("Failed to get service status for health checks")
 		return
@@ -268,28 +272,30 @@ func (e *Engine) performCommandHealthCheck(ctx context.Context, serviceName stri
 
 	// If we have container info, execute command inside the container
 	if containerInfo != nil {
-		// For now, we'll use docker exec to run the health check
-		// This could be enhanced to use the Docker API directly
-		dockerCmd := []string{"docker", "exec", containerInfo.Name}
-		dockerCmd = append(dockerCmd, command...)
+		execResult, err := e.rt.ExecCommand(ctx, containerInfo.Name, command)
 
-		cmd := exec.CommandContext(ctx, dockerCmd[0], dockerCmd[1:]...)
-		output, err := cmd.CombinedOutput()
-
-		if err != nil {
+		switch {
+		case err != nil:
 			result.Status = HealthStatusUnhealthy
 			result.Message = fmt.Sprintf("Command failed: %v", err)
 			result.Error = err
 			result.Details = map[string]interface{}{
 				"command": command,
-				"output":  string(output),
 			}
-		} else {
+		case execResult.ExitCode != 0:
+			result.Status = HealthStatusUnhealthy
+			result.Message = fmt.Sprintf("Command failed: exit code %d", execResult.ExitCode)
+			result.Error = fmt.Errorf("command exited with code %d", execResult.ExitCode)
+			result.Details = map[string]interface{}{
+				"command": command,
+				"output":  execResult.Stdout + execResult.Stderr,
+			}
+		default:
 			result.Status = HealthStatusHealthy
 			result.Message = "Command executed successfully"
 			result.Details = map[string]interface{}{
 				"command": command,
-				"output":  string(output),
+				"output":  execResult.Stdout + execResult.Stderr,
 			}
 		}
 	} else {
@@ -457,7 +463,7 @@ func (e *Engine) CheckServiceNow(ctx context.Context, serviceName string) (*Heal
 	}
 
 	// Get container info
-	containers, err := e.dockerClient.GetServiceStatus(ctx)
+	containers, err := docker.ListServices(ctx, e.rt, e.project)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get container status: %w", err)
 	}
@@ -539,9 +545,9 @@ func (e *Engine) GetHealthSummary() map[string]interface{} {
 	return summary
 }
 
-// GetDockerClient returns the Docker client
-func (e *Engine) GetDockerClient() *docker.Client {
-	return e.dockerClient
+// GetRuntime returns the container runtime
+func (e *Engine) GetRuntime() runtime.Runtime {
+	return e.rt
 }
 
 // GetConfig returns the configuration
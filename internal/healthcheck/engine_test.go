@@ -0,0 +1,105 @@
+package healthcheck
+
+import (
+	"context"
+	"testing"
+
+	"github.com/abdultolba/nizam/internal/config"
+	"github.com/abdultolba/nizam/internal/runtime"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestEngine(t *testing.T, fake *runtime.Fake) *Engine {
+	t.Helper()
+
+	cfg := &config.Config{
+		Project: "app",
+		Services: map[string]config.Service{
+			"postgres": {
+				Image: "postgres:16",
+				HealthCheck: &config.HealthCheck{
+					Test: []string{"CMD-SHELL", "pg_isready", "-U", "user"},
+				},
+			},
+			"redis": {Image: "redis:7"},
+		},
+	}
+
+	engine, err := NewEngine(fake, cfg)
+	require.NoError(t, err)
+	return engine
+}
+
+func addServiceContainer(fake *runtime.Fake, service, state string) {
+	fake.AddContainer(runtime.Container{
+		Name:  "nizam_app_" + service,
+		State: state,
+		Labels: map[string]string{
+			"nizam.managed": "true",
+			"nizam.project": "app",
+			"nizam.service": service,
+		},
+	})
+}
+
+func TestEngine_CommandCheckRunsInContainer(t *testing.T) {
+	fake := runtime.NewFake()
+	addServiceContainer(fake, "postgres", "")
+	engine := newTestEngine(t, fake)
+
+	exitCode := 0
+	fake.HandleExec("pg_isready", func(runtime.ExecCall) runtime.ExecResult {
+		return runtime.ExecResult{ExitCode: exitCode, Stdout: "accepting connections"}
+	})
+
+	result, err := engine.CheckServiceNow(context.Background(), "postgres")
+	require.NoError(t, err)
+	assert.Equal(t, HealthStatusHealthy, result.Status)
+	assert.Equal(t, HealthCheckTypeCommand, result.CheckType)
+
+	require.Len(t, fake.Execs, 1)
+	assert.Equal(t, "nizam_app_postgres", fake.Execs[0].Container)
+	assert.Equal(t, []string{"pg_isready", "-U", "user"}, fake.Execs[0].Cmd)
+
+	exitCode = 1
+	result, err = engine.CheckServiceNow(context.Background(), "postgres")
+	require.NoError(t, err)
+	assert.Equal(t, HealthStatusUnhealthy, result.Status)
+}
+
+func TestEngine_DefaultCheck(t *testing.T) {
+	fake := runtime.NewFake()
+	addServiceContainer(fake, "redis", "")
+	addServiceContainer(fake, "postgres", "exited")
+	engine := newTestEngine(t, fake)
+
+	engine.checkAllServices(context.Background())
+
+	redis, ok := engine.GetServiceHealth("redis")
+	require.True(t, ok)
+	assert.Equal(t, HealthStatusHealthy, redis.Status)
+	assert.True(t, redis.IsRunning)
+
+	postgres, ok := engine.GetServiceHealth("postgres")
+	require.True(t, ok)
+	assert.Equal(t, HealthStatusNotRunning, postgres.Status)
+	assert.Empty(t, fake.Execs)
+}
+
+func TestEngine_IgnoresOtherProjects(t *testing.T) {
+	fake := runtime.NewFake()
+	fake.AddContainer(runtime.Container{
+		Name: "nizam_other_redis",
+		Labels: map[string]string{
+			"nizam.managed": "true",
+			"nizam.project": "other",
+			"nizam.service": "redis",
+		},
+	})
+	engine := newTestEngine(t, fake)
+
+	result, err := engine.CheckServiceNow(context.Background(), "redis")
+	require.NoError(t, err)
+	assert.Equal(t, HealthStatusNotRunning, result.Status)
+}
@@ -26,6 +26,9 @@ func (r *Report) Add(f Finding) {
 
 func NoLatest(cfg *config.Config) (rep Report) {
 	for name, s := range cfg.Services {
+		if s.Build != nil {
+			continue // built locally and tagged by context hash
+		}
 		if s.Image == "" || !strings.Contains(s.Image, ":") || strings.HasSuffix(s.Image, ":latest") {
 			rep.Add(Finding{
 				Rule:       "no-latest",
@@ -40,7 +43,7 @@ func NoLatest(cfg *config.Config) (rep Report) {
 }
 
 func PortsShape(cfg *config.Config) (rep Report) {
-	re := regexp.MustCompile(`^[0-9]{2,5}:[0-9]{2,5}$`)
+	re := regexp.MustCompile(`^([0-9]{2,5}|auto):[0-9]{2,5}$`)
 	for name, s := range cfg.Services {
 		for i, p := range s.Ports {
 			if !re.MatchString(p) {
@@ -48,7 +51,7 @@ func PortsShape(cfg *config.Config) (rep Report) {
 					Rule:       "ports-shape",
 					Path:       fmt.Sprintf("services.%s.ports[%d]", name, i),
 					Severity:   "error",
-					Message:    "port mapping must be 'host:container' (no ranges)",
+					Message:    "port mapping must be 'host:container' or 'auto:container' (no ranges)",
 					Suggestion: "use single mapping like '8080:80'",
 				})
 			}
@@ -58,9 +61,11 @@ func PortsShape(cfg *config.Config) (rep Report) {
 }
 
 func LimitsRecommended(cfg *config.Config) (rep Report) {
-	// Example policy: encourage resource limits (not shown in structs)
-	// Treat as warning for now.
-	for name := range cfg.Services {
+	// Encourage CPU and memory limits; treat as warning only
+	for name, s := range cfg.Services {
+		if s.Resources.HasLimits() {
+			continue
+		}
 		rep.Add(Finding{
 			Rule:       "limits",
 			Path:       "services." + name,
@@ -72,6 +77,34 @@ func LimitsRecommended(cfg *config.Config) (rep Report) {
 	return
 }
 
+func LockStale(cfg *config.Config) (rep Report) {
+	// A project without a lockfile has opted out of digest pinning
+	lock, err := cfg.LoadLock()
+	if err != nil {
+		rep.Add(Finding{
+			Rule:       "lock-stale",
+			Path:       config.LockFileName,
+			Severity:   "warn",
+			Message:    err.Error(),
+			Suggestion: "run 'nizam lock' to regenerate it",
+		})
+		return
+	}
+	if lock == nil {
+		return
+	}
+	for _, entry := range lock.StaleEntries(cfg) {
+		rep.Add(Finding{
+			Rule:       "lock-stale",
+			Path:       "services." + entry.Service + ".image",
+			Severity:   "warn",
+			Message:    "lockfile is out of date: " + entry.Reason,
+			Suggestion: "run 'nizam lock' to update .nizam.lock",
+		})
+	}
+	return
+}
+
 func Combine(reps ...Report) Report {
 	out := Report{}
 	for _, r := range reps {
@@ -0,0 +1,459 @@
+// Package migrate applies versioned migration files to database services
+// through the engine's client inside the container and records the applied
+// versions in a bookkeeping table of the database.
+package migrate
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/abdultolba/nizam/internal/query"
+	"github.com/abdultolba/nizam/internal/resolve"
+	"github.com/abdultolba/nizam/internal/runtime"
+	"github.com/rs/zerolog/log"
+)
+
+// Table is the table (or MongoDB collection) recording applied migrations
+const Table = "nizam_migrations"
+
+// scriptDir is where migration files are copied in the container
+const scriptDir = "/tmp"
+
+// Migration is a versioned change with an up file and an optional down file
+type Migration struct {
+	Version  uint64
+	Name     string
+	UpFile   string
+	DownFile string
+}
+
+// String returns the migration as "<version>_<name>"
+func (m Migration) String() string {
+	return fmt.Sprintf("%d_%s", m.Version, m.Name)
+}
+
+// Status describes a migration and whether it has been applied. Migrations
+// recorded in the database whose files are missing have no UpFile.
+type Status struct {
+	Migration
+	Applied   bool
+	AppliedAt string
+}
+
+// fileName matches migration files: 0001_create_users.sql,
+// 0001_create_users.up.sql or 0001_create_users.down.sql
+var fileName = regexp.MustCompile(`^(\d+)_(.+?)(\.up|\.down)?\.(sql|js)$`)
+
+// Extension returns the migration file extension used for an engine
+func Extension(engine string) (string, error) {
+	switch engine {
+	case "postgres", "mysql":
+		return "sql", nil
+	case "mongo":
+		return "js", nil
+	}
+	return "", fmt.Errorf("migrations are not supported for engine '%s'", engine)
+}
+
+// Load reads the migrations for an engine from dir, ordered by version.
+// Files for other engines are ignored.
+func Load(dir, engine string) ([]Migration, error) {
+	ext, err := Extension(engine)
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read migrations directory: %w", err)
+	}
+
+	byVersion := make(map[uint64]*Migration)
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		match := fileName.FindStringSubmatch(entry.Name())
+		if match == nil || match[4] != ext {
+			continue
+		}
+
+		version, err := strconv.ParseUint(match[1], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid migration version in %s: %w", entry.Name(), err)
+		}
+
+		m, exists := byVersion[version]
+		if !exists {
+			m = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = m
+		} else if m.Name != match[2] {
+			return nil, fmt.Errorf("migration version %d is used by both %s and %s", version, m.Name, match[2])
+		}
+
+		path := filepath.Join(dir, entry.Name())
+		if match[3] == ".down" {
+			if m.DownFile != "" {
+				return nil, fmt.Errorf("duplicate down migration for version %d", version)
+			}
+			m.DownFile = path
+		} else {
+			if m.UpFile != "" {
+				return nil, fmt.Errorf("duplicate up migration for version %d", version)
+			}
+			m.UpFile = path
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.UpFile == "" {
+			return nil, fmt.Errorf("migration %s has a down file but no up file", m)
+		}
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+	return migrations, nil
+}
+
+// Migrator applies the migrations of a directory to a service
+type Migrator struct {
+	rt         runtime.Runtime
+	service    resolve.ServiceInfo
+	migrations []Migration
+}
+
+// New loads the migrations in dir for the service's engine
+func New(rt runtime.Runtime, service resolve.ServiceInfo, dir string) (*Migrator, error) {
+	migrations, err := Load(dir, service.Engine)
+	if err != nil {
+		return nil, err
+	}
+	return &Migrator{rt: rt, service: service, migrations: migrations}, nil
+}
+
+// Migrations returns the migrations found in the directory
+func (m *Migrator) Migrations() []Migration {
+	return m.migrations
+}
+
+// WaitReady waits until the database accepts the bookkeeping setup, for use
+// right after the container started
+func (m *Migrator) WaitReady(ctx context.Context, interval time.Duration) error {
+	for {
+		err := m.ensureTable(ctx)
+		if err == nil {
+			return nil
+		}
+		log.Debug().Err(err).Str("service", m.service.Name).Msg("Database not ready for migrations")
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("database not ready: %w", err)
+		case <-time.After(interval):
+		}
+	}
+}
+
+// Status lists every migration in the directory and every applied one, by
+// version
+func (m *Migrator) Status(ctx context.Context) ([]Status, error) {
+	if err := m.ensureTable(ctx); err != nil {
+		return nil, err
+	}
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var statuses []Status
+	seen := make(map[uint64]bool)
+	for _, migration := range m.migrations {
+		status := Status{Migration: migration}
+		if record, ok := applied[migration.Version]; ok {
+			status.Applied = true
+			status.AppliedAt = record.AppliedAt
+		}
+		statuses = append(statuses, status)
+		seen[migration.Version] = true
+	}
+	for version, record := range applied {
+		if !seen[version] {
+			statuses = append(statuses, record)
+		}
+	}
+
+	sort.Slice(statuses, func(i, j int) bool {
+		return statuses[i].Version < statuses[j].Version
+	})
+	return statuses, nil
+}
+
+// Up applies every pending migration in version order, calling onApply
+// before each, and returns the ones applied. It stops at the first failure.
+func (m *Migrator) Up(ctx context.Context, onApply func(Migration)) ([]Migration, error) {
+	statuses, err := m.Status(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var done []Migration
+	for _, status := range statuses {
+		if status.Applied {
+			continue
+		}
+		if onApply != nil {
+			onApply(status.Migration)
+		}
+		if err := m.apply(ctx, status.Migration, true); err != nil {
+			return done, err
+		}
+		done = append(done, status.Migration)
+	}
+	return done, nil
+}
+
+// Down reverts the n most recently applied migrations, newest first,
+// calling onRevert before each, and returns the ones reverted
+func (m *Migrator) Down(ctx context.Context, n int, onRevert func(Migration)) ([]Migration, error) {
+	statuses, err := m.Status(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var done []Migration
+	for i := len(statuses) - 1; i >= 0 && len(done) < n; i-- {
+		status := statuses[i]
+		if !status.Applied {
+			continue
+		}
+		if status.UpFile == "" {
+			return done, fmt.Errorf("migration %s is applied but its files are missing", status.Migration)
+		}
+		if status.DownFile == "" {
+			return done, fmt.Errorf("migration %s has no down file", status.Migration)
+		}
+		if onRevert != nil {
+			onRevert(status.Migration)
+		}
+		if err := m.apply(ctx, status.Migration, false); err != nil {
+			return done, err
+		}
+		done = append(done, status.Migration)
+	}
+	return done, nil
+}
+
+// Redo reverts the most recently applied migration and applies it again
+func (m *Migrator) Redo(ctx context.Context, onStep func(Migration, bool)) (Migration, error) {
+	reverted, err := m.Down(ctx, 1, func(migration Migration) {
+		if onStep != nil {
+			onStep(migration, false)
+		}
+	})
+	if err != nil {
+		return Migration{}, err
+	}
+	if len(reverted) == 0 {
+		return Migration{}, fmt.Errorf("no applied migrations to redo")
+	}
+
+	migration := reverted[0]
+	if onStep != nil {
+		onStep(migration, true)
+	}
+	return migration, m.apply(ctx, migration, true)
+}
+
+// apply runs a migration's up or down file together with the bookkeeping
+// change. On PostgreSQL both happen in one transaction.
+func (m *Migrator) apply(ctx context.Context, migration Migration, up bool) error {
+	file, direction := migration.UpFile, "up"
+	if !up {
+		file, direction = migration.DownFile, "down"
+	}
+
+	script, err := os.ReadFile(file)
+	if err != nil {
+		return fmt.Errorf("failed to read migration %s: %w", migration, err)
+	}
+	script = append(script, '\n')
+	script = append(script, m.record(migration, up)...)
+	script = append(script, '\n')
+
+	ext, _ := Extension(m.service.Engine)
+	path := fmt.Sprintf("%s/nizam-migration-%d.%s", scriptDir, migration.Version, ext)
+	if err := runtime.WriteFile(ctx, m.rt, m.service.Container, path, script, 0644); err != nil {
+		return fmt.Errorf("failed to copy migration %s to container: %w", migration, err)
+	}
+	defer func() {
+		if _, err := m.rt.ExecCommand(ctx, m.service.Container, []string{"rm", "-f", path}); err != nil {
+			log.Debug().Err(err).Str("file", path).Msg("Failed to remove migration file")
+		}
+	}()
+
+	cmd := m.command(path)
+	log.Debug().
+		Str("container", m.service.Container).
+		Str("migration", migration.String()).
+		Str("direction", direction).
+		Msg("Applying migration")
+
+	result, err := m.rt.ExecCommand(ctx, m.service.Container, cmd)
+	if err != nil {
+		return fmt.Errorf("failed to execute %s: %w", cmd[0], err)
+	}
+	if result.ExitCode != 0 {
+		message := strings.TrimSpace(result.Stderr)
+		if message == "" {
+			message = strings.TrimSpace(result.Stdout)
+		}
+		return fmt.Errorf("migration %s (%s) failed: %s", migration, direction, message)
+	}
+	return nil
+}
+
+// command builds the client command that runs a script file in the
+// container
+func (m *Migrator) command(path string) []string {
+	service := m.service
+	switch service.Engine {
+	case "postgres":
+		return []string{
+			"psql",
+			"-U", service.User,
+			"-d", service.Database,
+			"-X", "-q",
+			"-v", "ON_ERROR_STOP=1",
+			"--single-transaction",
+			"-f", path,
+		}
+	case "mysql":
+		cmd := []string{"mysql", "-u", service.User, "-h", "localhost"}
+		if service.Password != "" {
+			cmd = append(cmd, fmt.Sprintf("-p%s", service.Password))
+		}
+		return append(cmd, "-e", "source "+path, service.Database)
+	default: // mongo
+		cmd := []string{"mongosh", "--quiet", "--host", "localhost:27017"}
+		if service.User != "" {
+			cmd = append(cmd, "--username", service.User, "--authenticationDatabase", "admin")
+		}
+		if service.Password != "" {
+			cmd = append(cmd, "--password", service.Password)
+		}
+		return append(cmd, service.Database, "--file", path)
+	}
+}
+
+// record returns the statement that records a migration as applied, or
+// removes the record when it is reverted
+func (m *Migrator) record(migration Migration, up bool) string {
+	if m.service.Engine == "mongo" {
+		name, _ := json.Marshal(migration.Name)
+		if up {
+			return fmt.Sprintf("db.getCollection('%s').insertOne({version: %d, name: %s, applied_at: new Date()});",
+				Table, migration.Version, name)
+		}
+		return fmt.Sprintf("db.getCollection('%s').deleteOne({version: %d});", Table, migration.Version)
+	}
+
+	if up {
+		return fmt.Sprintf("INSERT INTO %s (version, name) VALUES (%d, '%s');",
+			Table, migration.Version, strings.ReplaceAll(migration.Name, "'", "''"))
+	}
+	return fmt.Sprintf("DELETE FROM %s WHERE version = %d;", Table, migration.Version)
+}
+
+// ensureTable creates the bookkeeping table if it does not exist. MongoDB
+// creates the collection on first insert.
+func (m *Migrator) ensureTable(ctx context.Context) error {
+	var statement string
+	switch m.service.Engine {
+	case "postgres":
+		statement = "CREATE TABLE IF NOT EXISTS " + Table +
+			" (version BIGINT PRIMARY KEY, name TEXT NOT NULL, applied_at TIMESTAMPTZ NOT NULL DEFAULT now())"
+	case "mysql":
+		statement = "CREATE TABLE IF NOT EXISTS " + Table +
+			" (version BIGINT UNSIGNED PRIMARY KEY, name VARCHAR(255) NOT NULL, applied_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP)"
+	case "mongo":
+		statement = "db.runCommand({ping: 1})"
+	default:
+		_, err := Extension(m.service.Engine)
+		return err
+	}
+
+	if _, err := query.Run(ctx, m.rt, m.service, statement); err != nil {
+		return fmt.Errorf("failed to create %s: %w", Table, err)
+	}
+	return nil
+}
+
+// applied returns the recorded migrations by version
+func (m *Migrator) applied(ctx context.Context) (map[uint64]Status, error) {
+	statement := "SELECT version, name, applied_at FROM " + Table + " ORDER BY version"
+	if m.service.Engine == "mongo" {
+		statement = fmt.Sprintf("db.getCollection('%s').find({}, {_id: 0, version: 1, name: 1, applied_at: 1}).sort({version: 1})", Table)
+	}
+
+	result, err := query.Run(ctx, m.rt, m.service, statement)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", Table, err)
+	}
+
+	columns := make(map[string]int)
+	for i, column := range result.Columns {
+		columns[column] = i
+	}
+	value := func(row []any, column string) string {
+		i, ok := columns[column]
+		if !ok || i >= len(row) {
+			return ""
+		}
+		return stringValue(row[i])
+	}
+
+	applied := make(map[uint64]Status, len(result.Rows))
+	for _, row := range result.Rows {
+		version, err := strconv.ParseUint(value(row, "version"), 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid version in %s: %w", Table, err)
+		}
+		applied[version] = Status{
+			Migration: Migration{Version: version, Name: value(row, "name")},
+			Applied:   true,
+			AppliedAt: value(row, "applied_at"),
+		}
+	}
+	return applied, nil
+}
+
+// stringValue formats a query value, unwrapping MongoDB extended JSON such
+// as {"$date": "..."}
+func stringValue(value any) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case json.Number:
+		return v.String()
+	case map[string]any:
+		for _, key := range []string{"$date", "$numberLong"} {
+			if inner, ok := v[key]; ok {
+				return stringValue(inner)
+			}
+		}
+	}
+	return fmt.Sprint(value)
+}
@@ -0,0 +1,244 @@
+package migrate
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/abdultolba/nizam/internal/resolve"
+	"github.com/abdultolba/nizam/internal/runtime"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testPostgresService = resolve.ServiceInfo{
+	Name:      "postgres",
+	Engine:    "postgres",
+	User:      "user",
+	Database:  "app",
+	Container: "nizam_test_postgres",
+}
+
+func writeMigrations(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0644))
+	}
+	return dir
+}
+
+var (
+	insertPattern = regexp.MustCompile(`INSERT INTO nizam_migrations \(version, name\) VALUES \((\d+), '(.*)'\);`)
+	deletePattern = regexp.MustCompile(`DELETE FROM nizam_migrations WHERE version = (\d+);`)
+)
+
+// newPostgresFake returns a fake whose psql keeps the bookkeeping table in
+// applied, and fails scripts containing FAIL
+func newPostgresFake(applied map[uint64]string) *runtime.Fake {
+	fake := runtime.NewFake()
+	fake.AddContainer(runtime.Container{Name: testPostgresService.Container})
+	fake.HandleExec("psql", func(call runtime.ExecCall) runtime.ExecResult {
+		args := call.Cmd
+		last := args[len(args)-1]
+		switch {
+		case strings.HasPrefix(last, "SELECT version"):
+			versions := make([]uint64, 0, len(applied))
+			for version := range applied {
+				versions = append(versions, version)
+			}
+			sort.Slice(versions, func(i, j int) bool { return versions[i] < versions[j] })
+
+			out := "version,name,applied_at\n"
+			for _, version := range versions {
+				out += fmt.Sprintf("%d,%s,2025-01-01 00:00:00+00\n", version, applied[version])
+			}
+			return runtime.ExecResult{Stdout: out}
+
+		case args[len(args)-2] == "-f":
+			script, _ := fake.ReadFile(call.Container, last)
+			if strings.Contains(string(script), "FAIL") {
+				return runtime.ExecResult{ExitCode: 3, Stderr: "ERROR:  syntax error at or near \"FAIL\""}
+			}
+			if match := insertPattern.FindSubmatch(script); match != nil {
+				version, _ := strconv.ParseUint(string(match[1]), 10, 64)
+				applied[version] = string(match[2])
+			}
+			if match := deletePattern.FindSubmatch(script); match != nil {
+				version, _ := strconv.ParseUint(string(match[1]), 10, 64)
+				delete(applied, version)
+			}
+		}
+		return runtime.ExecResult{}
+	})
+	return fake
+}
+
+func TestLoad(t *testing.T) {
+	dir := writeMigrations(t, map[string]string{
+		"0002_add_index.sql":         "CREATE INDEX users_email ON users (email);",
+		"0001_create_users.up.sql":   "CREATE TABLE users (id int);",
+		"0001_create_users.down.sql": "DROP TABLE users;",
+		"0010_seed.js":               "db.users.insertOne({})",
+		"README.md":                  "notes",
+	})
+
+	migrations, err := Load(dir, "postgres")
+	require.NoError(t, err)
+	require.Len(t, migrations, 2)
+	assert.Equal(t, uint64(1), migrations[0].Version)
+	assert.Equal(t, "create_users", migrations[0].Name)
+	assert.NotEmpty(t, migrations[0].DownFile)
+	assert.Equal(t, "2_add_index", migrations[1].String())
+	assert.Empty(t, migrations[1].DownFile)
+
+	migrations, err = Load(dir, "mongo")
+	require.NoError(t, err)
+	require.Len(t, migrations, 1)
+	assert.Equal(t, uint64(10), migrations[0].Version)
+
+	_, err = Load(dir, "redis")
+	assert.Error(t, err)
+}
+
+func TestLoad_Invalid(t *testing.T) {
+	dir := writeMigrations(t, map[string]string{
+		"0001_create_users.sql": "",
+		"0001_create_posts.sql": "",
+	})
+	_, err := Load(dir, "postgres")
+	assert.ErrorContains(t, err, "version 1")
+
+	dir = writeMigrations(t, map[string]string{"0001_create_users.down.sql": ""})
+	_, err = Load(dir, "postgres")
+	assert.ErrorContains(t, err, "no up file")
+}
+
+func TestMigrator_UpDownRedo(t *testing.T) {
+	ctx := context.Background()
+	dir := writeMigrations(t, map[string]string{
+		"0001_create_users.up.sql":   "CREATE TABLE users (id int);",
+		"0001_create_users.down.sql": "DROP TABLE users;",
+		"0002_add_email.up.sql":      "ALTER TABLE users ADD email text;",
+		"0002_add_email.down.sql":    "ALTER TABLE users DROP email;",
+		"0003_seed.sql":              "INSERT INTO users VALUES (1);",
+	})
+
+	applied := map[uint64]string{1: "create_users"}
+	fake := newPostgresFake(applied)
+	migrator, err := New(fake, testPostgresService, dir)
+	require.NoError(t, err)
+
+	statuses, err := migrator.Status(ctx)
+	require.NoError(t, err)
+	require.Len(t, statuses, 3)
+	assert.True(t, statuses[0].Applied)
+	assert.Equal(t, "2025-01-01 00:00:00+00", statuses[0].AppliedAt)
+	assert.False(t, statuses[1].Applied)
+
+	var order []string
+	done, err := migrator.Up(ctx, func(m Migration) { order = append(order, m.String()) })
+	require.NoError(t, err)
+	assert.Len(t, done, 2)
+	assert.Equal(t, []string{"2_add_email", "3_seed"}, order)
+	assert.Equal(t, map[uint64]string{1: "create_users", 2: "add_email", 3: "seed"}, applied)
+
+	// The migration and its bookkeeping run in one transaction
+	var applyCall runtime.ExecCall
+	for _, call := range fake.Execs {
+		if strings.Contains(call.Command(), "nizam-migration-2.sql") && call.Cmd[0] == "psql" {
+			applyCall = call
+		}
+	}
+	assert.Contains(t, applyCall.Cmd, "--single-transaction")
+	script, _ := fake.ReadFile(testPostgresService.Container, "/tmp/nizam-migration-2.sql")
+	assert.Equal(t, "ALTER TABLE users ADD email text;\nINSERT INTO nizam_migrations (version, name) VALUES (2, 'add_email');\n", string(script))
+
+	// 0003 has no down file
+	_, err = migrator.Down(ctx, 1, nil)
+	assert.ErrorContains(t, err, "no down file")
+
+	delete(applied, 3)
+	done, err = migrator.Down(ctx, 5, nil)
+	require.NoError(t, err)
+	assert.Len(t, done, 2)
+	assert.Empty(t, applied)
+
+	applied[1] = "create_users"
+	migration, err := migrator.Redo(ctx, nil)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(1), migration.Version)
+	assert.Equal(t, map[uint64]string{1: "create_users"}, applied)
+	script, _ = fake.ReadFile(testPostgresService.Container, "/tmp/nizam-migration-1.sql")
+	assert.Contains(t, string(script), "INSERT INTO nizam_migrations")
+}
+
+func TestMigrator_UpStopsOnFailure(t *testing.T) {
+	ctx := context.Background()
+	dir := writeMigrations(t, map[string]string{
+		"0001_ok.sql":     "SELECT 1;",
+		"0002_broken.sql": "FAIL;",
+		"0003_later.sql":  "SELECT 3;",
+	})
+
+	applied := map[uint64]string{}
+	migrator, err := New(newPostgresFake(applied), testPostgresService, dir)
+	require.NoError(t, err)
+
+	done, err := migrator.Up(ctx, nil)
+	assert.ErrorContains(t, err, "2_broken")
+	assert.ErrorContains(t, err, "syntax error")
+	assert.Len(t, done, 1)
+	assert.Equal(t, map[uint64]string{1: "ok"}, applied)
+}
+
+func TestMigrator_StatusMissingFile(t *testing.T) {
+	dir := writeMigrations(t, map[string]string{"0001_ok.sql": "SELECT 1;"})
+	migrator, err := New(newPostgresFake(map[uint64]string{1: "ok", 7: "gone"}), testPostgresService, dir)
+	require.NoError(t, err)
+
+	statuses, err := migrator.Status(context.Background())
+	require.NoError(t, err)
+	require.Len(t, statuses, 2)
+	assert.Equal(t, uint64(7), statuses[1].Version)
+	assert.True(t, statuses[1].Applied)
+	assert.Empty(t, statuses[1].UpFile)
+
+	_, err = migrator.Down(context.Background(), 1, nil)
+	assert.ErrorContains(t, err, "files are missing")
+}
+
+func TestMigrator_Mongo(t *testing.T) {
+	dir := writeMigrations(t, map[string]string{"0001_users.js": `db.createCollection("users")`})
+	service := resolve.ServiceInfo{Engine: "mongo", User: "root", Password: "secret", Database: "app", Container: "nizam_test_mongo"}
+
+	fake := runtime.NewFake()
+	fake.AddContainer(runtime.Container{Name: service.Container})
+	fake.HandleExec("mongosh", func(call runtime.ExecCall) runtime.ExecResult {
+		if strings.Contains(call.Command(), ".find(") {
+			return runtime.ExecResult{Stdout: "[]"}
+		}
+		return runtime.ExecResult{Stdout: "{}"}
+	})
+
+	migrator, err := New(fake, service, dir)
+	require.NoError(t, err)
+	done, err := migrator.Up(context.Background(), nil)
+	require.NoError(t, err)
+	assert.Len(t, done, 1)
+
+	script, ok := fake.ReadFile(service.Container, "/tmp/nizam-migration-1.js")
+	require.True(t, ok)
+	assert.Contains(t, string(script), `insertOne({version: 1, name: "users", applied_at: new Date()})`)
+}
+
+func TestStringValue(t *testing.T) {
+	assert.Equal(t, "2025-01-01T00:00:00Z", stringValue(map[string]any{"$date": "2025-01-01T00:00:00Z"}))
+	assert.Equal(t, "", stringValue(nil))
+}
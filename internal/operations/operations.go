@@ -1,106 +1,395 @@
 package operations
 
 import (
+	"context"
 	"fmt"
-	"os/exec"
+	"sort"
 	"strings"
+	"sync"
+	"time"
+
+	"github.com/abdultolba/nizam/internal/config"
+	"github.com/abdultolba/nizam/internal/docker"
+)
+
+// Status values reported through Event
+const (
+	StatusRunning = "running"
+	StatusWaiting = "waiting"
+	// StatusProgress carries a line of image pull or build output
+	StatusProgress = "progress"
+	StatusDone     = "done"
+	StatusSkipped  = "skipped"
+	StatusFailed   = "failed"
 )
 
-// Start starts the specified services or all services if none specified
-func Start(serviceNames []string) error {
-	args := []string{"compose", "up", "-d"}
-	if len(serviceNames) > 0 {
-		args = append(args, serviceNames...)
+// Event reports the progress of an operation on a single service
+type Event struct {
+	Service string
+	Status  string
+	Message string
+	Err     error
+}
+
+// ServiceError is the error of an operation on a single service
+type ServiceError struct {
+	Service string
+	Err     error
+}
+
+func (e ServiceError) Error() string {
+	return fmt.Sprintf("%s: %v", e.Service, e.Err)
+}
+
+func (e ServiceError) Unwrap() error {
+	return e.Err
+}
+
+// Errors aggregates the per-service failures of an operation
+type Errors []ServiceError
+
+func (e Errors) Error() string {
+	messages := make([]string, len(e))
+	for i, err := range e {
+		messages[i] = err.Error()
 	}
+	return fmt.Sprintf("%d service(s) failed: %s", len(e), strings.Join(messages, "; "))
+}
 
-	cmd := exec.Command("docker", args...)
-	output, err := cmd.CombinedOutput()
-	if err != nil {
-		return fmt.Errorf("docker compose up failed: %w\nOutput: %s", err, output)
+// StartOptions controls how services are started
+type StartOptions struct {
+	// WaitForDeps starts dependencies first and waits for their depends_on
+	// conditions before starting dependents
+	WaitForDeps bool
+	// WaitTimeout bounds the wait for each dependency condition
+	WaitTimeout time.Duration
+	// Recreate controls whether existing containers are replaced
+	Recreate docker.RecreatePolicy
+	// PortStrategy decides what happens when a host port is taken
+	PortStrategy string
+	// AdoptLegacy takes over containers and volumes created before project
+	// scoping
+	AdoptLegacy bool
+}
+
+// Runner performs lifecycle operations on the services of a config
+type Runner struct {
+	docker *docker.Client
+	cfg    *config.Config
+
+	// Progress, if set, receives an event whenever a service changes state.
+	// It may be called from several goroutines at once.
+	Progress func(Event)
+}
+
+// NewRunner creates a runner for the services in cfg
+func NewRunner(dockerClient *docker.Client, cfg *config.Config) *Runner {
+	return &Runner{docker: dockerClient, cfg: cfg}
+}
+
+func (r *Runner) report(service, status, message string, err error) {
+	if r.Progress != nil {
+		r.Progress(Event{Service: service, Status: status, Message: message, Err: err})
 	}
-	return nil
 }
 
-// Stop stops the specified services or all services if none specified
-func Stop(serviceNames []string) error {
-	args := []string{"compose", "stop"}
-	if len(serviceNames) > 0 {
-		args = append(args, serviceNames...)
+// progressFor forwards docker pull and build output as progress events
+func (r *Runner) progressFor(service string) docker.ProgressFunc {
+	if r.Progress == nil {
+		return nil
 	}
+	return func(message string) {
+		r.report(service, StatusProgress, message, nil)
+	}
+}
 
-	cmd := exec.Command("docker", args...)
-	output, err := cmd.CombinedOutput()
-	if err != nil {
-		return fmt.Errorf("docker compose stop failed: %w\nOutput: %s", err, output)
+// resolveServices validates the given service names, defaulting to all
+// configured services in sorted order
+func (r *Runner) resolveServices(serviceNames []string) ([]string, error) {
+	if len(serviceNames) == 0 {
+		names := r.cfg.GetServiceNames()
+		sort.Strings(names)
+		return names, nil
 	}
-	return nil
+	for _, name := range serviceNames {
+		if _, exists := r.cfg.GetService(name); !exists {
+			return nil, fmt.Errorf("service '%s' not found in configuration", name)
+		}
+	}
+	return serviceNames, nil
 }
 
-// Restart restarts the specified services or all services if none specified
-func Restart(serviceNames []string) error {
-	args := []string{"compose", "restart"}
-	if len(serviceNames) > 0 {
-		args = append(args, serviceNames...)
+// StartOrder returns the services Start acts on, in dependency order
+func (r *Runner) StartOrder(serviceNames []string, opts StartOptions) ([]string, error) {
+	if !opts.WaitForDeps {
+		return r.resolveServices(serviceNames)
 	}
+	return r.cfg.StartOrder(serviceNames)
+}
 
-	cmd := exec.Command("docker", args...)
-	output, err := cmd.CombinedOutput()
+// startResult tracks the outcome of starting one service so that
+// dependents can wait for it
+type startResult struct {
+	done chan struct{}
+	err  error
+}
+
+// Start starts the given services (all if none) concurrently. With
+// WaitForDeps, dependencies are included and each service waits for its
+// dependencies to meet their depends_on condition before starting.
+func (r *Runner) Start(ctx context.Context, serviceNames []string, opts StartOptions) error {
+	names, err := r.StartOrder(serviceNames, opts)
 	if err != nil {
-		return fmt.Errorf("docker compose restart failed: %w\nOutput: %s", err, output)
+		return err
+	}
+
+	results := make(map[string]*startResult, len(names))
+	for _, name := range names {
+		results[name] = &startResult{done: make(chan struct{})}
+	}
+
+	var (
+		mu     sync.Mutex
+		errors Errors
+		wg     sync.WaitGroup
+	)
+
+	for _, name := range names {
+		wg.Add(1)
+		go func(serviceName string) {
+			defer wg.Done()
+			result := results[serviceName]
+			defer close(result.done)
+
+			service, _ := r.cfg.GetService(serviceName)
+			var action docker.StartAction
+			if opts.WaitForDeps {
+				result.err = r.waitForDependencies(ctx, serviceName, service, results, opts.WaitTimeout)
+			}
+			if result.err == nil {
+				r.report(serviceName, StatusRunning, "starting", nil)
+				action, result.err = r.docker.StartServiceWithOptions(ctx, serviceName, service, docker.StartOptions{
+					Recreate:     opts.Recreate,
+					PortStrategy: opts.PortStrategy,
+					Progress:     r.progressFor(serviceName),
+					AdoptLegacy:  opts.AdoptLegacy,
+				})
+			}
+
+			if result.err != nil {
+				r.report(serviceName, StatusFailed, "", result.err)
+				mu.Lock()
+				errors = append(errors, ServiceError{Service: serviceName, Err: result.err})
+				mu.Unlock()
+				return
+			}
+			r.report(serviceName, StatusDone, string(action), nil)
+		}(name)
+	}
+
+	wg.Wait()
+	return errorsOrNil(errors)
+}
+
+func (r *Runner) waitForDependencies(ctx context.Context, serviceName string, service config.Service, results map[string]*startResult, timeout time.Duration) error {
+	for _, depName := range service.DependsOn.Names() {
+		depResult, inRun := results[depName]
+		if !inRun {
+			continue
+		}
+
+		<-depResult.done
+		if depResult.err != nil {
+			return fmt.Errorf("dependency %s failed to start", depName)
+		}
+
+		condition := service.DependsOn[depName].GetCondition()
+		if condition == config.ConditionStarted {
+			continue
+		}
+
+		r.report(serviceName, StatusWaiting, fmt.Sprintf("waiting for %s (%s)", depName, condition), nil)
+		waitCtx, cancel := context.WithTimeout(ctx, timeout)
+		depService, _ := r.cfg.GetService(depName)
+		err := r.docker.WaitForCondition(waitCtx, depName, depService, condition, time.Second)
+		cancel()
+		if err != nil {
+			return fmt.Errorf("dependency %s: %w", depName, err)
+		}
 	}
 	return nil
 }
 
-// Pull pulls images for the specified services or all services if none specified
-func Pull(serviceNames []string) error {
-	args := []string{"compose", "pull"}
-	if len(serviceNames) > 0 {
-		args = append(args, serviceNames...)
+// StopOrder returns the given services (all if none) ordered so that
+// dependents come before the services they depend on
+func (r *Runner) StopOrder(serviceNames []string) ([]string, error) {
+	order, err := r.RestartOrder(serviceNames)
+	if err != nil {
+		return nil, err
 	}
 
-	cmd := exec.Command("docker", args...)
-	output, err := cmd.CombinedOutput()
+	result := make([]string, 0, len(order))
+	for i := len(order) - 1; i >= 0; i-- {
+		result = append(result, order[i])
+	}
+	return result, nil
+}
+
+// RestartOrder returns the given services (all if none) in dependency
+// order. Unlike StartOrder, dependencies that were not asked for are left
+// out.
+func (r *Runner) RestartOrder(serviceNames []string) ([]string, error) {
+	names, err := r.resolveServices(serviceNames)
 	if err != nil {
-		return fmt.Errorf("docker compose pull failed: %w\nOutput: %s", err, output)
+		return nil, err
 	}
-	return nil
+
+	requested := make(map[string]bool, len(names))
+	for _, name := range names {
+		requested[name] = true
+	}
+
+	order, err := r.cfg.StartOrder(nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var result []string
+	for _, name := range order {
+		if requested[name] {
+			result = append(result, name)
+		}
+	}
+	return result, nil
 }
 
-// Build builds images for the specified services or all services if none specified
-func Build(serviceNames []string) error {
-	args := []string{"compose", "build"}
-	if len(serviceNames) > 0 {
-		args = append(args, serviceNames...)
+// Stop stops and removes the containers of the given services (all if
+// none), dependents first
+func (r *Runner) Stop(ctx context.Context, serviceNames []string) error {
+	names, err := r.StopOrder(serviceNames)
+	if err != nil {
+		return err
 	}
 
-	cmd := exec.Command("docker", args...)
-	output, err := cmd.CombinedOutput()
+	return r.sequential(names, "stopping", func(name string) (string, error) {
+		return "stopped", r.docker.StopService(ctx, name)
+	})
+}
+
+// Restart restarts the containers of the given services (all if none) in
+// dependency order
+func (r *Runner) Restart(ctx context.Context, serviceNames []string) error {
+	names, err := r.RestartOrder(serviceNames)
 	if err != nil {
-		return fmt.Errorf("docker compose build failed: %w\nOutput: %s", err, output)
+		return err
 	}
-	return nil
+
+	return r.sequential(names, "restarting", func(name string) (string, error) {
+		return "restarted", r.docker.RestartService(ctx, name)
+	})
 }
 
-// GetServiceStatus returns the status of all services
-func GetServiceStatus() (map[string]string, error) {
-	cmd := exec.Command("docker", "compose", "ps", "--format", "json")
-	output, err := cmd.CombinedOutput()
+// Pull pulls the latest images of the given services (all if none)
+func (r *Runner) Pull(ctx context.Context, serviceNames []string) error {
+	names, err := r.resolveServices(serviceNames)
 	if err != nil {
-		return nil, fmt.Errorf("docker compose ps failed: %w\nOutput: %s", err, output)
-	}
-
-	// Simple parsing - for a more robust implementation, use JSON parsing
-	status := make(map[string]string)
-	lines := strings.Split(string(output), "\n")
-	for _, line := range lines {
-		if strings.Contains(line, "Name") && strings.Contains(line, "State") {
-			// This is a simplified parser - in production, use proper JSON unmarshaling
-			parts := strings.Fields(line)
-			if len(parts) >= 2 {
-				status[parts[0]] = parts[1]
-			}
+		return err
+	}
+
+	return r.sequential(names, "pulling", func(name string) (string, error) {
+		service, _ := r.cfg.GetService(name)
+		if service.Build != nil {
+			return "", errSkip("built locally")
+		}
+		if service.Image == "" {
+			return "", errSkip("no image configured")
 		}
+		return service.Image, r.docker.PullImage(ctx, service.Image, r.progressFor(name))
+	})
+}
+
+// BuildOptions controls how service images are built
+type BuildOptions struct {
+	// Force rebuilds images that are up to date with their context
+	Force bool
+	// NoCache disables the Docker build cache
+	NoCache bool
+}
+
+// Build builds the images of the given services (all if none). Services
+// without a build configuration are skipped.
+func (r *Runner) Build(ctx context.Context, serviceNames []string, opts BuildOptions) error {
+	names, err := r.resolveServices(serviceNames)
+	if err != nil {
+		return err
+	}
+
+	return r.sequential(names, "building", func(name string) (string, error) {
+		service, _ := r.cfg.GetService(name)
+		if service.Build == nil {
+			return "", errSkip("no build configuration")
+		}
+
+		result, err := r.docker.BuildService(ctx, name, service, docker.BuildOptions{
+			Force:    opts.Force,
+			NoCache:  opts.NoCache,
+			Progress: r.progressFor(name),
+		})
+		if err != nil {
+			return "", err
+		}
+		if !result.Built {
+			return result.Image + ", up to date", nil
+		}
+		return result.Image, nil
+	})
+}
+
+// errSkip marks a service as skipped rather than failed
+type errSkip string
+
+func (e errSkip) Error() string { return string(e) }
+
+// sequential runs fn for each service in order, reporting progress and
+// collecting failures instead of stopping at the first one
+func (r *Runner) sequential(names []string, verb string, fn func(name string) (string, error)) error {
+	var errors Errors
+	for _, name := range names {
+		r.report(name, StatusRunning, verb, nil)
+		message, err := fn(name)
+		if skip, ok := err.(errSkip); ok {
+			r.report(name, StatusSkipped, string(skip), nil)
+			continue
+		}
+		if err != nil {
+			r.report(name, StatusFailed, "", err)
+			errors = append(errors, ServiceError{Service: name, Err: err})
+			continue
+		}
+		r.report(name, StatusDone, message, nil)
+	}
+	return errorsOrNil(errors)
+}
+
+func errorsOrNil(errors Errors) error {
+	if len(errors) == 0 {
+		return nil
+	}
+	sort.Slice(errors, func(i, j int) bool { return errors[i].Service < errors[j].Service })
+	return errors
+}
+
+// GetServiceStatus returns the container status of every service in the
+// runner's project, keyed by service name
+func (r *Runner) GetServiceStatus(ctx context.Context) (map[string]string, error) {
+	containers, err := r.docker.GetServiceStatus(ctx)
+	if err != nil {
+		return nil, err
 	}
 
+	status := make(map[string]string, len(containers))
+	for _, container := range containers {
+		status[container.Service] = container.Status
+	}
 	return status, nil
 }
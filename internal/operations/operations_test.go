@@ -0,0 +1,103 @@
+package operations
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/abdultolba/nizam/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testConfig() *config.Config {
+	return &config.Config{
+		Services: map[string]config.Service{
+			"postgres": {Image: "postgres:16"},
+			"redis":    {Image: "redis:7"},
+			"api": {
+				Image:     "api:latest",
+				DependsOn: config.Dependencies{"postgres": {}, "redis": {}},
+			},
+		},
+	}
+}
+
+func TestStartOrder(t *testing.T) {
+	runner := NewRunner(nil, testConfig())
+
+	order, err := runner.StartOrder([]string{"api"}, StartOptions{WaitForDeps: true})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"postgres", "redis", "api"}, order)
+
+	order, err = runner.StartOrder([]string{"api"}, StartOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"api"}, order)
+
+	_, err = runner.StartOrder([]string{"missing"}, StartOptions{})
+	assert.Error(t, err)
+}
+
+func TestStopOrder(t *testing.T) {
+	runner := NewRunner(nil, testConfig())
+
+	order, err := runner.StopOrder(nil)
+	require.NoError(t, err)
+	assert.Equal(t, "api", order[0])
+	assert.Len(t, order, 3)
+
+	order, err = runner.StopOrder([]string{"postgres", "api"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"api", "postgres"}, order)
+}
+
+func TestRestartOrder(t *testing.T) {
+	runner := NewRunner(nil, testConfig())
+
+	// Dependencies are not restarted along with their dependents
+	order, err := runner.RestartOrder([]string{"api"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"api"}, order)
+
+	order, err = runner.RestartOrder([]string{"api", "postgres"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"postgres", "api"}, order)
+
+	order, err = runner.RestartOrder(nil)
+	require.NoError(t, err)
+	assert.Len(t, order, 3)
+	assert.Equal(t, "api", order[2])
+
+	_, err = runner.RestartOrder([]string{"missing"})
+	assert.Error(t, err)
+}
+
+func TestSequentialAggregatesErrors(t *testing.T) {
+	runner := NewRunner(nil, testConfig())
+
+	var events []Event
+	runner.Progress = func(e Event) { events = append(events, e) }
+
+	err := runner.sequential([]string{"redis", "api", "postgres"}, "testing", func(name string) (string, error) {
+		switch name {
+		case "api":
+			return "", errSkip("nothing to do")
+		case "redis":
+			return "", errors.New("boom")
+		}
+		return "ok", nil
+	})
+
+	var failures Errors
+	require.ErrorAs(t, err, &failures)
+	require.Len(t, failures, 1)
+	assert.Equal(t, "redis", failures[0].Service)
+	assert.Contains(t, err.Error(), "redis: boom")
+
+	statuses := map[string]string{}
+	for _, e := range events {
+		statuses[e.Service] = e.Status
+	}
+	assert.Equal(t, StatusFailed, statuses["redis"])
+	assert.Equal(t, StatusSkipped, statuses["api"])
+	assert.Equal(t, StatusDone, statuses["postgres"])
+}
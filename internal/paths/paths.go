@@ -73,6 +73,22 @@ func GetSnapshotsDir() (string, error) {
 	return snapshotsDir, nil
 }
 
+// GetChunksDir returns the directory of the content-addressed chunk store
+// shared by chunked snapshots
+func GetChunksDir() (string, error) {
+	nizamDir, err := GetNizamDir()
+	if err != nil {
+		return "", err
+	}
+
+	chunksDir := filepath.Join(nizamDir, "chunks")
+	if err := os.MkdirAll(chunksDir, 0o755); err != nil {
+		return "", fmt.Errorf("failed to create chunks directory: %w", err)
+	}
+
+	return chunksDir, nil
+}
+
 // GetServiceSnapshotsDir returns the snapshots directory for a specific service
 func GetServiceSnapshotsDir(service string) (string, error) {
 	snapshotsDir, err := GetSnapshotsDir()
@@ -190,7 +206,8 @@ func ListSnapshots(service string) ([]string, error) {
 
 	var snapshots []string
 	for _, entry := range entries {
-		if entry.IsDir() {
+		// Hidden directories hold snapshots that are still being written
+		if entry.IsDir() && !strings.HasPrefix(entry.Name(), ".") {
 			snapshots = append(snapshots, filepath.Join(serviceDir, entry.Name()))
 		}
 	}
@@ -0,0 +1,556 @@
+// Package query runs statements against database services through the
+// engine's own client inside the container and returns the results in an
+// engine-independent form.
+package query
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+
+	"github.com/abdultolba/nizam/internal/resolve"
+	"github.com/abdultolba/nizam/internal/runtime"
+	"github.com/olekukonko/tablewriter"
+	"github.com/rs/zerolog/log"
+)
+
+// Output formats
+const (
+	FormatTable = "table"
+	FormatJSON  = "json"
+	FormatCSV   = "csv"
+)
+
+// Formats lists the supported output formats
+var Formats = []string{FormatTable, FormatJSON, FormatCSV}
+
+// pgNull is how psql is told to print NULL, the same marker COPY uses
+const pgNull = `\N`
+
+// Result holds the rows returned by a statement. Values are strings, nil for
+// NULL, or for MongoDB the decoded JSON value of a field.
+type Result struct {
+	Columns []string
+	Rows    [][]any
+}
+
+// Run executes statement against the service's database and parses the
+// client output. SQL engines run the statement as is, MongoDB evaluates it as
+// a mongosh expression (e.g. db.users.find()) and Redis runs it as a command.
+func Run(ctx context.Context, rt runtime.Runtime, service resolve.ServiceInfo, statement string) (*Result, error) {
+	cmd, err := Command(service, statement)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Debug().
+		Str("container", service.Container).
+		Str("engine", service.Engine).
+		Msg("Executing query")
+
+	result, err := rt.ExecCommand(ctx, service.Container, cmd)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute %s: %w", cmd[0], err)
+	}
+	if result.ExitCode != 0 {
+		message := strings.TrimSpace(result.Stderr)
+		if message == "" {
+			message = strings.TrimSpace(result.Stdout)
+		}
+		return nil, fmt.Errorf("%s failed with exit code %d: %s", cmd[0], result.ExitCode, message)
+	}
+
+	return Parse(service.Engine, result.Stdout)
+}
+
+// Command builds the client command that runs statement in the service's
+// container and prints the results in a form Parse understands
+func Command(service resolve.ServiceInfo, statement string) ([]string, error) {
+	statement = strings.TrimSpace(statement)
+	if statement == "" {
+		return nil, fmt.Errorf("empty statement")
+	}
+
+	switch service.Engine {
+	case "postgres", "mysql":
+		// Each statement prints its own result set, which one table cannot hold
+		if n := countStatements(service.Engine, statement); n > 1 {
+			return nil, fmt.Errorf("found %d statements; queries run a single statement", n)
+		}
+	}
+
+	switch service.Engine {
+	case "postgres":
+		return []string{
+			"psql",
+			"-U", service.User,
+			"-d", service.Database,
+			"-X", // Ignore ~/.psqlrc
+			"-q", // No command tags such as INSERT 0 1
+			"-v", "ON_ERROR_STOP=1",
+			"--csv",
+			"-P", "null=" + pgNull,
+			"-c", statement,
+		}, nil
+
+	case "mysql":
+		cmd := []string{"mysql", "-u", service.User, "-h", "localhost", "--batch"}
+		if service.Password != "" {
+			cmd = append(cmd, fmt.Sprintf("-p%s", service.Password))
+		}
+		return append(cmd, "-e", statement, service.Database), nil
+
+	case "mongo":
+		// Cursors are drained so the whole result is printed as one document
+		expression := strings.TrimRight(statement, "; \t\n")
+		script := fmt.Sprintf("const result = (%s);\n"+
+			"print(EJSON.stringify(result && typeof result.toArray === 'function' ? result.toArray() : result, {relaxed: true}));",
+			expression)
+
+		cmd := []string{"mongosh", "--quiet", "--host", "localhost:27017"}
+		if service.User != "" {
+			cmd = append(cmd, "--username", service.User, "--authenticationDatabase", "admin")
+		}
+		if service.Password != "" {
+			cmd = append(cmd, "--password", service.Password)
+		}
+		return append(cmd, service.Database, "--eval", script), nil
+
+	case "redis":
+		args, err := SplitArgs(statement)
+		if err != nil {
+			return nil, err
+		}
+		cmd := []string{"redis-cli"}
+		if service.Password != "" {
+			cmd = append(cmd, "--no-auth-warning", "-a", service.Password)
+		}
+		return append(cmd, args...), nil
+	}
+
+	return nil, fmt.Errorf("queries are not supported for engine '%s'", service.Engine)
+}
+
+// Parse converts the output of the command built by Command into a Result
+func Parse(engine, output string) (*Result, error) {
+	switch engine {
+	case "postgres":
+		return parsePostgres(output)
+	case "mysql":
+		return parseMySQL(output), nil
+	case "mongo":
+		return parseMongo(output)
+	case "redis":
+		return parseRedis(output), nil
+	}
+	return nil, fmt.Errorf("queries are not supported for engine '%s'", engine)
+}
+
+// parsePostgres reads psql's CSV output, whose first record is the header
+func parsePostgres(output string) (*Result, error) {
+	if strings.TrimSpace(output) == "" {
+		return &Result{}, nil
+	}
+
+	records, err := csv.NewReader(strings.NewReader(output)).ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse psql output: %w", err)
+	}
+
+	result := &Result{Columns: records[0]}
+	for _, record := range records[1:] {
+		row := make([]any, len(record))
+		for i, value := range record {
+			if value == pgNull {
+				row[i] = nil
+			} else {
+				row[i] = value
+			}
+		}
+		result.Rows = append(result.Rows, row)
+	}
+	return result, nil
+}
+
+// parseMySQL reads mysql's batch output: tab-separated fields with
+// backslash escapes, a header line, and NULL for null values
+func parseMySQL(output string) *Result {
+	output = strings.TrimRight(output, "\n")
+	if output == "" {
+		return &Result{}
+	}
+
+	lines := strings.Split(output, "\n")
+	result := &Result{Columns: strings.Split(lines[0], "\t")}
+	for i, column := range result.Columns {
+		result.Columns[i] = unescapeMySQL(column)
+	}
+
+	for _, line := range lines[1:] {
+		fields := strings.Split(line, "\t")
+		row := make([]any, len(fields))
+		for i, field := range fields {
+			if field == "NULL" {
+				row[i] = nil
+			} else {
+				row[i] = unescapeMySQL(field)
+			}
+		}
+		result.Rows = append(result.Rows, row)
+	}
+	return result
+}
+
+// unescapeMySQL reverses the escaping mysql --batch applies to values
+func unescapeMySQL(value string) string {
+	if !strings.Contains(value, `\`) {
+		return value
+	}
+	return strings.NewReplacer(`\\`, `\`, `\t`, "\t", `\n`, "\n", `\0`, "\x00").Replace(value)
+}
+
+// parseMongo turns the JSON printed by mongosh into rows: one per document,
+// with the union of the documents' fields as columns in order of appearance.
+// Anything that is not a document is returned in a single "result" column.
+func parseMongo(output string) (*Result, error) {
+	output = strings.TrimSpace(output)
+	if output == "" {
+		return &Result{}, nil
+	}
+
+	// Decode only the outer levels generically, so field order is kept
+	items := []json.RawMessage{json.RawMessage(output)}
+	if strings.HasPrefix(output, "[") {
+		items = nil
+		if err := json.Unmarshal([]byte(output), &items); err != nil {
+			return nil, fmt.Errorf("failed to parse mongosh output: %w", err)
+		}
+	}
+
+	documents := make([][]field, 0, len(items))
+	for _, item := range items {
+		doc, ok := orderedFields(item)
+		if !ok {
+			rows, err := scalarRows(items)
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse mongosh output: %w", err)
+			}
+			return &Result{Columns: []string{"result"}, Rows: rows}, nil
+		}
+		documents = append(documents, doc)
+	}
+
+	result := &Result{}
+	index := make(map[string]int)
+	for _, doc := range documents {
+		for _, f := range doc {
+			if _, seen := index[f.name]; !seen {
+				index[f.name] = len(result.Columns)
+				result.Columns = append(result.Columns, f.name)
+			}
+		}
+	}
+	for _, doc := range documents {
+		row := make([]any, len(result.Columns))
+		for _, f := range doc {
+			row[index[f.name]] = f.value
+		}
+		result.Rows = append(result.Rows, row)
+	}
+	return result, nil
+}
+
+// field is a document field kept in its original position
+type field struct {
+	name  string
+	value any
+}
+
+// orderedFields decodes a JSON object preserving the order of its fields,
+// which encoding/json maps do not. It reports false for other values.
+func orderedFields(data []byte) ([]field, bool) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if token, err := decoder.Token(); err != nil || token != json.Delim('{') {
+		return nil, false
+	}
+
+	var fields []field
+	for decoder.More() {
+		token, err := decoder.Token()
+		if err != nil {
+			return nil, false
+		}
+		var value any
+		if err := decoder.Decode(&value); err != nil {
+			return nil, false
+		}
+		fields = append(fields, field{name: token.(string), value: value})
+	}
+	return fields, true
+}
+
+// scalarRows decodes each value onto a row of its own
+func scalarRows(items []json.RawMessage) ([][]any, error) {
+	rows := make([][]any, len(items))
+	for i, item := range items {
+		var value any
+		decoder := json.NewDecoder(bytes.NewReader(item))
+		decoder.UseNumber()
+		if err := decoder.Decode(&value); err != nil {
+			return nil, err
+		}
+		rows[i] = []any{value}
+	}
+	return rows, nil
+}
+
+// parseRedis returns each line of redis-cli's reply as a row of a single
+// "value" column
+func parseRedis(output string) *Result {
+	result := &Result{Columns: []string{"value"}}
+	output = strings.TrimRight(output, "\n")
+	if output == "" {
+		return result
+	}
+	for _, line := range strings.Split(output, "\n") {
+		result.Rows = append(result.Rows, []any{line})
+	}
+	return result
+}
+
+// SplitArgs splits a Redis command line into arguments, honouring single and
+// double quotes and backslash escapes inside double quotes
+func SplitArgs(line string) ([]string, error) {
+	var args []string
+	var current strings.Builder
+	inArg := false
+	var quote rune
+
+	runes := []rune(line)
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			} else if r == '\\' && quote == '"' && i+1 < len(runes) {
+				i++
+				current.WriteRune(runes[i])
+			} else {
+				current.WriteRune(r)
+			}
+		case r == '"' || r == '\'':
+			quote = r
+			inArg = true
+		case r == ' ' || r == '\t' || r == '\n' || r == '\r':
+			if inArg {
+				args = append(args, current.String())
+				current.Reset()
+				inArg = false
+			}
+		default:
+			current.WriteRune(r)
+			inArg = true
+		}
+	}
+
+	if quote != 0 {
+		return nil, fmt.Errorf("unterminated quote in command")
+	}
+	if inArg {
+		args = append(args, current.String())
+	}
+	return args, nil
+}
+
+// countStatements counts the non-empty SQL statements separated by
+// semicolons, skipping semicolons inside quotes, comments and, for
+// PostgreSQL, dollar-quoted strings
+func countStatements(engine, sql string) int {
+	mysql := engine == "mysql"
+	count := 0
+	pending := false
+	for i := 0; i < len(sql); i++ {
+		c := sql[i]
+		switch {
+		case c == '\'' || c == '"' || c == '`':
+			end := i + 1
+			for end < len(sql) && sql[end] != c {
+				if sql[end] == '\\' && mysql {
+					end++
+				}
+				end++
+			}
+			i = end
+			pending = true
+		case c == '-' && strings.HasPrefix(sql[i:], "--"), c == '#' && mysql:
+			if end := strings.IndexByte(sql[i:], '\n'); end >= 0 {
+				i += end
+			} else {
+				i = len(sql)
+			}
+		case c == '/' && strings.HasPrefix(sql[i:], "/*"):
+			if end := strings.Index(sql[i+2:], "*/"); end >= 0 {
+				i += end + 3
+			} else {
+				i = len(sql)
+			}
+		case c == '$' && !mysql:
+			tag := dollarTag.FindString(sql[i:])
+			if tag == "" {
+				pending = true
+				continue
+			}
+			if end := strings.Index(sql[i+len(tag):], tag); end >= 0 {
+				i += len(tag) + end + len(tag) - 1
+			} else {
+				i = len(sql)
+			}
+			pending = true
+		case c == ';':
+			if pending {
+				count++
+				pending = false
+			}
+		case c != ' ' && c != '\t' && c != '\n' && c != '\r':
+			pending = true
+		}
+	}
+	if pending {
+		count++
+	}
+	return count
+}
+
+// dollarTag matches the opening tag of a dollar-quoted string, e.g. $$ or $body$
+var dollarTag = regexp.MustCompile(`^\$([A-Za-z_][A-Za-z0-9_]*)?\$`)
+
+// Write renders the result in one of Formats
+func (r *Result) Write(w io.Writer, format string) error {
+	switch format {
+	case FormatTable, "":
+		return r.writeTable(w)
+	case FormatJSON:
+		return r.writeJSON(w)
+	case FormatCSV:
+		return r.writeCSV(w)
+	}
+	return fmt.Errorf("unknown format '%s' (expected one of %s)", format, strings.Join(Formats, ", "))
+}
+
+// writeTable renders the rows as a text table
+func (r *Result) writeTable(w io.Writer) error {
+	if len(r.Columns) == 0 {
+		return nil
+	}
+
+	table := tablewriter.NewTable(w, tablewriter.WithHeader(r.Columns))
+	for _, row := range r.Rows {
+		cells := make([]string, len(row))
+		for i, value := range row {
+			if value == nil {
+				cells[i] = "NULL"
+			} else {
+				cells[i] = cellString(value)
+			}
+		}
+		if err := table.Append(cells); err != nil {
+			return fmt.Errorf("failed to render table: %w", err)
+		}
+	}
+	if err := table.Render(); err != nil {
+		return fmt.Errorf("failed to render table: %w", err)
+	}
+	fmt.Fprintf(w, "(%d rows)\n", len(r.Rows))
+	return nil
+}
+
+// writeJSON renders the rows as an array of objects whose keys follow the
+// column order
+func (r *Result) writeJSON(w io.Writer) error {
+	var buf bytes.Buffer
+	buf.WriteByte('[')
+	for i, row := range r.Rows {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		buf.WriteByte('{')
+		for j, column := range r.Columns {
+			if j > 0 {
+				buf.WriteByte(',')
+			}
+			key, _ := json.Marshal(column)
+			var value any
+			if j < len(row) {
+				value = row[j]
+			}
+			data, err := json.Marshal(value)
+			if err != nil {
+				return fmt.Errorf("failed to encode column '%s': %w", column, err)
+			}
+			buf.Write(key)
+			buf.WriteByte(':')
+			buf.Write(data)
+		}
+		buf.WriteByte('}')
+	}
+	buf.WriteByte(']')
+
+	var out bytes.Buffer
+	if err := json.Indent(&out, buf.Bytes(), "", "  "); err != nil {
+		return fmt.Errorf("failed to encode JSON: %w", err)
+	}
+	out.WriteByte('\n')
+	_, err := out.WriteTo(w)
+	return err
+}
+
+// writeCSV renders the rows as CSV with a header record. NULL becomes an
+// empty field.
+func (r *Result) writeCSV(w io.Writer) error {
+	if len(r.Columns) == 0 {
+		return nil
+	}
+
+	writer := csv.NewWriter(w)
+	if err := writer.Write(r.Columns); err != nil {
+		return fmt.Errorf("failed to write CSV: %w", err)
+	}
+	for _, row := range r.Rows {
+		record := make([]string, len(row))
+		for i, value := range row {
+			if value != nil {
+				record[i] = cellString(value)
+			}
+		}
+		if err := writer.Write(record); err != nil {
+			return fmt.Errorf("failed to write CSV: %w", err)
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// cellString formats a value for a table or CSV cell, encoding nested
+// MongoDB values as JSON
+func cellString(value any) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case json.Number:
+		return v.String()
+	case bool:
+		return fmt.Sprint(v)
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+	return string(data)
+}
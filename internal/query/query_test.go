@@ -0,0 +1,187 @@
+package query
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/abdultolba/nizam/internal/resolve"
+	"github.com/abdultolba/nizam/internal/runtime"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCommand(t *testing.T) {
+	pg := resolve.ServiceInfo{Engine: "postgres", User: "user", Database: "app"}
+	cmd, err := Command(pg, "  SELECT 1  ")
+	require.NoError(t, err)
+	assert.Equal(t, "psql", cmd[0])
+	assert.Contains(t, cmd, "--csv")
+	assert.Equal(t, "SELECT 1", cmd[len(cmd)-1])
+
+	mysql := resolve.ServiceInfo{Engine: "mysql", User: "root", Password: "secret", Database: "app"}
+	cmd, err = Command(mysql, "SELECT 1")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"mysql", "-u", "root", "-h", "localhost", "--batch", "-psecret", "-e", "SELECT 1", "app"}, cmd)
+
+	mongo := resolve.ServiceInfo{Engine: "mongo", User: "root", Password: "secret", Database: "app"}
+	cmd, err = Command(mongo, "db.users.find();")
+	require.NoError(t, err)
+	assert.Equal(t, "mongosh", cmd[0])
+	assert.Contains(t, cmd[len(cmd)-1], "const result = (db.users.find());")
+
+	redis := resolve.ServiceInfo{Engine: "redis", Password: "secret"}
+	cmd, err = Command(redis, `SET greeting "hello world"`)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"redis-cli", "--no-auth-warning", "-a", "secret", "SET", "greeting", "hello world"}, cmd)
+
+	_, err = Command(pg, "   ")
+	assert.Error(t, err)
+
+	_, err = Command(resolve.ServiceInfo{Engine: "elasticsearch"}, "GET /")
+	assert.Error(t, err)
+}
+
+func TestCommand_RejectsMultipleStatements(t *testing.T) {
+	pg := resolve.ServiceInfo{Engine: "postgres", User: "user", Database: "app"}
+	mysql := resolve.ServiceInfo{Engine: "mysql", User: "root", Database: "app"}
+
+	_, err := Command(pg, "SELECT 1; SELECT 2, 3;")
+	assert.ErrorContains(t, err, "found 2 statements")
+	_, err = Command(mysql, "SELECT 1;\nSELECT 2;")
+	assert.ErrorContains(t, err, "found 2 statements")
+
+	// Semicolons in strings, comments and function bodies don't separate statements
+	single := []string{
+		"SELECT 1;",
+		"SELECT ';' AS s; -- trailing; comment\n",
+		"SELECT 1 /* a; b */ ;  ;",
+		"DO $body$ BEGIN PERFORM 1; END $body$;",
+	}
+	for _, statement := range single {
+		_, err = Command(pg, statement)
+		assert.NoError(t, err, statement)
+	}
+	_, err = Command(mysql, `SELECT 'it\'s; fine', "a;b" # note; here`)
+	assert.NoError(t, err)
+
+	// Redis and MongoDB statements are not SQL
+	_, err = Command(resolve.ServiceInfo{Engine: "redis"}, "SET k a;b")
+	assert.NoError(t, err)
+}
+
+func TestSplitArgs(t *testing.T) {
+	args, err := SplitArgs(`HSET user:1 name 'Ada Lovelace' bio "say \"hi\""`)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"HSET", "user:1", "name", "Ada Lovelace", "bio", `say "hi"`}, args)
+
+	args, err = SplitArgs(`SET empty ""`)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"SET", "empty", ""}, args)
+
+	_, err = SplitArgs(`GET "unterminated`)
+	assert.Error(t, err)
+}
+
+func TestParsePostgres(t *testing.T) {
+	output := "id,email,note\n1,ada@example.com,\\N\n2,\"bob, jr@example.com\",\"multi\nline\"\n"
+
+	result, err := Parse("postgres", output)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"id", "email", "note"}, result.Columns)
+	assert.Equal(t, [][]any{
+		{"1", "ada@example.com", nil},
+		{"2", "bob, jr@example.com", "multi\nline"},
+	}, result.Rows)
+
+	// Statements without results print nothing in quiet mode
+	result, err = Parse("postgres", "")
+	require.NoError(t, err)
+	assert.Empty(t, result.Columns)
+}
+
+func TestParseMySQL(t *testing.T) {
+	output := "id\tname\tbio\n1\tAda\tNULL\n2\tBob\tline\\none\\ttab\n"
+
+	result, err := Parse("mysql", output)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"id", "name", "bio"}, result.Columns)
+	assert.Equal(t, [][]any{
+		{"1", "Ada", nil},
+		{"2", "Bob", "line\none\ttab"},
+	}, result.Rows)
+}
+
+func TestParseMongo(t *testing.T) {
+	output := `[{"_id":"a1","name":"Ada","tags":["x"]},{"_id":"b2","name":"Bob","age":36}]`
+
+	result, err := Parse("mongo", output)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"_id", "name", "tags", "age"}, result.Columns)
+	require.Len(t, result.Rows, 2)
+	assert.Equal(t, []any{"a1", "Ada", []any{"x"}, nil}, result.Rows[0])
+	assert.Equal(t, json.Number("36"), result.Rows[1][3])
+
+	// Scalars such as countDocuments() end up in a single column
+	result, err = Parse("mongo", "42\n")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"result"}, result.Columns)
+	assert.Equal(t, [][]any{{json.Number("42")}}, result.Rows)
+}
+
+func TestParseRedis(t *testing.T) {
+	result, err := Parse("redis", "first\nsecond\n")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"value"}, result.Columns)
+	assert.Equal(t, [][]any{{"first"}, {"second"}}, result.Rows)
+}
+
+func TestRun(t *testing.T) {
+	ctx := context.Background()
+	service := resolve.ServiceInfo{Engine: "postgres", User: "user", Database: "app", Container: "nizam_test_postgres"}
+
+	fake := runtime.NewFake()
+	fake.AddContainer(runtime.Container{Name: service.Container})
+	fake.HandleExec("psql", func(call runtime.ExecCall) runtime.ExecResult {
+		return runtime.ExecResult{Stdout: "count\n3\n"}
+	})
+
+	result, err := Run(ctx, fake, service, "SELECT count(*) FROM users")
+	require.NoError(t, err)
+	assert.Equal(t, [][]any{{"3"}}, result.Rows)
+
+	fake.HandleExec("psql", func(call runtime.ExecCall) runtime.ExecResult {
+		return runtime.ExecResult{ExitCode: 1, Stderr: `ERROR:  relation "missing" does not exist`}
+	})
+	_, err = Run(ctx, fake, service, "SELECT * FROM missing")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `relation "missing" does not exist`)
+}
+
+func TestResultWrite(t *testing.T) {
+	result := &Result{
+		Columns: []string{"name", "id", "note"},
+		Rows: [][]any{
+			{"Ada", "1", nil},
+			{"Bob", json.Number("2"), map[string]any{"a": true}},
+		},
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, result.Write(&buf, FormatJSON))
+	assert.JSONEq(t, `[{"name":"Ada","id":"1","note":null},{"name":"Bob","id":2,"note":{"a":true}}]`, buf.String())
+	// Keys follow the column order rather than being sorted
+	assert.Less(t, bytes.Index(buf.Bytes(), []byte(`"name"`)), bytes.Index(buf.Bytes(), []byte(`"id"`)))
+
+	buf.Reset()
+	require.NoError(t, result.Write(&buf, FormatCSV))
+	assert.Equal(t, "name,id,note\nAda,1,\nBob,2,\"{\"\"a\"\":true}\"\n", buf.String())
+
+	buf.Reset()
+	require.NoError(t, result.Write(&buf, FormatTable))
+	assert.Contains(t, buf.String(), "NULL")
+	assert.Contains(t, buf.String(), "(2 rows)")
+
+	assert.Error(t, result.Write(&buf, "xml"))
+}
@@ -0,0 +1,99 @@
+package readiness
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/abdultolba/nizam/internal/config"
+)
+
+// Result describes the outcome of a single readiness check
+type Result struct {
+	Ready   bool
+	Message string
+}
+
+// CheckService checks whether a service is ready by probing its HTTP health
+// check endpoint if one is configured, or otherwise its published ports.
+// Services with neither are considered ready.
+func CheckService(service config.Service) Result {
+	// Check if service has health check configuration
+	if service.HealthCheck != nil && len(service.HealthCheck.Test) > 0 {
+		// For simplicity, assume first test command is an HTTP endpoint if it starts with "http"
+		test := service.HealthCheck.Test[0]
+		if strings.HasPrefix(test, "http") {
+			if CheckHTTPEndpoint(test) {
+				return Result{Ready: true, Message: "health check passed"}
+			}
+			return Result{Message: "waiting for health check..."}
+		}
+		// For other health check types, assume ready (could be enhanced)
+		return Result{Ready: true, Message: "health check configured"}
+	}
+
+	if len(service.Ports) > 0 {
+		return CheckPorts(service)
+	}
+
+	// If no health check or ports, assume ready
+	return Result{Ready: true, Message: "no readiness checks configured"}
+}
+
+// CheckPorts reports ready as soon as one of the service's published host
+// ports accepts TCP connections
+func CheckPorts(service config.Service) Result {
+	if len(service.Ports) == 0 {
+		return Result{Ready: true, Message: "no ports published"}
+	}
+
+	for _, portMapping := range service.Ports {
+		hostPort, _, err := ParsePortMapping(portMapping)
+		if err != nil {
+			continue
+		}
+
+		if CheckTCPPort(fmt.Sprintf("localhost:%s", hostPort)) {
+			return Result{Ready: true, Message: fmt.Sprintf("port %s is ready", hostPort)}
+		}
+	}
+	return Result{Message: "waiting for ports..."}
+}
+
+// CheckHTTPEndpoint reports whether url answers with a 2xx or 3xx status
+func CheckHTTPEndpoint(url string) bool {
+	client := &http.Client{Timeout: 2 * time.Second}
+	resp, err := client.Get(url)
+	if err != nil {
+		return false
+	}
+	defer resp.Body.Close()
+	return resp.StatusCode >= 200 && resp.StatusCode < 400
+}
+
+// CheckTCPPort reports whether address accepts TCP connections
+func CheckTCPPort(address string) bool {
+	conn, err := net.DialTimeout("tcp", address, 2*time.Second)
+	if err != nil {
+		return false
+	}
+	conn.Close()
+	return true
+}
+
+// ParsePortMapping splits a "host:container" mapping. A bare port is used
+// for both sides.
+func ParsePortMapping(portMapping string) (hostPort, containerPort string, err error) {
+	if len(portMapping) == 0 {
+		return "", "", fmt.Errorf("empty port mapping")
+	}
+
+	host, container, found := strings.Cut(portMapping, ":")
+	if !found {
+		// Just a port number
+		return portMapping, portMapping, nil
+	}
+	return host, container, nil
+}
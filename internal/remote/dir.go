@@ -0,0 +1,118 @@
+package remote
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// Dir stores objects as files in a directory, such as a mounted network
+// share. Keys map to relative paths.
+type Dir struct {
+	root string
+}
+
+// NewDir returns a backend storing objects under root. The directory is
+// created on the first Put.
+func NewDir(root string) *Dir {
+	return &Dir{root: root}
+}
+
+// String returns the directory
+func (d *Dir) String() string {
+	return d.root
+}
+
+// path returns where the object under key is stored
+func (d *Dir) path(key string) (string, error) {
+	if key == "" || path.Clean(key) != key || strings.HasPrefix(key, "/") || key == ".." || strings.HasPrefix(key, "../") {
+		return "", fmt.Errorf("invalid key %q", key)
+	}
+	return filepath.Join(d.root, filepath.FromSlash(key)), nil
+}
+
+// Put writes the object to a temporary file and renames it into place, so
+// readers never see partial objects
+func (d *Dir) Put(ctx context.Context, key string, r io.Reader, size int64) error {
+	target, err := d.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+		return fmt.Errorf("failed to create directory for %s: %w", key, err)
+	}
+
+	temp, err := os.CreateTemp(filepath.Dir(target), "."+filepath.Base(target)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", key, err)
+	}
+	defer os.Remove(temp.Name())
+
+	written, err := io.Copy(temp, r)
+	if closeErr := temp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write %s: %w", key, err)
+	}
+	if size >= 0 && written != size {
+		return fmt.Errorf("failed to write %s: wrote %d bytes, expected %d", key, written, size)
+	}
+
+	if err := os.Rename(temp.Name(), target); err != nil {
+		return fmt.Errorf("failed to store %s: %w", key, err)
+	}
+	return nil
+}
+
+// Get opens the object's file
+func (d *Dir) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	target, err := d.path(key)
+	if err != nil {
+		return nil, err
+	}
+	file, err := os.Open(target)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("%s: %w", key, ErrNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %w", key, err)
+	}
+	return file, nil
+}
+
+// List walks the directory. Hidden files, such as partial writes, are
+// skipped.
+func (d *Dir) List(ctx context.Context, prefix string) ([]string, error) {
+	var keys []string
+	err := filepath.WalkDir(d.root, func(filePath string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) && filePath == d.root {
+				return fs.SkipAll
+			}
+			return err
+		}
+		if entry.IsDir() || strings.HasPrefix(entry.Name(), ".") {
+			return nil
+		}
+
+		rel, err := filepath.Rel(d.root, filePath)
+		if err != nil {
+			return err
+		}
+		if key := filepath.ToSlash(rel); strings.HasPrefix(key, prefix) {
+			keys = append(keys, key)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list %s: %w", d.root, err)
+	}
+	return keys, nil
+}
@@ -0,0 +1,69 @@
+// Package remote stores snapshots outside the project, where teammates and
+// other machines can reach them: a shared directory such as an NFS mount,
+// or an S3-compatible bucket such as the minio template service.
+package remote
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/abdultolba/nizam/internal/config"
+)
+
+// ErrNotFound is returned when an object does not exist
+var ErrNotFound = errors.New("object not found")
+
+// Backend stores objects under slash-separated keys
+type Backend interface {
+	// Put stores size bytes from r under key, replacing any object there.
+	// An object is only visible once it is completely written.
+	Put(ctx context.Context, key string, r io.Reader, size int64) error
+	// Get opens the object under key. It returns an error wrapping
+	// ErrNotFound if there is none.
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	// List returns the keys of all objects whose key starts with prefix
+	List(ctx context.Context, prefix string) ([]string, error)
+	// String describes where the objects are stored
+	String() string
+}
+
+// Open returns the backend configured in snapshots.remote. S3 credentials
+// missing from the config are read from AWS_ACCESS_KEY_ID and
+// AWS_SECRET_ACCESS_KEY.
+func Open(cfg *config.SnapshotRemote) (Backend, error) {
+	if cfg == nil {
+		return nil, fmt.Errorf("no remote configured; set snapshots.remote in the config")
+	}
+
+	switch cfg.Type {
+	case config.RemoteDir:
+		if cfg.Path == "" {
+			return nil, fmt.Errorf("remote: path is required for type %s", cfg.Type)
+		}
+		return NewDir(cfg.Path), nil
+	case config.RemoteS3:
+		opts := S3Options{
+			Endpoint:  cfg.Endpoint,
+			Bucket:    cfg.Bucket,
+			Prefix:    cfg.Prefix,
+			Region:    cfg.Region,
+			AccessKey: cfg.AccessKey,
+			SecretKey: cfg.SecretKey,
+			Insecure:  cfg.Insecure,
+		}
+		if opts.AccessKey == "" {
+			opts.AccessKey = os.Getenv("AWS_ACCESS_KEY_ID")
+		}
+		if opts.SecretKey == "" {
+			opts.SecretKey = os.Getenv("AWS_SECRET_ACCESS_KEY")
+		}
+		return NewS3(opts)
+	case "":
+		return nil, fmt.Errorf("remote: type is required (%s or %s)", config.RemoteDir, config.RemoteS3)
+	default:
+		return nil, fmt.Errorf("remote: unsupported type %s (must be: %s, %s)", cfg.Type, config.RemoteDir, config.RemoteS3)
+	}
+}
@@ -0,0 +1,90 @@
+package remote
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/abdultolba/nizam/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDir(t *testing.T) {
+	ctx := context.Background()
+	root := filepath.Join(t.TempDir(), "shared")
+	backend := NewDir(root)
+
+	// A missing directory holds no objects
+	keys, err := backend.List(ctx, "")
+	require.NoError(t, err)
+	assert.Empty(t, keys)
+
+	require.NoError(t, backend.Put(ctx, "postgres/20250101-120000/pg.dump", strings.NewReader("PGDMP"), 5))
+	require.NoError(t, backend.Put(ctx, "redis/20250101-120000/redis.rdb", strings.NewReader("REDIS"), 5))
+
+	object, err := backend.Get(ctx, "postgres/20250101-120000/pg.dump")
+	require.NoError(t, err)
+	data, err := io.ReadAll(object)
+	object.Close()
+	require.NoError(t, err)
+	assert.Equal(t, "PGDMP", string(data))
+
+	keys, err = backend.List(ctx, "postgres/")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"postgres/20250101-120000/pg.dump"}, keys)
+
+	_, err = backend.Get(ctx, "postgres/missing")
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestDir_PutIsAtomic(t *testing.T) {
+	ctx := context.Background()
+	root := t.TempDir()
+	backend := NewDir(root)
+
+	// A short read leaves nothing behind
+	err := backend.Put(ctx, "postgres/snap/pg.dump", strings.NewReader("PG"), 5)
+	assert.Error(t, err)
+
+	entries, err := os.ReadDir(filepath.Join(root, "postgres", "snap"))
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+}
+
+func TestDir_RejectsEscapingKeys(t *testing.T) {
+	backend := NewDir(t.TempDir())
+	for _, key := range []string{"", "../outside", "/etc/passwd", "a/../../b"} {
+		assert.Error(t, backend.Put(context.Background(), key, strings.NewReader(""), 0), key)
+	}
+}
+
+func TestOpen(t *testing.T) {
+	_, err := Open(nil)
+	assert.ErrorContains(t, err, "no remote configured")
+
+	_, err = Open(&config.SnapshotRemote{Type: "ftp"})
+	assert.ErrorContains(t, err, "unsupported type")
+
+	_, err = Open(&config.SnapshotRemote{Type: config.RemoteDir})
+	assert.ErrorContains(t, err, "path is required")
+
+	_, err = Open(&config.SnapshotRemote{Type: config.RemoteS3})
+	assert.ErrorContains(t, err, "bucket is required")
+
+	backend, err := Open(&config.SnapshotRemote{Type: config.RemoteDir, Path: "/mnt/team"})
+	require.NoError(t, err)
+	assert.Equal(t, "/mnt/team", backend.String())
+
+	backend, err = Open(&config.SnapshotRemote{
+		Type:     config.RemoteS3,
+		Endpoint: "http://localhost:9000",
+		Bucket:   "nizam",
+		Prefix:   "/team/",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "s3://nizam/team/", backend.String())
+}
@@ -0,0 +1,160 @@
+package remote
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+)
+
+// defaultS3Endpoint is used when no endpoint is configured
+const defaultS3Endpoint = "s3.amazonaws.com"
+
+// S3Options configures an S3-compatible backend
+type S3Options struct {
+	// Endpoint is the server's host and port, such as localhost:9000
+	Endpoint string
+	Bucket   string
+	// Prefix is prepended to every key, so projects can share a bucket
+	Prefix    string
+	Region    string
+	AccessKey string
+	SecretKey string
+	// Insecure talks plain HTTP, as a local MinIO container does
+	Insecure bool
+}
+
+// S3 stores objects in an S3-compatible bucket
+type S3 struct {
+	client *minio.Client
+	bucket string
+	prefix string
+	region string
+	// bucketReady records that the bucket is known to exist
+	bucketReady bool
+}
+
+// NewS3 returns a backend for the bucket in opts. No request is made until
+// the backend is used.
+func NewS3(opts S3Options) (*S3, error) {
+	if opts.Bucket == "" {
+		return nil, fmt.Errorf("remote: bucket is required for type s3")
+	}
+	endpoint := opts.Endpoint
+	if endpoint == "" {
+		endpoint = defaultS3Endpoint
+	}
+
+	// Accept endpoints written as URLs
+	secure := !opts.Insecure
+	switch {
+	case strings.HasPrefix(endpoint, "http://"):
+		endpoint = strings.TrimPrefix(endpoint, "http://")
+		secure = false
+	case strings.HasPrefix(endpoint, "https://"):
+		endpoint = strings.TrimPrefix(endpoint, "https://")
+		secure = true
+	}
+
+	client, err := minio.New(strings.TrimSuffix(endpoint, "/"), &minio.Options{
+		Creds:  credentials.NewStaticV4(opts.AccessKey, opts.SecretKey, ""),
+		Secure: secure,
+		Region: opts.Region,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create S3 client: %w", err)
+	}
+
+	prefix := strings.Trim(opts.Prefix, "/")
+	if prefix != "" {
+		prefix += "/"
+	}
+	return &S3{client: client, bucket: opts.Bucket, prefix: prefix, region: opts.Region}, nil
+}
+
+// String returns the bucket URL
+func (s *S3) String() string {
+	return fmt.Sprintf("s3://%s/%s", s.bucket, s.prefix)
+}
+
+// Put uploads the object, creating the bucket if it does not exist yet
+func (s *S3) Put(ctx context.Context, key string, r io.Reader, size int64) error {
+	if err := s.ensureBucket(ctx); err != nil {
+		return err
+	}
+	if _, err := s.client.PutObject(ctx, s.bucket, s.prefix+key, r, size, minio.PutObjectOptions{}); err != nil {
+		return fmt.Errorf("failed to upload %s: %w", key, err)
+	}
+	return nil
+}
+
+// Get downloads the object
+func (s *S3) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	object, err := s.client.GetObject(ctx, s.bucket, s.prefix+key, minio.GetObjectOptions{})
+	if err == nil {
+		// GetObject is lazy; Stat makes the request and reports missing
+		// objects
+		_, err = object.Stat()
+	}
+	if err != nil {
+		if object != nil {
+			object.Close()
+		}
+		if isNotFound(err) {
+			return nil, fmt.Errorf("%s: %w", key, ErrNotFound)
+		}
+		return nil, fmt.Errorf("failed to download %s: %w", key, err)
+	}
+	return object, nil
+}
+
+// List lists the bucket under the prefix. A missing bucket holds no
+// objects.
+func (s *S3) List(ctx context.Context, prefix string) ([]string, error) {
+	var keys []string
+	objects := s.client.ListObjects(ctx, s.bucket, minio.ListObjectsOptions{
+		Prefix:    s.prefix + prefix,
+		Recursive: true,
+	})
+	for object := range objects {
+		if object.Err != nil {
+			if isNotFound(object.Err) {
+				return nil, nil
+			}
+			return nil, fmt.Errorf("failed to list %s: %w", s, object.Err)
+		}
+		keys = append(keys, strings.TrimPrefix(object.Key, s.prefix))
+	}
+	return keys, nil
+}
+
+// ensureBucket creates the bucket on first use, so a fresh MinIO service
+// works without setup
+func (s *S3) ensureBucket(ctx context.Context) error {
+	if s.bucketReady {
+		return nil
+	}
+	exists, err := s.client.BucketExists(ctx, s.bucket)
+	if err != nil {
+		return fmt.Errorf("failed to check bucket %s: %w", s.bucket, err)
+	}
+	if !exists {
+		if err := s.client.MakeBucket(ctx, s.bucket, minio.MakeBucketOptions{Region: s.region}); err != nil {
+			return fmt.Errorf("failed to create bucket %s: %w", s.bucket, err)
+		}
+	}
+	s.bucketReady = true
+	return nil
+}
+
+// isNotFound reports whether err is a missing key or bucket
+func isNotFound(err error) bool {
+	switch minio.ToErrorResponse(err).Code {
+	case "NoSuchKey", "NoSuchBucket":
+		return true
+	}
+	return false
+}
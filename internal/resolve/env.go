@@ -0,0 +1,258 @@
+package resolve
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/abdultolba/nizam/internal/config"
+	"github.com/abdultolba/nizam/internal/runtime"
+)
+
+// Output formats of 'nizam env'
+const (
+	EnvFormatDotenv = "dotenv"
+	EnvFormatExport = "export"
+	EnvFormatJSON   = "json"
+	EnvFormatDirenv = "direnv"
+)
+
+// EnvFormats lists the supported output formats
+var EnvFormats = []string{EnvFormatDotenv, EnvFormatExport, EnvFormatJSON, EnvFormatDirenv}
+
+// EnvVar is an environment variable exported for application code
+type EnvVar struct {
+	Name  string
+	Value string
+}
+
+// DefaultEnvPrefix returns the variable prefix conventionally used for an
+// engine, e.g. DATABASE for DATABASE_URL
+func DefaultEnvPrefix(engine string) string {
+	switch engine {
+	case "postgres", "mysql":
+		return "DATABASE"
+	case "redis":
+		return "REDIS"
+	case "mongo":
+		return "MONGO"
+	}
+	return ""
+}
+
+// ServiceEnvPrefix derives a variable prefix from a service name, e.g.
+// ANALYTICS_DB for analytics-db
+func ServiceEnvPrefix(serviceName string) string {
+	prefix := strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z':
+			return r - 'a' + 'A'
+		case r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+			return r
+		}
+		return '_'
+	}, serviceName)
+
+	if prefix != "" && prefix[0] >= '0' && prefix[0] <= '9' {
+		prefix = "_" + prefix
+	}
+	return prefix
+}
+
+// URL returns the service's address as a standard URL with escaped
+// credentials, the form application libraries expect
+func (si ServiceInfo) URL() string {
+	u := url.URL{Host: net.JoinHostPort(si.Host, strconv.Itoa(si.Port))}
+
+	switch si.Engine {
+	case "postgres":
+		u.Scheme = "postgresql"
+		u.Path = "/" + si.Database
+		u.RawQuery = "sslmode=disable"
+	case "mysql":
+		u.Scheme = "mysql"
+		u.Path = "/" + si.Database
+	case "redis":
+		u.Scheme = "redis"
+		if si.Password != "" {
+			u.User = url.UserPassword("", si.Password)
+		}
+		return u.String()
+	case "mongo":
+		u.Scheme = "mongodb"
+		u.Path = "/" + si.Database
+		// The root user created by the image lives in the admin database
+		if si.Database != "admin" {
+			u.RawQuery = "authSource=admin"
+		}
+	default:
+		return ""
+	}
+
+	if si.User != "" {
+		u.User = url.UserPassword(si.User, si.Password)
+	}
+	return u.String()
+}
+
+// EnvVars returns the connection settings of a database service as
+// <prefix>_URL, _HOST, _PORT, _USER, _PASSWORD and _NAME variables, leaving
+// out the ones the engine has no value for
+func (si ServiceInfo) EnvVars(prefix string) []EnvVar {
+	vars := []EnvVar{
+		{Name: prefix + "_URL", Value: si.URL()},
+		{Name: prefix + "_HOST", Value: si.Host},
+		{Name: prefix + "_PORT", Value: strconv.Itoa(si.Port)},
+	}
+	if si.User != "" {
+		vars = append(vars, EnvVar{Name: prefix + "_USER", Value: si.User})
+	}
+	if si.Password != "" {
+		vars = append(vars, EnvVar{Name: prefix + "_PASSWORD", Value: si.Password})
+	}
+	if si.Database != "" {
+		vars = append(vars, EnvVar{Name: prefix + "_NAME", Value: si.Database})
+	}
+	return vars
+}
+
+// ServiceEnv returns the variables of the given services, in name order.
+// Database services use their engine's prefix (DATABASE, REDIS, MONGO)
+// unless several selected services share it, in which case each uses its
+// own name; other services export only their host and published port. A
+// service's env_prefix, or an entry in prefixes, overrides the prefix.
+// Ports are read from the containers if rt is not nil. Database services on
+// an auto port that is not published yet are an error; other services on
+// one are left out.
+func ServiceEnv(ctx context.Context, rt runtime.Runtime, cfg *config.Config, serviceNames []string, prefixes map[string]string) ([]EnvVar, error) {
+	names := append([]string(nil), serviceNames...)
+	sort.Strings(names)
+
+	// Count the services that would claim each engine prefix
+	claims := make(map[string]int)
+	for _, name := range names {
+		service, exists := cfg.GetService(name)
+		if !exists {
+			return nil, fmt.Errorf("service '%s' not found in config", name)
+		}
+		if engine, ok := DetectEngine(service.Image, name); ok {
+			claims[DefaultEnvPrefix(engine)]++
+		}
+	}
+
+	var vars []EnvVar
+	setBy := make(map[string]string)
+	for _, name := range names {
+		service := cfg.Services[name]
+		engine, isDatabase := DetectEngine(service.Image, name)
+
+		prefix := prefixes[name]
+		if prefix == "" {
+			prefix = service.EnvPrefix
+		}
+		if prefix == "" {
+			prefix = ServiceEnvPrefix(name)
+			if isDatabase && claims[DefaultEnvPrefix(engine)] == 1 {
+				prefix = DefaultEnvPrefix(engine)
+			}
+		}
+
+		// An auto port is only known while the container runs
+		port := 0
+		if len(service.Ports) > 0 {
+			var err error
+			if port, err = hostPort(ctx, rt, cfg.ContainerName(name), service.Ports[0]); err != nil {
+				return nil, fmt.Errorf("service '%s': %w", name, err)
+			}
+		}
+
+		var serviceVars []EnvVar
+		if isDatabase {
+			if len(service.Ports) > 0 && port == 0 {
+				return nil, autoPortError(name)
+			}
+			info, err := GetServiceInfo(ctx, rt, cfg, name)
+			if err != nil {
+				return nil, fmt.Errorf("service '%s': %w", name, err)
+			}
+			serviceVars = info.EnvVars(prefix)
+		} else if port != 0 {
+			serviceVars = []EnvVar{
+				{Name: prefix + "_HOST", Value: "localhost"},
+				{Name: prefix + "_PORT", Value: strconv.Itoa(port)},
+			}
+		}
+
+		for _, v := range serviceVars {
+			if other, taken := setBy[v.Name]; taken {
+				return nil, fmt.Errorf("services '%s' and '%s' both set %s; give one of them an env_prefix", other, name, v.Name)
+			}
+			setBy[v.Name] = name
+		}
+		vars = append(vars, serviceVars...)
+	}
+
+	return vars, nil
+}
+
+// FormatEnv renders variables in one of EnvFormats. The direnv format also
+// watches configPath, so direnv reloads when the config changes.
+func FormatEnv(vars []EnvVar, format, configPath string) (string, error) {
+	var b strings.Builder
+
+	switch format {
+	case EnvFormatDotenv, "":
+		for _, v := range vars {
+			fmt.Fprintf(&b, "%s=%s\n", v.Name, dotenvQuote(v.Value))
+		}
+	case EnvFormatExport:
+		for _, v := range vars {
+			fmt.Fprintf(&b, "export %s=%s\n", v.Name, shellQuote(v.Value))
+		}
+	case EnvFormatDirenv:
+		if configPath != "" {
+			fmt.Fprintf(&b, "watch_file %s\n", shellQuote(configPath))
+		}
+		for _, v := range vars {
+			fmt.Fprintf(&b, "export %s=%s\n", v.Name, shellQuote(v.Value))
+		}
+	case EnvFormatJSON:
+		values := make(map[string]string, len(vars))
+		for _, v := range vars {
+			values[v.Name] = v.Value
+		}
+		data, err := json.MarshalIndent(values, "", "  ")
+		if err != nil {
+			return "", fmt.Errorf("failed to encode JSON: %w", err)
+		}
+		b.Write(data)
+		b.WriteByte('\n')
+	default:
+		return "", fmt.Errorf("unknown format '%s' (expected one of %s)", format, strings.Join(EnvFormats, ", "))
+	}
+
+	return b.String(), nil
+}
+
+// dotenvQuote double-quotes values that dotenv parsers would otherwise
+// split or expand
+func dotenvQuote(value string) string {
+	if value != "" && !strings.ContainsAny(value, " \t\n\"'#$\\`") {
+		return value
+	}
+	replacer := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`, "$", `\$`)
+	return `"` + replacer.Replace(value) + `"`
+}
+
+// shellQuote single-quotes a value for POSIX shells
+func shellQuote(value string) string {
+	if value != "" && !strings.ContainsAny(value, " \t\n\"'#$\\&|;<>()*?[]{}~`!") {
+		return value
+	}
+	return "'" + strings.ReplaceAll(value, "'", `'\''`) + "'"
+}
@@ -1,12 +1,15 @@
 package resolve
 
 import (
+	"context"
 	"fmt"
 	"strconv"
 	"strings"
 
 	"github.com/abdultolba/nizam/internal/binary"
 	"github.com/abdultolba/nizam/internal/config"
+	"github.com/abdultolba/nizam/internal/runtime"
+	"github.com/rs/zerolog/log"
 )
 
 // ServiceInfo holds resolved service connection information
@@ -22,8 +25,13 @@ type ServiceInfo struct {
 	Image     string // Docker image
 }
 
-// GetServiceInfo resolves service information from config
-func GetServiceInfo(cfg *config.Config, serviceName string) (ServiceInfo, error) {
+// GetServiceInfo resolves service information from config. If rt is not
+// nil, the host port is read from the service's container, which may be
+// published on a different port than configured ("auto" ports or
+// --port-strategy=next-free); without a container the configured port is
+// used. A service on an "auto" port without a container is an error, as its
+// port is not known.
+func GetServiceInfo(ctx context.Context, rt runtime.Runtime, cfg *config.Config, serviceName string) (ServiceInfo, error) {
 	service, exists := cfg.GetService(serviceName)
 	if !exists {
 		return ServiceInfo{}, fmt.Errorf("service '%s' not found in config", serviceName)
@@ -31,7 +39,7 @@ func GetServiceInfo(cfg *config.Config, serviceName string) (ServiceInfo, error)
 
 	info := ServiceInfo{
 		Name:      serviceName,
-		Container: fmt.Sprintf("nizam_%s", serviceName),
+		Container: cfg.ContainerName(serviceName),
 		Image:     service.Image,
 		Host:      "localhost",
 	}
@@ -41,15 +49,14 @@ func GetServiceInfo(cfg *config.Config, serviceName string) (ServiceInfo, error)
 
 	// Parse ports to get the host port
 	if len(service.Ports) > 0 {
-		portMapping := service.Ports[0] // Use first port mapping
-		parts := strings.Split(portMapping, ":")
-		if len(parts) == 2 {
-			hostPort, err := strconv.Atoi(parts[0])
-			if err != nil {
-				return ServiceInfo{}, fmt.Errorf("invalid port mapping '%s': %w", portMapping, err)
-			}
-			info.Port = hostPort
+		port, err := hostPort(ctx, rt, info.Container, service.Ports[0]) // Use first port mapping
+		if err != nil {
+			return ServiceInfo{}, err
 		}
+		if port == 0 {
+			return ServiceInfo{}, autoPortError(serviceName)
+		}
+		info.Port = port
 	}
 
 	// Extract credentials and database from environment variables
@@ -70,41 +77,169 @@ func GetServiceInfo(cfg *config.Config, serviceName string) (ServiceInfo, error)
 	return info, nil
 }
 
-// DetermineEngine determines the database engine from image name or service name
+// hostPort returns the host port a port mapping is published on, read from
+// the container if rt is not nil. It is zero for an "auto" mapping without
+// a container.
+func hostPort(ctx context.Context, rt runtime.Runtime, containerName, spec string) (int, error) {
+	mapping, err := config.ParsePort(spec)
+	if err != nil {
+		return 0, err
+	}
+	if rt == nil {
+		return mapping.HostPort, nil
+	}
+
+	published, err := PublishedPorts(ctx, rt, containerName)
+	if err != nil {
+		log.Debug().Err(err).Str("container", containerName).Msg("Using configured port")
+	}
+	for _, port := range published {
+		if port.ContainerPort == mapping.ContainerPort {
+			return port.HostPort, nil
+		}
+	}
+	return mapping.HostPort, nil
+}
+
+// autoPortError reports that a service's auto port is not published yet
+func autoPortError(serviceName string) error {
+	return fmt.Errorf("service '%s' is published on an auto port, which is only known while it runs; start it with 'nizam up %s'", serviceName, serviceName)
+}
+
+// PublishedPorts returns the host ports a container is published on: the
+// live bindings while it runs, otherwise the mapping recorded when it was
+// created. A missing container has no published ports.
+func PublishedPorts(ctx context.Context, rt runtime.Runtime, containerName string) ([]config.PortMapping, error) {
+	container, err := rt.InspectContainer(ctx, containerName)
+	if runtime.IsNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if len(container.Ports) == 0 {
+		return config.ParsePortsLabel(container.Labels[config.PortsLabel]), nil
+	}
+
+	ports := make([]config.PortMapping, 0, len(container.Ports))
+	for _, spec := range container.Ports {
+		if port, err := config.ParsePort(spec); err == nil {
+			ports = append(ports, port)
+		}
+	}
+	return ports, nil
+}
+
+// WithPublishedPorts returns service with its port mappings replaced by the
+// ones its container is published on, so readiness checks probe the right
+// host ports. The service is returned unchanged if the container has none.
+func WithPublishedPorts(ctx context.Context, rt runtime.Runtime, containerName string, service config.Service) config.Service {
+	published, err := PublishedPorts(ctx, rt, containerName)
+	if err != nil || len(published) == 0 {
+		return service
+	}
+
+	service.Ports = make([]string, len(published))
+	for i, port := range published {
+		service.Ports[i] = port.String()
+	}
+	return service
+}
+
+// DetermineEngine determines the database engine from image name or service
+// name, falling back to postgres
 func DetermineEngine(image, serviceName string) string {
+	if engine, ok := DetectEngine(image, serviceName); ok {
+		return engine
+	}
+	return "postgres"
+}
+
+// DetectEngine determines the database engine from image name or service
+// name, and reports false if the service is not a known database
+func DetectEngine(image, serviceName string) (string, bool) {
 	image = strings.ToLower(image)
 	serviceName = strings.ToLower(serviceName)
 
 	// Check image name first
 	if strings.Contains(image, "postgres") {
-		return "postgres"
+		return "postgres", true
 	}
 	if strings.Contains(image, "mysql") || strings.Contains(image, "mariadb") {
-		return "mysql"
+		return "mysql", true
 	}
 	if strings.Contains(image, "redis") {
-		return "redis"
+		return "redis", true
 	}
 	if strings.Contains(image, "mongo") {
-		return "mongo"
+		return "mongo", true
 	}
 
 	// Fallback to service name
 	if strings.Contains(serviceName, "postgres") || strings.Contains(serviceName, "pg") {
-		return "postgres"
+		return "postgres", true
 	}
 	if strings.Contains(serviceName, "mysql") {
-		return "mysql"
+		return "mysql", true
 	}
 	if strings.Contains(serviceName, "redis") {
-		return "redis"
+		return "redis", true
 	}
 	if strings.Contains(serviceName, "mongo") {
-		return "mongo"
+		return "mongo", true
 	}
 
-	// Default fallback
-	return "postgres"
+	return "", false
+}
+
+// dataPaths maps image name fragments to the directory where that image
+// keeps its persistent data. Order matters: more specific fragments first.
+var dataPaths = []struct {
+	fragment string
+	path     string
+}{
+	{"postgres", "/var/lib/postgresql/data"},
+	{"postgis", "/var/lib/postgresql/data"},
+	{"mysql", "/var/lib/mysql"},
+	{"mariadb", "/var/lib/mysql"},
+	{"redis", "/data"},
+	{"mongo", "/data/db"},
+	{"minio", "/data"},
+	{"clickhouse", "/var/lib/clickhouse"},
+	{"elasticsearch", "/usr/share/elasticsearch/data"},
+	{"rabbitmq", "/var/lib/rabbitmq"},
+	{"meilisearch", "/meili_data"},
+	{"prometheus", "/prometheus"},
+	{"grafana", "/var/lib/grafana"},
+	{"redpanda", "/var/lib/redpanda/data"},
+	{"nats", "/data"},
+}
+
+// DefaultDataPath returns the container directory holding persistent data
+// for the given image. If the image is not recognized the service name is
+// tried against the engines known to DetermineEngine. An empty string means
+// the data path is unknown.
+func DefaultDataPath(image, serviceName string) string {
+	image = strings.ToLower(image)
+	// Strip the tag so that e.g. "myorg/app:postgres-client" does not match
+	if idx := strings.LastIndex(image, ":"); idx > strings.LastIndex(image, "/") {
+		image = image[:idx]
+	}
+
+	for _, entry := range dataPaths {
+		if strings.Contains(image, entry.fragment) {
+			return entry.path
+		}
+	}
+
+	name := strings.ToLower(serviceName)
+	for _, engine := range []string{"postgres", "mysql", "redis", "mongo"} {
+		if strings.Contains(name, engine) {
+			return DefaultDataPath(engine, "")
+		}
+	}
+	return ""
 }
 
 // setDefaults sets default values based on the engine type
@@ -248,3 +383,29 @@ func HasHostClientBinary(engine string) bool {
 		return false
 	}
 }
+
+// RedactConnectionString redacts sensitive information from connection strings
+func RedactConnectionString(connStr string, debug bool) string {
+	if debug {
+		return connStr
+	}
+
+	// Redact password in connection strings
+	if strings.Contains(connStr, "://") {
+		parts := strings.Split(connStr, "@")
+		if len(parts) == 2 {
+			// Handle username:password@host format
+			userParts := strings.Split(parts[0], ":")
+			if len(userParts) >= 3 { // protocol:username:password
+				userParts[2] = "***"
+				return strings.Join(userParts, ":") + "@" + parts[1]
+			}
+		}
+	}
+
+	// For other formats, look for password= patterns
+	redacted := strings.ReplaceAll(connStr, "password=", "password=***")
+	redacted = strings.ReplaceAll(redacted, "pwd=", "pwd=***")
+
+	return redacted
+}
@@ -1,9 +1,12 @@
 package resolve
 
 import (
+	"context"
+	"strings"
 	"testing"
 
 	"github.com/abdultolba/nizam/internal/config"
+	"github.com/abdultolba/nizam/internal/runtime"
 )
 
 func TestDetermineEngine(t *testing.T) {
@@ -35,6 +38,7 @@ func TestDetermineEngine(t *testing.T) {
 
 func TestGetServiceInfo(t *testing.T) {
 	cfg := &config.Config{
+		Project: "myapp",
 		Profile: "test",
 		Services: map[string]config.Service{
 			"postgres": {
@@ -55,7 +59,7 @@ func TestGetServiceInfo(t *testing.T) {
 	}
 
 	// Test PostgreSQL service
-	info, err := GetServiceInfo(cfg, "postgres")
+	info, err := GetServiceInfo(context.Background(), nil, cfg, "postgres")
 	if err != nil {
 		t.Fatalf("Unexpected error: %v", err)
 	}
@@ -75,12 +79,12 @@ func TestGetServiceInfo(t *testing.T) {
 	if info.Database != "testdb" {
 		t.Errorf("Expected database testdb, got %s", info.Database)
 	}
-	if info.Container != "nizam_postgres" {
-		t.Errorf("Expected container nizam_postgres, got %s", info.Container)
+	if info.Container != "nizam_myapp_postgres" {
+		t.Errorf("Expected container nizam_myapp_postgres, got %s", info.Container)
 	}
 
 	// Test Redis service
-	info, err = GetServiceInfo(cfg, "redis")
+	info, err = GetServiceInfo(context.Background(), nil, cfg, "redis")
 	if err != nil {
 		t.Fatalf("Unexpected error: %v", err)
 	}
@@ -93,12 +97,67 @@ func TestGetServiceInfo(t *testing.T) {
 	}
 
 	// Test nonexistent service
-	_, err = GetServiceInfo(cfg, "nonexistent")
+	_, err = GetServiceInfo(context.Background(), nil, cfg, "nonexistent")
 	if err == nil {
 		t.Error("Expected error for nonexistent service")
 	}
 }
 
+func TestGetServiceInfo_PublishedPort(t *testing.T) {
+	ctx := context.Background()
+	cfg := &config.Config{
+		Project: "myapp",
+		Services: map[string]config.Service{
+			"postgres": {Image: "postgres:16", Ports: []string{"auto:5432"}},
+		},
+	}
+
+	// Without a container the port is not known
+	fake := runtime.NewFake()
+	_, err := GetServiceInfo(ctx, fake, cfg, "postgres")
+	if err == nil || !strings.Contains(err.Error(), "auto port") {
+		t.Errorf("Expected an auto port error, got %v", err)
+	}
+	_, err = GetServiceInfo(ctx, nil, cfg, "postgres")
+	if err == nil || !strings.Contains(err.Error(), "auto port") {
+		t.Errorf("Expected an auto port error without a runtime, got %v", err)
+	}
+
+	// A running container reports its live bindings
+	fake.AddContainer(runtime.Container{
+		Name:   cfg.ContainerName("postgres"),
+		Ports:  []string{"5434:5432"},
+		Labels: map[string]string{config.PortsLabel: "5433:5432"},
+	})
+	info, err := GetServiceInfo(ctx, fake, cfg, "postgres")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if info.Port != 5434 {
+		t.Errorf("Expected port 5434, got %d", info.Port)
+	}
+
+	// A stopped container falls back to the recorded mapping
+	fake = runtime.NewFake()
+	fake.AddContainer(runtime.Container{
+		Name:   cfg.ContainerName("postgres"),
+		State:  "exited",
+		Labels: map[string]string{config.PortsLabel: "5433:5432"},
+	})
+	info, err = GetServiceInfo(ctx, fake, cfg, "postgres")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if info.Port != 5433 {
+		t.Errorf("Expected port 5433, got %d", info.Port)
+	}
+
+	service := WithPublishedPorts(ctx, fake, cfg.ContainerName("postgres"), cfg.Services["postgres"])
+	if len(service.Ports) != 1 || service.Ports[0] != "5433:5432" {
+		t.Errorf("Expected ports [5433:5432], got %v", service.Ports)
+	}
+}
+
 func TestGetConnectionString(t *testing.T) {
 	tests := []struct {
 		info     ServiceInfo
@@ -142,3 +201,31 @@ func TestGetConnectionString(t *testing.T) {
 		}
 	}
 }
+
+func TestDefaultDataPath(t *testing.T) {
+	tests := []struct {
+		image    string
+		service  string
+		expected string
+	}{
+		{"postgres:16", "db", "/var/lib/postgresql/data"},
+		{"mysql:8.0", "db", "/var/lib/mysql"},
+		{"mariadb:11", "db", "/var/lib/mysql"},
+		{"mongo:7", "db", "/data/db"},
+		{"redis:7", "cache", "/data"},
+		{"minio/minio:latest", "storage", "/data"},
+		{"clickhouse/clickhouse-server:24", "olap", "/var/lib/clickhouse"},
+		{"docker.elastic.co/elasticsearch/elasticsearch:8.11.0", "search", "/usr/share/elasticsearch/data"},
+		{"myorg/custom-pg:1", "postgres-main", "/var/lib/postgresql/data"},
+		{"myorg/app:postgres-client", "app", ""},
+		{"mailhog/mailhog:v1.0.1", "mail", ""},
+	}
+
+	for _, test := range tests {
+		result := DefaultDataPath(test.image, test.service)
+		if result != test.expected {
+			t.Errorf("DefaultDataPath(%s, %s): expected %q, got %q",
+				test.image, test.service, test.expected, result)
+		}
+	}
+}
@@ -0,0 +1,402 @@
+package runtime
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ExecFunc produces the result of a command run in a Fake container
+type ExecFunc func(call ExecCall) ExecResult
+
+// ExecCall records a command run in a Fake container
+type ExecCall struct {
+	Container string
+	Cmd       []string
+	// Stdin is everything the command read from its input
+	Stdin []byte
+}
+
+// Command returns the command line as a single string
+func (c ExecCall) Command() string {
+	return strings.Join(c.Cmd, " ")
+}
+
+type execHandler struct {
+	prefix string
+	fn     ExecFunc
+}
+
+// Fake is an in-memory Runtime for unit tests. Commands run in its
+// containers succeed with no output unless a handler registered with
+// HandleExec matches them, and every call is recorded in Execs.
+type Fake struct {
+	mu         sync.Mutex
+	nextID     int
+	containers map[string]*fakeContainer
+	handlers   []execHandler
+
+	// Execs lists every command run, in order
+	Execs []ExecCall
+}
+
+type fakeContainer struct {
+	Container
+	spec  ContainerSpec
+	logs  string
+	files map[string][]byte
+}
+
+// NewFake creates an empty Fake runtime
+func NewFake() *Fake {
+	return &Fake{containers: make(map[string]*fakeContainer)}
+}
+
+var _ Runtime = (*Fake)(nil)
+
+// AddContainer adds a container in the given state; an empty State means
+// "running"
+func (f *Fake) AddContainer(c Container) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if c.State == "" {
+		c.State = "running"
+	}
+	if c.Status == "" {
+		c.Status = c.State
+		if c.Running() {
+			c.Status = "Up Less than a second"
+		}
+	}
+	if c.ID == "" {
+		f.nextID++
+		c.ID = fmt.Sprintf("fake%012d", f.nextID)
+	}
+	f.containers[c.Name] = &fakeContainer{Container: c, files: make(map[string][]byte)}
+}
+
+// HandleExec registers fn for commands whose command line starts with
+// prefix. Later registrations take precedence.
+func (f *Fake) HandleExec(prefix string, fn ExecFunc) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.handlers = append([]execHandler{{prefix: prefix, fn: fn}}, f.handlers...)
+}
+
+// SetLogs sets the logs returned for a container
+func (f *Fake) SetLogs(name, logs string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if c, ok := f.containers[name]; ok {
+		c.logs = logs
+	}
+}
+
+// WriteFile places a file in a container, as if the image contained it
+func (f *Fake) WriteFile(name, filePath string, data []byte) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if c, ok := f.containers[name]; ok {
+		c.files[path.Clean(filePath)] = data
+	}
+}
+
+// ReadFile returns a file from a container
+func (f *Fake) ReadFile(name, filePath string) ([]byte, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	c, ok := f.containers[name]
+	if !ok {
+		return nil, false
+	}
+	data, ok := c.files[path.Clean(filePath)]
+	return data, ok
+}
+
+// Spec returns the spec a container was created from
+func (f *Fake) Spec(name string) (ContainerSpec, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	c, ok := f.containers[name]
+	if !ok {
+		return ContainerSpec{}, false
+	}
+	return c.spec, true
+}
+
+func (f *Fake) get(name string) (*fakeContainer, error) {
+	c, ok := f.containers[name]
+	if !ok {
+		return nil, NotFound(name)
+	}
+	return c, nil
+}
+
+// CreateContainer implements Runtime
+func (f *Fake) CreateContainer(ctx context.Context, spec ContainerSpec) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if _, exists := f.containers[spec.Name]; exists {
+		return "", fmt.Errorf("container %s already exists", spec.Name)
+	}
+
+	f.nextID++
+	c := &fakeContainer{
+		Container: Container{
+			ID:     fmt.Sprintf("fake%012d", f.nextID),
+			Name:   spec.Name,
+			State:  "created",
+			Status: "Created",
+		},
+		spec:  spec,
+		files: make(map[string][]byte),
+	}
+	if spec.Config != nil {
+		c.Image = spec.Config.Image
+		c.Labels = spec.Config.Labels
+	}
+	f.containers[spec.Name] = c
+	return c.ID, nil
+}
+
+// StartContainer implements Runtime
+func (f *Fake) StartContainer(ctx context.Context, name string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	c, err := f.get(name)
+	if err != nil {
+		return err
+	}
+	c.State, c.Status = "running", "Up Less than a second"
+	return nil
+}
+
+// StopContainer implements Runtime
+func (f *Fake) StopContainer(ctx context.Context, name string, timeout time.Duration) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	c, err := f.get(name)
+	if err != nil {
+		return err
+	}
+	c.State, c.Status = "exited", "Exited (0) Less than a second ago"
+	return nil
+}
+
+// RemoveContainer implements Runtime
+func (f *Fake) RemoveContainer(ctx context.Context, name string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if _, err := f.get(name); err != nil {
+		return err
+	}
+	delete(f.containers, name)
+	return nil
+}
+
+// InspectContainer implements Runtime
+func (f *Fake) InspectContainer(ctx context.Context, name string) (*Container, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	c, err := f.get(name)
+	if err != nil {
+		return nil, err
+	}
+	container := c.Container
+	return &container, nil
+}
+
+// ListContainers implements Runtime
+func (f *Fake) ListContainers(ctx context.Context, labels map[string]string) ([]Container, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var result []Container
+	for _, c := range f.containers {
+		matches := true
+		for key, value := range labels {
+			if c.Labels[key] != value {
+				matches = false
+				break
+			}
+		}
+		if matches {
+			result = append(result, c.Container)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Name < result[j].Name })
+	return result, nil
+}
+
+// exec records a command and produces its result
+func (f *Fake) exec(name string, cmd []string, stdin io.Reader) (ExecResult, error) {
+	var input []byte
+	if stdin != nil {
+		var err error
+		if input, err = io.ReadAll(stdin); err != nil {
+			return ExecResult{}, fmt.Errorf("failed to read stdin: %w", err)
+		}
+	}
+
+	f.mu.Lock()
+	c, err := f.get(name)
+	if err != nil {
+		f.mu.Unlock()
+		return ExecResult{}, err
+	}
+	if !c.Running() {
+		f.mu.Unlock()
+		return ExecResult{}, fmt.Errorf("container %s is not running", name)
+	}
+
+	call := ExecCall{Container: name, Cmd: cmd, Stdin: input}
+	f.Execs = append(f.Execs, call)
+
+	var handler ExecFunc
+	for _, h := range f.handlers {
+		if strings.HasPrefix(call.Command(), h.prefix) {
+			handler = h.fn
+			break
+		}
+	}
+	f.mu.Unlock()
+
+	if handler == nil {
+		return ExecResult{}, nil
+	}
+	return handler(call), nil
+}
+
+// ExecCommand implements Runtime
+func (f *Fake) ExecCommand(ctx context.Context, name string, cmd []string) (*ExecResult, error) {
+	result, err := f.exec(name, cmd, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// ExecStreaming implements Runtime
+func (f *Fake) ExecStreaming(ctx context.Context, name string, cmd []string, stdin io.Reader) (io.ReadCloser, error) {
+	result, err := f.exec(name, cmd, stdin)
+	if err != nil {
+		return nil, err
+	}
+
+	var output io.Reader = strings.NewReader(result.Stdout)
+	if result.ExitCode != 0 {
+		output = io.MultiReader(output, &errReader{err: &ExitError{ExitCode: result.ExitCode, Stderr: result.Stderr}})
+	}
+	return io.NopCloser(output), nil
+}
+
+// Logs implements Runtime
+func (f *Fake) Logs(ctx context.Context, name string, opts LogOptions) (io.ReadCloser, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	c, err := f.get(name)
+	if err != nil {
+		return nil, err
+	}
+	return io.NopCloser(strings.NewReader(c.logs)), nil
+}
+
+// CopyTo implements Runtime
+func (f *Fake) CopyTo(ctx context.Context, name, dstDir string, archive io.Reader) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	c, err := f.get(name)
+	if err != nil {
+		return err
+	}
+
+	tr := tar.NewReader(archive)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read archive: %w", err)
+		}
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+		data, err := io.ReadAll(tr)
+		if err != nil {
+			return fmt.Errorf("failed to read archive: %w", err)
+		}
+		c.files[path.Join(dstDir, header.Name)] = data
+	}
+}
+
+// CopyFrom implements Runtime
+func (f *Fake) CopyFrom(ctx context.Context, name, srcPath string) (io.ReadCloser, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	c, err := f.get(name)
+	if err != nil {
+		return nil, err
+	}
+
+	srcPath = path.Clean(srcPath)
+	var names []string
+	for filePath := range c.files {
+		if filePath == srcPath || strings.HasPrefix(filePath, srcPath+"/") {
+			names = append(names, filePath)
+		}
+	}
+	if len(names) == 0 {
+		return nil, fmt.Errorf("no such file in container %s: %s", name, srcPath)
+	}
+	sort.Strings(names)
+
+	// Like Docker, entries are named relative to the parent of srcPath
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	base := path.Dir(srcPath)
+	for _, filePath := range names {
+		data := c.files[filePath]
+		rel := strings.TrimPrefix(strings.TrimPrefix(filePath, base), "/")
+		if err := tw.WriteHeader(&tar.Header{Name: rel, Mode: 0644, Size: int64(len(data))}); err != nil {
+			return nil, err
+		}
+		if _, err := tw.Write(data); err != nil {
+			return nil, err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return nil, err
+	}
+	return io.NopCloser(&buf), nil
+}
+
+// errReader fails every read with err
+type errReader struct {
+	err error
+}
+
+func (r *errReader) Read([]byte) (int, error) {
+	return 0, r.err
+}
+
+// Close implements Runtime
+func (f *Fake) Close() error {
+	return nil
+}
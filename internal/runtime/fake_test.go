@@ -0,0 +1,131 @@
+package runtime
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFake_Lifecycle(t *testing.T) {
+	ctx := context.Background()
+	fake := NewFake()
+
+	id, err := fake.CreateContainer(ctx, ContainerSpec{
+		Name:   "nizam_app_redis",
+		Config: &container.Config{Image: "redis:7", Labels: map[string]string{"nizam.project": "app"}},
+	})
+	require.NoError(t, err)
+	assert.NotEmpty(t, id)
+
+	running, err := IsRunning(ctx, fake, "nizam_app_redis")
+	require.NoError(t, err)
+	assert.False(t, running)
+
+	require.NoError(t, fake.StartContainer(ctx, "nizam_app_redis"))
+	running, err = IsRunning(ctx, fake, "nizam_app_redis")
+	require.NoError(t, err)
+	assert.True(t, running)
+
+	containers, err := fake.ListContainers(ctx, map[string]string{"nizam.project": "app"})
+	require.NoError(t, err)
+	require.Len(t, containers, 1)
+	assert.Equal(t, "redis:7", containers[0].Image)
+
+	containers, err = fake.ListContainers(ctx, map[string]string{"nizam.project": "other"})
+	require.NoError(t, err)
+	assert.Empty(t, containers)
+
+	require.NoError(t, fake.RemoveContainer(ctx, "nizam_app_redis"))
+	exists, err := Exists(ctx, fake, "nizam_app_redis")
+	require.NoError(t, err)
+	assert.False(t, exists)
+
+	_, err = fake.InspectContainer(ctx, "nizam_app_redis")
+	assert.True(t, IsNotFound(err))
+}
+
+func TestFake_Exec(t *testing.T) {
+	ctx := context.Background()
+	fake := NewFake()
+	fake.AddContainer(Container{Name: "db"})
+
+	fake.HandleExec("psql", func(call ExecCall) ExecResult {
+		return ExecResult{Stdout: "generic"}
+	})
+	fake.HandleExec("psql -c", func(call ExecCall) ExecResult {
+		return ExecResult{Stdout: "specific"}
+	})
+
+	result, err := fake.ExecCommand(ctx, "db", []string{"psql", "-c", "SELECT 1"})
+	require.NoError(t, err)
+	assert.Equal(t, "specific", result.Stdout)
+
+	result, err = fake.ExecCommand(ctx, "db", []string{"psql", "-l"})
+	require.NoError(t, err)
+	assert.Equal(t, "generic", result.Stdout)
+
+	result, err = fake.ExecCommand(ctx, "db", []string{"true"})
+	require.NoError(t, err)
+	assert.Equal(t, 0, result.ExitCode)
+
+	require.Len(t, fake.Execs, 3)
+	assert.Equal(t, "psql -c SELECT 1", fake.Execs[0].Command())
+
+	require.NoError(t, fake.StopContainer(ctx, "db", 0))
+	_, err = fake.ExecCommand(ctx, "db", []string{"true"})
+	assert.ErrorContains(t, err, "not running")
+}
+
+func TestFake_ExecStreaming(t *testing.T) {
+	ctx := context.Background()
+	fake := NewFake()
+	fake.AddContainer(Container{Name: "db"})
+
+	fake.HandleExec("restore", func(call ExecCall) ExecResult {
+		if string(call.Stdin) != "data" {
+			return ExecResult{ExitCode: 2, Stderr: "bad input"}
+		}
+		return ExecResult{Stdout: "ok"}
+	})
+
+	stream, err := fake.ExecStreaming(ctx, "db", []string{"restore"}, strings.NewReader("data"))
+	require.NoError(t, err)
+	output, err := io.ReadAll(stream)
+	require.NoError(t, err)
+	assert.Equal(t, "ok", string(output))
+
+	stream, err = fake.ExecStreaming(ctx, "db", []string{"restore"}, strings.NewReader("junk"))
+	require.NoError(t, err)
+	_, err = io.ReadAll(stream)
+
+	var exitErr *ExitError
+	require.True(t, errors.As(err, &exitErr))
+	assert.Equal(t, 2, exitErr.ExitCode)
+	assert.Equal(t, "bad input", exitErr.Stderr)
+}
+
+func TestReadWriteFile(t *testing.T) {
+	ctx := context.Background()
+	fake := NewFake()
+	fake.AddContainer(Container{Name: "cache", State: "exited"})
+
+	// Copying works on stopped containers
+	require.NoError(t, WriteFile(ctx, fake, "cache", "/data/dump.rdb", []byte("REDIS"), 0644))
+
+	data, ok := fake.ReadFile("cache", "/data/dump.rdb")
+	require.True(t, ok)
+	assert.Equal(t, "REDIS", string(data))
+
+	data, err := ReadFile(ctx, fake, "cache", "/data/dump.rdb")
+	require.NoError(t, err)
+	assert.Equal(t, "REDIS", string(data))
+
+	_, err = ReadFile(ctx, fake, "cache", "/data/missing.rdb")
+	assert.Error(t, err)
+}
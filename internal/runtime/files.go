@@ -0,0 +1,54 @@
+package runtime
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"path"
+)
+
+// ReadFile returns the contents of a regular file in a container
+func ReadFile(ctx context.Context, rt Runtime, name, filePath string) ([]byte, error) {
+	archive, err := rt.CopyFrom(ctx, name, filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer archive.Close()
+
+	tr := tar.NewReader(archive)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return nil, fmt.Errorf("%s not found in container %s", filePath, name)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read archive: %w", err)
+		}
+		if header.Typeflag == tar.TypeReg && path.Base(header.Name) == path.Base(filePath) {
+			return io.ReadAll(tr)
+		}
+	}
+}
+
+// WriteFile writes a regular file into a container, which does not need to
+// be running
+func WriteFile(ctx context.Context, rt Runtime, name, filePath string, data []byte, mode int64) error {
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	if err := tw.WriteHeader(&tar.Header{
+		Name: path.Base(filePath),
+		Mode: mode,
+		Size: int64(len(data)),
+	}); err != nil {
+		return err
+	}
+	if _, err := tw.Write(data); err != nil {
+		return err
+	}
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	return rt.CopyTo(ctx, name, path.Dir(filePath), &buf)
+}
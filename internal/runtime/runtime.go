@@ -0,0 +1,141 @@
+// Package runtime defines the container runtime interface nizam's commands,
+// snapshot engines and health checks are written against. The Docker API
+// implementation lives in internal/docker; Fake is an in-memory
+// implementation for unit tests.
+package runtime
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/network"
+)
+
+// ErrNotFound is returned when a container does not exist
+var ErrNotFound = errors.New("container not found")
+
+// NotFound returns an error wrapping ErrNotFound for the named container
+func NotFound(name string) error {
+	return fmt.Errorf("%w: %s", ErrNotFound, name)
+}
+
+// IsNotFound reports whether err means a container does not exist
+func IsNotFound(err error) bool {
+	return errors.Is(err, ErrNotFound)
+}
+
+// Runtime creates, runs and inspects containers
+type Runtime interface {
+	// CreateContainer creates a container and returns its ID
+	CreateContainer(ctx context.Context, spec ContainerSpec) (string, error)
+	// StartContainer starts a created or stopped container
+	StartContainer(ctx context.Context, name string) error
+	// StopContainer stops a running container, killing it after timeout
+	StopContainer(ctx context.Context, name string, timeout time.Duration) error
+	// RemoveContainer force-removes a container and its anonymous volumes
+	RemoveContainer(ctx context.Context, name string) error
+	// InspectContainer returns a container's state; it returns an error
+	// satisfying IsNotFound if the container does not exist
+	InspectContainer(ctx context.Context, name string) (*Container, error)
+	// ListContainers returns all containers, running or not, that carry
+	// every given label
+	ListContainers(ctx context.Context, labels map[string]string) ([]Container, error)
+
+	// ExecCommand runs a command in a container and waits for it
+	ExecCommand(ctx context.Context, name string, cmd []string) (*ExecResult, error)
+	// ExecStreaming runs a command in a container, feeding it stdin if
+	// given, and returns its standard output as a stream. Reading to the
+	// end returns an *ExitError if the command failed.
+	ExecStreaming(ctx context.Context, name string, cmd []string, stdin io.Reader) (io.ReadCloser, error)
+	// Logs returns a container's log stream
+	Logs(ctx context.Context, name string, opts LogOptions) (io.ReadCloser, error)
+
+	// CopyTo extracts a tar archive into a directory of a container, which
+	// does not need to be running
+	CopyTo(ctx context.Context, name, dstDir string, archive io.Reader) error
+	// CopyFrom returns a tar archive of a path in a container
+	CopyFrom(ctx context.Context, name, srcPath string) (io.ReadCloser, error)
+
+	Close() error
+}
+
+// ContainerSpec describes a container to create
+type ContainerSpec struct {
+	Name       string
+	Config     *container.Config
+	HostConfig *container.HostConfig
+	Networking *network.NetworkingConfig
+}
+
+// Container is the state of a container
+type Container struct {
+	ID     string
+	Name   string
+	Image  string
+	Labels map[string]string
+	// State is the machine-readable state, e.g. "running" or "exited"
+	State string
+	// Status is the human-readable status, e.g. "Up 2 minutes"
+	Status string
+	// Health is the healthcheck status, empty without a healthcheck
+	Health   string
+	Ports    []string
+	Networks []string
+}
+
+// Running reports whether the container is running
+func (c Container) Running() bool {
+	return c.State == "running"
+}
+
+// ExecResult holds the result of an exec operation
+type ExecResult struct {
+	ExitCode int
+	Stdout   string
+	Stderr   string
+}
+
+// ExitError reports a streamed command that exited with a non-zero code
+type ExitError struct {
+	ExitCode int
+	Stderr   string
+}
+
+func (e *ExitError) Error() string {
+	if e.Stderr != "" {
+		return fmt.Sprintf("command exited with code %d: %s", e.ExitCode, e.Stderr)
+	}
+	return fmt.Sprintf("command exited with code %d", e.ExitCode)
+}
+
+// LogOptions selects which logs to return
+type LogOptions struct {
+	Follow bool
+	// Tail is the number of lines from the end, or "all"
+	Tail string
+}
+
+// IsRunning reports whether a container exists and is running
+func IsRunning(ctx context.Context, rt Runtime, name string) (bool, error) {
+	c, err := rt.InspectContainer(ctx, name)
+	if IsNotFound(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return c.Running(), nil
+}
+
+// Exists reports whether a container exists
+func Exists(ctx context.Context, rt Runtime, name string) (bool, error) {
+	_, err := rt.InspectContainer(ctx, name)
+	if IsNotFound(err) {
+		return false, nil
+	}
+	return err == nil, err
+}
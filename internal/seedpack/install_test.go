@@ -0,0 +1,98 @@
+package seedpack
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/abdultolba/nizam/internal/compress"
+	"github.com/abdultolba/nizam/internal/config"
+	"github.com/abdultolba/nizam/internal/paths"
+	"github.com/abdultolba/nizam/internal/runtime"
+	"github.com/abdultolba/nizam/internal/snapshot"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var installConfig = &config.Config{
+	Project: "test",
+	Services: map[string]config.Service{
+		"postgres": {
+			Image:       "postgres:16",
+			Environment: map[string]string{"POSTGRES_USER": "user", "POSTGRES_DB": "app"},
+		},
+	},
+}
+
+// writeTestPack writes a postgres pack holding one uncompressed dump into a
+// temporary project directory and returns the pack directory
+func writeTestPack(t *testing.T, data string) string {
+	t.Helper()
+	origDir, err := os.Getwd()
+	require.NoError(t, err)
+	require.NoError(t, os.Chdir(t.TempDir()))
+	t.Cleanup(func() { os.Chdir(origDir) })
+
+	packDir, err := paths.GetSeedPackVersionDir("postgres", "demo", "1.0.0")
+	require.NoError(t, err)
+	dataPath := filepath.Join(packDir, "pg.dump")
+	require.NoError(t, os.WriteFile(dataPath, []byte(data), 0o644))
+	checksum, err := compress.CalculateSHA256(dataPath)
+	require.NoError(t, err)
+
+	source := snapshot.NewSnapshotManifest("postgres", "postgres", "postgres:16", "", "", compress.CompNone)
+	source.AddFile("pg.dump", checksum, int64(len(data)))
+
+	manifest := NewSeedPackManifest("demo", "Demo", "Demo data", "tester", &source)
+	manifest.Checksum = manifest.ComputeChecksum()
+	require.NoError(t, manifest.WriteToFile(filepath.Join(packDir, "seedpack.json")))
+	return packDir
+}
+
+// newInstallFake returns a fake with a running postgres container whose
+// pg_restore calls are recorded
+func newInstallFake(calls *[]runtime.ExecCall) *runtime.Fake {
+	fake := runtime.NewFake()
+	fake.AddContainer(runtime.Container{Name: installConfig.ContainerName("postgres")})
+	fake.HandleExec("pg_restore", func(call runtime.ExecCall) runtime.ExecResult {
+		*calls = append(*calls, call)
+		return runtime.ExecResult{}
+	})
+	return fake
+}
+
+func TestInstall_RestoresPackData(t *testing.T) {
+	writeTestPack(t, "PGDMP pack data")
+	var calls []runtime.ExecCall
+	service := NewService(newInstallFake(&calls))
+
+	require.NoError(t, service.Install(context.Background(), installConfig, "postgres", "demo", InstallOptions{}))
+	require.Len(t, calls, 1)
+	assert.Equal(t, "PGDMP pack data", string(calls[0].Stdin))
+	assert.Contains(t, calls[0].Cmd, "--clean")
+	assert.Contains(t, calls[0].Cmd, "app")
+
+	require.NoError(t, service.Install(context.Background(), installConfig, "postgres", "demo@1.0.0", InstallOptions{Mode: InstallMerge}))
+	require.Len(t, calls, 2)
+	assert.NotContains(t, calls[1].Cmd, "--clean")
+}
+
+func TestInstall_VerifiesPack(t *testing.T) {
+	packDir := writeTestPack(t, "PGDMP pack data")
+	var calls []runtime.ExecCall
+	service := NewService(newInstallFake(&calls))
+
+	err := service.Install(context.Background(), installConfig, "postgres", "demo", InstallOptions{Mode: "append"})
+	assert.ErrorContains(t, err, "invalid install mode")
+
+	require.NoError(t, os.WriteFile(filepath.Join(packDir, "pg.dump"), []byte("PGDMP PACK DATA"), 0o644))
+	err = service.Install(context.Background(), installConfig, "postgres", "demo", InstallOptions{})
+	assert.ErrorContains(t, err, "checksum mismatch")
+	assert.Empty(t, calls)
+
+	manifest, err := LoadManifestFromDir(packDir)
+	require.NoError(t, err)
+	manifest.Checksum = "0000"
+	assert.ErrorContains(t, manifest.VerifyFiles(packDir), "pack checksum")
+}
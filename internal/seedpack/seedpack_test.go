@@ -7,8 +7,8 @@ import (
 	"testing"
 	"time"
 
-	"github.com/abdultolba/nizam/internal/dockerx"
 	"github.com/abdultolba/nizam/internal/paths"
+	"github.com/abdultolba/nizam/internal/runtime"
 	"github.com/abdultolba/nizam/internal/snapshot"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -146,22 +146,15 @@ func TestSeedPackManifestPersistence(t *testing.T) {
 	assert.Equal(t, manifest.Name, loadedFromDir.Name)
 }
 
-// TestSeedPackService tests the core functionality (integration test)
+// TestSeedPackService tests the core functionality
 func TestSeedPackService(t *testing.T) {
-	// Skip if Docker is not available
-	docker, err := dockerx.NewClient()
-	if err != nil {
-		t.Skip("Docker not available, skipping integration test")
-	}
-	defer docker.Close()
-
 	// Create a temporary directory for testing
 	tempDir, err := os.MkdirTemp("", "seedpack-service-test-*")
 	require.NoError(t, err)
 	defer os.RemoveAll(tempDir)
 
 	// Create seed pack service
-	service := NewService(docker)
+	service := NewService(runtime.NewFake())
 	assert.NotNil(t, service)
 
 	// Test parsePackName function
@@ -9,25 +9,26 @@ import (
 	"sort"
 	"strings"
 
+	"github.com/abdultolba/nizam/internal/compress"
 	"github.com/abdultolba/nizam/internal/config"
-	"github.com/abdultolba/nizam/internal/dockerx"
 	"github.com/abdultolba/nizam/internal/paths"
 	"github.com/abdultolba/nizam/internal/resolve"
+	"github.com/abdultolba/nizam/internal/runtime"
 	"github.com/abdultolba/nizam/internal/snapshot"
 	"github.com/rs/zerolog/log"
 )
 
 // Service provides seed pack operations
 type Service struct {
-	docker      *dockerx.Client
+	rt          runtime.Runtime
 	snapshotSvc *snapshot.Service
 }
 
 // NewService creates a new seed pack service
-func NewService(docker *dockerx.Client) *Service {
+func NewService(rt runtime.Runtime) *Service {
 	return &Service{
-		docker:      docker,
-		snapshotSvc: snapshot.NewService(docker),
+		rt:          rt,
+		snapshotSvc: snapshot.NewService(rt),
 	}
 }
 
@@ -51,7 +52,7 @@ type CreateOptions struct {
 // Create creates a new seed pack from an existing snapshot
 func (s *Service) Create(ctx context.Context, cfg *config.Config, serviceName, snapshotTag string, opts CreateOptions) (*SeedPackManifest, error) {
 	// Resolve service info
-	serviceInfo, err := resolve.GetServiceInfo(cfg, serviceName)
+	serviceInfo, err := resolve.GetServiceInfo(ctx, s.rt, cfg, serviceName)
 	if err != nil {
 		return nil, fmt.Errorf("failed to resolve service info: %w", err)
 	}
@@ -91,6 +92,13 @@ func (s *Service) Create(ctx context.Context, cfg *config.Config, serviceName, s
 		return nil, fmt.Errorf("failed to load snapshot manifest: %w", err)
 	}
 
+	// Packs are self-contained, so chunked snapshots are reassembled
+	snapshotDir, snapshotManifest, cleanup, err := s.snapshotSvc.Materialize(selectedSnapshot.Path, snapshotManifest, compress.CompZstd)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reassemble snapshot: %w", err)
+	}
+	defer cleanup()
+
 	// Set defaults for pack creation
 	if opts.Name == "" {
 		opts.Name = fmt.Sprintf("%s-pack", serviceName)
@@ -146,7 +154,7 @@ func (s *Service) Create(ctx context.Context, cfg *config.Config, serviceName, s
 		Msg("Creating seed pack")
 
 	// Copy snapshot files to pack directory
-	err = s.copySnapshotFiles(selectedSnapshot.Path, packDir, snapshotManifest)
+	err = s.copySnapshotFiles(snapshotDir, packDir, snapshotManifest)
 	if err != nil {
 		os.RemoveAll(packDir)
 		return nil, fmt.Errorf("failed to copy snapshot files: %w", err)
@@ -158,6 +166,8 @@ func (s *Service) Create(ctx context.Context, cfg *config.Config, serviceName, s
 		log.Warn().Err(err).Msg("Failed to enhance manifest with metadata")
 	}
 
+	manifest.Checksum = manifest.ComputeChecksum()
+
 	// Write seed pack manifest
 	if err := manifest.WriteToFile(manifestPath); err != nil {
 		os.RemoveAll(packDir)
@@ -335,19 +345,41 @@ func (s *Service) generateReadme(manifest *SeedPackManifest, path string) error
 	return os.WriteFile(path, []byte(content), 0o644)
 }
 
+// Install modes
+const (
+	// InstallReplace replaces the service's data with the pack's
+	InstallReplace = "replace"
+	// InstallMerge adds the pack's data to the existing data, keeping
+	// objects that already exist
+	InstallMerge = "merge"
+)
+
 // InstallOptions holds options for installing a seed pack
 type InstallOptions struct {
-	Force   bool
-	DryRun  bool
+	Force  bool
+	DryRun bool
+	// Mode is InstallReplace (the default) or InstallMerge
+	Mode string
+	// IdentityFile decrypts packs created from encrypted snapshots
+	IdentityFile string
 }
 
-// Install installs a seed pack to a service
+// Install installs a seed pack to a service, restoring the data files in
+// the pack directory through the engine after verifying them
 func (s *Service) Install(ctx context.Context, cfg *config.Config, serviceName, packName string, opts InstallOptions) error {
+	switch opts.Mode {
+	case "":
+		opts.Mode = InstallReplace
+	case InstallReplace, InstallMerge:
+	default:
+		return fmt.Errorf("invalid install mode '%s' (must be: %s, %s)", opts.Mode, InstallReplace, InstallMerge)
+	}
+
 	// Parse pack name (name@version or just name)
 	name, version := parsePackName(packName)
-	
+
 	// Resolve service info
-	serviceInfo, err := resolve.GetServiceInfo(cfg, serviceName)
+	serviceInfo, err := resolve.GetServiceInfo(ctx, s.rt, cfg, serviceName)
 	if err != nil {
 		return fmt.Errorf("failed to resolve service info: %w", err)
 	}
@@ -363,14 +395,22 @@ func (s *Service) Install(ctx context.Context, cfg *config.Config, serviceName,
 	if err != nil {
 		return fmt.Errorf("failed to load pack manifest: %w", err)
 	}
+	if err := manifest.Validate(); err != nil {
+		return fmt.Errorf("invalid pack manifest: %w", err)
+	}
 
 	// Validate compatibility
 	if manifest.Engine != serviceInfo.Engine {
 		return fmt.Errorf("pack engine %s is not compatible with service engine %s", manifest.Engine, serviceInfo.Engine)
 	}
 
+	// Verify the pack's data before touching the database
+	if err := manifest.VerifyFiles(packDir); err != nil {
+		return fmt.Errorf("pack %s failed verification: %w", manifest.GetFullName(), err)
+	}
+
 	// Check if container is running
-	running, err := s.docker.ContainerIsRunning(ctx, serviceInfo.Container)
+	running, err := runtime.IsRunning(ctx, s.rt, serviceInfo.Container)
 	if err != nil {
 		return fmt.Errorf("failed to check container status: %w", err)
 	}
@@ -383,6 +423,7 @@ func (s *Service) Install(ctx context.Context, cfg *config.Config, serviceName,
 			Str("pack", manifest.GetFullName()).
 			Str("service", serviceName).
 			Str("engine", manifest.Engine).
+			Str("mode", opts.Mode).
 			Msg("Would install seed pack (dry run)")
 		return nil
 	}
@@ -391,13 +432,16 @@ func (s *Service) Install(ctx context.Context, cfg *config.Config, serviceName,
 		Str("pack", manifest.GetFullName()).
 		Str("service", serviceName).
 		Str("engine", manifest.Engine).
+		Str("mode", opts.Mode).
 		Str("directory", packDir).
 		Msg("Installing seed pack")
 
-	// Use snapshot restore functionality to install the pack
-	err = s.snapshotSvc.Restore(ctx, cfg, serviceName, snapshot.RestoreOptions{
-		Latest: true, // We'll restore from the pack's snapshot data
-		Force:  opts.Force,
+	// The pack holds the source snapshot's data files, so it restores
+	// like a snapshot directory
+	err = s.snapshotSvc.RestoreFrom(ctx, cfg, serviceName, packDir, manifest.SourceSnapshot, snapshot.RestoreOptions{
+		Force:        opts.Force,
+		Merge:        opts.Mode == InstallMerge,
+		IdentityFile: opts.IdentityFile,
 	})
 	if err != nil {
 		return fmt.Errorf("failed to install pack: %w", err)
@@ -1,6 +1,7 @@
 package seedpack
 
 import (
+	"crypto/sha256"
 	"encoding/json"
 	"fmt"
 	"os"
@@ -280,6 +281,31 @@ func (m *SeedPackManifest) Validate() error {
 	return nil
 }
 
+// ComputeChecksum returns the checksum of the whole pack: the SHA-256 of
+// each file's name and checksum, in manifest order
+func (m *SeedPackManifest) ComputeChecksum() string {
+	hasher := sha256.New()
+	for _, file := range m.Files {
+		fmt.Fprintf(hasher, "%s  %s\n", file.Sha256, file.Name)
+	}
+	return fmt.Sprintf("%x", hasher.Sum(nil))
+}
+
+// VerifyFiles checks the pack checksum, if recorded, and that every file
+// exists in dir with the recorded size and SHA-256 checksum
+func (m *SeedPackManifest) VerifyFiles(dir string) error {
+	if m.Checksum != "" && m.Checksum != m.ComputeChecksum() {
+		return fmt.Errorf("pack checksum does not match its files")
+	}
+	for _, file := range m.Files {
+		snapshotFile := snapshot.SnapshotFile{Name: file.Name, Sha256: file.Sha256, Size: file.Size}
+		if err := snapshotFile.Verify(dir); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // GetMainDataFile returns the primary data file from the manifest
 func (m *SeedPackManifest) GetMainDataFile() (SeedPackFile, error) {
 	for _, file := range m.Files {
@@ -0,0 +1,260 @@
+package snapshot
+
+import (
+	"archive/tar"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/abdultolba/nizam/internal/compress"
+	"github.com/abdultolba/nizam/internal/paths"
+	"github.com/rs/zerolog/log"
+)
+
+// ArchiveExtension is the conventional extension of exported snapshots
+const ArchiveExtension = ".nzsnap"
+
+// ExportOptions selects the snapshot to export
+type ExportOptions struct {
+	Tag string
+}
+
+// ImportOptions holds options for importing a snapshot archive
+type ImportOptions struct {
+	// Service registers the snapshot under a different service than the
+	// one recorded in its manifest
+	Service string
+}
+
+// Export writes the snapshot of a service selected by opts to w as a tar
+// archive. Without a tag the latest snapshot is exported.
+func (s *Service) Export(serviceName string, opts ExportOptions, w io.Writer) (*SnapshotManifest, error) {
+	snapshotDir, err := s.findSnapshotToRestore(serviceName, RestoreOptions{Tag: opts.Tag, Latest: opts.Tag == ""})
+	if err != nil {
+		return nil, fmt.Errorf("failed to find snapshot: %w", err)
+	}
+	manifest, err := LoadManifestFromDir(snapshotDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load manifest: %w", err)
+	}
+
+	// Archives are self-contained, so chunked snapshots are reassembled
+	dataDir, manifest, cleanup, err := s.Materialize(snapshotDir, manifest, compress.CompZstd)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reassemble snapshot: %w", err)
+	}
+	defer cleanup()
+
+	return writeArchive(w, dataDir, filepath.Base(snapshotDir), manifest)
+}
+
+// WriteArchive writes a snapshot directory to w as a tar archive. Entries
+// live under the snapshot directory's name, with the manifest first so
+// readers can check it before the data arrives.
+func WriteArchive(w io.Writer, snapshotDir string) (*SnapshotManifest, error) {
+	manifest, err := LoadManifestFromDir(snapshotDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load manifest: %w", err)
+	}
+	return writeArchive(w, snapshotDir, filepath.Base(snapshotDir), manifest)
+}
+
+// writeArchive writes the manifest and data files in dir to w as a tar
+// archive with entries under prefix
+func writeArchive(w io.Writer, dir, prefix string, manifest *SnapshotManifest) (*SnapshotManifest, error) {
+	if err := manifest.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid manifest: %w", err)
+	}
+	if manifest.IsChunked() {
+		return nil, fmt.Errorf("chunked snapshots must be reassembled before archiving")
+	}
+
+	tw := tar.NewWriter(w)
+
+	names := []string{manifestFileName}
+	for _, file := range manifest.Files {
+		names = append(names, file.Name)
+	}
+	for _, name := range names {
+		if err := addArchiveFile(tw, filepath.Join(dir, name), path.Join(prefix, name)); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		return nil, fmt.Errorf("failed to finish archive: %w", err)
+	}
+	return manifest, nil
+}
+
+// addArchiveFile copies a regular file into the archive under name
+func addArchiveFile(tw *tar.Writer, filePath, name string) error {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %w", filepath.Base(filePath), err)
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return fmt.Errorf("failed to stat %s: %w", filepath.Base(filePath), err)
+	}
+
+	header := &tar.Header{
+		Typeflag: tar.TypeReg,
+		Name:     name,
+		Mode:     0o644,
+		Size:     info.Size(),
+		ModTime:  info.ModTime(),
+	}
+	if err := tw.WriteHeader(header); err != nil {
+		return fmt.Errorf("failed to write archive header: %w", err)
+	}
+	if _, err := io.Copy(tw, file); err != nil {
+		return fmt.Errorf("failed to write %s to archive: %w", name, err)
+	}
+	return nil
+}
+
+// Import reads a snapshot archive written by Export, verifies its files
+// against the manifest checksums and registers it with the service's
+// snapshots. Nothing is registered if verification fails.
+func (s *Service) Import(r io.Reader, opts ImportOptions) (*SnapshotInfo, error) {
+	tr := tar.NewReader(r)
+
+	// The manifest comes first and names the snapshot
+	header, err := tr.Next()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read archive: %w", err)
+	}
+	dirName, name, err := splitArchiveName(header.Name)
+	if err != nil {
+		return nil, err
+	}
+	if name != manifestFileName {
+		return nil, fmt.Errorf("invalid snapshot archive: expected %s first, found %s", manifestFileName, header.Name)
+	}
+
+	data, err := io.ReadAll(tr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read manifest: %w", err)
+	}
+	manifest, err := parseManifest(data)
+	if err != nil {
+		return nil, err
+	}
+	if opts.Service != "" {
+		manifest.Service = opts.Service
+	}
+	if err := manifest.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid manifest: %w", err)
+	}
+	for _, file := range manifest.Files {
+		if err := validFileName(file.Name); err != nil {
+			return nil, fmt.Errorf("invalid manifest: %w", err)
+		}
+	}
+
+	serviceDir, err := paths.GetServiceSnapshotsDir(manifest.Service)
+	if err != nil {
+		return nil, err
+	}
+	snapshotDir := filepath.Join(serviceDir, dirName)
+	if _, err := os.Stat(snapshotDir); err == nil {
+		return nil, fmt.Errorf("snapshot %s already exists for service %s", dirName, manifest.Service)
+	}
+
+	// Stage in a hidden directory so a failed import never shows up in
+	// the snapshot list
+	stagingDir, err := os.MkdirTemp(serviceDir, "."+dirName+".import-")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create staging directory: %w", err)
+	}
+	defer os.RemoveAll(stagingDir)
+
+	expected := make(map[string]bool, len(manifest.Files))
+	for _, file := range manifest.Files {
+		expected[file.Name] = true
+	}
+
+	for {
+		header, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read archive: %w", err)
+		}
+
+		entryDir, name, err := splitArchiveName(header.Name)
+		if err != nil {
+			return nil, err
+		}
+		if entryDir != dirName || !expected[name] || header.Typeflag != tar.TypeReg {
+			return nil, fmt.Errorf("invalid snapshot archive: unexpected entry %s", header.Name)
+		}
+		delete(expected, name)
+
+		if err := extractArchiveFile(tr, filepath.Join(stagingDir, name)); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := manifest.VerifyFiles(stagingDir); err != nil {
+		return nil, fmt.Errorf("snapshot archive failed verification: %w", err)
+	}
+	if err := manifest.WriteToFile(filepath.Join(stagingDir, manifestFileName)); err != nil {
+		return nil, err
+	}
+	if err := os.Rename(stagingDir, snapshotDir); err != nil {
+		return nil, fmt.Errorf("failed to register snapshot: %w", err)
+	}
+
+	log.Info().
+		Str("service", manifest.Service).
+		Str("snapshot", snapshotDir).
+		Msg("Imported snapshot")
+
+	info, err := s.getSnapshotInfo(snapshotDir)
+	if err != nil {
+		return nil, err
+	}
+	return &info, nil
+}
+
+// splitArchiveName splits an archive entry name into the snapshot directory
+// and file name, rejecting anything that could escape the snapshot
+func splitArchiveName(name string) (string, string, error) {
+	dir, file := path.Split(path.Clean(name))
+	dir = strings.TrimSuffix(dir, "/")
+	if dir == "" || file == "" || strings.Contains(dir, "/") || dir == ".." || strings.HasPrefix(dir, ".") || file == ".." {
+		return "", "", fmt.Errorf("invalid snapshot archive: bad entry name %s", name)
+	}
+	return dir, file, nil
+}
+
+// validFileName rejects manifest file names that could escape the snapshot
+// directory
+func validFileName(name string) error {
+	if name == "" || name != filepath.Base(name) || name != path.Base(name) || strings.HasPrefix(name, ".") || name == manifestFileName {
+		return fmt.Errorf("bad file name %q", name)
+	}
+	return nil
+}
+
+// extractArchiveFile writes the current archive entry to path
+func extractArchiveFile(r io.Reader, filePath string) error {
+	file, err := os.OpenFile(filePath, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", filepath.Base(filePath), err)
+	}
+	if _, err := io.Copy(file, r); err != nil {
+		file.Close()
+		return fmt.Errorf("failed to extract %s: %w", filepath.Base(filePath), err)
+	}
+	return file.Close()
+}
@@ -0,0 +1,185 @@
+package snapshot
+
+import (
+	"archive/tar"
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/abdultolba/nizam/internal/compress"
+	"github.com/abdultolba/nizam/internal/paths"
+	"github.com/abdultolba/nizam/internal/runtime"
+)
+
+// chdirTemp runs the test from an empty project directory
+func chdirTemp(t *testing.T) string {
+	t.Helper()
+	origDir, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("failed to get working directory: %v", err)
+	}
+	dir := t.TempDir()
+	if err := os.Chdir(dir); err != nil {
+		t.Fatalf("failed to change directory: %v", err)
+	}
+	t.Cleanup(func() { os.Chdir(origDir) })
+	return dir
+}
+
+// writeTestSnapshot writes a snapshot directory with one data file
+func writeTestSnapshot(t *testing.T, service, dirName string, data []byte) string {
+	t.Helper()
+	serviceDir, err := paths.GetServiceSnapshotsDir(service)
+	if err != nil {
+		t.Fatalf("GetServiceSnapshotsDir() error = %v", err)
+	}
+	dir := filepath.Join(serviceDir, dirName)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatalf("failed to create snapshot dir: %v", err)
+	}
+
+	dataPath := filepath.Join(dir, "pg.dump")
+	if err := os.WriteFile(dataPath, data, 0o644); err != nil {
+		t.Fatalf("failed to write data: %v", err)
+	}
+	checksum, _ := compress.CalculateSHA256(dataPath)
+
+	manifest := NewSnapshotManifest(service, "postgres", "postgres:16", "", "", compress.CompNone)
+	manifest.AddFile("pg.dump", checksum, int64(len(data)))
+	if err := manifest.WriteToFile(filepath.Join(dir, manifestFileName)); err != nil {
+		t.Fatalf("WriteToFile() error = %v", err)
+	}
+	return dir
+}
+
+func TestExportImport(t *testing.T) {
+	chdirTemp(t)
+	svc := NewService(runtime.NewFake())
+	writeTestSnapshot(t, "postgres", "20250101-120000-seeded", []byte("PGDMP data"))
+
+	var archive bytes.Buffer
+	manifest, err := svc.Export("postgres", ExportOptions{Tag: "seeded"}, &archive)
+	if err != nil {
+		t.Fatalf("Export() error = %v", err)
+	}
+	if manifest.Service != "postgres" {
+		t.Errorf("Export() manifest service = %s", manifest.Service)
+	}
+
+	// Importing under the same service collides with the original
+	if _, err := svc.Import(bytes.NewReader(archive.Bytes()), ImportOptions{}); err == nil || !strings.Contains(err.Error(), "already exists") {
+		t.Errorf("Import() duplicate error = %v", err)
+	}
+
+	info, err := svc.Import(bytes.NewReader(archive.Bytes()), ImportOptions{Service: "api-db"})
+	if err != nil {
+		t.Fatalf("Import() error = %v", err)
+	}
+	if info.Service != "api-db" || info.Tag != "seeded" || info.Size != int64(len("PGDMP data")) {
+		t.Errorf("Import() info = %+v", info)
+	}
+
+	data, err := os.ReadFile(filepath.Join(info.Path, "pg.dump"))
+	if err != nil || string(data) != "PGDMP data" {
+		t.Errorf("imported data = %q, %v", data, err)
+	}
+	snapshots, err := svc.List("api-db")
+	if err != nil || len(snapshots) != 1 {
+		t.Errorf("List() = %v, %v", snapshots, err)
+	}
+}
+
+func TestImport_RejectsCorruptArchive(t *testing.T) {
+	chdirTemp(t)
+	svc := NewService(runtime.NewFake())
+	dir := writeTestSnapshot(t, "postgres", "20250101-120000", []byte("PGDMP data"))
+
+	var archive bytes.Buffer
+	if _, err := WriteArchive(&archive, dir); err != nil {
+		t.Fatalf("WriteArchive() error = %v", err)
+	}
+
+	// Flip the data without changing its size
+	corrupt := bytes.Replace(archive.Bytes(), []byte("PGDMP data"), []byte("PGDMP DATA"), 1)
+	_, err := svc.Import(bytes.NewReader(corrupt), ImportOptions{Service: "other"})
+	if err == nil || !strings.Contains(err.Error(), "checksum mismatch") {
+		t.Errorf("Import() error = %v, want a checksum mismatch", err)
+	}
+
+	// Nothing was registered
+	serviceDir, _ := paths.GetServiceSnapshotsDir("other")
+	if entries, _ := os.ReadDir(serviceDir); len(entries) != 0 {
+		t.Errorf("failed import left %d entries behind", len(entries))
+	}
+}
+
+func TestImport_RejectsUnsafeEntries(t *testing.T) {
+	chdirTemp(t)
+	svc := NewService(runtime.NewFake())
+
+	var archive bytes.Buffer
+	tw := tar.NewWriter(&archive)
+	tw.WriteHeader(&tar.Header{Typeflag: tar.TypeReg, Name: "../../evil/manifest.json", Mode: 0o644})
+	tw.Close()
+
+	if _, err := svc.Import(&archive, ImportOptions{}); err == nil || !strings.Contains(err.Error(), "bad entry name") {
+		t.Errorf("Import() error = %v, want a bad entry name", err)
+	}
+}
+
+func TestImport_RejectsHostileManifest(t *testing.T) {
+	tests := []struct {
+		name     string
+		manifest string
+		want     string
+	}{
+		{
+			name:     "escaping service",
+			manifest: `{"service":"../../x","engine":"postgres","files":[{"name":"pg.dump"}]}`,
+			want:     "invalid service name",
+		},
+		{
+			name:     "hidden service",
+			manifest: `{"service":".chunks","engine":"postgres","files":[{"name":"pg.dump"}]}`,
+			want:     "invalid service name",
+		},
+		{
+			name:     "escaping file",
+			manifest: `{"service":"postgres","engine":"postgres","files":[{"name":"../../evil"}]}`,
+			want:     "bad file name",
+		},
+		{
+			name:     "manifest as file",
+			manifest: `{"service":"postgres","engine":"postgres","files":[{"name":"manifest.json"}]}`,
+			want:     "bad file name",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := chdirTemp(t)
+			svc := NewService(runtime.NewFake())
+
+			var archive bytes.Buffer
+			tw := tar.NewWriter(&archive)
+			tw.WriteHeader(&tar.Header{Typeflag: tar.TypeReg, Name: "20250101-120000/manifest.json", Mode: 0o644, Size: int64(len(tt.manifest))})
+			tw.Write([]byte(tt.manifest))
+			tw.Close()
+
+			_, err := svc.Import(&archive, ImportOptions{})
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Import() error = %v, want %q", err, tt.want)
+			}
+
+			// Nothing was created outside the snapshots directory
+			entries, _ := os.ReadDir(dir)
+			for _, entry := range entries {
+				if entry.Name() != ".nizam" {
+					t.Errorf("hostile import created %s", entry.Name())
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,327 @@
+package snapshot
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/abdultolba/nizam/internal/chunkstore"
+	"github.com/abdultolba/nizam/internal/compress"
+	"github.com/abdultolba/nizam/internal/paths"
+	"github.com/rs/zerolog/log"
+)
+
+// Storage backends for snapshot data
+const (
+	// StorageFiles keeps each snapshot's dump as a file in its directory
+	StorageFiles = "files"
+	// StorageChunked splits dumps into content-addressed chunks shared by
+	// all snapshots in .nizam/chunks/
+	StorageChunked = "chunked"
+)
+
+// defaultChunkGCGrace is how long unreferenced chunks are kept, so garbage
+// collection never removes chunks of a snapshot that is being created
+const defaultChunkGCGrace = time.Hour
+
+// chunkStore opens the project's chunk store
+func (s *Service) chunkStore() (*chunkstore.Store, error) {
+	dir, err := paths.GetChunksDir()
+	if err != nil {
+		return nil, err
+	}
+	return chunkstore.Open(dir)
+}
+
+// storeChunked moves the uncompressed data files of a new snapshot into the
+// chunk store and records their chunks in the manifest
+func (s *Service) storeChunked(dir string, manifest *SnapshotManifest) error {
+	store, err := s.chunkStore()
+	if err != nil {
+		return err
+	}
+
+	for i, file := range manifest.Files {
+		path := filepath.Join(dir, file.Name)
+		f, err := os.Open(path)
+		if err != nil {
+			return fmt.Errorf("failed to open %s: %w", file.Name, err)
+		}
+		chunks, err := store.WriteFrom(f)
+		f.Close()
+		if err != nil {
+			return fmt.Errorf("failed to store %s in chunks: %w", file.Name, err)
+		}
+		manifest.Files[i].Chunks = chunks
+	}
+
+	// Chunks are compressed in the store
+	manifest.Storage = StorageChunked
+	manifest.Compression = compress.CompZstd.String()
+	for _, file := range manifest.Files {
+		if err := os.Remove(filepath.Join(dir, file.Name)); err != nil {
+			return fmt.Errorf("failed to remove %s: %w", file.Name, err)
+		}
+	}
+	return nil
+}
+
+// Materialize returns a directory holding the snapshot in dir as plain data
+// files, with its manifest. A chunked snapshot is reassembled, compressed
+// with comp, into a temporary directory that the returned function removes;
+// other snapshots are returned as they are.
+func (s *Service) Materialize(dir string, manifest *SnapshotManifest, comp compress.Compression) (string, *SnapshotManifest, func(), error) {
+	if !manifest.IsChunked() {
+		return dir, manifest, func() {}, nil
+	}
+
+	store, err := s.chunkStore()
+	if err != nil {
+		return "", nil, nil, err
+	}
+
+	// A hidden sibling is never listed, and verify reports it as an orphan
+	// if it is left behind
+	tempDir, err := os.MkdirTemp(filepath.Dir(dir), "."+filepath.Base(dir)+".expand-*")
+	if err != nil {
+		return "", nil, nil, fmt.Errorf("failed to create temporary directory: %w", err)
+	}
+	cleanup := func() { os.RemoveAll(tempDir) }
+
+	plain := *manifest
+	plain.Storage = ""
+	plain.Compression = comp.String()
+	plain.Files = nil
+	for _, file := range manifest.Files {
+		name := file.Name + compressionExtension(comp)
+		checksum, size, err := writeChunkedFile(store, file, filepath.Join(tempDir, name), comp)
+		if err != nil {
+			cleanup()
+			return "", nil, nil, err
+		}
+		plain.AddFile(name, checksum, size)
+	}
+
+	if err := plain.WriteToFile(filepath.Join(tempDir, manifestFileName)); err != nil {
+		cleanup()
+		return "", nil, nil, err
+	}
+	return tempDir, &plain, cleanup, nil
+}
+
+// compressionExtension returns the file extension for a compression type
+func compressionExtension(comp compress.Compression) string {
+	switch comp {
+	case compress.CompZstd:
+		return ".zst"
+	case compress.CompGzip:
+		return ".gz"
+	}
+	return ""
+}
+
+// writeChunkedFile reassembles a chunked file at path, checking it against
+// the manifest, and returns the checksum and size of the written file
+func writeChunkedFile(store *chunkstore.Store, file SnapshotFile, path string, comp compress.Compression) (string, int64, error) {
+	writer, err := compress.NewCompressedWriter(path, comp)
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to create %s: %w", filepath.Base(path), err)
+	}
+
+	hasher := sha256.New()
+	size, err := io.Copy(writer, io.TeeReader(store.NewReader(file.Chunks), hasher))
+	if err != nil {
+		writer.Close()
+		return "", 0, fmt.Errorf("failed to reassemble %s: %w", file.Name, err)
+	}
+	checksum, err := writer.Close()
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to write %s: %w", filepath.Base(path), err)
+	}
+
+	if size != file.Size || hex.EncodeToString(hasher.Sum(nil)) != file.Sha256 {
+		return "", 0, fmt.Errorf("%s: reassembled data does not match the manifest", file.Name)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to stat %s: %w", filepath.Base(path), err)
+	}
+	return checksum, info.Size(), nil
+}
+
+// verifyChunked checks that every chunk of the snapshot's files is intact
+// and that they reassemble to the recorded size and checksum
+func (s *Service) verifyChunked(manifest *SnapshotManifest) []error {
+	store, err := s.chunkStore()
+	if err != nil {
+		return []error{err}
+	}
+
+	var errs []error
+	for _, file := range manifest.Files {
+		hasher := sha256.New()
+		size, err := io.Copy(hasher, store.NewReader(file.Chunks))
+		switch {
+		case err != nil:
+			errs = append(errs, fmt.Errorf("%s: %w", file.Name, err))
+		case size != file.Size:
+			errs = append(errs, fmt.Errorf("%s: size mismatch: expected %d, got %d", file.Name, file.Size, size))
+		case hex.EncodeToString(hasher.Sum(nil)) != file.Sha256:
+			errs = append(errs, fmt.Errorf("%s: checksum mismatch", file.Name))
+		}
+	}
+	return errs
+}
+
+// chunkedSize returns the bytes the distinct chunks of a manifest take in
+// the store. Missing chunks count as empty; verify reports them.
+func (s *Service) chunkedSize(manifest *SnapshotManifest) int64 {
+	store, err := s.chunkStore()
+	if err != nil {
+		return 0
+	}
+
+	var size int64
+	for id := range manifestChunks(manifest) {
+		if n, err := store.Size(id); err == nil {
+			size += n
+		}
+	}
+	return size
+}
+
+// manifestChunks returns the distinct chunks a manifest references
+func manifestChunks(manifest *SnapshotManifest) map[string]bool {
+	chunks := make(map[string]bool)
+	for _, file := range manifest.Files {
+		for _, id := range file.Chunks {
+			chunks[id] = true
+		}
+	}
+	return chunks
+}
+
+// chunkReferences counts how many snapshots reference each chunk, across
+// all services
+func (s *Service) chunkReferences() (map[string]int, error) {
+	snapshotsDir, err := paths.GetSnapshotsDir()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get snapshots directory: %w", err)
+	}
+
+	refs := make(map[string]int)
+	err = filepath.WalkDir(snapshotsDir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() || entry.Name() != manifestFileName {
+			return nil
+		}
+		manifest, err := LoadManifestFromFile(path)
+		if err != nil {
+			// Keeping the chunks of an unreadable snapshot is the safe
+			// choice, so collection stops
+			return fmt.Errorf("failed to load %s: %w", path, err)
+		}
+		for id := range manifestChunks(manifest) {
+			refs[id]++
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan snapshots: %w", err)
+	}
+	return refs, nil
+}
+
+// setDedupSizes fills in DedupSize: the bytes only that snapshot holds,
+// which removing it frees
+func (s *Service) setDedupSizes(snapshots []SnapshotInfo) {
+	var refs map[string]int
+	for i := range snapshots {
+		snapshots[i].DedupSize = snapshots[i].Size
+		if !snapshots[i].Chunked {
+			continue
+		}
+
+		if refs == nil {
+			var err error
+			if refs, err = s.chunkReferences(); err != nil {
+				log.Warn().Err(err).Msg("Failed to count chunk references")
+				return
+			}
+		}
+
+		manifest, err := LoadManifestFromDir(snapshots[i].Path)
+		if err != nil {
+			continue
+		}
+		store, err := s.chunkStore()
+		if err != nil {
+			continue
+		}
+		var unique int64
+		for id := range manifestChunks(manifest) {
+			if refs[id] > 1 {
+				continue
+			}
+			if n, err := store.Size(id); err == nil {
+				unique += n
+			}
+		}
+		snapshots[i].DedupSize = unique
+	}
+}
+
+// ChunkStoreUsage returns the number of chunks in the store and the bytes
+// they take
+func (s *Service) ChunkStoreUsage() (int, int64, error) {
+	store, err := s.chunkStore()
+	if err != nil {
+		return 0, 0, err
+	}
+	chunks, err := store.List()
+	if err != nil {
+		return 0, 0, err
+	}
+	var size int64
+	for _, n := range chunks {
+		size += n
+	}
+	return len(chunks), size, nil
+}
+
+// CollectGarbage removes chunks no snapshot references anymore
+func (s *Service) CollectGarbage() (chunkstore.GCResult, error) {
+	store, err := s.chunkStore()
+	if err != nil {
+		return chunkstore.GCResult{}, err
+	}
+	refs, err := s.chunkReferences()
+	if err != nil {
+		return chunkstore.GCResult{}, err
+	}
+
+	live := make(map[string]bool, len(refs))
+	for id := range refs {
+		live[id] = true
+	}
+	result, err := store.GC(live, time.Now().Add(-s.chunkGCGrace))
+	if err != nil {
+		return result, fmt.Errorf("failed to collect chunks: %w", err)
+	}
+
+	if result.Removed > 0 {
+		log.Info().
+			Int("chunks", result.Removed).
+			Str("size", formatSize(result.Freed)).
+			Msg("Removed unused chunks")
+	}
+	return result, nil
+}
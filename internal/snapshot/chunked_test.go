@@ -0,0 +1,202 @@
+package snapshot
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/abdultolba/nizam/internal/config"
+	"github.com/abdultolba/nizam/internal/runtime"
+)
+
+var chunkedConfig = &config.Config{
+	Project: "test",
+	Services: map[string]config.Service{
+		"postgres": {
+			Image:       "postgres:16",
+			Environment: map[string]string{"POSTGRES_USER": "user", "POSTGRES_DB": "app"},
+		},
+	},
+}
+
+// newChunkedFake returns a fake postgres container that dumps data and
+// records what pg_restore reads
+func newChunkedFake(data string, restored *[]byte) *runtime.Fake {
+	fake := runtime.NewFake()
+	fake.AddContainer(runtime.Container{Name: chunkedConfig.ContainerName("postgres")})
+	fake.HandleExec("pg_dump", func(runtime.ExecCall) runtime.ExecResult {
+		return runtime.ExecResult{Stdout: data}
+	})
+	fake.HandleExec("pg_restore", func(call runtime.ExecCall) runtime.ExecResult {
+		*restored = call.Stdin
+		return runtime.ExecResult{}
+	})
+	return fake
+}
+
+func TestChunkedSnapshots(t *testing.T) {
+	chdirTemp(t)
+	ctx := context.Background()
+	dump := "PGDMP " + strings.Repeat("INSERT INTO users VALUES (1);\n", 1000)
+	var restored []byte
+	fake := newChunkedFake(dump, &restored)
+	svc := NewService(fake)
+
+	first, err := svc.Create(ctx, chunkedConfig, "postgres", CreateOptions{Tag: "first", Storage: StorageChunked})
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	if !first.IsChunked() || len(first.Files) != 1 || len(first.Files[0].Chunks) == 0 {
+		t.Fatalf("unexpected manifest: %+v", first)
+	}
+	if first.Files[0].Size != int64(len(dump)) {
+		t.Errorf("file size = %d, want the uncompressed dump size %d", first.Files[0].Size, len(dump))
+	}
+	if !strings.Contains(fake.Execs[0].Command(), "--compress=0") {
+		t.Errorf("pg_dump should not compress chunked dumps: %s", fake.Execs[0].Command())
+	}
+
+	second, err := svc.Create(ctx, chunkedConfig, "postgres", CreateOptions{Tag: "second", Storage: StorageChunked})
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+
+	// Identical dumps share every chunk
+	snapshots, err := svc.List("postgres")
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	if len(snapshots) != 2 {
+		t.Fatalf("List() returned %d snapshots, want 2", len(snapshots))
+	}
+	for _, info := range snapshots {
+		entries, _ := os.ReadDir(info.Path)
+		if len(entries) != 1 {
+			t.Errorf("%s holds %d entries, want only the manifest", info.Path, len(entries))
+		}
+		if !info.Chunked || info.LogicalSize != int64(len(dump)) || info.DedupSize != 0 {
+			t.Errorf("unexpected sizes for %s: %+v", info.Tag, info)
+		}
+		if info.Size == 0 || info.Size >= info.LogicalSize {
+			t.Errorf("size = %d, want the compressed chunk size", info.Size)
+		}
+	}
+	chunks, _, err := svc.ChunkStoreUsage()
+	if err != nil {
+		t.Fatalf("ChunkStoreUsage() error = %v", err)
+	}
+	if chunks != len(second.Files[0].Chunks) {
+		t.Errorf("store holds %d chunks, want %d", chunks, len(second.Files[0].Chunks))
+	}
+
+	// Restores reassemble the dump
+	if err := svc.Restore(ctx, chunkedConfig, "postgres", RestoreOptions{Tag: "first"}); err != nil {
+		t.Fatalf("Restore() error = %v", err)
+	}
+	if string(restored) != dump {
+		t.Errorf("pg_restore read %d bytes, want the %d byte dump", len(restored), len(dump))
+	}
+
+	report, err := svc.Verify(ctx, "postgres", VerifyOptions{})
+	if err != nil {
+		t.Fatalf("Verify() error = %v", err)
+	}
+	if !report.OK || len(report.Orphans) != 0 {
+		t.Errorf("Verify() = %+v, want OK without orphans", report)
+	}
+
+	// Exports are self-contained
+	var archive bytes.Buffer
+	if _, err := svc.Export("postgres", ExportOptions{Tag: "first"}, &archive); err != nil {
+		t.Fatalf("Export() error = %v", err)
+	}
+	imported, err := svc.Import(&archive, ImportOptions{Service: "copy"})
+	if err != nil {
+		t.Fatalf("Import() error = %v", err)
+	}
+	if imported.Chunked {
+		t.Error("imported snapshot should hold plain files")
+	}
+}
+
+func TestChunkedSnapshots_RejectEncryption(t *testing.T) {
+	chdirTemp(t)
+	var restored []byte
+	svc := NewService(newChunkedFake("PGDMP", &restored))
+
+	_, err := svc.Create(context.Background(), chunkedConfig, "postgres", CreateOptions{
+		Storage:    StorageChunked,
+		Recipients: []string{"age1ql3z7hjy54pw3hyww5ayyfg7zqgvc7w3j2elw8zmrj2kg5sfn9aqmcac8p"},
+	})
+	if err == nil || !strings.Contains(err.Error(), "cannot be encrypted") {
+		t.Errorf("Create() error = %v, want encryption rejected", err)
+	}
+}
+
+func TestChunkedSnapshots_VerifyDetectsMissingChunks(t *testing.T) {
+	chdirTemp(t)
+	var restored []byte
+	svc := NewService(newChunkedFake("PGDMP data", &restored))
+
+	manifest, err := svc.Create(context.Background(), chunkedConfig, "postgres", CreateOptions{Storage: StorageChunked})
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	store, err := svc.chunkStore()
+	if err != nil {
+		t.Fatal(err)
+	}
+	id := manifest.Files[0].Chunks[0]
+	if err := os.Remove(filepath.Join(store.Dir(), id[:2], id)); err != nil {
+		t.Fatal(err)
+	}
+
+	report, err := svc.Verify(context.Background(), "postgres", VerifyOptions{})
+	if err != nil {
+		t.Fatalf("Verify() error = %v", err)
+	}
+	if report.OK {
+		t.Error("Verify() passed although a chunk is missing")
+	}
+}
+
+func TestCollectGarbage(t *testing.T) {
+	chdirTemp(t)
+	ctx := context.Background()
+	var restored []byte
+	svc := NewService(newChunkedFake("PGDMP shared data", &restored))
+	svc.chunkGCGrace = 0
+
+	if _, err := svc.Create(ctx, chunkedConfig, "postgres", CreateOptions{Tag: "first", Storage: StorageChunked}); err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	if _, err := svc.Create(ctx, chunkedConfig, "postgres", CreateOptions{Tag: "second", Storage: StorageChunked}); err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	snapshots, err := svc.List("postgres")
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+
+	// A chunk stays while any snapshot references it
+	os.RemoveAll(snapshots[0].Path)
+	result, err := svc.CollectGarbage()
+	if err != nil {
+		t.Fatalf("CollectGarbage() error = %v", err)
+	}
+	if result.Removed != 0 {
+		t.Errorf("CollectGarbage() removed %d chunks still in use", result.Removed)
+	}
+
+	os.RemoveAll(snapshots[1].Path)
+	result, err = svc.CollectGarbage()
+	if err != nil {
+		t.Fatalf("CollectGarbage() error = %v", err)
+	}
+	if result.Removed != 1 || result.Freed == 0 {
+		t.Errorf("CollectGarbage() = %+v, want the unused chunk removed", result)
+	}
+}
@@ -0,0 +1,62 @@
+package snapshot
+
+import (
+	"fmt"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/abdultolba/nizam/internal/resolve"
+)
+
+// ParseRef parses a snapshot reference of the form service[@tag]
+func ParseRef(ref string) (service, tag string) {
+	service, tag, _ = strings.Cut(ref, "@")
+	return service, tag
+}
+
+// CheckCompatible reports whether a snapshot can be restored into a
+// service: the engines must match, and a dump is not loaded into an older
+// major version of the image it was taken from. Unless allowOlder is set,
+// the version check is enforced; mismatched engines never restore.
+func (s *Service) CheckCompatible(manifest *SnapshotManifest, target resolve.ServiceInfo, allowOlder bool) error {
+	source, exists := s.engines[manifest.Engine]
+	if !exists {
+		return fmt.Errorf("unsupported snapshot engine: %s", manifest.Engine)
+	}
+	engine, exists := s.engines[target.Engine]
+	if !exists || engine.GetEngineType() != source.GetEngineType() {
+		return fmt.Errorf("a %s snapshot cannot be restored into %s, which runs %s", manifest.Engine, target.Name, target.Engine)
+	}
+
+	if allowOlder || manifest.Image == "" || target.Image == "" {
+		return nil
+	}
+	sourceRepo, sourceVersion := imageVersion(manifest.Image)
+	targetRepo, targetVersion := imageVersion(target.Image)
+	if sourceRepo == targetRepo && sourceVersion >= 0 && targetVersion >= 0 && targetVersion < sourceVersion {
+		return fmt.Errorf("snapshot of %s may not load into the older %s of %s; use --allow-older to try anyway", manifest.Image, target.Image, target.Name)
+	}
+	return nil
+}
+
+// imageVersion returns the repository name of an image without registry
+// or namespace, and the major version of its tag, or -1 if the tag is not
+// versioned (such as "latest")
+func imageVersion(image string) (string, int) {
+	image, _, _ = strings.Cut(image, "@")
+	repo, tag := image, ""
+	if i := strings.LastIndex(image, ":"); i > strings.LastIndex(image, "/") {
+		repo, tag = image[:i], image[i+1:]
+	}
+
+	end := 0
+	for end < len(tag) && tag[end] >= '0' && tag[end] <= '9' {
+		end++
+	}
+	major, err := strconv.Atoi(tag[:end])
+	if err != nil {
+		major = -1
+	}
+	return path.Base(repo), major
+}
@@ -0,0 +1,106 @@
+package snapshot
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/abdultolba/nizam/internal/config"
+	"github.com/abdultolba/nizam/internal/runtime"
+)
+
+func TestParseRef(t *testing.T) {
+	tests := []struct {
+		ref, service, tag string
+	}{
+		{"postgres", "postgres", ""},
+		{"postgres@before-migration", "postgres", "before-migration"},
+	}
+	for _, tt := range tests {
+		service, tag := ParseRef(tt.ref)
+		if service != tt.service || tag != tt.tag {
+			t.Errorf("ParseRef(%q) = %q, %q, want %q, %q", tt.ref, service, tag, tt.service, tt.tag)
+		}
+	}
+}
+
+func TestImageVersion(t *testing.T) {
+	tests := []struct {
+		image string
+		repo  string
+		major int
+	}{
+		{"postgres:16", "postgres", 16},
+		{"postgres:16.2-alpine", "postgres", 16},
+		{"docker.io/library/mysql:8.0", "mysql", 8},
+		{"localhost:5000/redis", "redis", -1},
+		{"mongo:latest", "mongo", -1},
+		{"postgres:15@sha256:abc", "postgres", 15},
+	}
+	for _, tt := range tests {
+		repo, major := imageVersion(tt.image)
+		if repo != tt.repo || major != tt.major {
+			t.Errorf("imageVersion(%q) = %q, %d, want %q, %d", tt.image, repo, major, tt.repo, tt.major)
+		}
+	}
+}
+
+func TestRestoreInto(t *testing.T) {
+	chdirTemp(t)
+	ctx := context.Background()
+	cfg := &config.Config{
+		Project: "test",
+		Services: map[string]config.Service{
+			"postgres":      {Image: "postgres:16"},
+			"postgres-copy": {Image: "postgres:16"},
+			"postgres-old":  {Image: "postgres:15"},
+			"cache":         {Image: "redis:7"},
+		},
+	}
+	fake := runtime.NewFake()
+	for name := range cfg.Services {
+		fake.AddContainer(runtime.Container{Name: cfg.ContainerName(name)})
+	}
+	var restoredBy []string
+	fake.HandleExec("pg_restore", func(call runtime.ExecCall) runtime.ExecResult {
+		restoredBy = append(restoredBy, call.Container)
+		return runtime.ExecResult{}
+	})
+	svc := NewService(fake)
+
+	dir := writeTestSnapshot(t, "postgres", "20250101-120000-seeded", []byte("PGDMP data"))
+	manifest, err := LoadManifestFromDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	manifest.Image = "postgres:16"
+	if err := manifest.WriteToFile(filepath.Join(dir, manifestFileName)); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := svc.Restore(ctx, cfg, "postgres", RestoreOptions{Tag: "seeded", Into: "postgres-copy"}); err != nil {
+		t.Fatalf("Restore() error = %v", err)
+	}
+	if len(restoredBy) != 1 || restoredBy[0] != cfg.ContainerName("postgres-copy") {
+		t.Errorf("pg_restore ran in %v, want the copy's container", restoredBy)
+	}
+
+	err = svc.Restore(ctx, cfg, "postgres", RestoreOptions{Into: "cache"})
+	if err == nil || !strings.Contains(err.Error(), "cannot be restored into cache") {
+		t.Errorf("Restore() into redis error = %v, want engine mismatch", err)
+	}
+
+	err = svc.Restore(ctx, cfg, "postgres", RestoreOptions{Into: "postgres-old"})
+	if err == nil || !strings.Contains(err.Error(), "older postgres:15") {
+		t.Errorf("Restore() into an older version error = %v, want version mismatch", err)
+	}
+	// --force only tolerates restore errors
+	err = svc.Restore(ctx, cfg, "postgres", RestoreOptions{Into: "postgres-old", Force: true})
+	if err == nil || !strings.Contains(err.Error(), "older postgres:15") {
+		t.Errorf("Restore() with force error = %v, want version mismatch", err)
+	}
+	if err := svc.Restore(ctx, cfg, "postgres", RestoreOptions{Into: "postgres-old", AllowOlder: true}); err != nil {
+		t.Errorf("Restore() with allow older error = %v", err)
+	}
+}
@@ -0,0 +1,72 @@
+package snapshot
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"filippo.io/age"
+	"github.com/abdultolba/nizam/internal/compress"
+)
+
+// Encryption methods recorded in a manifest
+const (
+	EncryptionNone = "none"
+	EncryptionAge  = "age"
+)
+
+// ParseRecipients parses age public keys (age1...)
+func ParseRecipients(keys []string) ([]age.Recipient, error) {
+	recipients := make([]age.Recipient, 0, len(keys))
+	for _, key := range keys {
+		recipient, err := age.ParseX25519Recipient(strings.TrimSpace(key))
+		if err != nil {
+			return nil, fmt.Errorf("invalid age recipient %q: %w", key, err)
+		}
+		recipients = append(recipients, recipient)
+	}
+	return recipients, nil
+}
+
+// LoadIdentities reads age private keys from an identity file, as written
+// by age-keygen
+func LoadIdentities(path string) ([]age.Identity, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open identity file: %w", err)
+	}
+	defer file.Close()
+
+	identities, err := age.ParseIdentities(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse identity file %s: %w", path, err)
+	}
+	return identities, nil
+}
+
+// newDataWriter creates the writer for a snapshot data file, encrypting it
+// to the recipients in opts if there are any
+func newDataWriter(path string, opts CreateOptions) (*compress.CompressedWriter, error) {
+	recipients, err := ParseRecipients(opts.Recipients)
+	if err != nil {
+		return nil, err
+	}
+	return compress.NewEncryptedWriter(path, opts.Compression, recipients...)
+}
+
+// openDataReader opens a snapshot data file for reading, decrypting it with
+// the identity file in opts if the snapshot is encrypted
+func openDataReader(path string, manifest *SnapshotManifest, opts RestoreOptions) (*compress.CompressedReader, error) {
+	if !manifest.IsEncrypted() {
+		return compress.NewCompressedReader(path, manifest.GetCompression())
+	}
+
+	if opts.IdentityFile == "" {
+		return nil, fmt.Errorf("snapshot is encrypted with age; an identity file is required to restore it")
+	}
+	identities, err := LoadIdentities(opts.IdentityFile)
+	if err != nil {
+		return nil, err
+	}
+	return compress.NewEncryptedReader(path, manifest.GetCompression(), identities...)
+}
@@ -0,0 +1,168 @@
+package snapshot
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"filippo.io/age"
+	"github.com/abdultolba/nizam/internal/compress"
+	"github.com/abdultolba/nizam/internal/resolve"
+	"github.com/abdultolba/nizam/internal/runtime"
+)
+
+// writeIdentity generates an age identity and writes it to a file in a
+// temporary directory
+func writeIdentity(t *testing.T) (*age.X25519Identity, string) {
+	t.Helper()
+	identity, err := age.GenerateX25519Identity()
+	if err != nil {
+		t.Fatalf("GenerateX25519Identity() error = %v", err)
+	}
+	path := filepath.Join(t.TempDir(), "age.key")
+	if err := os.WriteFile(path, []byte(identity.String()+"\n"), 0o600); err != nil {
+		t.Fatalf("failed to write identity: %v", err)
+	}
+	return identity, path
+}
+
+func TestPostgreSQLEngine_EncryptedCreateAndRestore(t *testing.T) {
+	ctx := context.Background()
+	fake := newPostgresFake()
+	engine := NewPostgreSQLEngine(fake)
+
+	var restored []byte
+	fake.HandleExec("pg_restore", func(call runtime.ExecCall) runtime.ExecResult {
+		restored = call.Stdin
+		return runtime.ExecResult{}
+	})
+
+	identity, identityFile := writeIdentity(t)
+	recipient := identity.Recipient().String()
+
+	dir := t.TempDir()
+	manifest, err := engine.Create(ctx, testPostgresService, dir, CreateOptions{
+		Compression: compress.CompNone,
+		Recipients:  []string{recipient},
+	})
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	if manifest.Encryption != EncryptionAge || len(manifest.Recipients) != 1 || manifest.Recipients[0] != recipient {
+		t.Fatalf("manifest does not record encryption: %+v", manifest)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, manifest.Files[0].Name))
+	if err != nil {
+		t.Fatalf("failed to read data file: %v", err)
+	}
+	if bytes.Contains(data, []byte("PGDMP")) {
+		t.Error("data file contains the plaintext dump")
+	}
+	if checksum, _ := compress.CalculateSHA256(filepath.Join(dir, manifest.Files[0].Name)); checksum != manifest.Files[0].Sha256 {
+		t.Errorf("checksum %s does not match the file on disk (%s)", manifest.Files[0].Sha256, checksum)
+	}
+
+	err = engine.Restore(ctx, testPostgresService, dir, manifest, RestoreOptions{})
+	if err == nil || !strings.Contains(err.Error(), "identity file is required") {
+		t.Errorf("Restore() without identity error = %v", err)
+	}
+
+	_, otherFile := writeIdentity(t)
+	if err := engine.Restore(ctx, testPostgresService, dir, manifest, RestoreOptions{IdentityFile: otherFile}); err == nil {
+		t.Error("Restore() with the wrong identity succeeded")
+	}
+
+	if err := engine.Restore(ctx, testPostgresService, dir, manifest, RestoreOptions{IdentityFile: identityFile}); err != nil {
+		t.Fatalf("Restore() error = %v", err)
+	}
+	if string(restored) != "PGDMP custom archive" {
+		t.Errorf("pg_restore read %q, want the dumped archive", restored)
+	}
+}
+
+func TestEncryptedRestore_KeepsDataWithoutIdentity(t *testing.T) {
+	identity, _ := writeIdentity(t)
+
+	// An encrypted dump, written the way Create does
+	dir := t.TempDir()
+	var encrypted bytes.Buffer
+	w, err := age.Encrypt(&encrypted, identity.Recipient())
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.Write([]byte("dump"))
+	w.Close()
+	dataPath := filepath.Join(dir, "dump.sql")
+	if err := os.WriteFile(dataPath, encrypted.Bytes(), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	checksum, _ := compress.CalculateSHA256(dataPath)
+
+	tests := []struct {
+		name   string
+		engine func(runtime.Runtime) Engine
+	}{
+		{name: "mysql", engine: func(rt runtime.Runtime) Engine { return NewMySQLEngine(rt) }},
+		{name: "mongo", engine: func(rt runtime.Runtime) Engine { return NewMongoDBEngine(rt) }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := runtime.NewFake()
+			fake.AddContainer(runtime.Container{Name: "nizam_test_db"})
+			service := resolve.ServiceInfo{Name: "db", Engine: tt.name, User: "root", Database: "app", Container: "nizam_test_db"}
+
+			manifest := NewSnapshotManifest("db", tt.name, tt.name, "", "", compress.CompNone)
+			manifest.SetRecipients([]string{identity.Recipient().String()})
+			manifest.AddFile("dump.sql", checksum, int64(encrypted.Len()))
+
+			err := tt.engine(fake).Restore(context.Background(), service, dir, &manifest, RestoreOptions{Force: true})
+			if err == nil || !strings.Contains(err.Error(), "identity file is required") {
+				t.Errorf("Restore() error = %v, want a missing identity", err)
+			}
+			// The database was not dropped
+			if len(fake.Execs) != 0 {
+				t.Errorf("Restore() ran %q before failing", fake.Execs[0].Command())
+			}
+		})
+	}
+}
+
+func TestParseRecipients(t *testing.T) {
+	identity, _ := writeIdentity(t)
+	recipients, err := ParseRecipients([]string{" " + identity.Recipient().String() + " "})
+	if err != nil || len(recipients) != 1 {
+		t.Errorf("ParseRecipients() = %v, %v", recipients, err)
+	}
+
+	if _, err := ParseRecipients([]string{"ssh-ed25519 AAAA"}); err == nil {
+		t.Error("ParseRecipients() accepted an invalid key")
+	}
+}
+
+func TestManifestEncryption(t *testing.T) {
+	manifest := NewSnapshotManifest("postgres", "postgres", "postgres:16", "", "", compress.CompZstd)
+	manifest.AddFile("pg.dump.zst", "abc", 1)
+	if manifest.IsEncrypted() {
+		t.Error("new manifest should not be encrypted")
+	}
+
+	manifest.SetRecipients(nil)
+	if manifest.IsEncrypted() {
+		t.Error("SetRecipients(nil) should not enable encryption")
+	}
+
+	manifest.SetRecipients([]string{"age1example"})
+	if !manifest.IsEncrypted() || manifest.Encryption != EncryptionAge {
+		t.Errorf("SetRecipients() encryption = %q", manifest.Encryption)
+	}
+
+	manifest.Encryption = "gpg"
+	if err := manifest.Validate(); err == nil {
+		t.Error("Validate() accepted an unknown encryption method")
+	}
+}
@@ -2,6 +2,7 @@ package snapshot
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"os"
@@ -10,19 +11,19 @@ import (
 	"time"
 
 	"github.com/abdultolba/nizam/internal/compress"
-	"github.com/abdultolba/nizam/internal/dockerx"
 	"github.com/abdultolba/nizam/internal/resolve"
+	"github.com/abdultolba/nizam/internal/runtime"
 	"github.com/rs/zerolog/log"
 )
 
 // MongoDBEngine implements snapshot operations for MongoDB
 type MongoDBEngine struct {
-	docker *dockerx.Client
+	rt runtime.Runtime
 }
 
 // NewMongoDBEngine creates a new MongoDB snapshot engine
-func NewMongoDBEngine(docker *dockerx.Client) *MongoDBEngine {
-	return &MongoDBEngine{docker: docker}
+func NewMongoDBEngine(rt runtime.Runtime) *MongoDBEngine {
+	return &MongoDBEngine{rt: rt}
 }
 
 // GetEngineType returns the engine type
@@ -36,7 +37,8 @@ func (e *MongoDBEngine) CanHandle(engine string) bool {
 }
 
 // Create creates a snapshot of a MongoDB database
-func (e *MongoDBEngine) Create(ctx context.Context, service resolve.ServiceInfo, outputDir string, comp compress.Compression, note string, tag string) (*SnapshotManifest, error) {
+func (e *MongoDBEngine) Create(ctx context.Context, service resolve.ServiceInfo, outputDir string, opts CreateOptions) (*SnapshotManifest, error) {
+	comp := opts.Compression
 	log.Info().
 		Str("service", service.Name).
 		Str("database", service.Database).
@@ -44,7 +46,8 @@ func (e *MongoDBEngine) Create(ctx context.Context, service resolve.ServiceInfo,
 		Msg("Creating MongoDB snapshot")
 
 	// Create manifest
-	manifest := NewSnapshotManifest(service.Name, service.Engine, service.Image, tag, note, comp)
+	manifest := NewSnapshotManifest(service.Name, service.Engine, service.Image, opts.Tag, opts.Note, comp)
+	manifest.SetRecipients(opts.Recipients)
 
 	// Determine output filename
 	var extension string
@@ -61,7 +64,7 @@ func (e *MongoDBEngine) Create(ctx context.Context, service resolve.ServiceInfo,
 	tempFile := outputFile + ".tmp"
 
 	// Create compressed writer
-	writer, err := compress.NewCompressedWriter(tempFile, comp)
+	writer, err := newDataWriter(tempFile, opts)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create compressed writer: %w", err)
 	}
@@ -91,7 +94,7 @@ func (e *MongoDBEngine) Create(ctx context.Context, service resolve.ServiceInfo,
 		Msg("Executing mongodump")
 
 	// Execute mongodump and stream to compressed writer
-	reader, err := e.docker.ExecStreaming(ctx, service.Container, cmd, nil)
+	reader, err := e.rt.ExecStreaming(ctx, service.Container, cmd, nil)
 	if err != nil {
 		writer.Close() // Close writer to cleanup
 		os.Remove(tempFile)
@@ -142,7 +145,7 @@ func (e *MongoDBEngine) Create(ctx context.Context, service resolve.ServiceInfo,
 }
 
 // Restore restores a MongoDB database from a snapshot
-func (e *MongoDBEngine) Restore(ctx context.Context, service resolve.ServiceInfo, snapshotDir string, manifest *SnapshotManifest, force bool) error {
+func (e *MongoDBEngine) Restore(ctx context.Context, service resolve.ServiceInfo, snapshotDir string, manifest *SnapshotManifest, opts RestoreOptions) error {
 	log.Info().
 		Str("service", service.Name).
 		Str("database", service.Database).
@@ -168,7 +171,7 @@ func (e *MongoDBEngine) Restore(ctx context.Context, service resolve.ServiceInfo
 	}
 
 	// Check if container is running
-	running, err := e.docker.ContainerIsRunning(ctx, service.Container)
+	running, err := runtime.IsRunning(ctx, e.rt, service.Container)
 	if err != nil {
 		return fmt.Errorf("failed to check container status: %w", err)
 	}
@@ -176,29 +179,34 @@ func (e *MongoDBEngine) Restore(ctx context.Context, service resolve.ServiceInfo
 		return fmt.Errorf("container %s is not running", service.Container)
 	}
 
+	// Open compressed reader before touching the database, so a missing
+	// identity fails the restore with the data still in place
+	reader, err := openDataReader(snapshotFile, manifest, opts)
+	if err != nil {
+		return fmt.Errorf("failed to create compressed reader: %w", err)
+	}
+	defer reader.Close()
+
 	// Drop database if force is enabled
-	if force {
+	if opts.Force && !opts.Merge {
 		if err := e.dropDatabase(ctx, service); err != nil {
 			return fmt.Errorf("failed to drop database: %w", err)
 		}
 	}
 
-	// Open compressed reader
-	reader, err := compress.NewCompressedReader(snapshotFile, manifest.GetCompression())
-	if err != nil {
-		return fmt.Errorf("failed to create compressed reader: %w", err)
-	}
-	defer reader.Close()
-
 	// Build mongorestore command
 	cmd := []string{
 		"mongorestore",
 		"--host", "localhost:27017", // Connect within container
 		"--db", service.Database,
-		"--archive",    // Read from stdin as archive
-		"--gzip",       // Handle gzip decompression
-		"--drop",       // Drop collections before restoring
-		"--stopOnError", // Stop on first error
+		"--archive", // Read from stdin as archive
+		"--gzip",    // Handle gzip decompression
+	}
+	if !opts.Merge {
+		cmd = append(cmd,
+			"--drop",        // Drop collections before restoring
+			"--stopOnError", // Stop on first error
+		)
 	}
 
 	// Add authentication if provided
@@ -215,24 +223,23 @@ func (e *MongoDBEngine) Restore(ctx context.Context, service resolve.ServiceInfo
 		Msg("Executing mongorestore")
 
 	// Execute mongorestore with streaming input
-	execReader, err := e.docker.ExecStreaming(ctx, service.Container, cmd, reader)
+	execReader, err := e.rt.ExecStreaming(ctx, service.Container, cmd, reader)
 	if err != nil {
 		return fmt.Errorf("failed to execute mongorestore: %w", err)
 	}
 	defer execReader.Close()
 
-	// Read output (for potential error messages)
-	output, err := io.ReadAll(execReader)
-	if err != nil {
-		return fmt.Errorf("failed to read mongorestore output: %w", err)
-	}
+	// Drain the output; a non-zero exit surfaces as an *ExitError carrying
+	// mongorestore's log, which it writes to stderr
+	if _, err := io.Copy(io.Discard, execReader); err != nil {
+		var exitErr *runtime.ExitError
+		if !errors.As(err, &exitErr) {
+			return fmt.Errorf("failed to read mongorestore output: %w", err)
+		}
 
-	// Check for errors in output
-	outputStr := string(output)
-	if strings.Contains(outputStr, "error") || strings.Contains(outputStr, "failed") {
-		log.Warn().Str("mongorestore_output", outputStr).Msg("MongoDB restore completed with warnings")
-		if !force {
-			return fmt.Errorf("mongorestore failed: %s", outputStr)
+		log.Warn().Str("mongorestore_output", exitErr.Stderr).Msg("MongoDB restore completed with errors")
+		if !opts.Force && mongoRestoreFailed(exitErr.Stderr, opts.Merge) {
+			return fmt.Errorf("mongorestore failed: %w", err)
 		}
 	}
 
@@ -243,6 +250,22 @@ func (e *MongoDBEngine) Restore(ctx context.Context, service resolve.ServiceInfo
 	return nil
 }
 
+// mongoRestoreFailed reports whether mongorestore output shows errors. When
+// merging, documents that already exist are skipped with duplicate key
+// errors, which are expected, and counted in the "failed to restore"
+// summary.
+func mongoRestoreFailed(output string, merge bool) bool {
+	for _, line := range strings.Split(output, "\n") {
+		if merge && (strings.Contains(line, "duplicate key") || strings.Contains(line, "document(s) failed to restore")) {
+			continue
+		}
+		if strings.Contains(line, "error") || strings.Contains(line, "failed") {
+			return true
+		}
+	}
+	return false
+}
+
 // dropDatabase drops the target database for a clean restore
 func (e *MongoDBEngine) dropDatabase(ctx context.Context, service resolve.ServiceInfo) error {
 	log.Debug().
@@ -268,7 +291,7 @@ func (e *MongoDBEngine) dropDatabase(ctx context.Context, service resolve.Servic
 	}
 
 	// Execute command
-	result, err := e.docker.ExecCommand(ctx, service.Container, cmd)
+	result, err := e.rt.ExecCommand(ctx, service.Container, cmd)
 	if err != nil {
 		return fmt.Errorf("failed to drop database: %w", err)
 	}
@@ -305,7 +328,7 @@ func (e *MongoDBEngine) waitForMongoReady(ctx context.Context, service resolve.S
 	timeout := time.Now().Add(30 * time.Second)
 
 	for time.Now().Before(timeout) {
-		result, err := e.docker.ExecCommand(ctx, service.Container, cmd)
+		result, err := e.rt.ExecCommand(ctx, service.Container, cmd)
 		if err == nil && result.ExitCode == 0 && strings.Contains(result.Stdout, "ok") {
 			return nil
 		}
@@ -1,13 +1,16 @@
 package snapshot
 
 import (
+	"context"
+	"strings"
 	"testing"
 
-	"github.com/abdultolba/nizam/internal/dockerx"
+	"github.com/abdultolba/nizam/internal/resolve"
+	"github.com/abdultolba/nizam/internal/runtime"
 )
 
 func TestMongoDBEngine_GetEngineType(t *testing.T) {
-	docker := &dockerx.Client{} // Mock client for testing
+	docker := runtime.NewFake()
 	engine := NewMongoDBEngine(docker)
 
 	if got := engine.GetEngineType(); got != "mongo" {
@@ -16,7 +19,7 @@ func TestMongoDBEngine_GetEngineType(t *testing.T) {
 }
 
 func TestMongoDBEngine_CanHandle(t *testing.T) {
-	docker := &dockerx.Client{} // Mock client for testing
+	docker := runtime.NewFake()
 	engine := NewMongoDBEngine(docker)
 
 	tests := []struct {
@@ -59,3 +62,43 @@ func TestMongoDBEngine_CanHandle(t *testing.T) {
 		})
 	}
 }
+
+func TestMongoRestoreFailed(t *testing.T) {
+	duplicate := "continuing through error: E11000 duplicate key error collection: app.users index: _id_\n1 document(s) restored successfully."
+	if !mongoRestoreFailed(duplicate, false) {
+		t.Error("duplicate keys should fail a replacing restore")
+	}
+	if mongoRestoreFailed(duplicate, true) {
+		t.Error("duplicate keys should not fail a merge")
+	}
+	summary := duplicate + "\n1 document(s) failed to restore."
+	if mongoRestoreFailed(summary, true) {
+		t.Error("documents skipped as duplicates should not fail a merge")
+	}
+	if !mongoRestoreFailed("Failed: app.users: error reading collection", true) {
+		t.Error("other errors should fail a merge")
+	}
+}
+
+func TestMongoDBEngine_RestoreFailure(t *testing.T) {
+	ctx := context.Background()
+	stderr := "continuing through error: E11000 duplicate key error collection: app.users index: _id_\n0 document(s) restored successfully. 1 document(s) failed to restore."
+	fake := runtime.NewFake()
+	fake.AddContainer(runtime.Container{Name: "nizam_test_mongo"})
+	fake.HandleExec("mongorestore", func(runtime.ExecCall) runtime.ExecResult {
+		return runtime.ExecResult{ExitCode: 1, Stderr: stderr}
+	})
+	engine := NewMongoDBEngine(fake)
+	service := resolve.ServiceInfo{Name: "mongo", Engine: "mongo", Database: "app", Container: "nizam_test_mongo"}
+	dir, manifest := writePlainSnapshot(t, "mongo", "archive")
+
+	err := engine.Restore(ctx, service, dir, manifest, RestoreOptions{})
+	if err == nil || !strings.Contains(err.Error(), "duplicate key") {
+		t.Errorf("Restore() error = %v, want mongorestore's stderr", err)
+	}
+
+	// Duplicates are expected when merging
+	if err := engine.Restore(ctx, service, dir, manifest, RestoreOptions{Merge: true}); err != nil {
+		t.Errorf("Restore(merge) error = %v", err)
+	}
+}
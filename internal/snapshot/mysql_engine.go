@@ -1,7 +1,9 @@
 package snapshot
 
 import (
+	"bufio"
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"os"
@@ -9,19 +11,19 @@ import (
 	"strings"
 
 	"github.com/abdultolba/nizam/internal/compress"
-	"github.com/abdultolba/nizam/internal/dockerx"
 	"github.com/abdultolba/nizam/internal/resolve"
+	"github.com/abdultolba/nizam/internal/runtime"
 	"github.com/rs/zerolog/log"
 )
 
 // MySQLEngine implements snapshot operations for MySQL
 type MySQLEngine struct {
-	docker *dockerx.Client
+	rt runtime.Runtime
 }
 
 // NewMySQLEngine creates a new MySQL snapshot engine
-func NewMySQLEngine(docker *dockerx.Client) *MySQLEngine {
-	return &MySQLEngine{docker: docker}
+func NewMySQLEngine(rt runtime.Runtime) *MySQLEngine {
+	return &MySQLEngine{rt: rt}
 }
 
 // GetEngineType returns the engine type
@@ -35,7 +37,8 @@ func (e *MySQLEngine) CanHandle(engine string) bool {
 }
 
 // Create creates a snapshot of a MySQL database
-func (e *MySQLEngine) Create(ctx context.Context, service resolve.ServiceInfo, outputDir string, comp compress.Compression, note string, tag string) (*SnapshotManifest, error) {
+func (e *MySQLEngine) Create(ctx context.Context, service resolve.ServiceInfo, outputDir string, opts CreateOptions) (*SnapshotManifest, error) {
+	comp := opts.Compression
 	log.Info().
 		Str("service", service.Name).
 		Str("database", service.Database).
@@ -43,7 +46,8 @@ func (e *MySQLEngine) Create(ctx context.Context, service resolve.ServiceInfo, o
 		Msg("Creating MySQL snapshot")
 
 	// Create manifest
-	manifest := NewSnapshotManifest(service.Name, service.Engine, service.Image, tag, note, comp)
+	manifest := NewSnapshotManifest(service.Name, service.Engine, service.Image, opts.Tag, opts.Note, comp)
+	manifest.SetRecipients(opts.Recipients)
 
 	// Determine output filename
 	var extension string
@@ -60,7 +64,7 @@ func (e *MySQLEngine) Create(ctx context.Context, service resolve.ServiceInfo, o
 	tempFile := outputFile + ".tmp"
 
 	// Create compressed writer
-	writer, err := compress.NewCompressedWriter(tempFile, comp)
+	writer, err := newDataWriter(tempFile, opts)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create compressed writer: %w", err)
 	}
@@ -93,7 +97,7 @@ func (e *MySQLEngine) Create(ctx context.Context, service resolve.ServiceInfo, o
 		Msg("Executing mysqldump")
 
 	// Execute mysqldump and stream to compressed writer
-	reader, err := e.docker.ExecStreaming(ctx, service.Container, cmd, nil)
+	reader, err := e.rt.ExecStreaming(ctx, service.Container, cmd, nil)
 	if err != nil {
 		writer.Close() // Close writer to cleanup
 		os.Remove(tempFile)
@@ -144,7 +148,7 @@ func (e *MySQLEngine) Create(ctx context.Context, service resolve.ServiceInfo, o
 }
 
 // Restore restores a MySQL database from a snapshot
-func (e *MySQLEngine) Restore(ctx context.Context, service resolve.ServiceInfo, snapshotDir string, manifest *SnapshotManifest, force bool) error {
+func (e *MySQLEngine) Restore(ctx context.Context, service resolve.ServiceInfo, snapshotDir string, manifest *SnapshotManifest, opts RestoreOptions) error {
 	log.Info().
 		Str("service", service.Name).
 		Str("database", service.Database).
@@ -170,7 +174,7 @@ func (e *MySQLEngine) Restore(ctx context.Context, service resolve.ServiceInfo,
 	}
 
 	// Check if container is running
-	running, err := e.docker.ContainerIsRunning(ctx, service.Container)
+	running, err := runtime.IsRunning(ctx, e.rt, service.Container)
 	if err != nil {
 		return fmt.Errorf("failed to check container status: %w", err)
 	}
@@ -178,19 +182,27 @@ func (e *MySQLEngine) Restore(ctx context.Context, service resolve.ServiceInfo,
 		return fmt.Errorf("container %s is not running", service.Container)
 	}
 
+	// Open compressed reader before touching the database, so a missing
+	// identity fails the restore with the data still in place
+	reader, err := openDataReader(snapshotFile, manifest, opts)
+	if err != nil {
+		return fmt.Errorf("failed to create compressed reader: %w", err)
+	}
+	defer reader.Close()
+
 	// Drop and recreate database if force is enabled
-	if force {
+	if opts.Force && !opts.Merge {
 		if err := e.recreateDatabase(ctx, service); err != nil {
 			return fmt.Errorf("failed to recreate database: %w", err)
 		}
 	}
 
-	// Open compressed reader
-	reader, err := compress.NewCompressedReader(snapshotFile, manifest.GetCompression())
-	if err != nil {
-		return fmt.Errorf("failed to create compressed reader: %w", err)
+	var input io.Reader = reader
+	if opts.Merge {
+		merged := mergeStatements(reader)
+		defer merged.Close()
+		input = merged
 	}
-	defer reader.Close()
 
 	// Build mysql command
 	cmd := []string{
@@ -213,24 +225,25 @@ func (e *MySQLEngine) Restore(ctx context.Context, service resolve.ServiceInfo,
 		Msg("Executing mysql")
 
 	// Execute mysql with streaming input
-	execReader, err := e.docker.ExecStreaming(ctx, service.Container, cmd, reader)
+	execReader, err := e.rt.ExecStreaming(ctx, service.Container, cmd, input)
 	if err != nil {
 		return fmt.Errorf("failed to execute mysql: %w", err)
 	}
 	defer execReader.Close()
 
-	// Read output (for potential error messages)
-	output, err := io.ReadAll(execReader)
-	if err != nil {
-		return fmt.Errorf("failed to read mysql output: %w", err)
-	}
+	// Drain the output; a non-zero exit surfaces as an *ExitError carrying
+	// mysql's stderr
+	if _, err := io.Copy(io.Discard, execReader); err != nil {
+		var exitErr *runtime.ExitError
+		if !errors.As(err, &exitErr) {
+			return fmt.Errorf("failed to read mysql output: %w", err)
+		}
 
-	// Check for errors in output
-	outputStr := string(output)
-	if strings.Contains(outputStr, "ERROR") {
-		log.Warn().Str("mysql_output", outputStr).Msg("MySQL restore completed with warnings")
-		if !force {
-			return fmt.Errorf("mysql restore failed: %s", outputStr)
+		log.Warn().Str("mysql_output", exitErr.Stderr).Msg("MySQL restore completed with errors")
+		// Merging rewrites the dump so existing tables and rows are skipped;
+		// errors it still reports are real, and only --force tolerates them
+		if !opts.Force {
+			return fmt.Errorf("mysql restore failed: %w", err)
 		}
 	}
 
@@ -265,7 +278,7 @@ func (e *MySQLEngine) recreateDatabase(ctx context.Context, service resolve.Serv
 	}
 
 	// Execute command
-	result, err := e.docker.ExecCommand(ctx, service.Container, cmd)
+	result, err := e.rt.ExecCommand(ctx, service.Container, cmd)
 	if err != nil {
 		return fmt.Errorf("failed to recreate database: %w", err)
 	}
@@ -277,6 +290,47 @@ func (e *MySQLEngine) recreateDatabase(ctx context.Context, service resolve.Serv
 	return nil
 }
 
+// mergeStatements rewrites a mysqldump stream to add to an existing
+// database: tables are no longer dropped, existing tables are kept and rows
+// whose keys already exist are skipped. The caller closes the returned
+// reader.
+func mergeStatements(r io.Reader) *io.PipeReader {
+	pr, pw := io.Pipe()
+	go func() {
+		br := bufio.NewReader(r)
+		for {
+			line, err := br.ReadString('\n')
+			if line != "" {
+				if _, werr := io.WriteString(pw, mergeStatement(line)); werr != nil {
+					pw.CloseWithError(werr)
+					return
+				}
+			}
+			if err != nil {
+				if err == io.EOF {
+					err = nil
+				}
+				pw.CloseWithError(err)
+				return
+			}
+		}
+	}()
+	return pr
+}
+
+// mergeStatement rewrites one line of a mysqldump for mergeStatements
+func mergeStatement(line string) string {
+	switch {
+	case strings.HasPrefix(line, "DROP TABLE IF EXISTS "):
+		return ""
+	case strings.HasPrefix(line, "CREATE TABLE `"):
+		return "CREATE TABLE IF NOT EXISTS " + strings.TrimPrefix(line, "CREATE TABLE ")
+	case strings.HasPrefix(line, "INSERT INTO "):
+		return "INSERT IGNORE INTO " + strings.TrimPrefix(line, "INSERT INTO ")
+	}
+	return line
+}
+
 // verifyChecksum verifies the SHA256 checksum of a file
 func (e *MySQLEngine) verifyChecksum(path, expectedChecksum string) error {
 	actualChecksum, err := compress.CalculateSHA256(path)
@@ -1,13 +1,36 @@
 package snapshot
 
 import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 
-	"github.com/abdultolba/nizam/internal/dockerx"
+	"github.com/abdultolba/nizam/internal/compress"
+	"github.com/abdultolba/nizam/internal/resolve"
+	"github.com/abdultolba/nizam/internal/runtime"
 )
 
+// writePlainSnapshot writes an uncompressed snapshot of data for engine and
+// returns its directory and manifest
+func writePlainSnapshot(t *testing.T, engine, data string) (string, *SnapshotManifest) {
+	t.Helper()
+	dir := t.TempDir()
+	dataPath := filepath.Join(dir, "dump")
+	if err := os.WriteFile(dataPath, []byte(data), 0o644); err != nil {
+		t.Fatalf("failed to write data: %v", err)
+	}
+	checksum, _ := compress.CalculateSHA256(dataPath)
+
+	manifest := NewSnapshotManifest("db", engine, engine, "", "", compress.CompNone)
+	manifest.AddFile("dump", checksum, int64(len(data)))
+	return dir, &manifest
+}
+
 func TestMySQLEngine_GetEngineType(t *testing.T) {
-	docker := &dockerx.Client{} // Mock client
+	docker := runtime.NewFake()
 	engine := NewMySQLEngine(docker)
 
 	if got := engine.GetEngineType(); got != "mysql" {
@@ -16,7 +39,7 @@ func TestMySQLEngine_GetEngineType(t *testing.T) {
 }
 
 func TestMySQLEngine_CanHandle(t *testing.T) {
-	docker := &dockerx.Client{} // Mock client
+	docker := runtime.NewFake()
 	engine := NewMySQLEngine(docker)
 
 	tests := []struct {
@@ -54,3 +77,46 @@ func TestMySQLEngine_CanHandle(t *testing.T) {
 		})
 	}
 }
+
+func TestMergeStatements(t *testing.T) {
+	dump := "DROP TABLE IF EXISTS `users`;\n" +
+		"CREATE TABLE `users` (\n  `id` int NOT NULL\n);\n" +
+		"INSERT INTO `users` (`id`) VALUES (1),(2);\n" +
+		"-- INSERT INTO in a comment\n"
+
+	merged := mergeStatements(strings.NewReader(dump))
+	defer merged.Close()
+	out, err := io.ReadAll(merged)
+	if err != nil {
+		t.Fatalf("ReadAll() error = %v", err)
+	}
+
+	want := "CREATE TABLE IF NOT EXISTS `users` (\n  `id` int NOT NULL\n);\n" +
+		"INSERT IGNORE INTO `users` (`id`) VALUES (1),(2);\n" +
+		"-- INSERT INTO in a comment\n"
+	if string(out) != want {
+		t.Errorf("mergeStatements() =\n%s\nwant\n%s", out, want)
+	}
+}
+
+func TestMySQLEngine_RestoreFailure(t *testing.T) {
+	ctx := context.Background()
+	fake := runtime.NewFake()
+	fake.AddContainer(runtime.Container{Name: "nizam_test_mysql"})
+	fake.HandleExec("mysql -u root -h localhost app", func(runtime.ExecCall) runtime.ExecResult {
+		return runtime.ExecResult{ExitCode: 1, Stderr: "ERROR 1050 (42S01) at line 3: Table 'users' already exists"}
+	})
+	engine := NewMySQLEngine(fake)
+	service := resolve.ServiceInfo{Name: "mysql", Engine: "mysql", User: "root", Database: "app", Container: "nizam_test_mysql"}
+	dir, manifest := writePlainSnapshot(t, "mysql", "CREATE TABLE `users` (id int);\n")
+
+	err := engine.Restore(ctx, service, dir, manifest, RestoreOptions{})
+	if err == nil || !strings.Contains(err.Error(), "already exists") {
+		t.Errorf("Restore() error = %v, want mysql's stderr", err)
+	}
+
+	// With force, errors reported by mysql are only logged
+	if err := engine.Restore(ctx, service, dir, manifest, RestoreOptions{Force: true}); err != nil {
+		t.Errorf("Restore(force) error = %v", err)
+	}
+}
@@ -2,6 +2,7 @@ package snapshot
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"os"
@@ -9,23 +10,24 @@ import (
 	"strings"
 
 	"github.com/abdultolba/nizam/internal/compress"
-	"github.com/abdultolba/nizam/internal/dockerx"
 	"github.com/abdultolba/nizam/internal/resolve"
+	"github.com/abdultolba/nizam/internal/runtime"
 	"github.com/rs/zerolog/log"
 )
 
 // PostgreSQLEngine implements snapshot operations for PostgreSQL
 type PostgreSQLEngine struct {
-	docker *dockerx.Client
+	rt runtime.Runtime
 }
 
 // NewPostgreSQLEngine creates a new PostgreSQL snapshot engine
-func NewPostgreSQLEngine(docker *dockerx.Client) *PostgreSQLEngine {
-	return &PostgreSQLEngine{docker: docker}
+func NewPostgreSQLEngine(rt runtime.Runtime) *PostgreSQLEngine {
+	return &PostgreSQLEngine{rt: rt}
 }
 
 // Create creates a snapshot of a PostgreSQL database
-func (e *PostgreSQLEngine) Create(ctx context.Context, service resolve.ServiceInfo, outputDir string, comp compress.Compression, note string, tag string) (*SnapshotManifest, error) {
+func (e *PostgreSQLEngine) Create(ctx context.Context, service resolve.ServiceInfo, outputDir string, opts CreateOptions) (*SnapshotManifest, error) {
+	comp := opts.Compression
 	log.Info().
 		Str("service", service.Name).
 		Str("database", service.Database).
@@ -33,7 +35,8 @@ func (e *PostgreSQLEngine) Create(ctx context.Context, service resolve.ServiceIn
 		Msg("Creating PostgreSQL snapshot")
 
 	// Create manifest
-	manifest := NewSnapshotManifest(service.Name, service.Engine, service.Image, tag, note, comp)
+	manifest := NewSnapshotManifest(service.Name, service.Engine, service.Image, opts.Tag, opts.Note, comp)
+	manifest.SetRecipients(opts.Recipients)
 
 	// Determine output filename
 	var extension string
@@ -50,7 +53,7 @@ func (e *PostgreSQLEngine) Create(ctx context.Context, service resolve.ServiceIn
 	tempFile := outputFile + ".tmp"
 
 	// Create compressed writer
-	writer, err := compress.NewCompressedWriter(tempFile, comp)
+	writer, err := newDataWriter(tempFile, opts)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create compressed writer: %w", err)
 	}
@@ -65,13 +68,19 @@ func (e *PostgreSQLEngine) Create(ctx context.Context, service resolve.ServiceIn
 		"-d", service.Database,
 	}
 
+	// The custom format compresses table data by default, which defeats
+	// deduplication; the chunk store compresses instead
+	if opts.Storage == StorageChunked {
+		cmd = append(cmd, "--compress=0")
+	}
+
 	log.Debug().
 		Str("container", service.Container).
 		Strs("command", cmd).
 		Msg("Executing pg_dump")
 
 	// Execute pg_dump and stream to compressed writer
-	reader, err := e.docker.ExecStreaming(ctx, service.Container, cmd, nil)
+	reader, err := e.rt.ExecStreaming(ctx, service.Container, cmd, nil)
 	if err != nil {
 		writer.Close() // Close writer to cleanup
 		os.Remove(tempFile)
@@ -122,7 +131,7 @@ func (e *PostgreSQLEngine) Create(ctx context.Context, service resolve.ServiceIn
 }
 
 // Restore restores a PostgreSQL database from a snapshot
-func (e *PostgreSQLEngine) Restore(ctx context.Context, service resolve.ServiceInfo, snapshotDir string, manifest *SnapshotManifest, force bool) error {
+func (e *PostgreSQLEngine) Restore(ctx context.Context, service resolve.ServiceInfo, snapshotDir string, manifest *SnapshotManifest, opts RestoreOptions) error {
 	log.Info().
 		Str("service", service.Name).
 		Str("database", service.Database).
@@ -148,7 +157,7 @@ func (e *PostgreSQLEngine) Restore(ctx context.Context, service resolve.ServiceI
 	}
 
 	// Check if container is running
-	running, err := e.docker.ContainerIsRunning(ctx, service.Container)
+	running, err := runtime.IsRunning(ctx, e.rt, service.Container)
 	if err != nil {
 		return fmt.Errorf("failed to check container status: %w", err)
 	}
@@ -157,23 +166,25 @@ func (e *PostgreSQLEngine) Restore(ctx context.Context, service resolve.ServiceI
 	}
 
 	// Open compressed reader
-	reader, err := compress.NewCompressedReader(snapshotFile, manifest.GetCompression())
+	reader, err := openDataReader(snapshotFile, manifest, opts)
 	if err != nil {
 		return fmt.Errorf("failed to create compressed reader: %w", err)
 	}
 	defer reader.Close()
 
 	// Build pg_restore command
-	cmd := []string{
-		"pg_restore",
-		"--clean",
-		"--if-exists",
+	cmd := []string{"pg_restore"}
+	if !opts.Merge {
+		// Drop existing objects before recreating them
+		cmd = append(cmd, "--clean", "--if-exists")
+	}
+	cmd = append(cmd,
 		"--no-owner",
 		"-U", service.User,
 		"-d", service.Database,
-	}
+	)
 
-	if force {
+	if opts.Force && !opts.Merge {
 		// Add --single-transaction for atomic restore
 		cmd = append(cmd, "--single-transaction")
 	}
@@ -184,24 +195,25 @@ func (e *PostgreSQLEngine) Restore(ctx context.Context, service resolve.ServiceI
 		Msg("Executing pg_restore")
 
 	// Execute pg_restore with streaming input
-	execReader, err := e.docker.ExecStreaming(ctx, service.Container, cmd, reader)
+	execReader, err := e.rt.ExecStreaming(ctx, service.Container, cmd, reader)
 	if err != nil {
 		return fmt.Errorf("failed to execute pg_restore: %w", err)
 	}
 	defer execReader.Close()
 
-	// Read output (for potential error messages)
-	output, err := io.ReadAll(execReader)
-	if err != nil {
-		return fmt.Errorf("failed to read pg_restore output: %w", err)
-	}
+	// Drain the output; a non-zero exit surfaces as an *ExitError carrying
+	// pg_restore's stderr
+	if _, err := io.Copy(io.Discard, execReader); err != nil {
+		var exitErr *runtime.ExitError
+		if !errors.As(err, &exitErr) {
+			return fmt.Errorf("failed to read pg_restore output: %w", err)
+		}
 
-	// Check for errors in output
-	outputStr := string(output)
-	if strings.Contains(outputStr, "ERROR") || strings.Contains(outputStr, "FATAL") {
-		log.Warn().Str("output", outputStr).Msg("pg_restore reported errors")
-		if !force {
-			return fmt.Errorf("pg_restore failed, output: %s", outputStr)
+		log.Warn().Str("output", exitErr.Stderr).Msg("pg_restore reported errors")
+		// Merging into a database that has the schema already reports
+		// every existing object; only other errors fail the restore
+		if !opts.Force && !(opts.Merge && onlyExistingObjectErrors(exitErr.Stderr)) {
+			return fmt.Errorf("pg_restore failed: %w", err)
 		}
 	}
 
@@ -213,6 +225,17 @@ func (e *PostgreSQLEngine) Restore(ctx context.Context, service resolve.ServiceI
 	return nil
 }
 
+// onlyExistingObjectErrors reports whether every error pg_restore printed
+// is about an object that already exists
+func onlyExistingObjectErrors(stderr string) bool {
+	for _, line := range strings.Split(stderr, "\n") {
+		if strings.HasPrefix(line, "pg_restore: error:") && !strings.Contains(line, "already exists") {
+			return false
+		}
+	}
+	return true
+}
+
 // verifyChecksum verifies the SHA256 checksum of a file
 func (e *PostgreSQLEngine) verifyChecksum(path, expectedChecksum string) error {
 	actualChecksum, err := compress.CalculateSHA256(path)
@@ -0,0 +1,155 @@
+package snapshot
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/abdultolba/nizam/internal/compress"
+	"github.com/abdultolba/nizam/internal/resolve"
+	"github.com/abdultolba/nizam/internal/runtime"
+)
+
+var testPostgresService = resolve.ServiceInfo{
+	Name:      "postgres",
+	Engine:    "postgres",
+	User:      "user",
+	Database:  "myapp",
+	Container: "nizam_test_postgres",
+	Image:     "postgres:16",
+}
+
+func newPostgresFake() *runtime.Fake {
+	fake := runtime.NewFake()
+	fake.AddContainer(runtime.Container{Name: testPostgresService.Container})
+	fake.HandleExec("pg_dump", func(runtime.ExecCall) runtime.ExecResult {
+		return runtime.ExecResult{Stdout: "PGDMP custom archive"}
+	})
+	return fake
+}
+
+func TestPostgreSQLEngine_CreateAndRestore(t *testing.T) {
+	ctx := context.Background()
+	fake := newPostgresFake()
+	engine := NewPostgreSQLEngine(fake)
+
+	var restored []byte
+	fake.HandleExec("pg_restore", func(call runtime.ExecCall) runtime.ExecResult {
+		restored = call.Stdin
+		return runtime.ExecResult{}
+	})
+
+	dir := t.TempDir()
+	manifest, err := engine.Create(ctx, testPostgresService, dir, CreateOptions{Compression: compress.CompZstd, Note: "note", Tag: "tag"})
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	if len(manifest.Files) != 1 || manifest.Files[0].Name != "pg.dump.zst" {
+		t.Fatalf("unexpected manifest files: %+v", manifest.Files)
+	}
+
+	dump := fake.Execs[0].Command()
+	if !strings.HasPrefix(dump, "pg_dump --format=custom") || !strings.Contains(dump, "-d myapp") {
+		t.Errorf("unexpected pg_dump command: %s", dump)
+	}
+
+	if err := engine.Restore(ctx, testPostgresService, dir, manifest, RestoreOptions{}); err != nil {
+		t.Fatalf("Restore() error = %v", err)
+	}
+	if string(restored) != "PGDMP custom archive" {
+		t.Errorf("pg_restore read %q, want the dumped archive", restored)
+	}
+}
+
+func TestPostgreSQLEngine_RestoreFailure(t *testing.T) {
+	ctx := context.Background()
+	fake := newPostgresFake()
+	engine := NewPostgreSQLEngine(fake)
+
+	fake.HandleExec("pg_restore", func(runtime.ExecCall) runtime.ExecResult {
+		return runtime.ExecResult{ExitCode: 1, Stderr: "pg_restore: error: relation already exists"}
+	})
+
+	dir := t.TempDir()
+	manifest, err := engine.Create(ctx, testPostgresService, dir, CreateOptions{Compression: compress.CompNone})
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+
+	err = engine.Restore(ctx, testPostgresService, dir, manifest, RestoreOptions{})
+	if err == nil || !strings.Contains(err.Error(), "relation already exists") {
+		t.Errorf("Restore() error = %v, want pg_restore's stderr", err)
+	}
+
+	// With force, errors reported by pg_restore are only logged
+	if err := engine.Restore(ctx, testPostgresService, dir, manifest, RestoreOptions{Force: true}); err != nil {
+		t.Errorf("Restore(force) error = %v", err)
+	}
+}
+
+func TestPostgreSQLEngine_CreateFailure(t *testing.T) {
+	fake := newPostgresFake()
+	engine := NewPostgreSQLEngine(fake)
+
+	fake.HandleExec("pg_dump", func(runtime.ExecCall) runtime.ExecResult {
+		return runtime.ExecResult{ExitCode: 1, Stderr: `pg_dump: error: database "myapp" does not exist`}
+	})
+
+	if _, err := engine.Create(context.Background(), testPostgresService, t.TempDir(), CreateOptions{Compression: compress.CompNone}); err == nil {
+		t.Error("Create() succeeded although pg_dump failed")
+	}
+}
+
+func TestPostgreSQLEngine_RestoreRequiresRunningContainer(t *testing.T) {
+	ctx := context.Background()
+	fake := newPostgresFake()
+	engine := NewPostgreSQLEngine(fake)
+
+	dir := t.TempDir()
+	manifest, err := engine.Create(ctx, testPostgresService, dir, CreateOptions{Compression: compress.CompNone})
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+
+	if err := fake.StopContainer(ctx, testPostgresService.Container, 0); err != nil {
+		t.Fatal(err)
+	}
+	err = engine.Restore(ctx, testPostgresService, dir, manifest, RestoreOptions{})
+	if err == nil || !strings.Contains(err.Error(), "is not running") {
+		t.Errorf("Restore() error = %v, want container not running", err)
+	}
+}
+
+func TestPostgreSQLEngine_MergeToleratesExistingObjects(t *testing.T) {
+	ctx := context.Background()
+	fake := newPostgresFake()
+	engine := NewPostgreSQLEngine(fake)
+
+	dir := t.TempDir()
+	manifest, err := engine.Create(ctx, testPostgresService, dir, CreateOptions{Compression: compress.CompNone})
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+
+	stderr := "pg_restore: error: could not execute query: ERROR:  relation \"users\" already exists\nCommand was: CREATE TABLE public.users (id integer);\npg_restore: warning: errors ignored on restore: 1"
+	fake.HandleExec("pg_restore", func(runtime.ExecCall) runtime.ExecResult {
+		return runtime.ExecResult{ExitCode: 1, Stderr: stderr}
+	})
+
+	if err := engine.Restore(ctx, testPostgresService, dir, manifest, RestoreOptions{}); err == nil {
+		t.Error("Restore() should fail on errors when replacing")
+	}
+	if err := engine.Restore(ctx, testPostgresService, dir, manifest, RestoreOptions{Merge: true}); err != nil {
+		t.Errorf("Restore() merge error = %v", err)
+	}
+	if strings.Contains(fake.Execs[len(fake.Execs)-1].Command(), "--clean") {
+		t.Error("merge should not drop existing objects")
+	}
+
+	fake.HandleExec("pg_restore", func(runtime.ExecCall) runtime.ExecResult {
+		return runtime.ExecResult{ExitCode: 1, Stderr: "pg_restore: error: could not execute query: ERROR:  duplicate key value violates unique constraint"}
+	})
+	if err := engine.Restore(ctx, testPostgresService, dir, manifest, RestoreOptions{Merge: true}); err == nil {
+		t.Error("Restore() merge should fail on other errors")
+	}
+}
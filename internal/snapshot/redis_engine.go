@@ -10,30 +10,35 @@ import (
 	"time"
 
 	"github.com/abdultolba/nizam/internal/compress"
-	"github.com/abdultolba/nizam/internal/dockerx"
 	"github.com/abdultolba/nizam/internal/resolve"
+	"github.com/abdultolba/nizam/internal/runtime"
 	"github.com/rs/zerolog/log"
 )
 
+// redisDumpPath is where the official Redis image keeps its RDB file
+const redisDumpPath = "/data/dump.rdb"
+
 // RedisEngine implements snapshot operations for Redis
 type RedisEngine struct {
-	docker *dockerx.Client
+	rt runtime.Runtime
 }
 
 // NewRedisEngine creates a new Redis snapshot engine
-func NewRedisEngine(docker *dockerx.Client) *RedisEngine {
-	return &RedisEngine{docker: docker}
+func NewRedisEngine(rt runtime.Runtime) *RedisEngine {
+	return &RedisEngine{rt: rt}
 }
 
 // Create creates a snapshot of a Redis database
-func (e *RedisEngine) Create(ctx context.Context, service resolve.ServiceInfo, outputDir string, comp compress.Compression, note string, tag string) (*SnapshotManifest, error) {
+func (e *RedisEngine) Create(ctx context.Context, service resolve.ServiceInfo, outputDir string, opts CreateOptions) (*SnapshotManifest, error) {
+	comp := opts.Compression
 	log.Info().
 		Str("service", service.Name).
 		Str("compression", comp.String()).
 		Msg("Creating Redis snapshot")
 
 	// Create manifest
-	manifest := NewSnapshotManifest(service.Name, service.Engine, service.Image, tag, note, comp)
+	manifest := NewSnapshotManifest(service.Name, service.Engine, service.Image, opts.Tag, opts.Note, comp)
+	manifest.SetRecipients(opts.Recipients)
 
 	// Determine output filename
 	var extension string
@@ -66,7 +71,7 @@ func (e *RedisEngine) Create(ctx context.Context, service resolve.ServiceInfo, o
 	}
 
 	// Create compressed writer
-	writer, err := compress.NewCompressedWriter(tempFile, comp)
+	writer, err := newDataWriter(tempFile, opts)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create compressed writer: %w", err)
 	}
@@ -114,12 +119,17 @@ func (e *RedisEngine) Create(ctx context.Context, service resolve.ServiceInfo, o
 }
 
 // Restore restores a Redis database from a snapshot
-func (e *RedisEngine) Restore(ctx context.Context, service resolve.ServiceInfo, snapshotDir string, manifest *SnapshotManifest, force bool) error {
+func (e *RedisEngine) Restore(ctx context.Context, service resolve.ServiceInfo, snapshotDir string, manifest *SnapshotManifest, opts RestoreOptions) error {
 	log.Info().
 		Str("service", service.Name).
 		Str("snapshot", snapshotDir).
 		Msg("Restoring Redis snapshot")
 
+	// An RDB file always replaces the whole dataset
+	if opts.Merge {
+		return fmt.Errorf("Redis snapshots replace the whole dataset and cannot be merged")
+	}
+
 	// Get main file from manifest
 	mainFile, err := manifest.GetMainFile()
 	if err != nil {
@@ -139,7 +149,7 @@ func (e *RedisEngine) Restore(ctx context.Context, service resolve.ServiceInfo,
 	}
 
 	// Check if container is running
-	running, err := e.docker.ContainerIsRunning(ctx, service.Container)
+	running, err := runtime.IsRunning(ctx, e.rt, service.Container)
 	if err != nil {
 		return fmt.Errorf("failed to check container status: %w", err)
 	}
@@ -148,7 +158,7 @@ func (e *RedisEngine) Restore(ctx context.Context, service resolve.ServiceInfo,
 	}
 
 	// Open compressed reader
-	reader, err := compress.NewCompressedReader(snapshotFile, manifest.GetCompression())
+	reader, err := openDataReader(snapshotFile, manifest, opts)
 	if err != nil {
 		return fmt.Errorf("failed to create compressed reader: %w", err)
 	}
@@ -171,7 +181,7 @@ func (e *RedisEngine) Restore(ctx context.Context, service resolve.ServiceInfo,
 	}
 
 	// Start Redis
-	if err := e.docker.StartContainer(ctx, service.Container); err != nil {
+	if err := e.rt.StartContainer(ctx, service.Container); err != nil {
 		return fmt.Errorf("failed to start Redis container: %w", err)
 	}
 
@@ -195,7 +205,7 @@ func (e *RedisEngine) triggerBGSave(ctx context.Context, service resolve.Service
 	}
 	cmd = append(cmd, "BGSAVE")
 
-	result, err := e.docker.ExecCommand(ctx, service.Container, cmd)
+	result, err := e.rt.ExecCommand(ctx, service.Container, cmd)
 	if err != nil {
 		return fmt.Errorf("failed to execute BGSAVE: %w", err)
 	}
@@ -223,7 +233,7 @@ func (e *RedisEngine) waitForBGSave(ctx context.Context, service resolve.Service
 	for time.Now().Before(timeout) {
 		// Check LASTSAVE time
 		lastSaveCmd := append(cmd, "LASTSAVE")
-		initialResult, err := e.docker.ExecCommand(ctx, service.Container, lastSaveCmd)
+		initialResult, err := e.rt.ExecCommand(ctx, service.Container, lastSaveCmd)
 		if err != nil {
 			return fmt.Errorf("failed to get LASTSAVE: %w", err)
 		}
@@ -231,7 +241,7 @@ func (e *RedisEngine) waitForBGSave(ctx context.Context, service resolve.Service
 		time.Sleep(1 * time.Second)
 
 		// Check LASTSAVE again
-		finalResult, err := e.docker.ExecCommand(ctx, service.Container, lastSaveCmd)
+		finalResult, err := e.rt.ExecCommand(ctx, service.Container, lastSaveCmd)
 		if err != nil {
 			return fmt.Errorf("failed to get LASTSAVE: %w", err)
 		}
@@ -243,7 +253,7 @@ func (e *RedisEngine) waitForBGSave(ctx context.Context, service resolve.Service
 
 		// Also check if BGSAVE is still running
 		infoCmd := append(cmd, "INFO", "persistence")
-		infoResult, err := e.docker.ExecCommand(ctx, service.Container, infoCmd)
+		infoResult, err := e.rt.ExecCommand(ctx, service.Container, infoCmd)
 		if err == nil && !strings.Contains(infoResult.Stdout, "rdb_bgsave_in_progress:1") {
 			return nil
 		}
@@ -256,18 +266,10 @@ func (e *RedisEngine) waitForBGSave(ctx context.Context, service resolve.Service
 
 // copyRDBFromContainer copies the RDB file from the Redis container
 func (e *RedisEngine) copyRDBFromContainer(ctx context.Context, service resolve.ServiceInfo) ([]byte, error) {
-	// Try to read the RDB file using cat
-	cmd := []string{"cat", "/data/dump.rdb"}
-	reader, err := e.docker.ExecStreaming(ctx, service.Container, cmd, nil)
+	data, err := runtime.ReadFile(ctx, e.rt, service.Container, redisDumpPath)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read RDB file: %w", err)
 	}
-	defer reader.Close()
-
-	data, err := io.ReadAll(reader)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read RDB data: %w", err)
-	}
 
 	if len(data) == 0 {
 		return nil, fmt.Errorf("RDB file is empty")
@@ -286,49 +288,20 @@ func (e *RedisEngine) flushAndStop(ctx context.Context, service resolve.ServiceI
 	cmd = append(cmd, "BGSAVE")
 
 	// Trigger one final save before stopping
-	_, err := e.docker.ExecCommand(ctx, service.Container, cmd)
+	_, err := e.rt.ExecCommand(ctx, service.Container, cmd)
 	if err != nil {
 		log.Warn().Err(err).Msg("Failed to trigger final BGSAVE before restore")
 	}
 
 	// Stop the container
-	return e.docker.StopContainer(ctx, service.Container, 10*time.Second)
+	return e.rt.StopContainer(ctx, service.Container, 10*time.Second)
 }
 
-// copyRDBToContainer copies RDB data to the Redis container
+// copyRDBToContainer copies RDB data to the Redis container. The copy
+// API works on stopped containers, so the file is in place before Redis
+// starts and loads it.
 func (e *RedisEngine) copyRDBToContainer(ctx context.Context, service resolve.ServiceInfo, data []byte) error {
-	// For now, we'll use a simple approach - write to a temp file and copy
-	// In a more sophisticated implementation, we could use Docker's copy API
-
-	// Create a temporary file with the RDB data
-	tempFile, err := os.CreateTemp("", "redis-restore-*.rdb")
-	if err != nil {
-		return fmt.Errorf("failed to create temp file: %w", err)
-	}
-	defer os.Remove(tempFile.Name())
-	defer tempFile.Close()
-
-	if _, err := tempFile.Write(data); err != nil {
-		return fmt.Errorf("failed to write temp RDB file: %w", err)
-	}
-
-	tempFile.Close()
-
-	// Copy the file into the container using docker cp-like functionality
-	// For simplicity, we'll use exec to write the data
-	// In production, you'd want to use the Docker API's CopyToContainer
-
-	// Write data via stdin to container
-	cmd := []string{"tee", "/data/dump.rdb"}
-	reader, err := e.docker.ExecStreaming(ctx, service.Container, cmd, strings.NewReader(string(data)))
-	if err != nil {
-		return fmt.Errorf("failed to copy RDB to container: %w", err)
-	}
-	defer reader.Close()
-
-	// Read any output
-	_, err = io.ReadAll(reader)
-	return err
+	return runtime.WriteFile(ctx, e.rt, service.Container, redisDumpPath, data, 0644)
 }
 
 // waitForRedisReady waits for Redis to be ready after restart
@@ -342,7 +315,7 @@ func (e *RedisEngine) waitForRedisReady(ctx context.Context, service resolve.Ser
 	timeout := time.Now().Add(30 * time.Second)
 
 	for time.Now().Before(timeout) {
-		result, err := e.docker.ExecCommand(ctx, service.Container, cmd)
+		result, err := e.rt.ExecCommand(ctx, service.Container, cmd)
 		if err == nil && result.ExitCode == 0 && strings.Contains(result.Stdout, "PONG") {
 			return nil
 		}
@@ -0,0 +1,113 @@
+package snapshot
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/abdultolba/nizam/internal/compress"
+	"github.com/abdultolba/nizam/internal/resolve"
+	"github.com/abdultolba/nizam/internal/runtime"
+)
+
+var testRedisService = resolve.ServiceInfo{
+	Name:      "redis",
+	Engine:    "redis",
+	Container: "nizam_test_redis",
+	Image:     "redis:7",
+}
+
+func newRedisFake() *runtime.Fake {
+	fake := runtime.NewFake()
+	fake.AddContainer(runtime.Container{Name: testRedisService.Container})
+	fake.HandleExec("redis-cli BGSAVE", func(runtime.ExecCall) runtime.ExecResult {
+		return runtime.ExecResult{Stdout: "Background saving started\n"}
+	})
+	fake.HandleExec("redis-cli INFO persistence", func(runtime.ExecCall) runtime.ExecResult {
+		return runtime.ExecResult{Stdout: "rdb_bgsave_in_progress:0\n"}
+	})
+	fake.HandleExec("redis-cli ping", func(runtime.ExecCall) runtime.ExecResult {
+		return runtime.ExecResult{Stdout: "PONG\n"}
+	})
+	return fake
+}
+
+func TestRedisEngine_CreateAndRestore(t *testing.T) {
+	ctx := context.Background()
+	fake := newRedisFake()
+	engine := NewRedisEngine(fake)
+
+	fake.WriteFile(testRedisService.Container, redisDumpPath, []byte("REDIS0011 snapshot"))
+
+	dir := t.TempDir()
+	manifest, err := engine.Create(ctx, testRedisService, dir, CreateOptions{Compression: compress.CompGzip})
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	if len(manifest.Files) != 1 || manifest.Files[0].Name != "redis.rdb.gz" {
+		t.Fatalf("unexpected manifest files: %+v", manifest.Files)
+	}
+
+	// Data written after the snapshot is replaced on restore
+	fake.WriteFile(testRedisService.Container, redisDumpPath, []byte("REDIS0011 newer"))
+
+	if err := engine.Restore(ctx, testRedisService, dir, manifest, RestoreOptions{}); err != nil {
+		t.Fatalf("Restore() error = %v", err)
+	}
+
+	data, ok := fake.ReadFile(testRedisService.Container, redisDumpPath)
+	if !ok || string(data) != "REDIS0011 snapshot" {
+		t.Errorf("dump.rdb = %q, want the snapshot contents", data)
+	}
+
+	running, err := runtime.IsRunning(ctx, fake, testRedisService.Container)
+	if err != nil || !running {
+		t.Errorf("container running = %v (err %v), want it restarted", running, err)
+	}
+}
+
+func TestRedisEngine_CreateWithoutDump(t *testing.T) {
+	fake := newRedisFake()
+	engine := NewRedisEngine(fake)
+
+	if _, err := engine.Create(context.Background(), testRedisService, t.TempDir(), CreateOptions{Compression: compress.CompNone}); err == nil {
+		t.Error("Create() succeeded without a dump.rdb in the container")
+	}
+}
+
+func TestRedisEngine_PasswordIsPassed(t *testing.T) {
+	ctx := context.Background()
+	fake := newRedisFake()
+	engine := NewRedisEngine(fake)
+
+	service := testRedisService
+	service.Password = "secret"
+
+	var bgsave []string
+	fake.HandleExec("redis-cli -a secret BGSAVE", func(call runtime.ExecCall) runtime.ExecResult {
+		bgsave = call.Cmd
+		return runtime.ExecResult{Stdout: "Background saving started\n"}
+	})
+	fake.HandleExec("redis-cli -a secret INFO", func(runtime.ExecCall) runtime.ExecResult {
+		return runtime.ExecResult{Stdout: "rdb_bgsave_in_progress:0\n"}
+	})
+	fake.WriteFile(service.Container, redisDumpPath, []byte("REDIS0011"))
+
+	if _, err := engine.Create(ctx, service, t.TempDir(), CreateOptions{Compression: compress.CompNone}); err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	if len(bgsave) == 0 {
+		t.Error("BGSAVE was not run with the service password")
+	}
+}
+
+func TestRedisEngine_RejectsMerge(t *testing.T) {
+	engine := NewRedisEngine(runtime.NewFake())
+	manifest := NewSnapshotManifest("redis", "redis", "redis:7", "", "", compress.CompNone)
+	manifest.AddFile("dump.rdb", "abc", 1)
+
+	err := engine.Restore(context.Background(), resolve.ServiceInfo{Name: "redis"}, t.TempDir(), &manifest, RestoreOptions{Merge: true})
+	if err == nil || !strings.Contains(err.Error(), "cannot be merged") {
+		t.Errorf("Restore() error = %v", err)
+	}
+}
@@ -0,0 +1,290 @@
+package snapshot
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/abdultolba/nizam/internal/compress"
+	"github.com/abdultolba/nizam/internal/paths"
+	"github.com/abdultolba/nizam/internal/remote"
+	"github.com/rs/zerolog/log"
+)
+
+// Remote snapshots are stored as <service>/<snapshot>/<file>, mirroring
+// .nizam/snapshots/. The manifest is uploaded last, so a snapshot is only
+// listed once its data is complete, and listing reads nothing but
+// manifests.
+
+// PushOptions selects the snapshot to push
+type PushOptions struct {
+	Tag string
+}
+
+// PullOptions selects the remote snapshot to pull
+type PullOptions struct {
+	Tag string
+}
+
+// remoteSnapshot is a snapshot found on a remote
+type remoteSnapshot struct {
+	// key is the snapshot's key prefix, <service>/<snapshot>
+	key      string
+	manifest *SnapshotManifest
+}
+
+// Push uploads the snapshot of a service selected by opts to the backend.
+// Without a tag the latest snapshot is pushed. Chunked snapshots are
+// reassembled, so remote snapshots never depend on the local chunk store.
+func (s *Service) Push(ctx context.Context, backend remote.Backend, serviceName string, opts PushOptions) (*SnapshotManifest, error) {
+	snapshotDir, err := s.findSnapshotToRestore(serviceName, RestoreOptions{Tag: opts.Tag, Latest: opts.Tag == ""})
+	if err != nil {
+		return nil, fmt.Errorf("failed to find snapshot: %w", err)
+	}
+	manifest, err := LoadManifestFromDir(snapshotDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load manifest: %w", err)
+	}
+	if err := manifest.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid manifest: %w", err)
+	}
+
+	key := path.Join(serviceName, filepath.Base(snapshotDir))
+	manifestKey := path.Join(key, manifestFileName)
+	if existing, err := backend.Get(ctx, manifestKey); err == nil {
+		existing.Close()
+		return nil, fmt.Errorf("snapshot %s already exists on %s", key, backend)
+	} else if !errors.Is(err, remote.ErrNotFound) {
+		return nil, err
+	}
+
+	dataDir, manifest, cleanup, err := s.Materialize(snapshotDir, manifest, compress.CompZstd)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reassemble snapshot: %w", err)
+	}
+	defer cleanup()
+
+	log.Info().
+		Str("service", serviceName).
+		Str("snapshot", key).
+		Str("remote", backend.String()).
+		Msg("Pushing snapshot")
+
+	for _, file := range manifest.Files {
+		if err := putFile(ctx, backend, path.Join(key, file.Name), filepath.Join(dataDir, file.Name)); err != nil {
+			return nil, err
+		}
+	}
+
+	// Pins protect local copies from pruning and mean nothing remotely
+	pushed := *manifest
+	pushed.Pinned = false
+	data, err := json.MarshalIndent(&pushed, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal manifest: %w", err)
+	}
+	if err := backend.Put(ctx, manifestKey, bytes.NewReader(data), int64(len(data))); err != nil {
+		return nil, err
+	}
+
+	return &pushed, nil
+}
+
+// putFile uploads a local file
+func putFile(ctx context.Context, backend remote.Backend, key, filePath string) error {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %w", filepath.Base(filePath), err)
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return fmt.Errorf("failed to stat %s: %w", filepath.Base(filePath), err)
+	}
+	return backend.Put(ctx, key, file, info.Size())
+}
+
+// Pull downloads a remote snapshot of a service, verifies its files against
+// the manifest checksums and registers it with the service's snapshots.
+// Without a tag the latest remote snapshot is pulled. Nothing is registered
+// if verification fails.
+func (s *Service) Pull(ctx context.Context, backend remote.Backend, serviceName string, opts PullOptions) (*SnapshotInfo, error) {
+	snapshots, err := listRemote(ctx, backend, serviceName)
+	if err != nil {
+		return nil, err
+	}
+	if len(snapshots) == 0 {
+		return nil, fmt.Errorf("no snapshots found for service %s on %s", serviceName, backend)
+	}
+
+	selected := snapshots[0]
+	if opts.Tag != "" {
+		found := false
+		for _, snapshot := range snapshots {
+			if tagFromDir(snapshot.key) == opts.Tag {
+				selected, found = snapshot, true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("snapshot with tag '%s' not found on %s", opts.Tag, backend)
+		}
+	}
+
+	manifest := selected.manifest
+	manifest.Service = serviceName
+	if err := manifest.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid manifest: %w", err)
+	}
+	if manifest.IsChunked() {
+		return nil, fmt.Errorf("invalid remote snapshot %s: chunked snapshots are reassembled before pushing", selected.key)
+	}
+
+	serviceDir, err := paths.GetServiceSnapshotsDir(serviceName)
+	if err != nil {
+		return nil, err
+	}
+	dirName := path.Base(selected.key)
+	snapshotDir := filepath.Join(serviceDir, dirName)
+	if _, err := os.Stat(snapshotDir); err == nil {
+		return nil, fmt.Errorf("snapshot %s already exists for service %s", dirName, serviceName)
+	}
+
+	log.Info().
+		Str("service", serviceName).
+		Str("snapshot", selected.key).
+		Str("remote", backend.String()).
+		Msg("Pulling snapshot")
+
+	// Stage in a hidden directory so a failed pull never shows up in the
+	// snapshot list
+	stagingDir, err := os.MkdirTemp(serviceDir, "."+dirName+".pull-")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create staging directory: %w", err)
+	}
+	defer os.RemoveAll(stagingDir)
+
+	for _, file := range manifest.Files {
+		if err := validFileName(file.Name); err != nil {
+			return nil, fmt.Errorf("invalid remote snapshot %s: %w", selected.key, err)
+		}
+		if err := getFile(ctx, backend, path.Join(selected.key, file.Name), filepath.Join(stagingDir, file.Name)); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := manifest.VerifyFiles(stagingDir); err != nil {
+		return nil, fmt.Errorf("remote snapshot failed verification: %w", err)
+	}
+	if err := manifest.WriteToFile(filepath.Join(stagingDir, manifestFileName)); err != nil {
+		return nil, err
+	}
+	if err := os.Rename(stagingDir, snapshotDir); err != nil {
+		return nil, fmt.Errorf("failed to register snapshot: %w", err)
+	}
+
+	info, err := s.getSnapshotInfo(snapshotDir)
+	if err != nil {
+		return nil, err
+	}
+	return &info, nil
+}
+
+// getFile downloads an object to a new local file
+func getFile(ctx context.Context, backend remote.Backend, key, filePath string) error {
+	object, err := backend.Get(ctx, key)
+	if err != nil {
+		return err
+	}
+	defer object.Close()
+
+	return extractArchiveFile(object, filePath)
+}
+
+// ListRemote lists the snapshots of a service on the backend, or of all
+// services if serviceName is empty, newest first. Only manifests are read.
+func (s *Service) ListRemote(ctx context.Context, backend remote.Backend, serviceName string) ([]SnapshotInfo, error) {
+	snapshots, err := listRemote(ctx, backend, serviceName)
+	if err != nil {
+		return nil, err
+	}
+
+	infos := make([]SnapshotInfo, 0, len(snapshots))
+	for _, snapshot := range snapshots {
+		var size int64
+		for _, file := range snapshot.manifest.Files {
+			size += file.Size
+		}
+		infos = append(infos, SnapshotInfo{
+			Service:     path.Dir(snapshot.key),
+			Tag:         tagFromDir(snapshot.key),
+			CreatedAt:   snapshot.manifest.CreatedAt,
+			Size:        size,
+			LogicalSize: size,
+			DedupSize:   size,
+			Path:        snapshot.key,
+			Engine:      snapshot.manifest.Engine,
+			Image:       snapshot.manifest.Image,
+			Note:        snapshot.manifest.Note,
+			Encrypted:   snapshot.manifest.IsEncrypted(),
+		})
+	}
+	return infos, nil
+}
+
+// listRemote reads the manifests of a service's remote snapshots, or of
+// all services if serviceName is empty, newest first
+func listRemote(ctx context.Context, backend remote.Backend, serviceName string) ([]remoteSnapshot, error) {
+	prefix := ""
+	if serviceName != "" {
+		prefix = serviceName + "/"
+	}
+	keys, err := backend.List(ctx, prefix)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list remote snapshots: %w", err)
+	}
+
+	var snapshots []remoteSnapshot
+	for _, key := range keys {
+		parts := strings.Split(key, "/")
+		if len(parts) != 3 || parts[2] != manifestFileName || strings.HasPrefix(parts[0], ".") || strings.HasPrefix(parts[1], ".") {
+			continue
+		}
+
+		manifest, err := getManifest(ctx, backend, key)
+		if err != nil {
+			log.Warn().Str("key", key).Err(err).Msg("Failed to read remote manifest")
+			continue
+		}
+		snapshots = append(snapshots, remoteSnapshot{key: path.Dir(key), manifest: manifest})
+	}
+
+	sort.Slice(snapshots, func(i, j int) bool {
+		return snapshots[i].manifest.CreatedAt.After(snapshots[j].manifest.CreatedAt)
+	})
+	return snapshots, nil
+}
+
+// getManifest downloads and decodes a manifest
+func getManifest(ctx context.Context, backend remote.Backend, key string) (*SnapshotManifest, error) {
+	object, err := backend.Get(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	defer object.Close()
+
+	data, err := io.ReadAll(object)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read manifest: %w", err)
+	}
+	return parseManifest(data)
+}
@@ -0,0 +1,125 @@
+package snapshot
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/abdultolba/nizam/internal/remote"
+	"github.com/abdultolba/nizam/internal/runtime"
+)
+
+func TestPushPull(t *testing.T) {
+	chdirTemp(t)
+	ctx := context.Background()
+	svc := NewService(runtime.NewFake())
+	backend := remote.NewDir(t.TempDir())
+
+	writeTestSnapshot(t, "postgres", "20250101-120000-seeded", []byte("PGDMP seeded"))
+	writeTestSnapshot(t, "postgres", "20250102-120000", []byte("PGDMP latest"))
+
+	if _, err := svc.Push(ctx, backend, "postgres", PushOptions{Tag: "seeded"}); err != nil {
+		t.Fatalf("Push() error = %v", err)
+	}
+	if _, err := svc.Push(ctx, backend, "postgres", PushOptions{}); err != nil {
+		t.Fatalf("Push() error = %v", err)
+	}
+	if _, err := svc.Push(ctx, backend, "postgres", PushOptions{Tag: "seeded"}); err == nil || !strings.Contains(err.Error(), "already exists") {
+		t.Errorf("Push() error = %v, want already exists", err)
+	}
+
+	remoteSnapshots, err := svc.ListRemote(ctx, backend, "")
+	if err != nil {
+		t.Fatalf("ListRemote() error = %v", err)
+	}
+	if len(remoteSnapshots) != 2 {
+		t.Fatalf("ListRemote() returned %d snapshots, want 2", len(remoteSnapshots))
+	}
+	seeded := remoteSnapshots[1]
+	if seeded.Service != "postgres" || seeded.Tag != "seeded" || seeded.Size != int64(len("PGDMP seeded")) {
+		t.Errorf("unexpected remote snapshot: %+v", seeded)
+	}
+
+	// Pull into a fresh project
+	chdirTemp(t)
+	info, err := svc.Pull(ctx, backend, "postgres", PullOptions{Tag: "seeded"})
+	if err != nil {
+		t.Fatalf("Pull() error = %v", err)
+	}
+	if info.Tag != "seeded" || filepath.Base(info.Path) != "20250101-120000-seeded" {
+		t.Errorf("unexpected pulled snapshot: %+v", info)
+	}
+	data, err := os.ReadFile(filepath.Join(info.Path, "pg.dump"))
+	if err != nil || string(data) != "PGDMP seeded" {
+		t.Errorf("pulled data = %q, %v", data, err)
+	}
+
+	if _, err := svc.Pull(ctx, backend, "postgres", PullOptions{Tag: "seeded"}); err == nil || !strings.Contains(err.Error(), "already exists") {
+		t.Errorf("Pull() error = %v, want already exists", err)
+	}
+	if _, err := svc.Pull(ctx, backend, "postgres", PullOptions{Tag: "missing"}); err == nil {
+		t.Error("Pull() of a missing tag succeeded")
+	}
+}
+
+func TestPull_RejectsTamperedData(t *testing.T) {
+	chdirTemp(t)
+	ctx := context.Background()
+	svc := NewService(runtime.NewFake())
+	root := t.TempDir()
+	backend := remote.NewDir(root)
+
+	writeTestSnapshot(t, "postgres", "20250101-120000-seeded", []byte("PGDMP seeded"))
+	if _, err := svc.Push(ctx, backend, "postgres", PushOptions{}); err != nil {
+		t.Fatalf("Push() error = %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "postgres", "20250101-120000-seeded", "pg.dump"), []byte("PGDMP evil!!"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	chdirTemp(t)
+	if _, err := svc.Pull(ctx, backend, "postgres", PullOptions{}); err == nil || !strings.Contains(err.Error(), "verification") {
+		t.Errorf("Pull() error = %v, want verification failure", err)
+	}
+	snapshots, err := svc.List("postgres")
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	if len(snapshots) != 0 {
+		t.Errorf("a failed pull registered %d snapshots", len(snapshots))
+	}
+}
+
+func TestPush_ReassemblesChunkedSnapshots(t *testing.T) {
+	chdirTemp(t)
+	ctx := context.Background()
+	var restored []byte
+	dump := "PGDMP " + strings.Repeat("INSERT INTO users VALUES (1);\n", 100)
+	svc := NewService(newChunkedFake(dump, &restored))
+	backend := remote.NewDir(t.TempDir())
+
+	if _, err := svc.Create(ctx, chunkedConfig, "postgres", CreateOptions{Storage: StorageChunked}); err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	pushed, err := svc.Push(ctx, backend, "postgres", PushOptions{})
+	if err != nil {
+		t.Fatalf("Push() error = %v", err)
+	}
+	if pushed.IsChunked() || pushed.Compression != "zstd" {
+		t.Errorf("pushed manifest = %+v, want plain zstd files", pushed)
+	}
+
+	// The pulled copy restores without the chunk store
+	chdirTemp(t)
+	if _, err := svc.Pull(ctx, backend, "postgres", PullOptions{}); err != nil {
+		t.Fatalf("Pull() error = %v", err)
+	}
+	if err := svc.Restore(ctx, chunkedConfig, "postgres", RestoreOptions{Latest: true}); err != nil {
+		t.Fatalf("Restore() error = %v", err)
+	}
+	if string(restored) != dump {
+		t.Errorf("pg_restore read %d bytes, want the %d byte dump", len(restored), len(dump))
+	}
+}
@@ -0,0 +1,276 @@
+package snapshot
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/abdultolba/nizam/internal/config"
+	"github.com/docker/go-units"
+	"github.com/rs/zerolog/log"
+)
+
+// RetentionPolicy decides which of a service's snapshots to keep. A snapshot
+// is kept if any rule keeps it, and tagged and pinned snapshots are always
+// kept. MaxBytes then caps the total size: the oldest snapshots that do not
+// fit are removed even if a rule kept them. Zero values disable a rule.
+type RetentionPolicy struct {
+	// KeepLast keeps the N most recent snapshots
+	KeepLast int
+	// KeepDaily keeps the newest snapshot of each of the last N days
+	KeepDaily int
+	// KeepWeekly keeps the newest snapshot of each of the last N ISO weeks
+	KeepWeekly int
+	// MaxBytes caps the total size of the service's snapshots
+	MaxBytes int64
+}
+
+// RetentionDecision records whether a snapshot is kept and which rules
+// decided it
+type RetentionDecision struct {
+	Snapshot SnapshotInfo `json:"snapshot"`
+	Keep     bool         `json:"keep"`
+	Reasons  []string     `json:"reasons"`
+}
+
+// PolicyFromConfig converts configured retention rules to a policy
+func PolicyFromConfig(retention *config.SnapshotRetention) (RetentionPolicy, error) {
+	if retention == nil {
+		return RetentionPolicy{}, nil
+	}
+
+	policy := RetentionPolicy{
+		KeepLast:   retention.KeepLast,
+		KeepDaily:  retention.KeepDaily,
+		KeepWeekly: retention.KeepWeekly,
+	}
+	if retention.MaxSize != "" {
+		size, err := units.RAMInBytes(retention.MaxSize)
+		if err != nil {
+			return RetentionPolicy{}, fmt.Errorf("invalid max_size %q: %w", retention.MaxSize, err)
+		}
+		policy.MaxBytes = size
+	}
+
+	return policy, policy.Validate()
+}
+
+// IsZero reports whether the policy has no rules
+func (p RetentionPolicy) IsZero() bool {
+	return p == RetentionPolicy{}
+}
+
+// Validate checks that the policy's rules are not negative
+func (p RetentionPolicy) Validate() error {
+	if p.KeepLast < 0 || p.KeepDaily < 0 || p.KeepWeekly < 0 || p.MaxBytes < 0 {
+		return fmt.Errorf("retention rules must not be negative")
+	}
+	return nil
+}
+
+// Apply decides for each snapshot whether the policy keeps it. Snapshots
+// must belong to one service and be sorted newest first, as List returns
+// them. Days and weeks are calendar periods in now's location.
+func (p RetentionPolicy) Apply(snapshots []SnapshotInfo, now time.Time) []RetentionDecision {
+	decisions := make([]RetentionDecision, len(snapshots))
+	for i, snapshot := range snapshots {
+		decisions[i].Snapshot = snapshot
+	}
+
+	keep := func(i int, reason string) {
+		decisions[i].Keep = true
+		decisions[i].Reasons = append(decisions[i].Reasons, reason)
+	}
+
+	// Tagged and pinned snapshots are never removed
+	protected := make([]bool, len(snapshots))
+	for i, snapshot := range snapshots {
+		if snapshot.Tag != "" {
+			keep(i, "tagged")
+			protected[i] = true
+		}
+		if snapshot.Pinned {
+			keep(i, "pinned")
+			protected[i] = true
+		}
+	}
+
+	for i := 0; i < len(snapshots) && i < p.KeepLast; i++ {
+		keep(i, fmt.Sprintf("last %d", p.KeepLast))
+	}
+
+	if p.KeepDaily > 0 {
+		today := startOfDay(now)
+		since := today.AddDate(0, 0, -(p.KeepDaily - 1))
+		seen := make(map[string]bool)
+		for i, snapshot := range snapshots {
+			created := snapshot.CreatedAt.In(now.Location())
+			day := created.Format("2006-01-02")
+			if created.Before(since) || seen[day] {
+				continue
+			}
+			seen[day] = true
+			keep(i, "daily "+day)
+		}
+	}
+
+	if p.KeepWeekly > 0 {
+		since := startOfWeek(now).AddDate(0, 0, -7*(p.KeepWeekly-1))
+		seen := make(map[string]bool)
+		for i, snapshot := range snapshots {
+			created := snapshot.CreatedAt.In(now.Location())
+			year, week := created.ISOWeek()
+			key := fmt.Sprintf("%d-W%02d", year, week)
+			if created.Before(since) || seen[key] {
+				continue
+			}
+			seen[key] = true
+			keep(i, "weekly "+key)
+		}
+	}
+
+	if p.MaxBytes > 0 {
+		// A size cap on its own keeps the newest snapshots that fit
+		onlyCap := p.KeepLast == 0 && p.KeepDaily == 0 && p.KeepWeekly == 0
+
+		// Protected snapshots use up the budget first
+		var total int64
+		for i, snapshot := range snapshots {
+			if protected[i] {
+				total += snapshot.Size
+			}
+		}
+
+		capReason := "over size cap " + units.BytesSize(float64(p.MaxBytes))
+		full := false
+		for i, snapshot := range snapshots {
+			if protected[i] || !(decisions[i].Keep || onlyCap) {
+				continue
+			}
+			if full || total+snapshot.Size > p.MaxBytes {
+				// Everything older than the first snapshot that does not
+				// fit is removed, so the cap never leaves gaps in history
+				full = true
+				decisions[i].Keep = false
+				decisions[i].Reasons = []string{capReason}
+				continue
+			}
+			total += snapshot.Size
+			if onlyCap {
+				keep(i, "within size cap")
+			}
+		}
+	}
+
+	for i := range decisions {
+		if !decisions[i].Keep && len(decisions[i].Reasons) == 0 {
+			decisions[i].Reasons = []string{"not kept by any rule"}
+		}
+	}
+
+	return decisions
+}
+
+// startOfDay returns midnight at the start of t's day
+func startOfDay(t time.Time) time.Time {
+	year, month, day := t.Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, t.Location())
+}
+
+// startOfWeek returns midnight at the start of t's ISO week, on Monday
+func startOfWeek(t time.Time) time.Time {
+	offset := (int(t.Weekday()) + 6) % 7
+	return startOfDay(t).AddDate(0, 0, -offset)
+}
+
+// SetPinned pins or unpins a snapshot. Pinned snapshots are never removed
+// by Prune. An empty tag selects the latest snapshot.
+func (s *Service) SetPinned(serviceName, tag string, pinned bool) (*SnapshotInfo, error) {
+	dir, err := s.findSnapshotToRestore(serviceName, RestoreOptions{Tag: tag})
+	if err != nil {
+		return nil, err
+	}
+
+	manifest, err := LoadManifestFromDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load manifest: %w", err)
+	}
+	manifest.Pinned = pinned
+
+	// Replace the manifest atomically so an interrupted write never
+	// leaves the snapshot without one
+	manifestPath := filepath.Join(dir, manifestFileName)
+	tempPath := manifestPath + ".tmp"
+	if err := manifest.WriteToFile(tempPath); err != nil {
+		return nil, err
+	}
+	if err := os.Rename(tempPath, manifestPath); err != nil {
+		os.Remove(tempPath)
+		return nil, fmt.Errorf("failed to replace manifest: %w", err)
+	}
+
+	info, err := s.getSnapshotInfo(dir)
+	if err != nil {
+		return nil, err
+	}
+	return &info, nil
+}
+
+// PruneOptions holds options for pruning snapshots
+type PruneOptions struct {
+	Policy RetentionPolicy
+	DryRun bool
+}
+
+// Prune removes the snapshots of a service that the retention policy does
+// not keep. It returns the decision for every snapshot; with DryRun nothing
+// is removed.
+func (s *Service) Prune(serviceName string, opts PruneOptions) ([]RetentionDecision, error) {
+	if err := opts.Policy.Validate(); err != nil {
+		return nil, err
+	}
+	if opts.Policy.IsZero() {
+		return nil, fmt.Errorf("no retention rules for service %s", serviceName)
+	}
+
+	snapshots, err := s.listServiceSnapshots(serviceName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list snapshots: %w", err)
+	}
+
+	decisions := opts.Policy.Apply(snapshots, time.Now())
+	if opts.DryRun {
+		return decisions, nil
+	}
+
+	var removed int
+	var removedSize int64
+	for _, decision := range decisions {
+		if decision.Keep {
+			continue
+		}
+		if err := os.RemoveAll(decision.Snapshot.Path); err != nil {
+			return decisions, fmt.Errorf("failed to remove snapshot %s: %w", decision.Snapshot.Path, err)
+		}
+		removed++
+		removedSize += decision.Snapshot.Size
+		log.Debug().Str("path", decision.Snapshot.Path).Msg("Removed snapshot")
+	}
+
+	log.Info().
+		Str("service", serviceName).
+		Int("removed", removed).
+		Int("kept", len(decisions)-removed).
+		Str("size", formatSize(removedSize)).
+		Msg("Prune completed")
+
+	// Free the chunks only the removed snapshots used
+	if removed > 0 {
+		if _, err := s.CollectGarbage(); err != nil {
+			return decisions, err
+		}
+	}
+
+	return decisions, nil
+}
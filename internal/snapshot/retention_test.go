@@ -0,0 +1,212 @@
+package snapshot
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/abdultolba/nizam/internal/config"
+	"github.com/abdultolba/nizam/internal/runtime"
+)
+
+// retentionNow is a Friday
+var retentionNow = time.Date(2025, 8, 15, 18, 0, 0, 0, time.UTC)
+
+// snapshotsAt builds snapshot infos created the given number of hours
+// before retentionNow, newest first
+func snapshotsAt(hours ...int) []SnapshotInfo {
+	var snapshots []SnapshotInfo
+	for _, h := range hours {
+		snapshots = append(snapshots, SnapshotInfo{
+			Service:   "postgres",
+			CreatedAt: retentionNow.Add(-time.Duration(h) * time.Hour),
+			Size:      100,
+		})
+	}
+	return snapshots
+}
+
+// kept returns the indexes of the kept snapshots
+func kept(decisions []RetentionDecision) []int {
+	indexes := []int{}
+	for i, decision := range decisions {
+		if decision.Keep {
+			indexes = append(indexes, i)
+		}
+	}
+	return indexes
+}
+
+func TestRetentionPolicy_Apply(t *testing.T) {
+	// Two snapshots today, one yesterday, two the day before, then one a
+	// week and one a month ago
+	snapshots := snapshotsAt(1, 5, 24, 48, 50, 24*7, 24*30)
+
+	tests := []struct {
+		name   string
+		policy RetentionPolicy
+		want   []int
+	}{
+		{"keep last", RetentionPolicy{KeepLast: 2}, []int{0, 1}},
+		{"keep daily", RetentionPolicy{KeepDaily: 3}, []int{0, 2, 3}},
+		{"keep weekly", RetentionPolicy{KeepWeekly: 2}, []int{0, 5}},
+		{"rules combine", RetentionPolicy{KeepLast: 1, KeepDaily: 2, KeepWeekly: 5}, []int{0, 2, 5, 6}},
+		{"size cap limits rules", RetentionPolicy{KeepDaily: 3, MaxBytes: 250}, []int{0, 2}},
+		{"size cap alone", RetentionPolicy{MaxBytes: 300}, []int{0, 1, 2}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			decisions := tt.policy.Apply(snapshots, retentionNow)
+			if got := kept(decisions); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("kept %v, want %v", got, tt.want)
+			}
+			for _, decision := range decisions {
+				if len(decision.Reasons) == 0 {
+					t.Errorf("decision for %s has no reason", decision.Snapshot.CreatedAt)
+				}
+			}
+		})
+	}
+}
+
+func TestRetentionPolicy_ApplyReasons(t *testing.T) {
+	snapshots := snapshotsAt(1, 24, 48)
+	snapshots[2].Tag = "seeded"
+
+	decisions := RetentionPolicy{KeepLast: 1, KeepDaily: 1}.Apply(snapshots, retentionNow)
+
+	want := [][]string{
+		{"last 1", "daily 2025-08-15"},
+		{"not kept by any rule"},
+		{"tagged"},
+	}
+	for i, decision := range decisions {
+		if !reflect.DeepEqual(decision.Reasons, want[i]) {
+			t.Errorf("snapshot %d reasons = %v, want %v", i, decision.Reasons, want[i])
+		}
+	}
+}
+
+func TestRetentionPolicy_ProtectedSnapshotsIgnoreSizeCap(t *testing.T) {
+	snapshots := snapshotsAt(1, 2, 3)
+	snapshots[2].Pinned = true
+
+	decisions := RetentionPolicy{KeepLast: 3, MaxBytes: 150}.Apply(snapshots, retentionNow)
+
+	// The pinned snapshot uses up the budget, so only it is kept
+	if got := kept(decisions); !reflect.DeepEqual(got, []int{2}) {
+		t.Errorf("kept %v, want [2]", got)
+	}
+	if decisions[0].Reasons[0] != "over size cap 150B" {
+		t.Errorf("reason = %q, want size cap", decisions[0].Reasons[0])
+	}
+}
+
+func TestPolicyFromConfig(t *testing.T) {
+	policy, err := PolicyFromConfig(&config.SnapshotRetention{KeepLast: 5, KeepWeekly: 4, MaxSize: "2g"})
+	if err != nil {
+		t.Fatalf("PolicyFromConfig() error = %v", err)
+	}
+	want := RetentionPolicy{KeepLast: 5, KeepWeekly: 4, MaxBytes: 2 << 30}
+	if policy != want {
+		t.Errorf("PolicyFromConfig() = %+v, want %+v", policy, want)
+	}
+
+	if _, err := PolicyFromConfig(&config.SnapshotRetention{MaxSize: "lots"}); err == nil {
+		t.Error("PolicyFromConfig() accepted an invalid max_size")
+	}
+	if _, err := PolicyFromConfig(&config.SnapshotRetention{KeepDaily: -1}); err == nil {
+		t.Error("PolicyFromConfig() accepted a negative rule")
+	}
+	if policy, err := PolicyFromConfig(nil); err != nil || !policy.IsZero() {
+		t.Errorf("PolicyFromConfig(nil) = %+v, %v; want an empty policy", policy, err)
+	}
+}
+
+// setCreatedAt rewrites the creation time in a snapshot's manifest
+func setCreatedAt(t *testing.T, dir string, createdAt time.Time) {
+	t.Helper()
+	manifest, err := LoadManifestFromDir(dir)
+	if err != nil {
+		t.Fatalf("LoadManifestFromDir() error = %v", err)
+	}
+	manifest.CreatedAt = createdAt
+	if err := manifest.WriteToFile(filepath.Join(dir, manifestFileName)); err != nil {
+		t.Fatalf("WriteToFile() error = %v", err)
+	}
+}
+
+func TestPrune(t *testing.T) {
+	chdirTemp(t)
+	svc := NewService(runtime.NewFake())
+
+	now := time.Now().UTC()
+	newest := writeTestSnapshot(t, "postgres", "20250103-120000", []byte("new"))
+	setCreatedAt(t, newest, now.Add(-time.Hour))
+	middle := writeTestSnapshot(t, "postgres", "20250102-120000", []byte("middle"))
+	setCreatedAt(t, middle, now.Add(-2*time.Hour))
+	tagged := writeTestSnapshot(t, "postgres", "20250101-120000-seeded", []byte("tagged"))
+	setCreatedAt(t, tagged, now.Add(-3*time.Hour))
+
+	if _, err := svc.Prune("postgres", PruneOptions{}); err == nil {
+		t.Error("Prune() without rules should fail")
+	}
+
+	opts := PruneOptions{Policy: RetentionPolicy{KeepLast: 1}, DryRun: true}
+	decisions, err := svc.Prune("postgres", opts)
+	if err != nil {
+		t.Fatalf("Prune(dry run) error = %v", err)
+	}
+	if got := kept(decisions); !reflect.DeepEqual(got, []int{0, 2}) {
+		t.Errorf("dry run kept %v, want [0 2]", got)
+	}
+	if _, err := os.Stat(middle); err != nil {
+		t.Errorf("dry run removed a snapshot: %v", err)
+	}
+
+	opts.DryRun = false
+	if _, err := svc.Prune("postgres", opts); err != nil {
+		t.Fatalf("Prune() error = %v", err)
+	}
+	if _, err := os.Stat(middle); !os.IsNotExist(err) {
+		t.Errorf("Prune() kept %s", middle)
+	}
+	for _, dir := range []string{newest, tagged} {
+		if _, err := os.Stat(dir); err != nil {
+			t.Errorf("Prune() removed %s: %v", dir, err)
+		}
+	}
+}
+
+func TestSetPinned(t *testing.T) {
+	chdirTemp(t)
+	svc := NewService(runtime.NewFake())
+	dir := writeTestSnapshot(t, "postgres", "20250101-120000", []byte("data"))
+
+	info, err := svc.SetPinned("postgres", "", true)
+	if err != nil {
+		t.Fatalf("SetPinned() error = %v", err)
+	}
+	if !info.Pinned || info.Path != dir {
+		t.Errorf("SetPinned() = %+v, want the latest snapshot pinned", info)
+	}
+
+	// Pinning keeps the snapshot through a prune that keeps nothing else
+	decisions, err := svc.Prune("postgres", PruneOptions{Policy: RetentionPolicy{MaxBytes: 1}})
+	if err != nil {
+		t.Fatalf("Prune() error = %v", err)
+	}
+	if got := kept(decisions); !reflect.DeepEqual(got, []int{0}) {
+		t.Errorf("Prune() kept %v, want the pinned snapshot", got)
+	}
+
+	if info, err := svc.SetPinned("postgres", "", false); err != nil || info.Pinned {
+		t.Errorf("SetPinned(false) = %+v, %v", info, err)
+	}
+	if _, err := svc.SetPinned("postgres", "missing", true); err == nil {
+		t.Error("SetPinned() with an unknown tag should fail")
+	}
+}
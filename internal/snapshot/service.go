@@ -11,49 +11,52 @@ import (
 
 	"github.com/abdultolba/nizam/internal/compress"
 	"github.com/abdultolba/nizam/internal/config"
-	"github.com/abdultolba/nizam/internal/dockerx"
 	"github.com/abdultolba/nizam/internal/paths"
 	"github.com/abdultolba/nizam/internal/resolve"
+	"github.com/abdultolba/nizam/internal/runtime"
 	"github.com/rs/zerolog/log"
 )
 
 // Engine represents a snapshot engine for a specific database type
 type Engine interface {
-	Create(ctx context.Context, service resolve.ServiceInfo, outputDir string, comp compress.Compression, note string, tag string) (*SnapshotManifest, error)
-	Restore(ctx context.Context, service resolve.ServiceInfo, snapshotDir string, manifest *SnapshotManifest, force bool) error
+	Create(ctx context.Context, service resolve.ServiceInfo, outputDir string, opts CreateOptions) (*SnapshotManifest, error)
+	Restore(ctx context.Context, service resolve.ServiceInfo, snapshotDir string, manifest *SnapshotManifest, opts RestoreOptions) error
 	GetEngineType() string
 	CanHandle(engine string) bool
 }
 
 // Service provides snapshot operations
 type Service struct {
-	docker  *dockerx.Client
+	rt      runtime.Runtime
 	engines map[string]Engine
+	// chunkGCGrace protects recently written chunks from collection
+	chunkGCGrace time.Duration
 }
 
 // NewService creates a new snapshot service
-func NewService(docker *dockerx.Client) *Service {
+func NewService(rt runtime.Runtime) *Service {
 	engines := make(map[string]Engine)
 
 	// Register engines directly to avoid circular imports
-	pgEngine := NewPostgreSQLEngine(docker)
+	pgEngine := NewPostgreSQLEngine(rt)
 	engines["postgres"] = pgEngine
 	engines["postgresql"] = pgEngine
 
-	redisEngine := NewRedisEngine(docker)
+	redisEngine := NewRedisEngine(rt)
 	engines["redis"] = redisEngine
 
-	mysqlEngine := NewMySQLEngine(docker)
+	mysqlEngine := NewMySQLEngine(rt)
 	engines["mysql"] = mysqlEngine
 	engines["mariadb"] = mysqlEngine
 
-	mongoEngine := NewMongoDBEngine(docker)
+	mongoEngine := NewMongoDBEngine(rt)
 	engines["mongo"] = mongoEngine
 	engines["mongodb"] = mongoEngine
 
 	return &Service{
-		docker:  docker,
-		engines: engines,
+		rt:           rt,
+		engines:      engines,
+		chunkGCGrace: defaultChunkGCGrace,
 	}
 }
 
@@ -62,12 +65,17 @@ type CreateOptions struct {
 	Tag         string
 	Note        string
 	Compression compress.Compression
+	// Recipients are age public keys to encrypt the snapshot to; without
+	// any the snapshot is not encrypted
+	Recipients []string
+	// Storage is StorageFiles (the default) or StorageChunked
+	Storage string
 }
 
 // Create creates a snapshot for a service
 func (s *Service) Create(ctx context.Context, cfg *config.Config, serviceName string, opts CreateOptions) (*SnapshotManifest, error) {
 	// Resolve service info
-	serviceInfo, err := resolve.GetServiceInfo(cfg, serviceName)
+	serviceInfo, err := resolve.GetServiceInfo(ctx, s.rt, cfg, serviceName)
 	if err != nil {
 		return nil, fmt.Errorf("failed to resolve service info: %w", err)
 	}
@@ -83,8 +91,23 @@ func (s *Service) Create(ctx context.Context, cfg *config.Config, serviceName st
 		opts.Compression = compress.CompZstd // default
 	}
 
+	if _, err := ParseRecipients(opts.Recipients); err != nil {
+		return nil, err
+	}
+
+	switch opts.Storage {
+	case "", StorageFiles:
+	case StorageChunked:
+		// Encrypted data is unique every time, so it never deduplicates
+		if len(opts.Recipients) > 0 {
+			return nil, fmt.Errorf("chunked snapshots cannot be encrypted")
+		}
+	default:
+		return nil, fmt.Errorf("invalid storage: %s (must be: %s, %s)", opts.Storage, StorageFiles, StorageChunked)
+	}
+
 	// Check if container is running
-	running, err := s.docker.ContainerIsRunning(ctx, serviceInfo.Container)
+	running, err := runtime.IsRunning(ctx, s.rt, serviceInfo.Container)
 	if err != nil {
 		return nil, fmt.Errorf("failed to check container status: %w", err)
 	}
@@ -105,14 +128,28 @@ func (s *Service) Create(ctx context.Context, cfg *config.Config, serviceName st
 		Str("compression", opts.Compression.String()).
 		Msg("Creating snapshot")
 
+	// Chunks are compressed in the store, so engines write plain dumps
+	// that deduplicate
+	engineOpts := opts
+	if opts.Storage == StorageChunked {
+		engineOpts.Compression = compress.CompNone
+	}
+
 	// Create snapshot using appropriate engine
-	manifest, err := engine.Create(ctx, serviceInfo, snapshotDir, opts.Compression, opts.Note, opts.Tag)
+	manifest, err := engine.Create(ctx, serviceInfo, snapshotDir, engineOpts)
 	if err != nil {
 		// Cleanup on error
 		os.RemoveAll(snapshotDir)
 		return nil, fmt.Errorf("failed to create snapshot: %w", err)
 	}
 
+	if opts.Storage == StorageChunked {
+		if err := s.storeChunked(snapshotDir, manifest); err != nil {
+			os.RemoveAll(snapshotDir)
+			return nil, fmt.Errorf("failed to store snapshot in chunks: %w", err)
+		}
+	}
+
 	// Write manifest
 	manifestPath := filepath.Join(snapshotDir, "manifest.json")
 	if err := manifest.WriteToFile(manifestPath); err != nil {
@@ -134,22 +171,22 @@ type RestoreOptions struct {
 	Latest bool
 	Before *time.Time
 	Force  bool
+	// IdentityFile holds the age private key that decrypts encrypted
+	// snapshots
+	IdentityFile string
+	// Merge loads the data into the existing database instead of replacing
+	// it; objects that already exist are kept
+	Merge bool
+	// Into restores into another service than the one the snapshot was
+	// taken from
+	Into string
+	// AllowOlder restores into an older major version of the image the
+	// snapshot was taken from
+	AllowOlder bool
 }
 
-// Restore restores a snapshot for a service
+// Restore restores a snapshot of a service into it, or into opts.Into
 func (s *Service) Restore(ctx context.Context, cfg *config.Config, serviceName string, opts RestoreOptions) error {
-	// Resolve service info
-	serviceInfo, err := resolve.GetServiceInfo(cfg, serviceName)
-	if err != nil {
-		return fmt.Errorf("failed to resolve service info: %w", err)
-	}
-
-	// Get appropriate engine
-	engine, exists := s.engines[serviceInfo.Engine]
-	if !exists {
-		return fmt.Errorf("unsupported engine: %s", serviceInfo.Engine)
-	}
-
 	// Find snapshot to restore
 	snapshotDir, err := s.findSnapshotToRestore(serviceName, opts)
 	if err != nil {
@@ -162,31 +199,103 @@ func (s *Service) Restore(ctx context.Context, cfg *config.Config, serviceName s
 		return fmt.Errorf("failed to load manifest: %w", err)
 	}
 
+	target := serviceName
+	if opts.Into != "" {
+		target = opts.Into
+	}
+	return s.RestoreFrom(ctx, cfg, target, snapshotDir, manifest, opts)
+}
+
+// RestoreFrom restores the data files in dir, described by manifest, into a
+// service through its engine. It serves snapshots as well as other
+// directories holding snapshot data, such as seed packs.
+func (s *Service) RestoreFrom(ctx context.Context, cfg *config.Config, serviceName, dir string, manifest *SnapshotManifest, opts RestoreOptions) error {
+	// Resolve service info
+	serviceInfo, err := resolve.GetServiceInfo(ctx, s.rt, cfg, serviceName)
+	if err != nil {
+		return fmt.Errorf("failed to resolve service info: %w", err)
+	}
+
+	// Get appropriate engine
+	engine, exists := s.engines[serviceInfo.Engine]
+	if !exists {
+		return fmt.Errorf("unsupported engine: %s", serviceInfo.Engine)
+	}
+
 	// Validate manifest
 	if err := manifest.Validate(); err != nil {
 		return fmt.Errorf("invalid manifest: %w", err)
 	}
+	if err := s.CheckCompatible(manifest, serviceInfo, opts.AllowOlder); err != nil {
+		return err
+	}
+
+	// Fail before the engine touches the database, since some engines clear
+	// it before reading the snapshot
+	if manifest.IsEncrypted() && opts.IdentityFile == "" {
+		return fmt.Errorf("snapshot is encrypted with age; an identity file is required to restore it")
+	}
 
 	log.Info().
 		Str("service", serviceName).
-		Str("snapshot", snapshotDir).
+		Str("snapshot", dir).
 		Str("created", manifest.CreatedAt.Format("2006-01-02 15:04:05")).
+		Bool("merge", opts.Merge).
 		Msg("Restoring snapshot")
 
+	// Engines read plain files; reassemble chunked snapshots first
+	dir, manifest, cleanup, err := s.Materialize(dir, manifest, compress.CompNone)
+	if err != nil {
+		return fmt.Errorf("failed to reassemble snapshot: %w", err)
+	}
+	defer cleanup()
+
 	// Restore using appropriate engine
-	if err := engine.Restore(ctx, serviceInfo, snapshotDir, manifest, opts.Force); err != nil {
+	if err := engine.Restore(ctx, serviceInfo, dir, manifest, opts); err != nil {
 		return fmt.Errorf("failed to restore snapshot: %w", err)
 	}
 
 	return nil
 }
 
+// SupportsEngine reports whether snapshots can be taken of an engine
+func (s *Service) SupportsEngine(engine string) bool {
+	_, exists := s.engines[engine]
+	return exists
+}
+
+// WaitReady waits until a service's database accepts connections
+func (s *Service) WaitReady(ctx context.Context, cfg *config.Config, serviceName string, interval time.Duration) error {
+	serviceInfo, err := resolve.GetServiceInfo(ctx, s.rt, cfg, serviceName)
+	if err != nil {
+		return fmt.Errorf("failed to resolve service info: %w", err)
+	}
+	engine, exists := s.engines[serviceInfo.Engine]
+	if !exists {
+		return fmt.Errorf("unsupported engine: %s", serviceInfo.Engine)
+	}
+	spec, exists := trialSpecs[engine.GetEngineType()]
+	if !exists {
+		return fmt.Errorf("no readiness probe for engine %s", serviceInfo.Engine)
+	}
+	return waitForProbe(ctx, s.rt, serviceInfo.Container, spec.probe, interval)
+}
+
 // List lists snapshots for a service
 func (s *Service) List(serviceName string) ([]SnapshotInfo, error) {
+	var snapshots []SnapshotInfo
+	var err error
 	if serviceName == "" {
-		return s.listAllSnapshots()
+		snapshots, err = s.listAllSnapshots()
+	} else {
+		snapshots, err = s.listServiceSnapshots(serviceName)
+	}
+	if err != nil {
+		return nil, err
 	}
-	return s.listServiceSnapshots(serviceName)
+
+	s.setDedupSizes(snapshots)
+	return snapshots, nil
 }
 
 // listServiceSnapshots lists snapshots for a specific service
@@ -250,6 +359,27 @@ func (s *Service) listAllSnapshots() ([]SnapshotInfo, error) {
 	return allSnapshots, nil
 }
 
+// ListServices returns the services that have snapshots on disk
+func (s *Service) ListServices() ([]string, error) {
+	snapshotsDir, err := paths.GetSnapshotsDir()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get snapshots directory: %w", err)
+	}
+
+	entries, err := os.ReadDir(snapshotsDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read snapshots directory: %w", err)
+	}
+
+	var services []string
+	for _, entry := range entries {
+		if entry.IsDir() && !strings.HasPrefix(entry.Name(), ".") {
+			services = append(services, entry.Name())
+		}
+	}
+	return services, nil
+}
+
 // getSnapshotInfo extracts snapshot information from a directory
 func (s *Service) getSnapshotInfo(dir string) (SnapshotInfo, error) {
 	manifest, err := LoadManifestFromDir(dir)
@@ -263,24 +393,24 @@ func (s *Service) getSnapshotInfo(dir string) (SnapshotInfo, error) {
 		totalSize += file.Size
 	}
 
-	// Extract tag from directory name if available
-	dirName := filepath.Base(dir)
-	parts := strings.Split(dirName, "-")
-	var tag string
-	if len(parts) > 2 {
-		// Format: YYYYMMDD-HHMMSS-tag
-		tag = strings.Join(parts[2:], "-")
+	size := totalSize
+	if manifest.IsChunked() {
+		size = s.chunkedSize(manifest)
 	}
 
 	return SnapshotInfo{
-		Service:   manifest.Service,
-		Tag:       tag,
-		CreatedAt: manifest.CreatedAt,
-		Size:      totalSize,
-		Path:      dir,
-		Engine:    manifest.Engine,
-		Image:     manifest.Image,
-		Note:      manifest.Note,
+		Service:     manifest.Service,
+		Tag:         tagFromDir(dir),
+		CreatedAt:   manifest.CreatedAt,
+		Size:        size,
+		LogicalSize: totalSize,
+		Chunked:     manifest.IsChunked(),
+		Path:        dir,
+		Engine:      manifest.Engine,
+		Image:       manifest.Image,
+		Note:        manifest.Note,
+		Encrypted:   manifest.IsEncrypted(),
+		Pinned:      manifest.Pinned,
 	}, nil
 }
 
@@ -324,84 +454,6 @@ func (s *Service) findSnapshotToRestore(serviceName string, opts RestoreOptions)
 	return snapshots[0].Path, nil
 }
 
-// PruneOptions holds options for pruning snapshots
-type PruneOptions struct {
-	Keep   int
-	DryRun bool
-}
-
-// Prune removes old snapshots, keeping the N most recent
-func (s *Service) Prune(serviceName string, opts PruneOptions) error {
-	if opts.Keep <= 0 {
-		return fmt.Errorf("keep value must be positive")
-	}
-
-	snapshots, err := s.listServiceSnapshots(serviceName)
-	if err != nil {
-		return fmt.Errorf("failed to list snapshots: %w", err)
-	}
-
-	if len(snapshots) <= opts.Keep {
-		log.Info().
-			Str("service", serviceName).
-			Int("total", len(snapshots)).
-			Int("keep", opts.Keep).
-			Msg("No snapshots to prune")
-		return nil
-	}
-
-	// Calculate which snapshots to remove (everything after opts.Keep)
-	toRemove := snapshots[opts.Keep:]
-
-	var totalSize int64
-	for _, snapshot := range toRemove {
-		totalSize += snapshot.Size
-	}
-
-	if opts.DryRun {
-		log.Info().
-			Str("service", serviceName).
-			Int("count", len(toRemove)).
-			Str("size", formatSize(totalSize)).
-			Msg("Would remove snapshots (dry run)")
-
-		for _, snapshot := range toRemove {
-			log.Info().
-				Str("path", snapshot.Path).
-				Str("tag", snapshot.Tag).
-				Time("created", snapshot.CreatedAt).
-				Str("size", snapshot.FormatSize()).
-				Msg("Would remove")
-		}
-		return nil
-	}
-
-	// Remove snapshots
-	log.Info().
-		Str("service", serviceName).
-		Int("count", len(toRemove)).
-		Str("size", formatSize(totalSize)).
-		Msg("Removing old snapshots")
-
-	var removed int
-	for _, snapshot := range toRemove {
-		if err := os.RemoveAll(snapshot.Path); err != nil {
-			log.Warn().Str("path", snapshot.Path).Err(err).Msg("Failed to remove snapshot")
-		} else {
-			removed++
-			log.Debug().Str("path", snapshot.Path).Msg("Removed snapshot")
-		}
-	}
-
-	log.Info().
-		Str("service", serviceName).
-		Int("removed", removed).
-		Int("kept", len(snapshots)-removed).
-		Msg("Prune completed")
-
-	return nil
-}
-
 // formatSize formats a size in bytes as a human-readable string
 func formatSize(size int64) string {
 	const unit = 1024
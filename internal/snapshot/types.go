@@ -5,12 +5,16 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/abdultolba/nizam/internal/compress"
 	"github.com/abdultolba/nizam/internal/version"
 )
 
+// manifestFileName is the name of the manifest in a snapshot directory
+const manifestFileName = "manifest.json"
+
 // SnapshotManifest represents the metadata for a snapshot
 type SnapshotManifest struct {
 	Service     string         `json:"service"`
@@ -20,16 +24,22 @@ type SnapshotManifest struct {
 	Tag         string         `json:"tag"`
 	ToolVersion string         `json:"toolVersion"`
 	Compression string         `json:"compression"`
+	Storage     string         `json:"storage,omitempty"`
 	Encryption  string         `json:"encryption"`
+	Recipients  []string       `json:"recipients,omitempty"`
 	Note        string         `json:"note"`
+	Pinned      bool           `json:"pinned,omitempty"`
 	Files       []SnapshotFile `json:"files"`
 }
 
-// SnapshotFile represents a file within a snapshot
+// SnapshotFile represents a file within a snapshot. Files of chunked
+// snapshots are not stored in the snapshot directory but as Chunks in the
+// chunk store; Sha256 and Size then describe the uncompressed data.
 type SnapshotFile struct {
-	Name   string `json:"name"`
-	Sha256 string `json:"sha256"`
-	Size   int64  `json:"size"`
+	Name   string   `json:"name"`
+	Sha256 string   `json:"sha256"`
+	Size   int64    `json:"size"`
+	Chunks []string `json:"chunks,omitempty"`
 }
 
 // NewSnapshotManifest creates a new snapshot manifest
@@ -42,12 +52,32 @@ func NewSnapshotManifest(service, engine, image, tag, note string, comp compress
 		Tag:         tag,
 		ToolVersion: version.Version(),
 		Compression: comp.String(),
-		Encryption:  "none", // TODO: Implement age encryption
+		Encryption:  EncryptionNone,
 		Note:        note,
 		Files:       []SnapshotFile{},
 	}
 }
 
+// SetRecipients marks the snapshot as encrypted to the given age recipients.
+// It does nothing if there are none.
+func (m *SnapshotManifest) SetRecipients(recipients []string) {
+	if len(recipients) == 0 {
+		return
+	}
+	m.Encryption = EncryptionAge
+	m.Recipients = append([]string(nil), recipients...)
+}
+
+// IsEncrypted reports whether the snapshot's data files are encrypted
+func (m *SnapshotManifest) IsEncrypted() bool {
+	return m.Encryption != "" && m.Encryption != EncryptionNone
+}
+
+// IsChunked reports whether the snapshot's data lives in the chunk store
+func (m *SnapshotManifest) IsChunked() bool {
+	return m.Storage == StorageChunked
+}
+
 // AddFile adds a file to the manifest
 func (m *SnapshotManifest) AddFile(name, sha256 string, size int64) {
 	m.Files = append(m.Files, SnapshotFile{
@@ -78,6 +108,11 @@ func LoadManifestFromFile(path string) (*SnapshotManifest, error) {
 		return nil, fmt.Errorf("failed to read manifest file: %w", err)
 	}
 
+	return parseManifest(data)
+}
+
+// parseManifest decodes a manifest from JSON
+func parseManifest(data []byte) (*SnapshotManifest, error) {
 	var manifest SnapshotManifest
 	if err := json.Unmarshal(data, &manifest); err != nil {
 		return nil, fmt.Errorf("failed to unmarshal manifest: %w", err)
@@ -88,10 +123,43 @@ func LoadManifestFromFile(path string) (*SnapshotManifest, error) {
 
 // LoadManifestFromDir loads a manifest from a snapshot directory
 func LoadManifestFromDir(dir string) (*SnapshotManifest, error) {
-	manifestPath := filepath.Join(dir, "manifest.json")
+	manifestPath := filepath.Join(dir, manifestFileName)
 	return LoadManifestFromFile(manifestPath)
 }
 
+// VerifyFiles checks that every file in the manifest exists in dir with the
+// recorded size and SHA-256 checksum
+func (m *SnapshotManifest) VerifyFiles(dir string) error {
+	for _, file := range m.Files {
+		if err := file.Verify(dir); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Verify checks that the file exists in dir with the recorded size and
+// SHA-256 checksum
+func (f SnapshotFile) Verify(dir string) error {
+	filePath := filepath.Join(dir, f.Name)
+	info, err := os.Stat(filePath)
+	if err != nil {
+		return fmt.Errorf("missing file %s: %w", f.Name, err)
+	}
+	if info.Size() != f.Size {
+		return fmt.Errorf("file %s is %d bytes, expected %d", f.Name, info.Size(), f.Size)
+	}
+
+	checksum, err := compress.CalculateSHA256(filePath)
+	if err != nil {
+		return err
+	}
+	if checksum != f.Sha256 {
+		return fmt.Errorf("checksum mismatch for %s: got %s, expected %s", f.Name, checksum, f.Sha256)
+	}
+	return nil
+}
+
 // GetCompression returns the compression type from the manifest
 func (m *SnapshotManifest) GetCompression() compress.Compression {
 	switch m.Compression {
@@ -119,12 +187,25 @@ func (m *SnapshotManifest) Validate() error {
 	if m.Service == "" {
 		return fmt.Errorf("service name is required")
 	}
+	// The service names a directory under .nizam/snapshots
+	if strings.ContainsAny(m.Service, `/\`) || strings.HasPrefix(m.Service, ".") {
+		return fmt.Errorf("invalid service name %q", m.Service)
+	}
 	if m.Engine == "" {
 		return fmt.Errorf("engine is required")
 	}
 	if len(m.Files) == 0 {
 		return fmt.Errorf("at least one file is required")
 	}
+	if m.IsEncrypted() && m.Encryption != EncryptionAge {
+		return fmt.Errorf("unsupported encryption: %s", m.Encryption)
+	}
+	if m.Storage != "" && m.Storage != StorageFiles && m.Storage != StorageChunked {
+		return fmt.Errorf("unsupported storage: %s", m.Storage)
+	}
+	if m.IsChunked() && m.IsEncrypted() {
+		return fmt.Errorf("chunked snapshots cannot be encrypted")
+	}
 	return nil
 }
 
@@ -133,11 +214,21 @@ type SnapshotInfo struct {
 	Service   string    `json:"service"`
 	Tag       string    `json:"tag"`
 	CreatedAt time.Time `json:"createdAt"`
-	Size      int64     `json:"size"`
-	Path      string    `json:"path"`
-	Engine    string    `json:"engine"`
-	Image     string    `json:"image"`
-	Note      string    `json:"note"`
+	// Size is the bytes the snapshot takes on disk on its own
+	Size int64 `json:"size"`
+	// LogicalSize is the size of the snapshot's data files; for chunked
+	// snapshots, the uncompressed dump
+	LogicalSize int64 `json:"logicalSize"`
+	// DedupSize is the bytes no other snapshot shares, which removing the
+	// snapshot frees
+	DedupSize int64  `json:"dedupSize"`
+	Chunked   bool   `json:"chunked"`
+	Path      string `json:"path"`
+	Engine    string `json:"engine"`
+	Image     string `json:"image"`
+	Note      string `json:"note"`
+	Encrypted bool   `json:"encrypted"`
+	Pinned    bool   `json:"pinned"`
 }
 
 // GetDisplayName returns a display name for the snapshot
@@ -170,7 +261,22 @@ func (si *SnapshotInfo) GetAge() string {
 
 // FormatSize returns a human-readable size string
 func (si *SnapshotInfo) FormatSize() string {
-	size := float64(si.Size)
+	return FormatBytes(si.Size)
+}
+
+// FormatLogicalSize returns the logical size as a human-readable string
+func (si *SnapshotInfo) FormatLogicalSize() string {
+	return FormatBytes(si.LogicalSize)
+}
+
+// FormatDedupSize returns the deduplicated size as a human-readable string
+func (si *SnapshotInfo) FormatDedupSize() string {
+	return FormatBytes(si.DedupSize)
+}
+
+// FormatBytes returns a human-readable size string
+func FormatBytes(n int64) string {
+	size := float64(n)
 	units := []string{"B", "KB", "MB", "GB", "TB"}
 
 	for _, unit := range units {
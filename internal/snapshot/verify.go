@@ -0,0 +1,334 @@
+package snapshot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/abdultolba/nizam/internal/compress"
+	"github.com/abdultolba/nizam/internal/paths"
+	"github.com/abdultolba/nizam/internal/resolve"
+	"github.com/abdultolba/nizam/internal/runtime"
+	"github.com/docker/docker/api/types/container"
+	"github.com/rs/zerolog/log"
+)
+
+// Trial restore outcomes
+const (
+	TrialPassed  = "passed"
+	TrialFailed  = "failed"
+	TrialSkipped = "skipped"
+)
+
+// verifyLabel marks throwaway trial restore containers
+const verifyLabel = "nizam.verify"
+
+// errImageUnavailable means the trial image could not be pulled, which says
+// nothing about the snapshot
+var errImageUnavailable = errors.New("image unavailable")
+
+// imagePuller is implemented by runtimes that can pull a missing image, such
+// as the Docker client
+type imagePuller interface {
+	EnsureImage(ctx context.Context, image string) error
+}
+
+// VerifyOptions holds options for verifying snapshots
+type VerifyOptions struct {
+	// Trial restores every intact snapshot into a throwaway container of
+	// its manifest's image
+	Trial bool
+	// IdentityFile decrypts encrypted snapshots for the trial restore
+	IdentityFile string
+	// ReadyInterval is how often the trial container is probed while it
+	// starts; zero means one second
+	ReadyInterval time.Duration
+}
+
+// VerifyResult is the outcome of verifying one snapshot directory
+type VerifyResult struct {
+	Service   string    `json:"service"`
+	Path      string    `json:"path"`
+	Tag       string    `json:"tag,omitempty"`
+	CreatedAt time.Time `json:"createdAt"`
+	OK        bool      `json:"ok"`
+	Errors    []string  `json:"errors,omitempty"`
+	Trial     string    `json:"trial,omitempty"`
+	// TrialNote explains a skipped trial restore
+	TrialNote string `json:"trialNote,omitempty"`
+}
+
+// VerifyReport is the outcome of verifying a set of snapshots
+type VerifyReport struct {
+	Snapshots []VerifyResult `json:"snapshots"`
+	// Orphans are leftovers of interrupted snapshots and imports: partial
+	// .tmp data files and hidden staging directories
+	Orphans []string `json:"orphans"`
+	OK      bool     `json:"ok"`
+}
+
+// Verify checks the snapshots of a service, or of every service if
+// serviceName is empty: each manifest must load and validate and each file
+// must match its recorded size and checksum. Unlike List, snapshots whose
+// manifest is broken are reported rather than skipped.
+func (s *Service) Verify(ctx context.Context, serviceName string, opts VerifyOptions) (*VerifyReport, error) {
+	services := []string{serviceName}
+	if serviceName == "" {
+		snapshotsDir, err := paths.GetSnapshotsDir()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get snapshots directory: %w", err)
+		}
+		entries, err := os.ReadDir(snapshotsDir)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read snapshots directory: %w", err)
+		}
+		services = services[:0]
+		for _, entry := range entries {
+			if entry.IsDir() {
+				services = append(services, entry.Name())
+			}
+		}
+	}
+
+	report := &VerifyReport{Snapshots: []VerifyResult{}, Orphans: []string{}, OK: true}
+	for _, service := range services {
+		serviceDir, err := paths.GetServiceSnapshotsDir(service)
+		if err != nil {
+			return nil, err
+		}
+		entries, err := os.ReadDir(serviceDir)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read snapshots directory: %w", err)
+		}
+
+		for _, entry := range entries {
+			entryPath := filepath.Join(serviceDir, entry.Name())
+			if !entry.IsDir() {
+				continue
+			}
+			if strings.HasPrefix(entry.Name(), ".") {
+				report.Orphans = append(report.Orphans, entryPath)
+				continue
+			}
+
+			result, orphans := s.verifySnapshot(ctx, service, entryPath, opts)
+			report.Snapshots = append(report.Snapshots, result)
+			report.Orphans = append(report.Orphans, orphans...)
+		}
+	}
+
+	sort.Slice(report.Snapshots, func(i, j int) bool {
+		return report.Snapshots[i].Path < report.Snapshots[j].Path
+	})
+	for _, result := range report.Snapshots {
+		if !result.OK {
+			report.OK = false
+		}
+	}
+	if len(report.Orphans) > 0 {
+		report.OK = false
+	}
+
+	return report, nil
+}
+
+// verifySnapshot checks one snapshot directory and returns its result and
+// any partial files in it
+func (s *Service) verifySnapshot(ctx context.Context, service, dir string, opts VerifyOptions) (VerifyResult, []string) {
+	result := VerifyResult{Service: service, Path: dir, Tag: tagFromDir(dir)}
+
+	var orphans []string
+	if entries, err := os.ReadDir(dir); err == nil {
+		for _, entry := range entries {
+			if strings.HasSuffix(entry.Name(), ".tmp") {
+				orphans = append(orphans, filepath.Join(dir, entry.Name()))
+			}
+		}
+	}
+
+	fail := func(format string, args ...any) (VerifyResult, []string) {
+		result.Errors = append(result.Errors, fmt.Sprintf(format, args...))
+		return result, orphans
+	}
+
+	manifest, err := LoadManifestFromDir(dir)
+	if err != nil {
+		return fail("%v", err)
+	}
+	result.CreatedAt = manifest.CreatedAt
+	if err := manifest.Validate(); err != nil {
+		return fail("invalid manifest: %v", err)
+	}
+	if manifest.Service != service {
+		result.Errors = append(result.Errors, fmt.Sprintf("manifest is for service %s", manifest.Service))
+	}
+	if manifest.IsChunked() {
+		for _, err := range s.verifyChunked(manifest) {
+			result.Errors = append(result.Errors, err.Error())
+		}
+	} else {
+		for _, file := range manifest.Files {
+			if err := file.Verify(dir); err != nil {
+				result.Errors = append(result.Errors, err.Error())
+			}
+		}
+	}
+	if len(result.Errors) > 0 {
+		return result, orphans
+	}
+
+	result.OK = true
+	switch {
+	case !opts.Trial:
+	case manifest.IsEncrypted() && opts.IdentityFile == "":
+		// Without the key there is nothing to restore
+		result.Trial = TrialSkipped
+		result.TrialNote = "encrypted; no identity file"
+	default:
+		err := s.trialRestore(ctx, dir, manifest, opts)
+		switch {
+		case errors.Is(err, errImageUnavailable):
+			result.Trial = TrialSkipped
+			result.TrialNote = err.Error()
+		case err != nil:
+			result.OK = false
+			result.Trial = TrialFailed
+			result.Errors = append(result.Errors, fmt.Sprintf("trial restore: %v", err))
+		default:
+			result.Trial = TrialPassed
+		}
+	}
+
+	return result, orphans
+}
+
+// trialSpec describes how to run an engine's image for a trial restore
+type trialSpec struct {
+	env     []string
+	service resolve.ServiceInfo
+	// probe succeeds once the database accepts connections over TCP, which
+	// the official images only enable after their init phase
+	probe []string
+}
+
+// trialSpecs are the trial containers for each engine type
+var trialSpecs = map[string]trialSpec{
+	"postgres": {
+		env:     []string{"POSTGRES_PASSWORD=nizam"},
+		service: resolve.ServiceInfo{User: "postgres", Password: "nizam", Database: "postgres"},
+		probe:   []string{"pg_isready", "-h", "127.0.0.1", "-U", "postgres"},
+	},
+	"mysql": {
+		env:     []string{"MYSQL_ROOT_PASSWORD=nizam", "MYSQL_DATABASE=nizam", "MARIADB_ROOT_PASSWORD=nizam", "MARIADB_DATABASE=nizam"},
+		service: resolve.ServiceInfo{User: "root", Password: "nizam", Database: "nizam"},
+		probe:   []string{"mysqladmin", "ping", "-h", "127.0.0.1", "-uroot", "-pnizam"},
+	},
+	"mongo": {
+		service: resolve.ServiceInfo{Database: "nizam"},
+		probe:   []string{"mongosh", "--quiet", "--host", "127.0.0.1", "--eval", "db.adminCommand('ping').ok"},
+	},
+	"redis": {
+		probe: []string{"redis-cli", "-h", "127.0.0.1", "ping"},
+	},
+}
+
+// trialRestore restores a snapshot into a throwaway container of the
+// manifest's image to prove the dump loads
+func (s *Service) trialRestore(ctx context.Context, dir string, manifest *SnapshotManifest, opts VerifyOptions) error {
+	engine, exists := s.engines[manifest.Engine]
+	if !exists {
+		return fmt.Errorf("unsupported engine: %s", manifest.Engine)
+	}
+	spec, exists := trialSpecs[engine.GetEngineType()]
+	if !exists {
+		return fmt.Errorf("no trial restore for engine %s", manifest.Engine)
+	}
+	if manifest.Image == "" {
+		return fmt.Errorf("manifest does not record an image")
+	}
+	if puller, ok := s.rt.(imagePuller); ok {
+		if err := puller.EnsureImage(ctx, manifest.Image); err != nil {
+			return fmt.Errorf("%w: %v", errImageUnavailable, err)
+		}
+	}
+
+	name := fmt.Sprintf("nizam-verify-%s-%d", manifest.Service, time.Now().UnixNano())
+	_, err := s.rt.CreateContainer(ctx, runtime.ContainerSpec{
+		Name: name,
+		Config: &container.Config{
+			Image:  manifest.Image,
+			Env:    spec.env,
+			Labels: map[string]string{verifyLabel: "true"},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create trial container from %s: %w", manifest.Image, err)
+	}
+	defer func() {
+		if err := s.rt.RemoveContainer(context.Background(), name); err != nil {
+			log.Warn().Str("container", name).Err(err).Msg("Failed to remove trial container")
+		}
+	}()
+
+	if err := s.rt.StartContainer(ctx, name); err != nil {
+		return fmt.Errorf("failed to start trial container: %w", err)
+	}
+
+	interval := opts.ReadyInterval
+	if interval <= 0 {
+		interval = time.Second
+	}
+	if err := waitForProbe(ctx, s.rt, name, spec.probe, interval); err != nil {
+		return err
+	}
+
+	service := spec.service
+	service.Name = manifest.Service
+	service.Engine = engine.GetEngineType()
+	service.Container = name
+	service.Image = manifest.Image
+
+	log.Info().
+		Str("snapshot", dir).
+		Str("container", name).
+		Msg("Trial restoring snapshot")
+
+	dir, manifest, cleanup, err := s.Materialize(dir, manifest, compress.CompNone)
+	if err != nil {
+		return fmt.Errorf("failed to reassemble snapshot: %w", err)
+	}
+	defer cleanup()
+
+	return engine.Restore(ctx, service, dir, manifest, RestoreOptions{IdentityFile: opts.IdentityFile})
+}
+
+// waitForProbe runs probe in a container until it succeeds or ctx ends
+func waitForProbe(ctx context.Context, rt runtime.Runtime, name string, probe []string, interval time.Duration) error {
+	for {
+		result, err := rt.ExecCommand(ctx, name, probe)
+		if err == nil && result.ExitCode == 0 {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("container %s not ready: %w", name, ctx.Err())
+		case <-time.After(interval):
+		}
+	}
+}
+
+// tagFromDir extracts the tag from a snapshot directory name of the form
+// YYYYMMDD-HHMMSS-tag
+func tagFromDir(dir string) string {
+	parts := strings.Split(filepath.Base(dir), "-")
+	if len(parts) > 2 {
+		return strings.Join(parts[2:], "-")
+	}
+	return ""
+}
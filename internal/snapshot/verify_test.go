@@ -0,0 +1,167 @@
+package snapshot
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/abdultolba/nizam/internal/paths"
+	"github.com/abdultolba/nizam/internal/runtime"
+)
+
+func TestVerify(t *testing.T) {
+	chdirTemp(t)
+	svc := NewService(runtime.NewFake())
+
+	writeTestSnapshot(t, "postgres", "20250101-120000-good", []byte("PGDMP data"))
+	corrupt := writeTestSnapshot(t, "postgres", "20250102-120000-corrupt", []byte("PGDMP data"))
+	if err := os.WriteFile(filepath.Join(corrupt, "pg.dump"), []byte("PGDMP DATA"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	// A snapshot interrupted before its manifest was written
+	partial := writeTestSnapshot(t, "redis", "20250103-120000", []byte("REDIS0011"))
+	os.Remove(filepath.Join(partial, manifestFileName))
+	os.WriteFile(filepath.Join(partial, "dump.rdb.tmp"), []byte("REDIS"), 0o644)
+
+	serviceDir, _ := paths.GetServiceSnapshotsDir("postgres")
+	os.Mkdir(filepath.Join(serviceDir, ".20250104-120000.import-123"), 0o755)
+
+	report, err := svc.Verify(context.Background(), "", VerifyOptions{})
+	if err != nil {
+		t.Fatalf("Verify() error = %v", err)
+	}
+	if report.OK {
+		t.Error("report should fail")
+	}
+	if len(report.Snapshots) != 3 {
+		t.Fatalf("Verify() checked %d snapshots, want 3", len(report.Snapshots))
+	}
+
+	results := make(map[string]VerifyResult)
+	for _, result := range report.Snapshots {
+		results[filepath.Base(result.Path)] = result
+	}
+	if good := results["20250101-120000-good"]; !good.OK || good.Tag != "good" || len(good.Errors) != 0 {
+		t.Errorf("good snapshot result = %+v", good)
+	}
+	if bad := results["20250102-120000-corrupt"]; bad.OK || len(bad.Errors) != 1 || !strings.Contains(bad.Errors[0], "checksum mismatch") {
+		t.Errorf("corrupt snapshot result = %+v", bad)
+	}
+	if missing := results["20250103-120000"]; missing.OK || missing.Service != "redis" {
+		t.Errorf("partial snapshot result = %+v", missing)
+	}
+	if len(report.Orphans) != 2 {
+		t.Errorf("Verify() orphans = %v, want the .tmp file and the staging directory", report.Orphans)
+	}
+
+	// A single intact service passes
+	writeTestSnapshot(t, "api-db", "20250101-120000", []byte("PGDMP data"))
+	report, err = svc.Verify(context.Background(), "api-db", VerifyOptions{})
+	if err != nil || !report.OK {
+		t.Errorf("Verify(api-db) = %+v, %v", report, err)
+	}
+}
+
+func TestVerify_TrialRestore(t *testing.T) {
+	chdirTemp(t)
+	fake := runtime.NewFake()
+	svc := NewService(fake)
+	writeTestSnapshot(t, "postgres", "20250101-120000", []byte("PGDMP data"))
+
+	var restored []byte
+	var restoredInto string
+	fake.HandleExec("pg_isready", func(runtime.ExecCall) runtime.ExecResult {
+		return runtime.ExecResult{}
+	})
+	fake.HandleExec("pg_restore", func(call runtime.ExecCall) runtime.ExecResult {
+		restored = call.Stdin
+		restoredInto = call.Container
+		return runtime.ExecResult{}
+	})
+
+	report, err := svc.Verify(context.Background(), "postgres", VerifyOptions{Trial: true})
+	if err != nil {
+		t.Fatalf("Verify() error = %v", err)
+	}
+	if !report.OK || report.Snapshots[0].Trial != TrialPassed {
+		t.Fatalf("Verify() = %+v", report.Snapshots)
+	}
+	if string(restored) != "PGDMP data" || !strings.HasPrefix(restoredInto, "nizam-verify-postgres-") {
+		t.Errorf("pg_restore read %q in %s", restored, restoredInto)
+	}
+
+	// The throwaway container is gone
+	if containers, _ := fake.ListContainers(context.Background(), map[string]string{verifyLabel: "true"}); len(containers) != 0 {
+		t.Errorf("trial container was not removed: %+v", containers)
+	}
+
+	// A failing restore fails verification
+	fake.HandleExec("pg_restore", func(runtime.ExecCall) runtime.ExecResult {
+		return runtime.ExecResult{ExitCode: 1, Stderr: "pg_restore: error: input file is too short"}
+	})
+	report, err = svc.Verify(context.Background(), "postgres", VerifyOptions{Trial: true})
+	if err != nil {
+		t.Fatalf("Verify() error = %v", err)
+	}
+	if report.OK || report.Snapshots[0].Trial != TrialFailed {
+		t.Errorf("Verify() = %+v", report.Snapshots)
+	}
+}
+
+// pullingFake is a runtime that pulls images, failing for missing ones
+type pullingFake struct {
+	*runtime.Fake
+	pulled []string
+}
+
+func (f *pullingFake) EnsureImage(ctx context.Context, image string) error {
+	if strings.HasPrefix(image, "missing") {
+		return fmt.Errorf("failed to pull image %s: not found", image)
+	}
+	f.pulled = append(f.pulled, image)
+	return nil
+}
+
+func TestVerify_TrialRestoreImage(t *testing.T) {
+	chdirTemp(t)
+	fake := &pullingFake{Fake: runtime.NewFake()}
+	svc := NewService(fake)
+	dir := writeTestSnapshot(t, "postgres", "20250101-120000", []byte("PGDMP data"))
+	fake.HandleExec("pg_isready", func(runtime.ExecCall) runtime.ExecResult {
+		return runtime.ExecResult{}
+	})
+	fake.HandleExec("pg_restore", func(runtime.ExecCall) runtime.ExecResult {
+		return runtime.ExecResult{}
+	})
+
+	// The image is pulled before the trial container is created
+	report, err := svc.Verify(context.Background(), "postgres", VerifyOptions{Trial: true})
+	if err != nil {
+		t.Fatalf("Verify() error = %v", err)
+	}
+	if !report.OK || report.Snapshots[0].Trial != TrialPassed || len(fake.pulled) != 1 {
+		t.Fatalf("Verify() = %+v, pulled %v", report.Snapshots, fake.pulled)
+	}
+
+	// An image that cannot be pulled skips the trial without failing the snapshot
+	manifest, err := LoadManifestFromDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	manifest.Image = "missing/postgres:16"
+	if err := manifest.WriteToFile(filepath.Join(dir, manifestFileName)); err != nil {
+		t.Fatal(err)
+	}
+	report, err = svc.Verify(context.Background(), "postgres", VerifyOptions{Trial: true})
+	if err != nil {
+		t.Fatalf("Verify() error = %v", err)
+	}
+	result := report.Snapshots[0]
+	if !report.OK || result.Trial != TrialSkipped || !strings.Contains(result.TrialNote, "missing/postgres:16") {
+		t.Errorf("Verify() = %+v", result)
+	}
+}